@@ -0,0 +1,113 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	anystore "github.com/anyproto/any-store"
+	"go.uber.org/zap"
+
+	"github.com/anyproto/any-sync-node/archive/archiveinfo"
+	"github.com/anyproto/any-sync-node/nodestorage"
+)
+
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+var (
+	ErrNotArchived     = errors.New("space is not archived")
+	ErrAlreadyArchived = errors.New("space is already archived")
+)
+
+func newSpaceInfo(entry nodestorage.SpaceStatusEntry) archiveinfo.SpaceInfo {
+	return archiveinfo.SpaceInfo{
+		SpaceId:          entry.SpaceId,
+		Status:           entry.Status.String(),
+		Archived:         entry.Status == nodestorage.SpaceStatusArchived,
+		SizeCompressed:   entry.ArchiveSizeCompressed,
+		SizeUncompressed: entry.ArchiveSizeUncompressed,
+		Checksum:         entry.ArchiveChecksum,
+		LastAccess:       entry.LastAccess,
+		Error:            entry.Error,
+	}
+}
+
+func (a *archive) ArchiveNow(ctx context.Context, spaceId string) (err error) {
+	status, err := a.storageProvider.IndexStorage().SpaceStatus(ctx, spaceId)
+	if err != nil {
+		return
+	}
+	switch status {
+	case nodestorage.SpaceStatusOk:
+	case nodestorage.SpaceStatusArchived:
+		return ErrAlreadyArchived
+	default:
+		return fmt.Errorf("can't archive the space with status %s", status)
+	}
+	st := time.Now()
+	if err = a.Archive(ctx, spaceId); err != nil {
+		log.Warn("manual archive failed", zap.String("spaceId", spaceId), zap.Error(err))
+		return
+	}
+	log.Info("space is archived manually", zap.String("spaceId", spaceId), zap.Duration("dur", time.Since(st)))
+	return
+}
+
+func (a *archive) RestoreNow(ctx context.Context, spaceId string) (err error) {
+	status, err := a.storageProvider.IndexStorage().SpaceStatus(ctx, spaceId)
+	if err != nil {
+		return
+	}
+	if status != nodestorage.SpaceStatusArchived {
+		return ErrNotArchived
+	}
+	// the job is shared with the lazy restore on a client request
+	job := a.startRestore(spaceId, true)
+	select {
+	case <-job.done:
+		return job.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *archive) ListArchived(ctx context.Context, filter archiveinfo.ListFilter, cursor string) (result archiveinfo.ListResult, err error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+	entries, err := a.storageProvider.IndexStorage().ListArchived(ctx, nodestorage.ArchivedFilter{
+		MinSizeUncompressed: filter.MinSizeUncompressed,
+		LastAccessBefore:    filter.LastAccessBefore,
+	}, cursor, limit+1)
+	if err != nil {
+		return
+	}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		result.NextCursor = entries[limit-1].SpaceId
+	}
+	result.Spaces = make([]archiveinfo.SpaceInfo, 0, len(entries))
+	for _, entry := range entries {
+		result.Spaces = append(result.Spaces, newSpaceInfo(entry))
+	}
+	return
+}
+
+func (a *archive) ArchiveStatus(ctx context.Context, spaceId string) (info archiveinfo.SpaceInfo, err error) {
+	entry, err := a.storageProvider.IndexStorage().SpaceStatusEntry(ctx, spaceId)
+	if err != nil {
+		if errors.Is(err, anystore.ErrDocNotFound) {
+			err = nodestorage.ErrUnknownSpaceId
+		}
+		return
+	}
+	return newSpaceInfo(entry), nil
+}
@@ -0,0 +1,90 @@
+package archive
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	anystore "github.com/anyproto/any-store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/anyproto/any-sync-node/archive/archiveinfo"
+	"github.com/anyproto/any-sync-node/archive/archivestore"
+	"github.com/anyproto/any-sync-node/nodestorage"
+)
+
+func TestArchive_ArchiveNow(t *testing.T) {
+	t.Run("already archived", func(t *testing.T) {
+		fx := newFixture(t)
+		fx.indexStorage.EXPECT().SpaceStatus(ctx, "space.id").Return(nodestorage.SpaceStatusArchived, nil)
+		assert.ErrorIs(t, fx.ArchiveNow(ctx, "space.id"), ErrAlreadyArchived)
+	})
+	t.Run("locked", func(t *testing.T) {
+		fx := newFixture(t)
+		fx.indexStorage.EXPECT().SpaceStatus(ctx, "space.id").Return(nodestorage.SpaceStatusOk, nil)
+		fx.storage.EXPECT().TryLockAndOpenDb(ctx, "space.id", gomock.Any()).Return(nodestorage.ErrLocked)
+		assert.ErrorIs(t, fx.ArchiveNow(ctx, "space.id"), nodestorage.ErrLocked)
+	})
+}
+
+func TestArchive_RestoreNow(t *testing.T) {
+	t.Run("not archived", func(t *testing.T) {
+		fx := newFixture(t)
+		fx.indexStorage.EXPECT().SpaceStatus(ctx, "space.id").Return(nodestorage.SpaceStatusOk, nil)
+		assert.ErrorIs(t, fx.RestoreNow(ctx, "space.id"), ErrNotArchived)
+	})
+	t.Run("restore job", func(t *testing.T) {
+		fx := newFixture(t)
+		fx.indexStorage.EXPECT().SpaceStatus(ctx, "space.id").Return(nodestorage.SpaceStatusArchived, nil)
+		fx.storage.EXPECT().StoreDir("space.id").Return(filepath.Join(t.TempDir(), "space.id"))
+		fx.indexStorage.EXPECT().SpaceStatusEntry(gomock.Any(), "space.id").Return(nodestorage.SpaceStatusEntry{}, nil)
+		fx.archiveStore.EXPECT().Get(gomock.Any(), "space.id").Return(nil, archivestore.ErrNotFound)
+		// waits for the job and returns its error
+		assert.ErrorIs(t, fx.RestoreNow(ctx, "space.id"), archivestore.ErrNotFound)
+	})
+}
+
+func TestArchive_ListArchived(t *testing.T) {
+	fx := newFixture(t)
+	entries := []nodestorage.SpaceStatusEntry{
+		{SpaceId: "s1", Status: nodestorage.SpaceStatusArchived, ArchiveSizeCompressed: 1, ArchiveSizeUncompressed: 10},
+		{SpaceId: "s2", Status: nodestorage.SpaceStatusArchived, ArchiveSizeCompressed: 2, ArchiveSizeUncompressed: 20},
+		{SpaceId: "s3", Status: nodestorage.SpaceStatusArchived, ArchiveSizeCompressed: 3, ArchiveSizeUncompressed: 30},
+	}
+	before := time.Now()
+	filter := nodestorage.ArchivedFilter{MinSizeUncompressed: 5, LastAccessBefore: before}
+
+	fx.indexStorage.EXPECT().ListArchived(ctx, filter, "", 3).Return(entries, nil)
+	res, err := fx.ListArchived(ctx, archiveinfo.ListFilter{Limit: 2, MinSizeUncompressed: 5, LastAccessBefore: before}, "")
+	require.NoError(t, err)
+	require.Len(t, res.Spaces, 2)
+	assert.Equal(t, "s2", res.NextCursor)
+	assert.Equal(t, int64(20), res.Spaces[1].SizeUncompressed)
+	assert.True(t, res.Spaces[1].Archived)
+
+	fx.indexStorage.EXPECT().ListArchived(ctx, filter, "s2", 3).Return(entries[2:], nil)
+	res, err = fx.ListArchived(ctx, archiveinfo.ListFilter{Limit: 2, MinSizeUncompressed: 5, LastAccessBefore: before}, "s2")
+	require.NoError(t, err)
+	require.Len(t, res.Spaces, 1)
+	assert.Empty(t, res.NextCursor)
+}
+
+func TestArchive_ArchiveStatus(t *testing.T) {
+	fx := newFixture(t)
+	fx.indexStorage.EXPECT().SpaceStatusEntry(ctx, "unknown").Return(nodestorage.SpaceStatusEntry{}, anystore.ErrDocNotFound)
+	_, err := fx.ArchiveStatus(ctx, "unknown")
+	assert.ErrorIs(t, err, nodestorage.ErrUnknownSpaceId)
+
+	fx.indexStorage.EXPECT().SpaceStatusEntry(ctx, "space.id").Return(nodestorage.SpaceStatusEntry{
+		SpaceId:                 "space.id",
+		Status:                  nodestorage.SpaceStatusArchived,
+		ArchiveSizeCompressed:   10,
+		ArchiveSizeUncompressed: 100,
+	}, nil)
+	info, err := fx.ArchiveStatus(ctx, "space.id")
+	require.NoError(t, err)
+	assert.Equal(t, "archived", info.Status)
+	assert.Equal(t, int64(100), info.SizeUncompressed)
+}
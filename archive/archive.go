@@ -3,21 +3,28 @@
 package archive
 
 import (
-	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	anystore "github.com/anyproto/any-store"
+	"github.com/anyproto/any-sync/accountservice"
 	"github.com/anyproto/any-sync/app"
 	"github.com/anyproto/any-sync/app/logger"
 	"github.com/anyproto/any-sync/metric"
+	"github.com/anyproto/any-sync/util/crypto"
 	"github.com/anyproto/any-sync/util/periodicsync"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 
+	"github.com/anyproto/any-sync-node/archive/archiveinfo"
 	"github.com/anyproto/any-sync-node/archive/archivestore"
 	"github.com/anyproto/any-sync-node/nodestorage"
 	"github.com/anyproto/any-sync-node/nodesync"
@@ -33,16 +40,47 @@ func New() Archive {
 
 type Archive interface {
 	app.ComponentRunnable
+	// Restore restores the archived space on a client request, returns ErrSpaceRestoring when it takes longer than RestoreWaitSeconds.
+	// Concurrent calls for the same space share one restore job.
 	Restore(ctx context.Context, spaceId string) (err error)
+	// ArchiveNow archives the space immediately regardless of its last access time
+	ArchiveNow(ctx context.Context, spaceId string) (err error)
+	// RestoreNow restores the archived space without waiting for a client request
+	RestoreNow(ctx context.Context, spaceId string) (err error)
+	// Prefetch queues restores of archived spaces, they run in the background with up to RestoreConcurrency at once
+	Prefetch(ctx context.Context, spaceIds []string) (result archiveinfo.PrefetchResult, err error)
+	// RestoreJobs returns running, queued and recently finished restores
+	RestoreJobs() (jobs []archiveinfo.RestoreJob)
+	// ListArchived returns a page of archived spaces sorted by id, the cursor is the NextCursor of the previous page
+	ListArchived(ctx context.Context, filter archiveinfo.ListFilter, cursor string) (result archiveinfo.ListResult, err error)
+	// ArchiveStatus returns the archive state of the space
+	ArchiveStatus(ctx context.Context, spaceId string) (info archiveinfo.SpaceInfo, err error)
+	// ExplainPolicy tells whether the periodic check would archive the space and why
+	ExplainPolicy(ctx context.Context, spaceId string) (decision archiveinfo.PolicyDecision, err error)
+	// Reconcile finds objects without index entries and index entries without objects, and repairs them unless dryRun is set
+	Reconcile(ctx context.Context, dryRun bool) (report archiveinfo.ReconcileReport, err error)
 }
 
 type archive struct {
 	storageProvider nodestorage.NodeStorage
 	archiveStore    archivestore.ArchiveStore
 	config          Config
+	codec           Codec
+	keyring         *keyring
 	checker         periodicsync.PeriodicSync
-	accessDurCutoff time.Duration
+	reconciler      periodicsync.PeriodicSync
+	reconcileMu     sync.Mutex
+	reconcileGrace  time.Duration
+	policy          *policy
+	uploadLimiter   *rate.Limiter
+	diskReadLimiter *rate.Limiter
 	stat            *archiveStat
+	restoreMu       sync.Mutex
+	restoreJobs     map[string]*restoreJob
+	restoreSem      chan struct{}
+	urgentSem       chan struct{}
+	restoreWait     time.Duration
+	restoreWg       sync.WaitGroup
 	syncWaiter      <-chan struct{}
 	runCtx          context.Context
 	runCtxCancel    context.CancelFunc
@@ -55,27 +93,78 @@ func (a *archive) Init(ap *app.App) (err error) {
 	if a.config.ArchiveAfterDays <= 0 {
 		a.config.ArchiveAfterDays = 7
 	}
-	a.accessDurCutoff = time.Duration(a.config.ArchiveAfterDays) * time.Hour * 24
+	if a.codec, err = ParseCodec(a.config.Compression); err != nil {
+		return
+	}
+	if a.keyring, err = a.newKeyring(ap); err != nil {
+		return
+	}
+	a.policy = newPolicy(a.config, func() string {
+		// the root of all space stores
+		return a.storageProvider.StoreDir("")
+	})
 	a.syncWaiter = ap.MustComponent(nodesync.CName).(nodesync.NodeSync).WaitSyncOnStart()
 	a.runCtx, a.runCtxCancel = context.WithCancel(context.Background())
+	if a.config.Concurrency <= 0 {
+		a.config.Concurrency = 1
+	}
+	if a.config.RestoreConcurrency <= 0 {
+		a.config.RestoreConcurrency = 4
+	}
+	if a.config.UrgentRestoreConcurrency <= 0 {
+		a.config.UrgentRestoreConcurrency = 4
+	}
+	if a.config.RestoreWaitSeconds <= 0 {
+		a.config.RestoreWaitSeconds = 5
+	}
+	a.restoreJobs = make(map[string]*restoreJob)
+	a.restoreSem = make(chan struct{}, a.config.RestoreConcurrency)
+	a.urgentSem = make(chan struct{}, a.config.UrgentRestoreConcurrency)
+	a.restoreWait = time.Second * time.Duration(a.config.RestoreWaitSeconds)
+	a.uploadLimiter = newLimiter(a.config.UploadBytesPerSec)
+	a.diskReadLimiter = newLimiter(a.config.DiskReadBytesPerSec)
 	if a.config.CheckPeriodMinutes <= 0 {
 		a.config.CheckPeriodMinutes = 2
 	}
 	period := time.Minute * time.Duration(a.config.CheckPeriodMinutes)
 	a.checker = periodicsync.NewPeriodicSyncDuration(period, time.Hour, a.check, log)
-	a.stat = new(archiveStat)
+	if a.config.Reconcile.PeriodHours <= 0 {
+		a.config.Reconcile.PeriodHours = 24
+	}
+	if a.config.Reconcile.GracePeriodMinutes <= 0 {
+		a.config.Reconcile.GracePeriodMinutes = 60
+	}
+	a.reconcileGrace = time.Minute * time.Duration(a.config.Reconcile.GracePeriodMinutes)
+	reconcilePeriod := time.Hour * time.Duration(a.config.Reconcile.PeriodHours)
+	a.reconciler = periodicsync.NewPeriodicSyncDuration(reconcilePeriod, time.Hour, a.reconcile, log)
+	a.stat = newArchiveStat()
 	if m := ap.Component(metric.CName); m != nil {
 		registerMetric(a.stat, m.(metric.Metric).Registry())
 	}
 	return
 }
 
+func (a *archive) newKeyring(ap *app.App) (*keyring, error) {
+	var accountKey crypto.PrivKey
+	if a.config.Encryption.DeriveFromAccount {
+		accountKey = ap.MustComponent(accountservice.CName).(accountservice.Service).Account().SignKey
+	}
+	kr, err := newKeyring(a.config.Encryption, accountKey)
+	if err != nil {
+		return nil, err
+	}
+	if kr.encryptionEnabled() {
+		log.Info("archive encryption enabled", zap.String("keyId", kr.currentId), zap.Strings("keys", kr.KeyIds()))
+	}
+	return kr, nil
+}
+
 func (a *archive) Name() (name string) {
 	return CName
 }
 
 func (a *archive) Run(_ context.Context) (err error) {
-	if !a.config.Enabled {
+	if !a.config.Enabled && !a.config.Reconcile.Enabled {
 		return
 	}
 	go func() {
@@ -84,7 +173,12 @@ func (a *archive) Run(_ context.Context) (err error) {
 			return
 		case <-a.syncWaiter:
 		}
-		a.checker.Run()
+		if a.config.Enabled {
+			a.checker.Run()
+		}
+		if a.config.Reconcile.Enabled {
+			a.reconciler.Run()
+		}
 	}()
 	return
 }
@@ -92,7 +186,10 @@ func (a *archive) Run(_ context.Context) (err error) {
 var errArchived = errors.New("archived")
 
 func (a *archive) Archive(ctx context.Context, spaceId string) (err error) {
-	var gzSize, dbSize int64
+	var arcSize, dbSize int64
+	st := time.Now()
+	a.stat.inProgress.Add(1)
+	defer a.stat.inProgress.Add(-1)
 	tmpDir, err := os.MkdirTemp("", spaceId)
 	if err != nil {
 		return
@@ -102,36 +199,35 @@ func (a *archive) Archive(ctx context.Context, spaceId string) (err error) {
 	}()
 	err = a.storageProvider.TryLockAndOpenDb(ctx, spaceId, func(db anystore.DB) error {
 		storePath := filepath.Join(tmpDir, "store.db")
-		if err = db.Backup(ctx, storePath); err != nil {
+		// the store reads are paid to the disk read limiter once, by the backup; the local copy is read unthrottled
+		if err = a.backupStore(ctx, db, spaceId, storePath); err != nil {
 			return err
 		}
-		gzPath, gzSz, dbSz, err := a.createGzipFromStore(tmpDir)
+		header, arcSz, err := a.uploadArchive(ctx, spaceId, storePath)
 		if err != nil {
 			return err
 		}
-		gzSize, dbSize = gzSz, dbSz
+		arcSize, dbSize = arcSz, int64(header.Size)
 
-		r, err := os.Open(gzPath)
-		if err != nil {
-			return err
-		}
-		defer func() {
-			if cerr := r.Close(); err == nil && cerr != nil {
-				err = cerr
+		if a.config.VerifyUpload {
+			if err = a.verifyStored(ctx, spaceId, header.Checksum); err != nil {
+				a.stat.verifyError.Add(1)
+				_ = a.archiveStore.Delete(ctx, spaceId)
+				return err
 			}
-		}()
-
-		if err = a.archiveStore.Put(ctx, spaceId, r); err != nil {
-			return err
 		}
 
-		if err = a.storageProvider.IndexStorage().MarkArchived(ctx, spaceId, gzSize, dbSize); err != nil {
+		checksum := hex.EncodeToString(header.Checksum[:])
+		if err = a.storageProvider.IndexStorage().MarkArchived(ctx, spaceId, arcSize, dbSize, checksum); err != nil {
 			return err
 		}
 
 		_ = db.Close()
 		_ = os.RemoveAll(a.storageProvider.StoreDir(spaceId))
 		a.stat.archived.Add(1)
+		a.stat.archiveDuration.Observe(time.Since(st).Seconds())
+		a.stat.archiveBytes.Observe(float64(dbSize))
+		a.stat.archiveCompressedBytes.Observe(float64(arcSize))
 		return errArchived
 	})
 
@@ -141,65 +237,86 @@ func (a *archive) Archive(ctx context.Context, spaceId string) (err error) {
 	return
 }
 
-// createGzipFromStore creates store.gz from store.db inside spaceDir.
-// Returns path to .gz, its size and original db size.
-func (a *archive) createGzipFromStore(spaceDir string) (gzPath string, gzSize, dbSize int64, err error) {
-	storePath := filepath.Join(spaceDir, "store.db")
-	gzPath = filepath.Join(spaceDir, "store.gz")
-
+// uploadArchive streams the archive of the store backup to the archive store without a temporary compressed copy.
+// Returns the archive header and the uploaded size.
+func (a *archive) uploadArchive(ctx context.Context, spaceId, storePath string) (header archiveHeader, arcSize int64, err error) {
 	storeFile, err := os.Open(storePath)
 	if err != nil {
-		return "", 0, 0, err
+		return
 	}
 	defer func() {
-		if cerr := storeFile.Close(); err == nil && cerr != nil {
-			err = cerr
-		}
+		_ = storeFile.Close()
 	}()
 
-	gzFile, err := os.Create(gzPath)
+	pr, pw := io.Pipe()
+	counter := &countingWriter{w: pw}
+	var (
+		writeErr error
+		written  = make(chan struct{})
+	)
+	go func() {
+		defer close(written)
+		header, writeErr = writeArchive(counter, storeFile, a.codec, a.config.CompressionLevel, a.keyring)
+		_ = pw.CloseWithError(writeErr)
+	}()
+	// an encrypted archive gets a new nonce on every attempt, so its parts never match an interrupted upload
+	err = a.archiveStore.PutStream(ctx, spaceId, newThrottledReader(ctx, pr, a.uploadLimiter), !a.keyring.encryptionEnabled())
+	// unblock the writer if the upload has stopped before the end of the stream
+	_ = pr.CloseWithError(err)
+	<-written
+	if writeErr != nil {
+		return header, 0, writeErr
+	}
 	if err != nil {
-		return "", 0, 0, err
+		return
+	}
+	return header, counter.n, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (n int, err error) {
+	n, err = cw.w.Write(p)
+	cw.n += int64(n)
+	return
+}
+
+// verifyStored reads the uploaded archive back and checks that it decodes to the data with the expected checksum
+func (a *archive) verifyStored(ctx context.Context, spaceId string, checksum [sha256.Size]byte) (err error) {
+	reader, err := a.archiveStore.Get(ctx, spaceId)
+	if err != nil {
+		return fmt.Errorf("verify: %w", err)
 	}
 	defer func() {
-		if cerr := gzFile.Close(); err == nil && cerr != nil {
-			err = cerr
-		}
+		_ = reader.Close()
 	}()
-
-	gw := gzip.NewWriter(gzFile)
-	dbSize, err = io.Copy(gw, storeFile)
+	dataReader, header, err := openArchive(reader, a.keyring)
 	if err != nil {
-		_ = gw.Close()
-		return "", 0, 0, err
+		return fmt.Errorf("verify: %w", err)
 	}
-
-	if err = gw.Close(); err != nil {
-		return "", 0, 0, err
+	defer func() {
+		_ = dataReader.Close()
+	}()
+	if header.Checksum != checksum {
+		return fmt.Errorf("verify: %w", ErrChecksumMismatch)
 	}
-
-	info, err := gzFile.Stat()
-	if err != nil {
-		return "", 0, 0, err
+	if _, err = io.Copy(io.Discard, dataReader); err != nil {
+		return fmt.Errorf("verify: %w", err)
 	}
-	gzSize = info.Size()
-
-	return gzPath, gzSize, dbSize, nil
+	return nil
 }
 
-func (a *archive) Restore(ctx context.Context, spaceId string) (err error) {
-	if err = a.restoreFile(ctx, spaceId); err != nil {
-		_ = os.RemoveAll(a.storageProvider.StoreDir(spaceId))
-		return err
-	}
-	if err = a.storageProvider.IndexStorage().SetSpaceStatus(ctx, spaceId, nodestorage.SpaceStatusOk, ""); err != nil {
+func (a *archive) restoreFile(ctx context.Context, job *restoreJob) (err error) {
+	spaceId := job.spaceId
+	entry, err := a.storageProvider.IndexStorage().SpaceStatusEntry(ctx, spaceId)
+	if err != nil {
 		return
 	}
-	a.stat.restored.Add(1)
-	return a.archiveStore.Delete(ctx, spaceId)
-}
+	job.total.Store(entry.ArchiveSizeCompressed)
 
-func (a *archive) restoreFile(ctx context.Context, spaceId string) (err error) {
 	reader, err := a.archiveStore.Get(ctx, spaceId)
 	if err != nil {
 		return
@@ -208,15 +325,19 @@ func (a *archive) restoreFile(ctx context.Context, spaceId string) (err error) {
 		_ = reader.Close()
 	}()
 
-	gzipReader, err := gzip.NewReader(reader)
+	dataReader, header, err := openArchive(progressReader{r: reader, job: job}, a.keyring)
 	if err != nil {
-		return
+		return corrupted(err)
 	}
 
 	defer func() {
-		_ = gzipReader.Close()
+		_ = dataReader.Close()
 	}()
 
+	if entry.ArchiveChecksum != "" && !header.Legacy && hex.EncodeToString(header.Checksum[:]) != entry.ArchiveChecksum {
+		return corrupted(ErrChecksumMismatch)
+	}
+
 	storeDir := a.storageProvider.StoreDir(spaceId)
 	storePath := filepath.Join(storeDir, "store.db")
 	if err = os.MkdirAll(storeDir, 0755); err != nil {
@@ -241,48 +362,160 @@ func (a *archive) restoreFile(ctx context.Context, spaceId string) (err error) {
 		}
 	}()
 
-	if _, err = io.Copy(storeFile, gzipReader); err != nil {
-		return
+	hasher := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(storeFile, hasher), dataReader); err != nil {
+		return corrupted(err)
+	}
+	// legacy archives have no checksum in the header, so check the one from the index
+	if entry.ArchiveChecksum != "" && hex.EncodeToString(hasher.Sum(nil)) != entry.ArchiveChecksum {
+		return corrupted(ErrChecksumMismatch)
 	}
 	return
 }
 
+// check archives inactive spaces with up to Concurrency workers.
+// The inactive list is paged with the last seen space as the cursor, so every candidate is read from the index once per check.
 func (a *archive) check(ctx context.Context) error {
 	indexStore := a.storageProvider.IndexStorage()
 	deadline, _ := ctx.Deadline()
-	var skip int
+	threshold, usage := a.policy.ageThreshold()
+	log.Info("check spaces", zap.Time("lastAccessTime", time.Now().Add(-threshold)), zap.Float64("diskUsage", usage), zap.Int("concurrency", a.config.Concurrency))
+	var (
+		mu            sync.Mutex
+		candidates    = &inactiveIter{index: indexStore, olderThan: threshold}
+		stop          bool
+		resultErr     error
+		inProgress    = make(map[string]struct{})
+		reservedBytes int64
+		maxBytes      = a.config.Policy.MaxBytesPerRun
+		workers       = make(chan struct{}, a.config.Concurrency)
+		wg            sync.WaitGroup
+	)
+	defer wg.Wait()
+
+	archiveSpace := func(spaceId string) {
+		defer func() {
+			<-workers
+			wg.Done()
+		}()
+		st := time.Now()
+		err := a.Archive(ctx, spaceId)
+		mu.Lock()
+		defer mu.Unlock()
+		delete(inProgress, spaceId)
+		if err == nil {
+			log.Info("space is archived", zap.String("spaceId", spaceId), zap.Duration("dur", time.Since(st)))
+			return
+		}
+		log.Error("space archive failed", zap.String("spaceId", spaceId), zap.Error(err))
+		if errors.Is(err, nodestorage.ErrLocked) {
+			return
+		}
+		a.stat.archiveError.Add(1)
+		stop = true
+		// the failed space isn't retried, so its unfinished upload would stay in the store
+		if aErr := a.archiveStore.AbortStream(ctx, spaceId); aErr != nil {
+			log.Warn("can't abort the archive upload", zap.String("spaceId", spaceId), zap.Error(aErr))
+		}
+		if mErr := indexStore.MarkError(nodestorage.WithOrigin(ctx, nodestorage.OriginArchive), spaceId, err.Error()); mErr != nil {
+			resultErr = mErr
+		}
+	}
+
 	for {
-		log.Info("check spaces", zap.Time("lastAccessTime", time.Now().Add(-a.accessDurCutoff)))
-		spaceId, err := indexStore.FindOldestInactiveSpace(ctx, a.accessDurCutoff, skip)
+		// take a worker before reading the index, so a failed worker stops the loop before the next candidate
+		select {
+		case workers <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		mu.Lock()
+		curStop := stop
+		mu.Unlock()
+		if curStop || (!deadline.IsZero() && time.Until(deadline) < time.Minute*10) {
+			<-workers
+			break
+		}
+
+		spaceId, decision, err := a.nextCandidate(ctx, threshold, candidates)
 		if err != nil {
+			<-workers
 			if errors.Is(err, anystore.ErrDocNotFound) {
-				return nil
+				break
 			}
 			return err
 		}
-		st := time.Now()
-		if err = a.Archive(ctx, spaceId); err != nil {
-			log.Error("space archive failed", zap.String("spaceId", spaceId), zap.Error(err))
-			if errors.Is(err, nodestorage.ErrLocked) {
-				skip++
-				continue
+
+		mu.Lock()
+		_, busy := inProgress[spaceId]
+		if busy || !decision.Archive {
+			if !busy {
+				log.Debug("space is skipped by the policy", zap.String("spaceId", spaceId), zap.String("reason", decision.Reason))
 			}
-			a.stat.archiveError.Add(1)
-			return indexStore.MarkError(ctx, spaceId, err.Error())
+			mu.Unlock()
+			<-workers
+			continue
 		}
-		log.Info("space is archived", zap.String("spaceId", spaceId), zap.Duration("dur", time.Since(st)))
-		if !deadline.IsZero() && deadline.Sub(time.Now()) < time.Minute*10 {
-			return nil
+		if maxBytes > 0 && reservedBytes > 0 && reservedBytes+decision.SizeBytes > maxBytes {
+			log.Info("archive limit per run is reached", zap.Int64("archivedBytes", reservedBytes))
+			mu.Unlock()
+			<-workers
+			break
 		}
+		reservedBytes += decision.SizeBytes
+		inProgress[spaceId] = struct{}{}
+		mu.Unlock()
+
+		wg.Add(1)
+		go archiveSpace(spaceId)
 	}
+	wg.Wait()
+	return resultErr
+}
+
+func (a *archive) nextCandidate(ctx context.Context, threshold time.Duration, candidates *inactiveIter) (spaceId string, decision archiveinfo.PolicyDecision, err error) {
+	if spaceId, err = candidates.next(ctx); err != nil {
+		return
+	}
+	decision, err = a.explain(ctx, spaceId, threshold)
+	return
+}
+
+// inactiveIterPageSize is small, so the candidates don't get stale while the previous ones are archived
+const inactiveIterPageSize = 32
+
+// inactiveIter reads the inactive spaces list by pages, the last returned space is the cursor of the next page
+type inactiveIter struct {
+	index     nodestorage.IndexStorage
+	olderThan time.Duration
+	page      []nodestorage.InactiveSpace
+	last      nodestorage.InactiveSpace
+}
+
+// next returns the next inactive space or anystore.ErrDocNotFound at the end of the list
+func (it *inactiveIter) next(ctx context.Context) (spaceId string, err error) {
+	if len(it.page) == 0 {
+		if it.page, err = it.index.FindInactiveSpaces(ctx, it.olderThan, it.last, inactiveIterPageSize); err != nil {
+			return
+		}
+		if len(it.page) == 0 {
+			return "", anystore.ErrDocNotFound
+		}
+	}
+	it.last, it.page = it.page[0], it.page[1:]
+	return it.last.SpaceId, nil
 }
 
 func (a *archive) Close(_ context.Context) (err error) {
 	if a.checker != nil {
 		a.checker.Close()
 	}
+	if a.reconciler != nil {
+		a.reconciler.Close()
+	}
 	if a.runCtxCancel != nil {
 		a.runCtxCancel()
 	}
+	a.restoreWg.Wait()
 	return
 }
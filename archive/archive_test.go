@@ -1,11 +1,16 @@
 package archive
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/hex"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	anystore "github.com/anyproto/any-store"
 	"github.com/anyproto/any-sync/app"
@@ -25,7 +30,15 @@ import (
 var ctx = context.Background()
 
 func TestArchive_Archive(t *testing.T) {
-	fx := newFixture(t)
+	for _, compression := range []string{"gzip", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			testArchive(t, Config{Compression: compression})
+		})
+	}
+}
+
+func testArchive(t *testing.T, conf Config) {
+	fx := newFixtureConf(t, conf)
 
 	var spaceId = "space.id"
 
@@ -49,26 +62,33 @@ func TestArchive_Archive(t *testing.T) {
 			return openFunc(db)
 		})
 
-	fx.archiveStore.EXPECT().Put(ctx, spaceId, gomock.Any()).DoAndReturn(func(ctx context.Context, spaceId string, rd io.ReadSeeker) error {
+	fx.archiveStore.EXPECT().PutStream(ctx, spaceId, gomock.Any(), true).DoAndReturn(func(ctx context.Context, spaceId string, rd io.Reader, _ bool) error {
 		bytes, err := io.ReadAll(rd)
 		require.NoError(t, err)
-		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "archive.gz"), bytes, 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "archive.arc"), bytes, 0644))
 		return nil
 	})
 
-	fx.indexStorage.EXPECT().MarkArchived(ctx, spaceId, gomock.Not(0), gomock.Not(0))
+	var checksum string
+	fx.indexStorage.EXPECT().MarkArchived(ctx, spaceId, gomock.Not(0), gomock.Not(0), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _, _ int64, sum string) error {
+			checksum = sum
+			return nil
+		})
 
 	require.NoError(t, fx.Archive.(*archive).Archive(ctx, spaceId))
 
 	_, err = os.Stat(spaceDir)
 	require.True(t, os.IsNotExist(err))
+	require.Len(t, checksum, 64)
 
-	fx.archiveStore.EXPECT().Get(ctx, spaceId).DoAndReturn(func(_ context.Context, _ string) (io.ReadCloser, error) {
-		return os.Open(filepath.Join(tmpDir, "archive.gz"))
+	fx.indexStorage.EXPECT().SpaceStatusEntry(gomock.Any(), spaceId).Return(nodestorage.SpaceStatusEntry{ArchiveChecksum: checksum}, nil)
+	fx.archiveStore.EXPECT().Get(gomock.Any(), spaceId).DoAndReturn(func(_ context.Context, _ string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(tmpDir, "archive.arc"))
 	})
 
-	fx.indexStorage.EXPECT().SetSpaceStatus(ctx, spaceId, nodestorage.SpaceStatusOk, "")
-	fx.archiveStore.EXPECT().Delete(ctx, spaceId)
+	fx.indexStorage.EXPECT().SetSpaceStatus(gomock.Any(), spaceId, nodestorage.SpaceStatusOk, "")
+	fx.archiveStore.EXPECT().Delete(gomock.Any(), spaceId)
 
 	require.NoError(t, fx.Restore(ctx, spaceId))
 
@@ -79,6 +99,191 @@ func TestArchive_Archive(t *testing.T) {
 	assert.Equal(t, []string{"test"}, coll)
 }
 
+func TestArchive_RestoreLegacy(t *testing.T) {
+	fx := newFixture(t)
+	var spaceId = "space.id"
+
+	tmpDir := t.TempDir()
+	spaceDir := filepath.Join(tmpDir, "spaceid")
+	fx.storage.EXPECT().StoreDir(spaceId).Return(spaceDir).AnyTimes()
+
+	// legacy archives are headerless gzip of store.db
+	dbPath := filepath.Join(tmpDir, "legacy.db")
+	db, err := anystore.Open(ctx, dbPath, nil)
+	require.NoError(t, err)
+	_, err = db.CreateCollection(ctx, "legacy")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+	dbData, err := os.ReadFile(dbPath)
+	require.NoError(t, err)
+	var gzData bytes.Buffer
+	gw := gzip.NewWriter(&gzData)
+	_, err = gw.Write(dbData)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	fx.indexStorage.EXPECT().SpaceStatusEntry(gomock.Any(), spaceId).Return(nodestorage.SpaceStatusEntry{}, nil)
+	fx.archiveStore.EXPECT().Get(gomock.Any(), spaceId).Return(io.NopCloser(&gzData), nil)
+	fx.indexStorage.EXPECT().SetSpaceStatus(gomock.Any(), spaceId, nodestorage.SpaceStatusOk, "")
+	fx.archiveStore.EXPECT().Delete(gomock.Any(), spaceId)
+
+	require.NoError(t, fx.Restore(ctx, spaceId))
+
+	db, err = anystore.Open(ctx, filepath.Join(spaceDir, "store.db"), nil)
+	require.NoError(t, err)
+	defer db.Close()
+	coll, err := db.GetCollectionNames(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"legacy"}, coll)
+}
+
+func TestArchive_RestoreCorrupted(t *testing.T) {
+	var spaceId = "space.id"
+	data := bytes.Repeat([]byte("store"), 1000)
+	var arc bytes.Buffer
+	header, err := writeArchive(&arc, bytes.NewReader(data), CodecZstd, 0, nil)
+	require.NoError(t, err)
+	checksum := hex.EncodeToString(header.Checksum[:])
+
+	t.Run("payload", func(t *testing.T) {
+		fx := newFixture(t)
+		spaceDir := filepath.Join(t.TempDir(), "spaceid")
+		fx.storage.EXPECT().StoreDir(spaceId).Return(spaceDir).AnyTimes()
+
+		broken := bytes.Clone(arc.Bytes())
+		broken[headerSize-1] ^= 0xff
+		fx.indexStorage.EXPECT().SpaceStatusEntry(gomock.Any(), spaceId).Return(nodestorage.SpaceStatusEntry{ArchiveChecksum: checksum}, nil)
+		fx.archiveStore.EXPECT().Get(gomock.Any(), spaceId).Return(io.NopCloser(bytes.NewReader(broken)), nil)
+		fx.indexStorage.EXPECT().MarkError(gomock.Any(), spaceId, gomock.Any())
+
+		require.ErrorIs(t, fx.Restore(ctx, spaceId), ErrCorrupted)
+		_, err = os.Stat(filepath.Join(spaceDir, "store.db"))
+		require.True(t, os.IsNotExist(err))
+	})
+	t.Run("index checksum", func(t *testing.T) {
+		fx := newFixture(t)
+		spaceDir := filepath.Join(t.TempDir(), "spaceid")
+		fx.storage.EXPECT().StoreDir(spaceId).Return(spaceDir).AnyTimes()
+
+		fx.indexStorage.EXPECT().SpaceStatusEntry(gomock.Any(), spaceId).Return(nodestorage.SpaceStatusEntry{ArchiveChecksum: "other"}, nil)
+		fx.archiveStore.EXPECT().Get(gomock.Any(), spaceId).Return(io.NopCloser(bytes.NewReader(arc.Bytes())), nil)
+		fx.indexStorage.EXPECT().MarkError(gomock.Any(), spaceId, gomock.Any())
+
+		require.ErrorIs(t, fx.Restore(ctx, spaceId), ErrCorrupted)
+	})
+	t.Run("store error", func(t *testing.T) {
+		fx := newFixture(t)
+		spaceDir := filepath.Join(t.TempDir(), "spaceid")
+		fx.storage.EXPECT().StoreDir(spaceId).Return(spaceDir).AnyTimes()
+
+		fx.indexStorage.EXPECT().SpaceStatusEntry(gomock.Any(), spaceId).Return(nodestorage.SpaceStatusEntry{ArchiveChecksum: checksum}, nil)
+		fx.archiveStore.EXPECT().Get(gomock.Any(), spaceId).Return(nil, archivestore.ErrNotFound)
+
+		require.ErrorIs(t, fx.Restore(ctx, spaceId), archivestore.ErrNotFound)
+	})
+}
+
+func TestArchive_UploadFailed(t *testing.T) {
+	fx := newFixture(t)
+	var spaceId = "space.id"
+
+	spaceDir := filepath.Join(t.TempDir(), "spaceid")
+	require.NoError(t, os.Mkdir(spaceDir, 0755))
+
+	db, err := anystore.Open(ctx, filepath.Join(spaceDir, "test.db"), nil)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.CreateCollection(ctx, "test")
+	require.NoError(t, err)
+
+	fx.storage.EXPECT().
+		TryLockAndOpenDb(ctx, spaceId, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, openFunc nodestorage.DoAfterOpenFunc) error {
+			return openFunc(db)
+		})
+	// the upload stops without reading the stream, the archive writer must not hang
+	uploadErr := errors.New("upload failed")
+	fx.archiveStore.EXPECT().PutStream(ctx, spaceId, gomock.Any(), true).Return(uploadErr)
+
+	assert.ErrorIs(t, fx.Archive.(*archive).Archive(ctx, spaceId), uploadErr)
+	_, err = os.Stat(filepath.Join(spaceDir, "test.db"))
+	require.NoError(t, err)
+}
+
+func TestArchive_CheckUploadFailed(t *testing.T) {
+	fx := newFixtureConf(t, Config{Encryption: EncryptionConfig{
+		Enabled: true,
+		KeyId:   "k1",
+		Keys:    map[string]string{"k1": newTestKey()},
+	}})
+	fx.Archive.(*archive).policy.enabled = true
+	var spaceId = "space.id"
+	old := time.Now().Add(-time.Hour * 24 * 30)
+	threshold := time.Hour * 24 * 7
+
+	spaceDir := filepath.Join(t.TempDir(), "spaceid")
+	require.NoError(t, os.Mkdir(spaceDir, 0755))
+	fx.storage.EXPECT().StoreDir(spaceId).Return(spaceDir).AnyTimes()
+	db, err := anystore.Open(ctx, filepath.Join(spaceDir, "store.db"), nil)
+	require.NoError(t, err)
+	defer db.Close()
+
+	fx.indexStorage.EXPECT().FindInactiveSpaces(gomock.Any(), threshold, nodestorage.InactiveSpace{}, inactiveIterPageSize).
+		Return([]nodestorage.InactiveSpace{{SpaceId: spaceId, LastAccess: old}}, nil)
+	fx.indexStorage.EXPECT().SpaceStatusEntry(gomock.Any(), spaceId).Return(nodestorage.SpaceStatusEntry{SpaceId: spaceId, LastAccess: old}, nil)
+	fx.storage.EXPECT().TryLockAndOpenDb(gomock.Any(), spaceId, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, openFunc nodestorage.DoAfterOpenFunc) error {
+			return openFunc(db)
+		})
+	// encrypted archives are uploaded without resume
+	uploadErr := errors.New("upload failed")
+	fx.archiveStore.EXPECT().PutStream(gomock.Any(), spaceId, gomock.Any(), false).Return(uploadErr)
+	// the failed space isn't retried, so its upload is aborted
+	fx.archiveStore.EXPECT().AbortStream(gomock.Any(), spaceId)
+	fx.indexStorage.EXPECT().MarkError(gomock.Any(), spaceId, gomock.Any())
+
+	require.NoError(t, fx.Archive.(*archive).check(ctx))
+}
+
+func TestArchive_VerifyUpload(t *testing.T) {
+	fx := newFixtureConf(t, Config{VerifyUpload: true})
+	var spaceId = "space.id"
+
+	spaceDir := filepath.Join(t.TempDir(), "spaceid")
+	require.NoError(t, os.Mkdir(spaceDir, 0755))
+	fx.storage.EXPECT().StoreDir(spaceId).Return(spaceDir).AnyTimes()
+
+	db, err := anystore.Open(ctx, filepath.Join(spaceDir, "test.db"), nil)
+	require.NoError(t, err)
+	_, err = db.CreateCollection(ctx, "test")
+	require.NoError(t, err)
+
+	fx.storage.EXPECT().
+		TryLockAndOpenDb(ctx, spaceId, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, openFunc nodestorage.DoAfterOpenFunc) error {
+			return openFunc(db)
+		})
+
+	// the store returns a broken object
+	var stored []byte
+	fx.archiveStore.EXPECT().PutStream(ctx, spaceId, gomock.Any(), true).DoAndReturn(func(ctx context.Context, spaceId string, rd io.Reader, _ bool) (err error) {
+		stored, err = io.ReadAll(rd)
+		stored[len(stored)-10] ^= 0xff
+		return
+	})
+	fx.archiveStore.EXPECT().Get(ctx, spaceId).DoAndReturn(func(_ context.Context, _ string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(stored)), nil
+	})
+	fx.archiveStore.EXPECT().Delete(ctx, spaceId)
+
+	require.Error(t, fx.Archive.(*archive).Archive(ctx, spaceId))
+	require.NoError(t, db.Close())
+
+	// local copy must be kept
+	_, err = os.Stat(filepath.Join(spaceDir, "test.db"))
+	require.NoError(t, err)
+}
+
 type fixture struct {
 	Archive
 	a            *app.App
@@ -89,6 +294,10 @@ type fixture struct {
 }
 
 func newFixture(t *testing.T) *fixture {
+	return newFixtureConf(t, Config{})
+}
+
+func newFixtureConf(t *testing.T, conf Config) *fixture {
 	ctrl := gomock.NewController(t)
 	fx := &fixture{
 		a:            new(app.App),
@@ -109,7 +318,7 @@ func newFixture(t *testing.T) *fixture {
 	fx.a.Register(fx.archiveStore).
 		Register(fx.nodeSync).
 		Register(fx.storage).
-		Register(&testConfig{}).
+		Register(&testConfig{conf: conf}).
 		Register(fx.Archive)
 
 	require.NoError(t, fx.a.Start(ctx))
@@ -123,6 +332,7 @@ func newFixture(t *testing.T) *fixture {
 }
 
 type testConfig struct {
+	conf Config
 }
 
 func (t testConfig) Init(_ *app.App) error {
@@ -134,5 +344,5 @@ func (t testConfig) Name() string {
 }
 
 func (t testConfig) GetArchive() Config {
-	return Config{}
+	return t.conf
 }
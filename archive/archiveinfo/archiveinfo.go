@@ -0,0 +1,117 @@
+// Package archiveinfo contains types of the archive admin API.
+// It has no dependencies, so mocks of archive.Archive can be used by any package.
+package archiveinfo
+
+import "time"
+
+type SpaceInfo struct {
+	SpaceId          string    `json:"spaceId"`
+	Status           string    `json:"status"`
+	Archived         bool      `json:"archived"`
+	SizeCompressed   int64     `json:"sizeCompressed"`
+	SizeUncompressed int64     `json:"sizeUncompressed"`
+	Checksum         string    `json:"checksum,omitempty"`
+	LastAccess       time.Time `json:"lastAccess"`
+	Error            string    `json:"error,omitempty"`
+}
+
+type ListFilter struct {
+	MinSizeUncompressed int64
+	LastAccessBefore    time.Time
+	Limit               int
+}
+
+type ListResult struct {
+	Spaces     []SpaceInfo `json:"spaces"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
+// Reconcile actions, in a dry run they describe what would be done
+const (
+	// ActionNone means the problem is only reported
+	ActionNone = "none"
+	// ActionSkip means the item changed during the run or is too fresh to be judged
+	ActionSkip = "skip"
+	// ActionDeleteObject removes the orphaned object from the archive store
+	ActionDeleteObject = "deleteObject"
+	// ActionMarkOk returns the space to the ok status because its local data exists
+	ActionMarkOk = "markOk"
+	// ActionMarkError puts the space to the error status because its archive is lost
+	ActionMarkError = "markError"
+)
+
+// ReconcileItem is a single mismatch between the archive store and the index
+type ReconcileItem struct {
+	SpaceId string `json:"spaceId"`
+	Status  string `json:"status,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+	Action  string `json:"action"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ReconcileReport is the result of comparing the archive store with the index
+type ReconcileReport struct {
+	DryRun    bool      `json:"dryRun"`
+	StartedAt time.Time `json:"startedAt"`
+	// Objects is the number of objects found in the archive store
+	Objects int `json:"objects"`
+	// Archived is the number of archived spaces found in the index
+	Archived int `json:"archived"`
+	// Orphans are objects without an archived space in the index
+	Orphans []ReconcileItem `json:"orphans"`
+	// Dangling are archived spaces without an object in the archive store
+	Dangling []ReconcileItem `json:"dangling"`
+	Repaired int             `json:"repaired"`
+}
+
+// Policy decision reasons
+const (
+	ReasonEligible         = "eligible for archiving"
+	ReasonDisabled         = "archiving is disabled"
+	ReasonStatus           = "space status is not ok"
+	ReasonDenied           = "space is in the deny list"
+	ReasonNotAllowed       = "space is not in the allow list"
+	ReasonTooSmall         = "space is smaller than the minimum size"
+	ReasonRecentlyAccessed = "space was accessed recently"
+)
+
+// PolicyDecision explains whether the archive policy takes the space
+type PolicyDecision struct {
+	SpaceId    string    `json:"spaceId"`
+	Archive    bool      `json:"archive"`
+	Reason     string    `json:"reason"`
+	Status     string    `json:"status"`
+	LastAccess time.Time `json:"lastAccess"`
+	SizeBytes  int64     `json:"sizeBytes"`
+	// ArchiveAfter is the effective age threshold, it's lower than configured when the disk is almost full
+	ArchiveAfter     string  `json:"archiveAfter"`
+	DiskUsagePercent float64 `json:"diskUsagePercent"`
+}
+
+// Restore job states
+const (
+	RestoreQueued  = "queued"
+	RestoreRunning = "running"
+	RestoreDone    = "done"
+	RestoreFailed  = "failed"
+)
+
+// RestoreJob is a restore of an archived space, finished jobs are kept for a while
+type RestoreJob struct {
+	SpaceId    string    `json:"spaceId"`
+	State      string    `json:"state"`
+	CreatedAt  time.Time `json:"createdAt"`
+	StartedAt  time.Time `json:"startedAt,omitempty"`
+	FinishedAt time.Time `json:"finishedAt,omitempty"`
+	// BytesRead is the number of archive bytes downloaded, BytesTotal is the archive size
+	BytesRead  int64   `json:"bytesRead"`
+	BytesTotal int64   `json:"bytesTotal"`
+	Progress   float64 `json:"progress"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// PrefetchResult lists spaces queued for restore and spaces skipped with the reason
+type PrefetchResult struct {
+	Queued  []string          `json:"queued"`
+	Skipped map[string]string `json:"skipped,omitempty"`
+}
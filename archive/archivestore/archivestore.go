@@ -6,14 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"strings"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/anyproto/any-sync/app"
 	"github.com/anyproto/any-sync/app/logger"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
+	"go.uber.org/zap"
 )
 
 const CName = "node.archive.store"
@@ -21,8 +20,9 @@ const CName = "node.archive.store"
 var log = logger.NewNamed(CName)
 
 var (
-	ErrNotFound = errors.New("archive store: not found")
-	ErrDisabled = errors.New("archive store is disabled")
+	ErrNotFound       = errors.New("archive store: not found")
+	ErrDisabled       = errors.New("archive store is disabled")
+	ErrUnknownBackend = errors.New("archive store: unknown backend type")
 )
 
 func New() ArchiveStore {
@@ -32,63 +32,109 @@ func New() ArchiveStore {
 type ArchiveStore interface {
 	app.Component
 	Get(ctx context.Context, name string) (data io.ReadCloser, err error)
+	// Stat returns the object info without reading the object
+	Stat(ctx context.Context, name string) (obj ObjectInfo, err error)
 	Put(ctx context.Context, name string, data io.ReadSeeker) (err error)
+	// PutStream uploads data of unknown size with a multipart upload when the backend supports it.
+	// With resume, an interrupted upload of the same object is resumed; the data must be reproducible for it, so
+	// encrypted data with a random nonce must be uploaded without resume.
+	PutStream(ctx context.Context, name string, data io.Reader, resume bool) (err error)
+	// AbortStream removes unfinished uploads of the object, it does nothing for backends without multipart support
+	AbortStream(ctx context.Context, name string) (err error)
 	Delete(ctx context.Context, name string) (err error)
+	List(ctx context.Context, iterFunc func(obj ObjectInfo) (bool, error)) (err error)
+}
+
+// ObjectInfo describes a stored object, the name doesn't include the key prefix
+type ObjectInfo struct {
+	Name     string
+	Size     int64
+	Modified time.Time
+}
+
+// Backend is an object storage implementation used by the ArchiveStore component.
+// Every backend must follow the same contract:
+//   - Get and Stat return ErrNotFound when the object doesn't exist
+//   - Put overwrites an existing object
+//   - Delete of a missing object is not an error
+//   - List iterates all objects under the key prefix until iterFunc returns false or an error
+type Backend interface {
+	Get(ctx context.Context, name string) (data io.ReadCloser, err error)
+	Stat(ctx context.Context, name string) (obj ObjectInfo, err error)
+	Put(ctx context.Context, name string, data io.ReadSeeker) (err error)
+	Delete(ctx context.Context, name string) (err error)
+	List(ctx context.Context, iterFunc func(obj ObjectInfo) (bool, error)) (err error)
+}
+
+// BackendConstructor creates a backend from the given config
+type BackendConstructor func(conf Config) (Backend, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]BackendConstructor{}
+)
+
+// RegisterBackend makes a backend available by the given type name
+func RegisterBackend(typ string, constructor BackendConstructor) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if _, ok := backends[typ]; ok {
+		panic(fmt.Sprintf("archive store: backend %q registered twice", typ))
+	}
+	backends[typ] = constructor
+}
+
+// Backends returns the sorted list of registered backend types
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	types := make([]string, 0, len(backends))
+	for typ := range backends {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// NewBackend creates a backend registered with conf.Type
+func NewBackend(conf Config) (Backend, error) {
+	typ := conf.Type
+	if typ == "" {
+		typ = TypeS3
+	}
+	backendsMu.RLock()
+	constructor, ok := backends[typ]
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBackend, typ)
+	}
+	return constructor(conf)
 }
 
 type archiveStore struct {
-	sess      *session.Session
-	bucket    *string
-	client    *s3.S3
-	keyPrefix string
-	enabled   bool
+	backend  Backend
+	enabled  bool
+	partSize int
 }
 
 func (as *archiveStore) Init(a *app.App) (err error) {
-	conf := a.MustComponent("config").(configSource).GetS3Store()
+	conf := a.MustComponent("config").(configSource).GetArchiveStore()
 	if !conf.Enabled {
 		return
 	}
-	if conf.Profile == "" {
-		conf.Profile = "default"
-	}
-	if conf.Bucket == "" {
-		return fmt.Errorf("s3 bucket is empty")
-	}
-
-	var endpoint *string
-	if conf.Endpoint != "" {
-		endpoint = aws.String(conf.Endpoint)
+	if as.backend, err = NewBackend(conf); err != nil {
+		return
 	}
-
-	var creds *credentials.Credentials
-	// If creds are provided in the configuration, they are directly forwarded to the client as static credentials.
-	// This is mainly used for self-hosted scenarii where users store the data in a S3-compatible object store. In that
-	// case it does not really make sense to create an AWS configuration since there is no related AWS account.
-	// If credentials are not provided in the config however, the AWS credentials are determined by the SDK.
-	if conf.Credentials.AccessKey != "" && conf.Credentials.SecretKey != "" {
-		creds = credentials.NewStaticCredentials(conf.Credentials.AccessKey, conf.Credentials.SecretKey, "")
+	if conf.PartSizeMb <= 0 {
+		conf.PartSizeMb = defaultPartSizeMb
 	}
-
-	as.sess, err = session.NewSessionWithOptions(session.Options{
-		Profile: conf.Profile,
-		Config: aws.Config{
-			Region:      aws.String(conf.Region),
-			Endpoint:    endpoint,
-			Credentials: creds,
-			// By default S3 client uses virtual hosted bucket addressing when possible but this cannot work
-			// for self-hosted. We can switch to path style instead with a configuration flag.
-			S3ForcePathStyle: aws.Bool(conf.ForcePathStyle),
-		},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create session to s3: %v", err)
+	if conf.PartSizeMb < minPartSizeMb {
+		return fmt.Errorf("%w: %d", ErrPartSizeSmall, conf.PartSizeMb)
 	}
-	as.bucket = aws.String(conf.Bucket)
-
-	as.client = s3.New(as.sess)
-	as.keyPrefix = conf.KeyPrefix + "/"
 	as.enabled = true
+	as.partSize = conf.PartSizeMb << 20
+	_, multipart := as.backend.(MultipartBackend)
+	log.Info("archive store enabled", zap.String("type", conf.Type), zap.Bool("multipart", multipart))
 	return
 }
 
@@ -100,41 +146,53 @@ func (as *archiveStore) Get(ctx context.Context, name string) (data io.ReadClose
 	if !as.enabled {
 		return nil, ErrDisabled
 	}
-	name = as.keyPrefix + name
-	obj, err := as.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
-		Bucket: as.bucket,
-		Key:    aws.String(name),
-	})
-	if err != nil {
-		if strings.HasPrefix(err.Error(), s3.ErrCodeNoSuchKey) {
-			return nil, ErrNotFound
-		}
-		return nil, err
+	return as.backend.Get(ctx, name)
+}
+
+func (as *archiveStore) Stat(ctx context.Context, name string) (obj ObjectInfo, err error) {
+	if !as.enabled {
+		return obj, ErrDisabled
 	}
-	return obj.Body, nil
+	return as.backend.Stat(ctx, name)
 }
 
 func (as *archiveStore) Put(ctx context.Context, name string, data io.ReadSeeker) (err error) {
 	if !as.enabled {
 		return ErrDisabled
 	}
-	name = as.keyPrefix + name
-	_, err = as.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
-		Key:    aws.String(name),
-		Body:   data,
-		Bucket: as.bucket,
-	})
-	return
+	return as.backend.Put(ctx, name, data)
+}
+
+func (as *archiveStore) PutStream(ctx context.Context, name string, data io.Reader, resume bool) (err error) {
+	if !as.enabled {
+		return ErrDisabled
+	}
+	if mb, ok := as.backend.(MultipartBackend); ok {
+		return putStream(ctx, mb, name, data, as.partSize, resume)
+	}
+	return putViaTempFile(ctx, as.backend, name, data)
+}
+
+func (as *archiveStore) AbortStream(ctx context.Context, name string) (err error) {
+	if !as.enabled {
+		return ErrDisabled
+	}
+	if mb, ok := as.backend.(MultipartBackend); ok {
+		return abortMultiparts(ctx, mb, name)
+	}
+	return nil
 }
 
 func (as *archiveStore) Delete(ctx context.Context, name string) (err error) {
 	if !as.enabled {
 		return ErrDisabled
 	}
-	name = as.keyPrefix + name
-	_, err = as.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
-		Bucket: as.bucket,
-		Key:    aws.String(name),
-	})
-	return
+	return as.backend.Delete(ctx, name)
+}
+
+func (as *archiveStore) List(ctx context.Context, iterFunc func(obj ObjectInfo) (bool, error)) (err error) {
+	if !as.enabled {
+		return ErrDisabled
+	}
+	return as.backend.List(ctx, iterFunc)
 }
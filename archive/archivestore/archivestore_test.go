@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/anyproto/any-sync/app"
@@ -17,44 +19,162 @@ func TestArchiveStore(t *testing.T) {
 	// skip the test because it needs amazon credentials
 	t.Skip()
 
+	store := newStore(t, Config{
+		Enabled:  true,
+		Type:     TypeS3,
+		Region:   "us-east-1",
+		Endpoint: "https://storage.googleapis.com",
+		Bucket:   "anytype-cheggaaa-test",
+	})
+	testBackend(t, store)
+}
+
+func TestArchiveStore_Local(t *testing.T) {
+	dir := t.TempDir()
+	store := newStore(t, Config{
+		Enabled:   true,
+		Type:      TypeLocal,
+		Path:      dir,
+		KeyPrefix: "n1",
+	})
+	testBackend(t, store)
+
+	t.Run("no temp files left", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "clean", bytes.NewReader([]byte{1})))
+		entries, err := os.ReadDir(filepath.Join(dir, "n1"))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "clean", entries[0].Name())
+	})
+	t.Run("put stream without multipart", func(t *testing.T) {
+		data := bytes.Repeat([]byte{7}, 1000)
+		require.NoError(t, store.PutStream(ctx, "stream", bytes.NewReader(data), true))
+		assert.Equal(t, data, readAll(t, store, "stream"))
+		require.NoError(t, store.Delete(ctx, "stream"))
+	})
+	t.Run("invalid name", func(t *testing.T) {
+		for _, name := range []string{"", "..", "../escape", "/abs"} {
+			assert.ErrorIs(t, store.Put(ctx, name, bytes.NewReader(nil)), ErrInvalidName, name)
+		}
+	})
+}
+
+func TestArchiveStore_Disabled(t *testing.T) {
+	store := newStore(t, Config{Type: TypeLocal})
+	_, err := store.Get(ctx, "test")
+	assert.ErrorIs(t, err, ErrDisabled)
+	_, err = store.Stat(ctx, "test")
+	assert.ErrorIs(t, err, ErrDisabled)
+	assert.ErrorIs(t, store.Put(ctx, "test", bytes.NewReader(nil)), ErrDisabled)
+	assert.ErrorIs(t, store.PutStream(ctx, "test", bytes.NewReader(nil), true), ErrDisabled)
+	assert.ErrorIs(t, store.AbortStream(ctx, "test"), ErrDisabled)
+	assert.ErrorIs(t, store.Delete(ctx, "test"), ErrDisabled)
+	assert.ErrorIs(t, store.List(ctx, nil), ErrDisabled)
+}
+
+func TestArchiveStore_UnknownBackend(t *testing.T) {
 	a := new(app.App)
-	store := New()
-	a.Register(&config{})
-	a.Register(store)
-	require.NoError(t, a.Start(ctx))
-	defer a.Close(ctx)
+	a.Register(&config{conf: Config{Enabled: true, Type: "unknown"}})
+	a.Register(New())
+	assert.ErrorIs(t, a.Start(ctx), ErrUnknownBackend)
+}
+
+func TestArchiveStore_PartSize(t *testing.T) {
+	a := new(app.App)
+	a.Register(&config{conf: Config{Enabled: true, Type: TypeLocal, Path: t.TempDir(), PartSizeMb: 4}})
+	a.Register(New())
+	assert.ErrorIs(t, a.Start(ctx), ErrPartSizeSmall)
+}
 
+// testBackend is a conformance suite that every backend must pass
+func testBackend(t *testing.T, store Backend) {
 	var dataBytes = []byte{1, 2, 3, 4, 5, 6, 7, 8, 9}
-	var data = bytes.NewReader(dataBytes)
 
-	require.NoError(t, store.Put(ctx, "test", data))
+	t.Run("get not existing", func(t *testing.T) {
+		_, err := store.Get(ctx, "not.existing")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+	t.Run("stat not existing", func(t *testing.T) {
+		_, err := store.Stat(ctx, "not.existing")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+	t.Run("put get delete", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "test", bytes.NewReader(dataBytes)))
+		assert.Equal(t, dataBytes, readAll(t, store, "test"))
+		obj, err := store.Stat(ctx, "test")
+		require.NoError(t, err)
+		assert.Equal(t, "test", obj.Name)
+		assert.Equal(t, int64(len(dataBytes)), obj.Size)
+		assert.False(t, obj.Modified.IsZero())
 
-	reader, err := store.Get(ctx, "test")
-	require.NoError(t, err)
+		require.NoError(t, store.Delete(ctx, "test"))
+		_, err = store.Get(ctx, "test")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+	t.Run("overwrite", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "overwrite", bytes.NewReader(dataBytes)))
+		require.NoError(t, store.Put(ctx, "overwrite", bytes.NewReader([]byte{42})))
+		assert.Equal(t, []byte{42}, readAll(t, store, "overwrite"))
+		require.NoError(t, store.Delete(ctx, "overwrite"))
+	})
+	t.Run("delete not existing", func(t *testing.T) {
+		assert.NoError(t, store.Delete(ctx, "not.existing"))
+	})
+	t.Run("list", func(t *testing.T) {
+		names := []string{"list.1", "list.2", "list.3"}
+		for _, name := range names {
+			require.NoError(t, store.Put(ctx, name, bytes.NewReader(dataBytes)))
+		}
+		var listed []string
+		require.NoError(t, store.List(ctx, func(obj ObjectInfo) (bool, error) {
+			listed = append(listed, obj.Name)
+			assert.Equal(t, int64(len(dataBytes)), obj.Size)
+			assert.False(t, obj.Modified.IsZero())
+			return true, nil
+		}))
+		assert.Equal(t, names, listed)
 
-	gotData, err := io.ReadAll(reader)
-	require.NoError(t, err)
-	require.NoError(t, reader.Close())
+		listed = listed[:0]
+		require.NoError(t, store.List(ctx, func(obj ObjectInfo) (bool, error) {
+			listed = append(listed, obj.Name)
+			return false, nil
+		}))
+		assert.Len(t, listed, 1)
 
-	assert.Equal(t, dataBytes, gotData)
+		for _, name := range names {
+			require.NoError(t, store.Delete(ctx, name))
+		}
+	})
+}
 
-	require.NoError(t, store.Delete(ctx, "test"))
+func readAll(t *testing.T, store Backend, name string) []byte {
+	reader, err := store.Get(ctx, name)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	return data
+}
 
-	_, err = store.Get(ctx, "test")
-	assert.ErrorIs(t, err, ErrNotFound)
+func newStore(t *testing.T, conf Config) ArchiveStore {
+	a := new(app.App)
+	store := New()
+	a.Register(&config{conf: conf})
+	a.Register(store)
+	require.NoError(t, a.Start(ctx))
+	t.Cleanup(func() {
+		_ = a.Close(ctx)
+	})
+	return store
 }
 
 type config struct {
+	conf Config
 }
 
 func (c config) Init(a *app.App) error { return nil }
 func (c config) Name() string          { return "config" }
 
-func (c config) GetS3Store() Config {
-	return Config{
-		Enabled:  true,
-		Region:   "us-east-1",
-		Endpoint: "https://storage.googleapis.com",
-		Bucket:   "anytype-cheggaaa-test",
-	}
+func (c config) GetArchiveStore() Config {
+	return c.conf
 }
@@ -1,7 +1,7 @@
 package archivestore
 
 type configSource interface {
-	GetS3Store() Config
+	GetArchiveStore() Config
 }
 
 type Credentials struct {
@@ -10,12 +10,20 @@ type Credentials struct {
 }
 
 type Config struct {
-	Enabled        bool        `yaml:"enabled"`
+	Enabled bool `yaml:"enabled"`
+	// Type is a registered backend type: s3 (default) or local
+	Type string `yaml:"type"`
+	// s3 options
 	Profile        string      `yaml:"profile"`
 	Region         string      `yaml:"region"`
 	Bucket         string      `yaml:"bucket"`
 	Endpoint       string      `yaml:"endpoint"`
 	Credentials    Credentials `yaml:"credentials"`
 	ForcePathStyle bool        `yaml:"forcePathStyle"`
-	KeyPrefix      string      `yaml:"keyPrefix"`
+	// local options
+	Path string `yaml:"path"`
+
+	KeyPrefix string `yaml:"keyPrefix"`
+	// PartSizeMb is a part size of multipart uploads, 16 by default and at least 5; it limits the object size to 10000 parts
+	PartSizeMb int `yaml:"partSizeMb"`
 }
@@ -0,0 +1,188 @@
+package archivestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const TypeLocal = "local"
+
+var ErrInvalidName = errors.New("archive store: invalid object name")
+
+func init() {
+	RegisterBackend(TypeLocal, newLocalBackend)
+}
+
+// localBackend keeps objects as files inside a local (or mounted NFS) directory
+type localBackend struct {
+	root string
+}
+
+func newLocalBackend(conf Config) (Backend, error) {
+	if conf.Path == "" {
+		return nil, fmt.Errorf("local archive store path is empty")
+	}
+	root := filepath.Join(conf.Path, conf.KeyPrefix)
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &localBackend{root: root}, nil
+}
+
+func (lb *localBackend) path(name string) (string, error) {
+	if name == "" || filepath.IsAbs(name) {
+		return "", ErrInvalidName
+	}
+	cleaned := filepath.Clean(filepath.FromSlash(name))
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidName
+	}
+	return filepath.Join(lb.root, cleaned), nil
+}
+
+func (lb *localBackend) Get(ctx context.Context, name string) (data io.ReadCloser, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	p, err := lb.path(name)
+	if err != nil {
+		return
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+func (lb *localBackend) Stat(ctx context.Context, name string) (obj ObjectInfo, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	p, err := lb.path(name)
+	if err != nil {
+		return
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return obj, ErrNotFound
+		}
+		return obj, err
+	}
+	if info.IsDir() {
+		return obj, ErrNotFound
+	}
+	return ObjectInfo{Name: name, Size: info.Size(), Modified: info.ModTime()}, nil
+}
+
+// Put writes data to a temp file in the target dir and atomically renames it
+func (lb *localBackend) Put(ctx context.Context, name string, data io.ReadSeeker) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	p, err := lb.path(name)
+	if err != nil {
+		return
+	}
+	dir := filepath.Dir(p)
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(p)+".tmp*")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = io.Copy(tmp, data); err != nil {
+		return
+	}
+	if err = tmp.Sync(); err != nil {
+		return
+	}
+	if err = tmp.Close(); err != nil {
+		return
+	}
+	if err = os.Rename(tmp.Name(), p); err != nil {
+		return
+	}
+	syncDir(dir)
+	return
+}
+
+func (lb *localBackend) Delete(ctx context.Context, name string) (err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	p, err := lb.path(name)
+	if err != nil {
+		return
+	}
+	if err = os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return
+	}
+	return nil
+}
+
+// List walks the root dir in lexical order, temp files of unfinished writes are skipped
+func (lb *localBackend) List(ctx context.Context, iterFunc func(obj ObjectInfo) (bool, error)) (err error) {
+	err = filepath.WalkDir(lb.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		name, err := filepath.Rel(lb.root, p)
+		if err != nil {
+			return err
+		}
+		cont, err := iterFunc(ObjectInfo{
+			Name:     filepath.ToSlash(name),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+		})
+		if err != nil {
+			return err
+		}
+		if !cont {
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return
+}
+
+// syncDir flushes directory entries after rename; errors are ignored because not every filesystem supports it
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
@@ -14,6 +14,7 @@ import (
 	io "io"
 	reflect "reflect"
 
+	archivestore "github.com/anyproto/any-sync-node/archive/archivestore"
 	app "github.com/anyproto/any-sync/app"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -42,6 +43,20 @@ func (m *MockArchiveStore) EXPECT() *MockArchiveStoreMockRecorder {
 	return m.recorder
 }
 
+// AbortStream mocks base method.
+func (m *MockArchiveStore) AbortStream(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbortStream", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AbortStream indicates an expected call of AbortStream.
+func (mr *MockArchiveStoreMockRecorder) AbortStream(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortStream", reflect.TypeOf((*MockArchiveStore)(nil).AbortStream), ctx, name)
+}
+
 // Delete mocks base method.
 func (m *MockArchiveStore) Delete(ctx context.Context, name string) error {
 	m.ctrl.T.Helper()
@@ -85,6 +100,20 @@ func (mr *MockArchiveStoreMockRecorder) Init(a any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockArchiveStore)(nil).Init), a)
 }
 
+// List mocks base method.
+func (m *MockArchiveStore) List(ctx context.Context, iterFunc func(archivestore.ObjectInfo) (bool, error)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, iterFunc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// List indicates an expected call of List.
+func (mr *MockArchiveStoreMockRecorder) List(ctx, iterFunc any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockArchiveStore)(nil).List), ctx, iterFunc)
+}
+
 // Name mocks base method.
 func (m *MockArchiveStore) Name() string {
 	m.ctrl.T.Helper()
@@ -112,3 +141,32 @@ func (mr *MockArchiveStoreMockRecorder) Put(ctx, name, data any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Put", reflect.TypeOf((*MockArchiveStore)(nil).Put), ctx, name, data)
 }
+
+// PutStream mocks base method.
+func (m *MockArchiveStore) PutStream(ctx context.Context, name string, data io.Reader, resume bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutStream", ctx, name, data, resume)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutStream indicates an expected call of PutStream.
+func (mr *MockArchiveStoreMockRecorder) PutStream(ctx, name, data, resume any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutStream", reflect.TypeOf((*MockArchiveStore)(nil).PutStream), ctx, name, data, resume)
+}
+
+// Stat mocks base method.
+func (m *MockArchiveStore) Stat(ctx context.Context, name string) (archivestore.ObjectInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stat", ctx, name)
+	ret0, _ := ret[0].(archivestore.ObjectInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Stat indicates an expected call of Stat.
+func (mr *MockArchiveStoreMockRecorder) Stat(ctx, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stat", reflect.TypeOf((*MockArchiveStore)(nil).Stat), ctx, name)
+}
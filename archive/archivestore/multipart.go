@@ -0,0 +1,165 @@
+package archivestore
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPartSizeMb = 16
+	// minPartSizeMb is the s3 limit of all parts except the last one
+	minPartSizeMb = 5
+	// maxParts is the s3 limit of parts in one upload
+	maxParts = 10000
+)
+
+var (
+	ErrTooLarge      = errors.New("archive store: object is too large for multipart upload")
+	ErrPartSizeSmall = fmt.Errorf("archive store: part size must be at least %d MiB", minPartSizeMb)
+)
+
+// Part is an uploaded part of a multipart upload, numbers start from 1
+type Part struct {
+	Number int
+	ETag   string
+	Size   int64
+}
+
+// MultipartBackend is an optional Backend extension for uploading objects in parts.
+// An unfinished upload stays in the backend after a failure, so the next upload of the same object can resume it.
+// The ETag of a part must be the hex md5 of its data, otherwise the parts are just uploaded again.
+type MultipartBackend interface {
+	Backend
+	// FindMultipart returns the latest unfinished upload of the object or ErrNotFound
+	FindMultipart(ctx context.Context, name string) (uploadId string, err error)
+	CreateMultipart(ctx context.Context, name string) (uploadId string, err error)
+	ListParts(ctx context.Context, name, uploadId string) (parts []Part, err error)
+	UploadPart(ctx context.Context, name, uploadId string, number int, data io.ReadSeeker) (etag string, err error)
+	CompleteMultipart(ctx context.Context, name, uploadId string, parts []Part) (err error)
+	AbortMultipart(ctx context.Context, name, uploadId string) (err error)
+}
+
+// putStream uploads data in parts of partSize bytes, so only one part is kept in memory.
+// With resume, parts of an unfinished upload with the same content are not uploaded again;
+// without it, unfinished uploads are aborted and the object is uploaded from the start.
+func putStream(ctx context.Context, mb MultipartBackend, name string, data io.Reader, partSize int, resume bool) (err error) {
+	var (
+		uploadId string
+		existing map[int]Part
+	)
+	if resume {
+		uploadId, existing, err = findOrCreateMultipart(ctx, mb, name)
+	} else if err = abortMultiparts(ctx, mb, name); err == nil {
+		uploadId, err = mb.CreateMultipart(ctx, name)
+	}
+	if err != nil {
+		return
+	}
+	var (
+		buf     = make([]byte, partSize)
+		parts   []Part
+		resumed int
+	)
+	for number := 1; ; number++ {
+		n, rErr := io.ReadFull(data, buf)
+		if rErr != nil && rErr != io.EOF && rErr != io.ErrUnexpectedEOF {
+			return rErr
+		}
+		// the empty object still needs one part
+		if n == 0 && number > 1 {
+			break
+		}
+		if number > maxParts {
+			// can't be finished with this part size, so there is nothing to resume
+			_ = mb.AbortMultipart(ctx, name, uploadId)
+			return ErrTooLarge
+		}
+		chunk := buf[:n]
+		sum := md5.Sum(chunk)
+		if part, ok := existing[number]; ok && part.ETag == hex.EncodeToString(sum[:]) && part.Size == int64(n) {
+			parts = append(parts, part)
+			resumed++
+		} else {
+			etag, uErr := mb.UploadPart(ctx, name, uploadId, number, bytes.NewReader(chunk))
+			if uErr != nil {
+				return uErr
+			}
+			parts = append(parts, Part{Number: number, ETag: etag, Size: int64(n)})
+		}
+		if rErr != nil {
+			break
+		}
+	}
+	if resumed > 0 {
+		log.Info("multipart upload resumed", zap.String("name", name), zap.Int("resumedParts", resumed), zap.Int("parts", len(parts)))
+	}
+	return mb.CompleteMultipart(ctx, name, uploadId, parts)
+}
+
+func findOrCreateMultipart(ctx context.Context, mb MultipartBackend, name string) (uploadId string, existing map[int]Part, err error) {
+	uploadId, err = mb.FindMultipart(ctx, name)
+	if errors.Is(err, ErrNotFound) {
+		uploadId, err = mb.CreateMultipart(ctx, name)
+		return
+	}
+	if err != nil {
+		return
+	}
+	parts, err := mb.ListParts(ctx, name, uploadId)
+	if err != nil {
+		return
+	}
+	existing = make(map[int]Part, len(parts))
+	for _, part := range parts {
+		existing[part.Number] = part
+	}
+	return
+}
+
+// abortMultiparts aborts all unfinished uploads of the object
+func abortMultiparts(ctx context.Context, mb MultipartBackend, name string) error {
+	var prevId string
+	for {
+		uploadId, err := mb.FindMultipart(ctx, name)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if uploadId == prevId {
+			return fmt.Errorf("archive store: multipart upload %q of %q is not aborted", uploadId, name)
+		}
+		if err = mb.AbortMultipart(ctx, name, uploadId); err != nil {
+			return err
+		}
+		prevId = uploadId
+	}
+}
+
+// putViaTempFile is a fallback for backends without multipart support, the data is buffered in a temp file
+func putViaTempFile(ctx context.Context, b Backend, name string, data io.Reader) (err error) {
+	tmp, err := os.CreateTemp("", "archive-upload-*")
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err = io.Copy(tmp, data); err != nil {
+		return fmt.Errorf("buffer upload: %w", err)
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+	return b.Put(ctx, name, tmp)
+}
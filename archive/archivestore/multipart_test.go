@@ -0,0 +1,245 @@
+package archivestore
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPutStream(t *testing.T) {
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	t.Run("parts", func(t *testing.T) {
+		mb := newMemMultipart()
+		require.NoError(t, putStream(ctx, mb, "obj", bytes.NewReader(data), 300, true))
+		assert.Equal(t, data, mb.objects["obj"])
+		assert.Equal(t, 4, mb.uploads)
+		assert.Empty(t, mb.multiparts)
+	})
+	t.Run("empty", func(t *testing.T) {
+		mb := newMemMultipart()
+		require.NoError(t, putStream(ctx, mb, "obj", bytes.NewReader(nil), 300, true))
+		assert.Empty(t, mb.objects["obj"])
+		assert.Equal(t, 1, mb.uploads)
+	})
+	t.Run("resume", func(t *testing.T) {
+		mb := newMemMultipart()
+		mb.failOnPart = 3
+		require.Error(t, putStream(ctx, mb, "obj", bytes.NewReader(data), 300, true))
+		assert.Len(t, mb.multiparts, 1, true)
+		assert.Equal(t, 2, mb.uploads)
+
+		mb.failOnPart = 0
+		mb.uploads = 0
+		require.NoError(t, putStream(ctx, mb, "obj", bytes.NewReader(data), 300, true))
+		assert.Equal(t, data, mb.objects["obj"])
+		// only parts 3 and 4 are uploaded
+		assert.Equal(t, 2, mb.uploads)
+		assert.Empty(t, mb.multiparts)
+	})
+	t.Run("resume changed data", func(t *testing.T) {
+		mb := newMemMultipart()
+		mb.failOnPart = 3
+		require.Error(t, putStream(ctx, mb, "obj", bytes.NewReader(data), 300, true))
+
+		changed := bytes.Clone(data)
+		changed[0] = 42
+		mb.failOnPart = 0
+		mb.uploads = 0
+		require.NoError(t, putStream(ctx, mb, "obj", bytes.NewReader(changed), 300, true))
+		assert.Equal(t, changed, mb.objects["obj"])
+		// the first part differs, the second one is reused
+		assert.Equal(t, 3, mb.uploads)
+	})
+	t.Run("without resume", func(t *testing.T) {
+		mb := newMemMultipart()
+		mb.failOnPart = 3
+		require.Error(t, putStream(ctx, mb, "obj", bytes.NewReader(data), 300, false))
+		require.Error(t, putStream(ctx, mb, "obj", bytes.NewReader(data), 300, false))
+		// the previous unfinished upload is aborted before the new one
+		assert.Len(t, mb.multiparts, 1)
+
+		mb.failOnPart = 0
+		mb.uploads = 0
+		require.NoError(t, putStream(ctx, mb, "obj", bytes.NewReader(data), 300, false))
+		assert.Equal(t, data, mb.objects["obj"])
+		assert.Equal(t, 4, mb.uploads)
+		assert.Empty(t, mb.multiparts)
+	})
+	t.Run("abort", func(t *testing.T) {
+		mb := newMemMultipart()
+		_, err := mb.CreateMultipart(ctx, "obj")
+		require.NoError(t, err)
+		_, err = mb.CreateMultipart(ctx, "obj")
+		require.NoError(t, err)
+		_, err = mb.CreateMultipart(ctx, "other")
+		require.NoError(t, err)
+		require.NoError(t, abortMultiparts(ctx, mb, "obj"))
+		assert.Len(t, mb.multiparts, 1)
+		assert.Contains(t, mb.multiparts, mb.uploadKey("other", "3"))
+	})
+	t.Run("too large", func(t *testing.T) {
+		mb := newMemMultipart()
+		err := putStream(ctx, mb, "obj", io.LimitReader(zeroReader{}, maxParts+1), 1, true)
+		assert.ErrorIs(t, err, ErrTooLarge)
+		assert.Empty(t, mb.multiparts)
+	})
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// memMultipart is an in-memory multipart backend
+type memMultipart struct {
+	mu         sync.Mutex
+	objects    map[string][]byte
+	multiparts map[string]map[int][]byte
+	nextId     int
+	uploads    int
+	failOnPart int
+}
+
+func newMemMultipart() *memMultipart {
+	return &memMultipart{
+		objects:    map[string][]byte{},
+		multiparts: map[string]map[int][]byte{},
+	}
+}
+
+func (m *memMultipart) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memMultipart) Stat(_ context.Context, name string) (ObjectInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[name]
+	if !ok {
+		return ObjectInfo{}, ErrNotFound
+	}
+	return ObjectInfo{Name: name, Size: int64(len(data))}, nil
+}
+
+func (m *memMultipart) Put(_ context.Context, name string, data io.ReadSeeker) error {
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[name] = b
+	return nil
+}
+
+func (m *memMultipart) Delete(_ context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, name)
+	return nil
+}
+
+func (m *memMultipart) List(_ context.Context, iterFunc func(obj ObjectInfo) (bool, error)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, data := range m.objects {
+		if cont, err := iterFunc(ObjectInfo{Name: name, Size: int64(len(data))}); err != nil || !cont {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memMultipart) uploadKey(name, uploadId string) string {
+	return name + "/" + uploadId
+}
+
+func (m *memMultipart) FindMultipart(_ context.Context, name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.multiparts {
+		if id, ok := strings.CutPrefix(key, name+"/"); ok {
+			return id, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+func (m *memMultipart) CreateMultipart(_ context.Context, name string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextId++
+	id := fmt.Sprint(m.nextId)
+	m.multiparts[m.uploadKey(name, id)] = map[int][]byte{}
+	return id, nil
+}
+
+func (m *memMultipart) ListParts(_ context.Context, name, uploadId string) (parts []Part, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for number, data := range m.multiparts[m.uploadKey(name, uploadId)] {
+		sum := md5.Sum(data)
+		parts = append(parts, Part{Number: number, ETag: hex.EncodeToString(sum[:]), Size: int64(len(data))})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	return
+}
+
+func (m *memMultipart) UploadPart(_ context.Context, name, uploadId string, number int, data io.ReadSeeker) (string, error) {
+	if number == m.failOnPart {
+		return "", errors.New("upload failed")
+	}
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return "", err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.uploads++
+	m.multiparts[m.uploadKey(name, uploadId)][number] = b
+	sum := md5.Sum(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (m *memMultipart) CompleteMultipart(_ context.Context, name, uploadId string, parts []Part) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	uploaded := m.multiparts[m.uploadKey(name, uploadId)]
+	var buf bytes.Buffer
+	for _, part := range parts {
+		buf.Write(uploaded[part.Number])
+	}
+	m.objects[name] = buf.Bytes()
+	delete(m.multiparts, m.uploadKey(name, uploadId))
+	return nil
+}
+
+func (m *memMultipart) AbortMultipart(_ context.Context, name, uploadId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.multiparts, m.uploadKey(name, uploadId))
+	return nil
+}
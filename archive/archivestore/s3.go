@@ -0,0 +1,252 @@
+package archivestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+const TypeS3 = "s3"
+
+func init() {
+	RegisterBackend(TypeS3, newS3Backend)
+}
+
+var _ MultipartBackend = (*s3Backend)(nil)
+
+type s3Backend struct {
+	sess      *session.Session
+	bucket    *string
+	client    *s3.S3
+	keyPrefix string
+}
+
+func newS3Backend(conf Config) (Backend, error) {
+	if conf.Profile == "" {
+		conf.Profile = "default"
+	}
+	if conf.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is empty")
+	}
+
+	var endpoint *string
+	if conf.Endpoint != "" {
+		endpoint = aws.String(conf.Endpoint)
+	}
+
+	var creds *credentials.Credentials
+	// If creds are provided in the configuration, they are directly forwarded to the client as static credentials.
+	// This is mainly used for self-hosted scenarii where users store the data in a S3-compatible object store. In that
+	// case it does not really make sense to create an AWS configuration since there is no related AWS account.
+	// If credentials are not provided in the config however, the AWS credentials are determined by the SDK.
+	if conf.Credentials.AccessKey != "" && conf.Credentials.SecretKey != "" {
+		creds = credentials.NewStaticCredentials(conf.Credentials.AccessKey, conf.Credentials.SecretKey, "")
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Profile: conf.Profile,
+		Config: aws.Config{
+			Region:      aws.String(conf.Region),
+			Endpoint:    endpoint,
+			Credentials: creds,
+			// By default S3 client uses virtual hosted bucket addressing when possible but this cannot work
+			// for self-hosted. We can switch to path style instead with a configuration flag.
+			S3ForcePathStyle: aws.Bool(conf.ForcePathStyle),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session to s3: %v", err)
+	}
+	return &s3Backend{
+		sess:      sess,
+		bucket:    aws.String(conf.Bucket),
+		client:    s3.New(sess),
+		keyPrefix: conf.KeyPrefix + "/",
+	}, nil
+}
+
+func (sb *s3Backend) Get(ctx context.Context, name string) (data io.ReadCloser, err error) {
+	name = sb.keyPrefix + name
+	obj, err := sb.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: sb.bucket,
+		Key:    aws.String(name),
+	})
+	if err != nil {
+		if strings.HasPrefix(err.Error(), s3.ErrCodeNoSuchKey) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return obj.Body, nil
+}
+
+func (sb *s3Backend) Stat(ctx context.Context, name string) (obj ObjectInfo, err error) {
+	head, err := sb.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: sb.bucket,
+		Key:    aws.String(sb.keyPrefix + name),
+	})
+	if err != nil {
+		// HEAD responses have no body, so a missing object is only recognizable by the status code
+		var reqErr awserr.RequestFailure
+		if errors.As(err, &reqErr) && reqErr.StatusCode() == http.StatusNotFound {
+			return obj, ErrNotFound
+		}
+		return obj, err
+	}
+	return ObjectInfo{
+		Name:     name,
+		Size:     aws.Int64Value(head.ContentLength),
+		Modified: aws.TimeValue(head.LastModified),
+	}, nil
+}
+
+func (sb *s3Backend) Put(ctx context.Context, name string, data io.ReadSeeker) (err error) {
+	name = sb.keyPrefix + name
+	_, err = sb.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Key:    aws.String(name),
+		Body:   data,
+		Bucket: sb.bucket,
+	})
+	return
+}
+
+func (sb *s3Backend) Delete(ctx context.Context, name string) (err error) {
+	name = sb.keyPrefix + name
+	_, err = sb.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: sb.bucket,
+		Key:    aws.String(name),
+	})
+	return
+}
+
+func (sb *s3Backend) List(ctx context.Context, iterFunc func(obj ObjectInfo) (bool, error)) (err error) {
+	var iterErr error
+	err = sb.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: sb.bucket,
+		Prefix: aws.String(sb.keyPrefix),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, obj := range page.Contents {
+			cont, err := iterFunc(ObjectInfo{
+				Name:     strings.TrimPrefix(aws.StringValue(obj.Key), sb.keyPrefix),
+				Size:     aws.Int64Value(obj.Size),
+				Modified: aws.TimeValue(obj.LastModified),
+			})
+			if err != nil {
+				iterErr = err
+				return false
+			}
+			if !cont {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return
+	}
+	return iterErr
+}
+
+func (sb *s3Backend) FindMultipart(ctx context.Context, name string) (uploadId string, err error) {
+	key := sb.keyPrefix + name
+	var latest *s3.MultipartUpload
+	err = sb.client.ListMultipartUploadsPagesWithContext(ctx, &s3.ListMultipartUploadsInput{
+		Bucket: sb.bucket,
+		Prefix: aws.String(key),
+	}, func(page *s3.ListMultipartUploadsOutput, _ bool) bool {
+		for _, upload := range page.Uploads {
+			if aws.StringValue(upload.Key) != key {
+				continue
+			}
+			if latest == nil || aws.TimeValue(upload.Initiated).After(aws.TimeValue(latest.Initiated)) {
+				latest = upload
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return
+	}
+	if latest == nil {
+		return "", ErrNotFound
+	}
+	return aws.StringValue(latest.UploadId), nil
+}
+
+func (sb *s3Backend) CreateMultipart(ctx context.Context, name string) (uploadId string, err error) {
+	out, err := sb.client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+		Bucket: sb.bucket,
+		Key:    aws.String(sb.keyPrefix + name),
+	})
+	if err != nil {
+		return
+	}
+	return aws.StringValue(out.UploadId), nil
+}
+
+func (sb *s3Backend) ListParts(ctx context.Context, name, uploadId string) (parts []Part, err error) {
+	err = sb.client.ListPartsPagesWithContext(ctx, &s3.ListPartsInput{
+		Bucket:   sb.bucket,
+		Key:      aws.String(sb.keyPrefix + name),
+		UploadId: aws.String(uploadId),
+	}, func(page *s3.ListPartsOutput, _ bool) bool {
+		for _, part := range page.Parts {
+			parts = append(parts, Part{
+				Number: int(aws.Int64Value(part.PartNumber)),
+				ETag:   strings.Trim(aws.StringValue(part.ETag), `"`),
+				Size:   aws.Int64Value(part.Size),
+			})
+		}
+		return true
+	})
+	return
+}
+
+func (sb *s3Backend) UploadPart(ctx context.Context, name, uploadId string, number int, data io.ReadSeeker) (etag string, err error) {
+	out, err := sb.client.UploadPartWithContext(ctx, &s3.UploadPartInput{
+		Bucket:     sb.bucket,
+		Key:        aws.String(sb.keyPrefix + name),
+		UploadId:   aws.String(uploadId),
+		PartNumber: aws.Int64(int64(number)),
+		Body:       data,
+	})
+	if err != nil {
+		return
+	}
+	return strings.Trim(aws.StringValue(out.ETag), `"`), nil
+}
+
+func (sb *s3Backend) CompleteMultipart(ctx context.Context, name, uploadId string, parts []Part) (err error) {
+	completed := make([]*s3.CompletedPart, 0, len(parts))
+	for _, part := range parts {
+		completed = append(completed, &s3.CompletedPart{
+			ETag:       aws.String(`"` + part.ETag + `"`),
+			PartNumber: aws.Int64(int64(part.Number)),
+		})
+	}
+	_, err = sb.client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          sb.bucket,
+		Key:             aws.String(sb.keyPrefix + name),
+		UploadId:        aws.String(uploadId),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	return
+}
+
+func (sb *s3Backend) AbortMultipart(ctx context.Context, name, uploadId string) (err error) {
+	_, err = sb.client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   sb.bucket,
+		Key:      aws.String(sb.keyPrefix + name),
+		UploadId: aws.String(uploadId),
+	})
+	return
+}
@@ -4,8 +4,63 @@ type configSource interface {
 	GetArchive() Config
 }
 
+// Config of the archive.
+// Every archived space is copied to the system temp dir (TMPDIR) first: the uncompressed store backup and, for archive
+// store backends without multipart uploads, the compressed archive as well. The temp dir must have free space for
+// Concurrency times the largest space store, twice that for such backends.
 type Config struct {
 	Enabled            bool `yaml:"enabled"`
 	ArchiveAfterDays   int  `yaml:"archiveAfterDays"`
 	CheckPeriodMinutes int  `yaml:"checkPeriodMinutes"`
+	// Concurrency is a number of spaces archived in parallel, 1 by default; each one takes temp disk space, see Config
+	Concurrency int `yaml:"concurrency"`
+	// RestoreConcurrency is a number of spaces restored in parallel, 4 by default.
+	RestoreConcurrency int `yaml:"restoreConcurrency"`
+	// UrgentRestoreConcurrency is a number of extra slots reserved for restores requested by clients, 4 by default.
+	// Client requests take a free slot of either kind, so they don't wait behind prefetched restores.
+	UrgentRestoreConcurrency int `yaml:"urgentRestoreConcurrency"`
+	// RestoreWaitSeconds is how long a client request waits for the restore before getting a retryable error, 5 by default
+	RestoreWaitSeconds int `yaml:"restoreWaitSeconds"`
+	// UploadBytesPerSec limits the total upload speed of all workers, 0 means no limit
+	UploadBytesPerSec int64 `yaml:"uploadBytesPerSec"`
+	// DiskReadBytesPerSec limits the total speed of reading space stores for backups, 0 means no limit
+	DiskReadBytesPerSec int64 `yaml:"diskReadBytesPerSec"`
+	// Compression is a codec for new archives: gzip (default) or zstd
+	Compression string `yaml:"compression"`
+	// CompressionLevel is a codec-specific level, 0 means the codec default
+	CompressionLevel int `yaml:"compressionLevel"`
+	// VerifyUpload enables reading the archive back from the store before deleting the local copy
+	VerifyUpload bool `yaml:"verifyUpload"`
+	// Encryption configures client-side encryption of archives
+	Encryption EncryptionConfig `yaml:"encryption"`
+	// Reconcile configures the periodic comparison of the archive store with the index
+	Reconcile ReconcileConfig `yaml:"reconcile"`
+	// Policy tunes which spaces are taken by the periodic check
+	Policy PolicyConfig `yaml:"policy"`
+}
+
+type PolicyConfig struct {
+	// MinSpaceSizeBytes keeps smaller spaces on the local disk, archiving them saves almost nothing
+	MinSpaceSizeBytes int64 `yaml:"minSpaceSizeBytes"`
+	// Allow restricts archiving to the listed space ids when not empty
+	Allow []string `yaml:"allow"`
+	// Deny lists space ids that are always kept on the local disk
+	Deny []string `yaml:"deny"`
+	// DiskHighWaterPercent is the disk usage after which the age threshold goes down linearly
+	// from ArchiveAfterDays to MinArchiveAfterDays at the full disk, 0 disables it
+	DiskHighWaterPercent float64 `yaml:"diskHighWaterPercent"`
+	// MinArchiveAfterDays is the lowest age threshold, 1 by default
+	MinArchiveAfterDays int `yaml:"minArchiveAfterDays"`
+	// MaxBytesPerRun caps the size of spaces archived during one check, 0 means no limit
+	MaxBytesPerRun int64 `yaml:"maxBytesPerRun"`
+}
+
+type ReconcileConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// PeriodHours is an interval between runs, 24 by default
+	PeriodHours int `yaml:"periodHours"`
+	// DryRun only reports orphans and dangling entries without repairing them
+	DryRun bool `yaml:"dryRun"`
+	// GracePeriodMinutes protects recently modified objects from being treated as orphans, 60 by default
+	GracePeriodMinutes int `yaml:"gracePeriodMinutes"`
 }
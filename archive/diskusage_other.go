@@ -0,0 +1,9 @@
+//go:build !unix
+
+package archive
+
+import "errors"
+
+func diskUsagePercent(_ string) (float64, error) {
+	return 0, errors.New("disk usage is not supported on this platform")
+}
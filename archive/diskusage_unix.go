@@ -0,0 +1,19 @@
+//go:build unix
+
+package archive
+
+import "syscall"
+
+// diskUsagePercent returns the used space of the volume containing path
+func diskUsagePercent(path string) (float64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	total := uint64(st.Blocks) * uint64(st.Bsize)
+	if total == 0 {
+		return 0, nil
+	}
+	avail := uint64(st.Bavail) * uint64(st.Bsize)
+	return float64(total-avail) / float64(total) * 100, nil
+}
@@ -0,0 +1,272 @@
+package archive
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/anyproto/any-sync/util/crypto"
+)
+
+// Encrypted payload is a sequence of AES-256-GCM sealed chunks (the STREAM construction).
+// Each chunk nonce is noncePrefix | chunk counter uint32 | last flag; the header is the additional data,
+// so the header can't be changed and the payload can't be truncated or reordered without an error.
+
+type Encryption uint8
+
+const (
+	EncryptionNone      Encryption = 0
+	EncryptionAES256GCM Encryption = 1
+)
+
+const (
+	encryptionKeySize = 32
+	noncePrefixSize   = 7
+	encChunkSize      = 64 << 10
+
+	accountKeyIdPrefix = "account:"
+	accountKeyInfo     = "any-sync-node archive encryption key"
+)
+
+var (
+	ErrUnknownKey        = errors.New("archive: unknown encryption key")
+	ErrDecrypt           = errors.New("archive: can't decrypt")
+	ErrUnknownEncryption = errors.New("archive: unknown encryption")
+)
+
+type EncryptionConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// KeyId is an id of the key for new archives; empty means the account derived key
+	KeyId string `yaml:"keyId"`
+	// Keys are base64 encoded 32-byte keys by id; keep old keys to be able to restore older archives
+	Keys map[string]string `yaml:"keys"`
+	// DeriveFromAccount adds a key derived from the node signing key
+	DeriveFromAccount bool `yaml:"deriveFromAccount"`
+}
+
+// keyring keeps all known archive keys and the id of the key for new archives
+type keyring struct {
+	currentId string
+	keys      map[string][]byte
+}
+
+func newKeyring(conf EncryptionConfig, accountKey crypto.PrivKey) (*keyring, error) {
+	kr := &keyring{keys: map[string][]byte{}}
+	for id, encoded := range conf.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("archive encryption key %q: %w", id, err)
+		}
+		if len(key) != encryptionKeySize {
+			return nil, fmt.Errorf("archive encryption key %q: expected %d bytes, got %d", id, encryptionKeySize, len(key))
+		}
+		if len(id) > 255 {
+			return nil, fmt.Errorf("archive encryption key id %q is too long", id)
+		}
+		kr.keys[id] = key
+	}
+	var accountKeyId string
+	if conf.DeriveFromAccount {
+		if accountKey == nil {
+			return nil, fmt.Errorf("archive encryption: account key is not available")
+		}
+		id, key, err := deriveAccountKey(accountKey)
+		if err != nil {
+			return nil, err
+		}
+		kr.keys[id] = key
+		accountKeyId = id
+	}
+	if !conf.Enabled {
+		return kr, nil
+	}
+	kr.currentId = conf.KeyId
+	if kr.currentId == "" {
+		kr.currentId = accountKeyId
+	}
+	if _, ok := kr.keys[kr.currentId]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kr.currentId)
+	}
+	return kr, nil
+}
+
+// deriveAccountKey derives the archive key from the account key; the key id depends on the key, so a new account key gets a new id
+func deriveAccountKey(accountKey crypto.PrivKey) (id string, key []byte, err error) {
+	raw, err := accountKey.Raw()
+	if err != nil {
+		return
+	}
+	if key, err = hkdf.Key(sha256.New, raw, nil, accountKeyInfo, encryptionKeySize); err != nil {
+		return
+	}
+	sum := sha256.Sum256(key)
+	return accountKeyIdPrefix + hex.EncodeToString(sum[:4]), key, nil
+}
+
+func (kr *keyring) encryptionEnabled() bool {
+	return kr != nil && kr.currentId != ""
+}
+
+func (kr *keyring) aead(keyId string) (cipher.AEAD, error) {
+	if kr == nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, keyId)
+	}
+	key, ok := kr.keys[keyId]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, keyId)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// KeyIds returns the sorted ids of all known keys
+func (kr *keyring) KeyIds() []string {
+	if kr == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(kr.keys))
+	for id := range kr.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func newNoncePrefix() (prefix [noncePrefixSize]byte, err error) {
+	_, err = rand.Read(prefix[:])
+	return
+}
+
+func chunkNonce(prefix [noncePrefixSize]byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 0, noncePrefixSize+5)
+	nonce = append(nonce, prefix[:]...)
+	nonce = binary.BigEndian.AppendUint32(nonce, counter)
+	if last {
+		return append(nonce, 1)
+	}
+	return append(nonce, 0)
+}
+
+// encryptWriter seals data by chunks; a full chunk is sealed only when the next byte arrives, so Close always writes the last chunk
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  [noncePrefixSize]byte
+	ad      []byte
+	buf     []byte
+	out     []byte
+	counter uint32
+}
+
+func newEncryptWriter(w io.Writer, aead cipher.AEAD, prefix [noncePrefixSize]byte, ad []byte) *encryptWriter {
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		prefix: prefix,
+		ad:     ad,
+		buf:    make([]byte, 0, encChunkSize),
+		out:    make([]byte, 0, encChunkSize+aead.Overhead()),
+	}
+}
+
+func (ew *encryptWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if len(ew.buf) == encChunkSize {
+			if err = ew.seal(false); err != nil {
+				return
+			}
+		}
+		l := copy(ew.buf[len(ew.buf):encChunkSize], p)
+		ew.buf = ew.buf[:len(ew.buf)+l]
+		p = p[l:]
+		n += l
+	}
+	return
+}
+
+func (ew *encryptWriter) seal(last bool) (err error) {
+	if ew.counter == ^uint32(0) {
+		return fmt.Errorf("archive: too many encrypted chunks")
+	}
+	ew.out = ew.aead.Seal(ew.out[:0], chunkNonce(ew.prefix, ew.counter, last), ew.buf, ew.ad)
+	ew.counter++
+	ew.buf = ew.buf[:0]
+	_, err = ew.w.Write(ew.out)
+	return
+}
+
+func (ew *encryptWriter) Close() error {
+	return ew.seal(true)
+}
+
+// decryptReader opens chunks written by encryptWriter
+type decryptReader struct {
+	r       *bufio.Reader
+	aead    cipher.AEAD
+	prefix  [noncePrefixSize]byte
+	ad      []byte
+	in      []byte
+	buf     []byte
+	counter uint32
+	done    bool
+}
+
+func newDecryptReader(r *bufio.Reader, aead cipher.AEAD, prefix [noncePrefixSize]byte, ad []byte) *decryptReader {
+	return &decryptReader{
+		r:      r,
+		aead:   aead,
+		prefix: prefix,
+		ad:     ad,
+		in:     make([]byte, encChunkSize+aead.Overhead()),
+	}
+}
+
+func (dr *decryptReader) Read(p []byte) (n int, err error) {
+	for len(dr.buf) == 0 {
+		if dr.done {
+			return 0, io.EOF
+		}
+		if err = dr.open(); err != nil {
+			return
+		}
+	}
+	n = copy(p, dr.buf)
+	dr.buf = dr.buf[n:]
+	return
+}
+
+func (dr *decryptReader) open() (err error) {
+	l, err := io.ReadFull(dr.r, dr.in)
+	var last bool
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		last = true
+	case err != nil:
+		return err
+	default:
+		if _, pErr := dr.r.Peek(1); errors.Is(pErr, io.EOF) {
+			last = true
+		} else if pErr != nil {
+			return pErr
+		}
+	}
+	if dr.buf, err = dr.aead.Open(dr.in[:0], chunkNonce(dr.prefix, dr.counter, last), dr.in[:l], dr.ad); err != nil {
+		return fmt.Errorf("%w: chunk %d", ErrDecrypt, dr.counter)
+	}
+	dr.counter++
+	dr.done = last
+	return nil
+}
@@ -0,0 +1,148 @@
+package archive
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/anyproto/any-sync/commonspace/object/accountdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKey() string {
+	key := make([]byte, encryptionKeySize)
+	_, _ = rand.Read(key)
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func TestEncryption_RoundTrip(t *testing.T) {
+	kr, err := newKeyring(EncryptionConfig{
+		Enabled: true,
+		KeyId:   "k1",
+		Keys:    map[string]string{"k1": newTestKey()},
+	}, nil)
+	require.NoError(t, err)
+
+	// random data doesn't compress, so the payload has several chunks
+	data := make([]byte, encChunkSize*3+100)
+	_, _ = rand.Read(data)
+
+	for _, codec := range []Codec{CodecGzip, CodecZstd} {
+		t.Run(codec.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			h, err := writeArchive(&buf, bytes.NewReader(data), codec, 0, kr)
+			require.NoError(t, err)
+			assert.Equal(t, EncryptionAES256GCM, h.Encryption)
+			assert.Equal(t, "k1", h.KeyId)
+			assert.False(t, bytes.Contains(buf.Bytes(), data[:64]))
+			// the plaintext checksum is sealed with the payload
+			assert.Equal(t, sha256.Sum256(data), h.Checksum)
+			assert.False(t, bytes.Contains(buf.Bytes(), h.Checksum[:]))
+
+			rc, rh, err := openArchive(bytes.NewReader(buf.Bytes()), kr)
+			require.NoError(t, err)
+			got, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			assert.Equal(t, data, got)
+			assert.Equal(t, h.NoncePrefix, rh.NoncePrefix)
+			assert.Equal(t, h.Checksum, rh.Checksum)
+			assert.Equal(t, uint64(len(data)), rh.Size)
+		})
+	}
+}
+
+func TestEncryption_KeyRotation(t *testing.T) {
+	keys := map[string]string{"k1": newTestKey()}
+	kr1, err := newKeyring(EncryptionConfig{Enabled: true, KeyId: "k1", Keys: keys}, nil)
+	require.NoError(t, err)
+
+	data := bytes.Repeat([]byte("rotation"), 1000)
+	var old bytes.Buffer
+	_, err = writeArchive(&old, bytes.NewReader(data), CodecZstd, 0, kr1)
+	require.NoError(t, err)
+
+	keys["k2"] = newTestKey()
+	kr2, err := newKeyring(EncryptionConfig{Enabled: true, KeyId: "k2", Keys: keys}, nil)
+	require.NoError(t, err)
+
+	var fresh bytes.Buffer
+	h, err := writeArchive(&fresh, bytes.NewReader(data), CodecZstd, 0, kr2)
+	require.NoError(t, err)
+	assert.Equal(t, "k2", h.KeyId)
+
+	for _, arc := range []*bytes.Buffer{&old, &fresh} {
+		rc, _, err := openArchive(bytes.NewReader(arc.Bytes()), kr2)
+		require.NoError(t, err)
+		got, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		assert.Equal(t, data, got)
+	}
+
+	// without the old key
+	kr3, err := newKeyring(EncryptionConfig{Enabled: true, KeyId: "k2", Keys: map[string]string{"k2": keys["k2"]}}, nil)
+	require.NoError(t, err)
+	_, _, err = openArchive(bytes.NewReader(old.Bytes()), kr3)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestEncryption_Tampered(t *testing.T) {
+	kr, err := newKeyring(EncryptionConfig{Enabled: true, KeyId: "k1", Keys: map[string]string{"k1": newTestKey()}}, nil)
+	require.NoError(t, err)
+	data := make([]byte, encChunkSize*2)
+	_, _ = rand.Read(data)
+	var buf bytes.Buffer
+	h, err := writeArchive(&buf, bytes.NewReader(data), CodecGzip, 0, kr)
+	require.NoError(t, err)
+	hLen := len(h.Marshal())
+
+	read := func(b []byte) error {
+		rc, _, err := openArchive(bytes.NewReader(b), kr)
+		if err != nil {
+			return err
+		}
+		_, err = io.ReadAll(rc)
+		return err
+	}
+
+	t.Run("payload", func(t *testing.T) {
+		b := bytes.Clone(buf.Bytes())
+		b[hLen+10] ^= 0xff
+		assert.ErrorIs(t, corrupted(read(b)), ErrCorrupted)
+	})
+	t.Run("header", func(t *testing.T) {
+		b := bytes.Clone(buf.Bytes())
+		b[hLen-1] ^= 0xff
+		assert.ErrorIs(t, read(b), ErrDecrypt)
+	})
+	t.Run("header digest", func(t *testing.T) {
+		b := bytes.Clone(buf.Bytes())
+		b[headerSize-1] ^= 0xff
+		assert.ErrorIs(t, read(b), ErrCorrupted)
+	})
+	t.Run("truncated at chunk boundary", func(t *testing.T) {
+		b := bytes.Clone(buf.Bytes())[:hLen+encChunkSize+16]
+		assert.ErrorIs(t, read(b), ErrDecrypt)
+	})
+}
+
+func TestEncryption_AccountKey(t *testing.T) {
+	acc, err := accountdata.NewRandom()
+	require.NoError(t, err)
+
+	kr, err := newKeyring(EncryptionConfig{Enabled: true, DeriveFromAccount: true}, acc.SignKey)
+	require.NoError(t, err)
+	assert.Contains(t, kr.currentId, accountKeyIdPrefix)
+
+	// derivation is stable
+	kr2, err := newKeyring(EncryptionConfig{DeriveFromAccount: true}, acc.SignKey)
+	require.NoError(t, err)
+	assert.Equal(t, kr.keys[kr.currentId], kr2.keys[kr.currentId])
+	assert.False(t, kr2.encryptionEnabled())
+
+	_, err = newKeyring(EncryptionConfig{Enabled: true, KeyId: "missing"}, nil)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+}
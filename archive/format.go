@@ -0,0 +1,342 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Archive object layout:
+//
+//	v1: magic [4]byte | version uint8 | codec uint8 | original size uint64 | sha256 [32]byte | compressed payload
+//	v2: v1 header | encryption uint8 | key id length uint8 | key id | nonce prefix [7]byte (only when encrypted) | payload
+//	v3: v2 layout, but encrypted archives keep size and sha256 of the header zeroed and seal them as the payload prefix:
+//	    encrypted(original size uint64 | sha256 [32]byte | compressed payload)
+//
+// Objects without the magic prefix are legacy archives: a plain gzip stream of store.db.
+
+var archiveMagic = [4]byte{'A', 'N', 'Y', 'A'}
+
+const (
+	formatVersion1 uint8 = 1
+	formatVersion2 uint8 = 2
+	formatVersion3 uint8 = 3
+	formatVersion        = formatVersion3
+
+	headerSize = len(archiveMagic) + 1 + 1 + 8 + sha256.Size
+	digestSize = 8 + sha256.Size
+)
+
+var (
+	ErrUnknownCodec     = errors.New("archive: unknown codec")
+	ErrUnknownVersion   = errors.New("archive: unknown format version")
+	ErrChecksumMismatch = errors.New("archive: checksum mismatch")
+	ErrSizeMismatch     = errors.New("archive: size mismatch")
+	ErrCorrupted        = errors.New("archive is corrupted")
+)
+
+type Codec uint8
+
+const (
+	CodecGzip Codec = 1
+	CodecZstd Codec = 2
+)
+
+func (c Codec) String() string {
+	switch c {
+	case CodecGzip:
+		return "gzip"
+	case CodecZstd:
+		return "zstd"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(c))
+	}
+}
+
+// ParseCodec converts a config value to the Codec, empty string means gzip
+func ParseCodec(s string) (Codec, error) {
+	switch s {
+	case "", "gzip":
+		return CodecGzip, nil
+	case "zstd":
+		return CodecZstd, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrUnknownCodec, s)
+	}
+}
+
+type archiveHeader struct {
+	Version  uint8
+	Codec    Codec
+	Size     uint64
+	Checksum [sha256.Size]byte
+	// v2 fields
+	Encryption  Encryption
+	KeyId       string
+	NoncePrefix [noncePrefixSize]byte
+	// Legacy is true for headerless gzip objects
+	Legacy bool
+}
+
+// sealedDigest is true when size and checksum are encrypted with the payload, so the cleartext header doesn't fingerprint the content
+func (h archiveHeader) sealedDigest() bool {
+	return h.Version >= formatVersion3 && h.Encryption != EncryptionNone
+}
+
+func (h archiveHeader) Marshal() []byte {
+	buf := make([]byte, 0, headerSize+2+len(h.KeyId)+noncePrefixSize)
+	buf = append(buf, archiveMagic[:]...)
+	buf = append(buf, h.Version, uint8(h.Codec))
+	if h.sealedDigest() {
+		buf = append(buf, make([]byte, digestSize)...)
+	} else {
+		buf = binary.BigEndian.AppendUint64(buf, h.Size)
+		buf = append(buf, h.Checksum[:]...)
+	}
+	if h.Version < formatVersion2 {
+		return buf
+	}
+	buf = append(buf, uint8(h.Encryption), uint8(len(h.KeyId)))
+	buf = append(buf, h.KeyId...)
+	if h.Encryption != EncryptionNone {
+		buf = append(buf, h.NoncePrefix[:]...)
+	}
+	return buf
+}
+
+// readArchiveHeader reads the header from r; when the object has no magic prefix, it returns a legacy gzip header and doesn't consume anything
+func readArchiveHeader(r *bufio.Reader) (h archiveHeader, err error) {
+	magic, err := r.Peek(len(archiveMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return
+	}
+	if !bytes.Equal(magic, archiveMagic[:]) {
+		return archiveHeader{Codec: CodecGzip, Legacy: true}, nil
+	}
+	buf := make([]byte, headerSize)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		return h, fmt.Errorf("archive: read header: %w", err)
+	}
+	buf = buf[len(archiveMagic):]
+	h.Version = buf[0]
+	if h.Version < formatVersion1 || h.Version > formatVersion3 {
+		return h, fmt.Errorf("%w: %d", ErrUnknownVersion, h.Version)
+	}
+	h.Codec = Codec(buf[1])
+	h.Size = binary.BigEndian.Uint64(buf[2:10])
+	copy(h.Checksum[:], buf[10:])
+	if h.Version < formatVersion2 {
+		return h, nil
+	}
+
+	var encBuf [2]byte
+	if _, err = io.ReadFull(r, encBuf[:]); err != nil {
+		return h, fmt.Errorf("archive: read header: %w", err)
+	}
+	h.Encryption = Encryption(encBuf[0])
+	keyId := make([]byte, encBuf[1])
+	if _, err = io.ReadFull(r, keyId); err != nil {
+		return h, fmt.Errorf("archive: read header: %w", err)
+	}
+	h.KeyId = string(keyId)
+	switch h.Encryption {
+	case EncryptionNone:
+	case EncryptionAES256GCM:
+		if _, err = io.ReadFull(r, h.NoncePrefix[:]); err != nil {
+			return h, fmt.Errorf("archive: read header: %w", err)
+		}
+	default:
+		return h, fmt.Errorf("%w: %d", ErrUnknownEncryption, h.Encryption)
+	}
+	// the sealed digest is in the payload, the header bytes aren't authenticated and must stay zeroed
+	if h.sealedDigest() && (h.Size != 0 || h.Checksum != [sha256.Size]byte{}) {
+		return h, fmt.Errorf("%w: unexpected digest in the header", ErrCorrupted)
+	}
+	return h, nil
+}
+
+// newCompressor returns a writer compressing to w with the given codec; level 0 means the codec default
+func newCompressor(w io.Writer, codec Codec, level int) (io.WriteCloser, error) {
+	switch codec {
+	case CodecGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	case CodecZstd:
+		encLevel := zstd.SpeedDefault
+		if level != 0 {
+			encLevel = zstd.EncoderLevelFromZstd(level)
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(encLevel))
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnknownCodec, codec)
+	}
+}
+
+func newDecompressor(r io.Reader, codec Codec) (io.ReadCloser, error) {
+	switch codec {
+	case CodecGzip:
+		return gzip.NewReader(r)
+	case CodecZstd:
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnknownCodec, codec)
+	}
+}
+
+// openArchive parses an archive object of any known format and returns a reader of the original data.
+// For versioned archives the reader checks size and checksum at EOF. Encrypted archives need the key from the keyring.
+func openArchive(r io.Reader, kr *keyring) (rc io.ReadCloser, h archiveHeader, err error) {
+	br := bufio.NewReader(r)
+	if h, err = readArchiveHeader(br); err != nil {
+		return
+	}
+	var payload io.Reader = br
+	if h.Encryption != EncryptionNone {
+		aead, aErr := kr.aead(h.KeyId)
+		if aErr != nil {
+			return nil, h, aErr
+		}
+		payload = newDecryptReader(br, aead, h.NoncePrefix, h.Marshal())
+		if h.sealedDigest() {
+			digest := make([]byte, digestSize)
+			if _, err = io.ReadFull(payload, digest); err != nil {
+				return nil, h, fmt.Errorf("archive: read digest: %w", err)
+			}
+			h.Size = binary.BigEndian.Uint64(digest[:8])
+			copy(h.Checksum[:], digest[8:])
+		}
+	}
+	dec, err := newDecompressor(payload, h.Codec)
+	if err != nil {
+		return
+	}
+	if h.Legacy {
+		return dec, h, nil
+	}
+	return &verifyingReader{
+		ReadCloser: dec,
+		header:     h,
+		hash:       sha256.New(),
+	}, h, nil
+}
+
+type verifyingReader struct {
+	io.ReadCloser
+	header archiveHeader
+	hash   hash.Hash
+	size   uint64
+}
+
+func (vr *verifyingReader) Read(p []byte) (n int, err error) {
+	n, err = vr.ReadCloser.Read(p)
+	if n > 0 {
+		vr.hash.Write(p[:n])
+		vr.size += uint64(n)
+	}
+	if errors.Is(err, io.EOF) {
+		if vr.size != vr.header.Size {
+			return n, fmt.Errorf("%w: expected %d, got %d", ErrSizeMismatch, vr.header.Size, vr.size)
+		}
+		if !bytes.Equal(vr.hash.Sum(nil), vr.header.Checksum[:]) {
+			return n, ErrChecksumMismatch
+		}
+	}
+	return
+}
+
+// corrupted marks integrity errors with ErrCorrupted; other errors (e.g. network) are returned as is
+func corrupted(err error) error {
+	var flateErr flate.CorruptInputError
+	switch {
+	case errors.Is(err, ErrChecksumMismatch),
+		errors.Is(err, ErrSizeMismatch),
+		errors.Is(err, ErrUnknownCodec),
+		errors.Is(err, ErrUnknownVersion),
+		errors.Is(err, ErrUnknownEncryption),
+		errors.Is(err, ErrDecrypt),
+		errors.Is(err, gzip.ErrHeader),
+		errors.Is(err, gzip.ErrChecksum),
+		errors.Is(err, zstd.ErrMagicMismatch),
+		errors.Is(err, zstd.ErrCRCMismatch),
+		errors.As(err, &flateErr):
+		return fmt.Errorf("%w: %w", ErrCorrupted, err)
+	default:
+		return err
+	}
+}
+
+// writeArchive writes a versioned archive of src to dst; the src is read twice: for the checksum and for the payload.
+// The payload is encrypted with the current keyring key when encryption is enabled.
+func writeArchive(dst io.Writer, src io.ReadSeeker, codec Codec, level int, kr *keyring) (h archiveHeader, err error) {
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, src)
+	if err != nil {
+		return
+	}
+	if _, err = src.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+	h = archiveHeader{
+		Version: formatVersion,
+		Codec:   codec,
+		Size:    uint64(size),
+	}
+	copy(h.Checksum[:], hasher.Sum(nil))
+
+	var (
+		payload   io.Writer = dst
+		encWriter *encryptWriter
+	)
+	if kr.encryptionEnabled() {
+		h.Encryption = EncryptionAES256GCM
+		h.KeyId = kr.currentId
+		if h.NoncePrefix, err = newNoncePrefix(); err != nil {
+			return
+		}
+		aead, aErr := kr.aead(h.KeyId)
+		if aErr != nil {
+			return h, aErr
+		}
+		encWriter = newEncryptWriter(dst, aead, h.NoncePrefix, h.Marshal())
+		payload = encWriter
+	}
+	if _, err = dst.Write(h.Marshal()); err != nil {
+		return
+	}
+	if h.sealedDigest() {
+		digest := binary.BigEndian.AppendUint64(make([]byte, 0, digestSize), h.Size)
+		if _, err = payload.Write(append(digest, h.Checksum[:]...)); err != nil {
+			return
+		}
+	}
+	cw, err := newCompressor(payload, codec, level)
+	if err != nil {
+		return
+	}
+	if _, err = io.Copy(cw, src); err != nil {
+		_ = cw.Close()
+		return
+	}
+	if err = cw.Close(); err != nil {
+		return
+	}
+	if encWriter != nil {
+		err = encWriter.Close()
+	}
+	return
+}
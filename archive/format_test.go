@@ -0,0 +1,102 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat_RoundTrip(t *testing.T) {
+	data := make([]byte, 1<<20)
+	_, _ = rand.Read(data[:1<<10])
+
+	for _, codec := range []Codec{CodecGzip, CodecZstd} {
+		t.Run(codec.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			h, err := writeArchive(&buf, bytes.NewReader(data), codec, 0, nil)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(len(data)), h.Size)
+			assert.Less(t, buf.Len(), len(data))
+
+			rc, rh, err := openArchive(&buf, nil)
+			require.NoError(t, err)
+			got, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			require.NoError(t, rc.Close())
+			assert.Equal(t, data, got)
+			assert.False(t, rh.Legacy)
+			assert.Equal(t, codec, rh.Codec)
+			assert.Equal(t, h.Checksum, rh.Checksum)
+		})
+	}
+}
+
+func TestFormat_Legacy(t *testing.T) {
+	data := []byte("legacy store.db contents")
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := gw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	rc, h, err := openArchive(&buf, nil)
+	require.NoError(t, err)
+	assert.True(t, h.Legacy)
+	got, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+}
+
+func TestFormat_Corrupted(t *testing.T) {
+	data := bytes.Repeat([]byte("data"), 1000)
+	var buf bytes.Buffer
+	_, err := writeArchive(&buf, bytes.NewReader(data), CodecZstd, 0, nil)
+	require.NoError(t, err)
+
+	t.Run("checksum", func(t *testing.T) {
+		corrupted := bytes.Clone(buf.Bytes())
+		corrupted[headerSize-1] ^= 0xff
+		rc, _, err := openArchive(bytes.NewReader(corrupted), nil)
+		require.NoError(t, err)
+		_, err = io.ReadAll(rc)
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+	t.Run("version", func(t *testing.T) {
+		corrupted := bytes.Clone(buf.Bytes())
+		corrupted[len(archiveMagic)] = 42
+		_, _, err := openArchive(bytes.NewReader(corrupted), nil)
+		assert.ErrorIs(t, err, ErrUnknownVersion)
+	})
+	t.Run("codec", func(t *testing.T) {
+		corrupted := bytes.Clone(buf.Bytes())
+		corrupted[len(archiveMagic)+1] = 42
+		_, _, err := openArchive(bytes.NewReader(corrupted), nil)
+		assert.ErrorIs(t, err, ErrUnknownCodec)
+	})
+}
+
+func TestFormat_V1(t *testing.T) {
+	data := []byte("v1 store.db contents")
+	h := archiveHeader{Version: formatVersion1, Codec: CodecZstd, Size: uint64(len(data))}
+	h.Checksum = sha256.Sum256(data)
+	var buf bytes.Buffer
+	buf.Write(h.Marshal())
+	zw, err := newCompressor(&buf, CodecZstd, 0)
+	require.NoError(t, err)
+	_, err = zw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	rc, rh, err := openArchive(&buf, nil)
+	require.NoError(t, err)
+	assert.Equal(t, formatVersion1, rh.Version)
+	got, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+}
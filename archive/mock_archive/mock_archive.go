@@ -13,6 +13,7 @@ import (
 	context "context"
 	reflect "reflect"
 
+	archiveinfo "github.com/anyproto/any-sync-node/archive/archiveinfo"
 	app "github.com/anyproto/any-sync/app"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -41,6 +42,35 @@ func (m *MockArchive) EXPECT() *MockArchiveMockRecorder {
 	return m.recorder
 }
 
+// ArchiveNow mocks base method.
+func (m *MockArchive) ArchiveNow(ctx context.Context, spaceId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveNow", ctx, spaceId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ArchiveNow indicates an expected call of ArchiveNow.
+func (mr *MockArchiveMockRecorder) ArchiveNow(ctx, spaceId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveNow", reflect.TypeOf((*MockArchive)(nil).ArchiveNow), ctx, spaceId)
+}
+
+// ArchiveStatus mocks base method.
+func (m *MockArchive) ArchiveStatus(ctx context.Context, spaceId string) (archiveinfo.SpaceInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveStatus", ctx, spaceId)
+	ret0, _ := ret[0].(archiveinfo.SpaceInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ArchiveStatus indicates an expected call of ArchiveStatus.
+func (mr *MockArchiveMockRecorder) ArchiveStatus(ctx, spaceId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveStatus", reflect.TypeOf((*MockArchive)(nil).ArchiveStatus), ctx, spaceId)
+}
+
 // Close mocks base method.
 func (m *MockArchive) Close(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -55,6 +85,21 @@ func (mr *MockArchiveMockRecorder) Close(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockArchive)(nil).Close), ctx)
 }
 
+// ExplainPolicy mocks base method.
+func (m *MockArchive) ExplainPolicy(ctx context.Context, spaceId string) (archiveinfo.PolicyDecision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExplainPolicy", ctx, spaceId)
+	ret0, _ := ret[0].(archiveinfo.PolicyDecision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExplainPolicy indicates an expected call of ExplainPolicy.
+func (mr *MockArchiveMockRecorder) ExplainPolicy(ctx, spaceId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExplainPolicy", reflect.TypeOf((*MockArchive)(nil).ExplainPolicy), ctx, spaceId)
+}
+
 // Init mocks base method.
 func (m *MockArchive) Init(a *app.App) error {
 	m.ctrl.T.Helper()
@@ -69,6 +114,21 @@ func (mr *MockArchiveMockRecorder) Init(a any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockArchive)(nil).Init), a)
 }
 
+// ListArchived mocks base method.
+func (m *MockArchive) ListArchived(ctx context.Context, filter archiveinfo.ListFilter, cursor string) (archiveinfo.ListResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListArchived", ctx, filter, cursor)
+	ret0, _ := ret[0].(archiveinfo.ListResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListArchived indicates an expected call of ListArchived.
+func (mr *MockArchiveMockRecorder) ListArchived(ctx, filter, cursor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArchived", reflect.TypeOf((*MockArchive)(nil).ListArchived), ctx, filter, cursor)
+}
+
 // Name mocks base method.
 func (m *MockArchive) Name() string {
 	m.ctrl.T.Helper()
@@ -83,6 +143,36 @@ func (mr *MockArchiveMockRecorder) Name() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockArchive)(nil).Name))
 }
 
+// Prefetch mocks base method.
+func (m *MockArchive) Prefetch(ctx context.Context, spaceIds []string) (archiveinfo.PrefetchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Prefetch", ctx, spaceIds)
+	ret0, _ := ret[0].(archiveinfo.PrefetchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Prefetch indicates an expected call of Prefetch.
+func (mr *MockArchiveMockRecorder) Prefetch(ctx, spaceIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prefetch", reflect.TypeOf((*MockArchive)(nil).Prefetch), ctx, spaceIds)
+}
+
+// Reconcile mocks base method.
+func (m *MockArchive) Reconcile(ctx context.Context, dryRun bool) (archiveinfo.ReconcileReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reconcile", ctx, dryRun)
+	ret0, _ := ret[0].(archiveinfo.ReconcileReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reconcile indicates an expected call of Reconcile.
+func (mr *MockArchiveMockRecorder) Reconcile(ctx, dryRun any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reconcile", reflect.TypeOf((*MockArchive)(nil).Reconcile), ctx, dryRun)
+}
+
 // Restore mocks base method.
 func (m *MockArchive) Restore(ctx context.Context, spaceId string) error {
 	m.ctrl.T.Helper()
@@ -97,6 +187,34 @@ func (mr *MockArchiveMockRecorder) Restore(ctx, spaceId any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockArchive)(nil).Restore), ctx, spaceId)
 }
 
+// RestoreJobs mocks base method.
+func (m *MockArchive) RestoreJobs() []archiveinfo.RestoreJob {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreJobs")
+	ret0, _ := ret[0].([]archiveinfo.RestoreJob)
+	return ret0
+}
+
+// RestoreJobs indicates an expected call of RestoreJobs.
+func (mr *MockArchiveMockRecorder) RestoreJobs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreJobs", reflect.TypeOf((*MockArchive)(nil).RestoreJobs))
+}
+
+// RestoreNow mocks base method.
+func (m *MockArchive) RestoreNow(ctx context.Context, spaceId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreNow", ctx, spaceId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreNow indicates an expected call of RestoreNow.
+func (mr *MockArchiveMockRecorder) RestoreNow(ctx, spaceId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreNow", reflect.TypeOf((*MockArchive)(nil).RestoreNow), ctx, spaceId)
+}
+
 // Run mocks base method.
 func (m *MockArchive) Run(ctx context.Context) error {
 	m.ctrl.T.Helper()
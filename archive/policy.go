@@ -0,0 +1,139 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	anystore "github.com/anyproto/any-store"
+	"go.uber.org/zap"
+
+	"github.com/anyproto/any-sync-node/archive/archiveinfo"
+	"github.com/anyproto/any-sync-node/nodestorage"
+)
+
+// policy decides which inactive spaces the periodic check archives
+type policy struct {
+	conf            PolicyConfig
+	enabled         bool
+	archiveAfter    time.Duration
+	minArchiveAfter time.Duration
+	allow           map[string]struct{}
+	deny            map[string]struct{}
+	diskPath        func() string
+	diskUsage       func(path string) (percent float64, err error)
+}
+
+func newPolicy(conf Config, diskPath func() string) *policy {
+	p := &policy{
+		conf:         conf.Policy,
+		enabled:      conf.Enabled,
+		archiveAfter: time.Duration(conf.ArchiveAfterDays) * time.Hour * 24,
+		diskPath:     diskPath,
+		diskUsage:    diskUsagePercent,
+	}
+	if p.conf.MinArchiveAfterDays <= 0 {
+		p.conf.MinArchiveAfterDays = 1
+	}
+	p.minArchiveAfter = time.Duration(p.conf.MinArchiveAfterDays) * time.Hour * 24
+	if p.minArchiveAfter > p.archiveAfter {
+		p.minArchiveAfter = p.archiveAfter
+	}
+	if len(p.conf.Allow) != 0 {
+		p.allow = make(map[string]struct{}, len(p.conf.Allow))
+		for _, id := range p.conf.Allow {
+			p.allow[id] = struct{}{}
+		}
+	}
+	p.deny = make(map[string]struct{}, len(p.conf.Deny))
+	for _, id := range p.conf.Deny {
+		p.deny[id] = struct{}{}
+	}
+	return p
+}
+
+// ageThreshold returns the access age after which spaces are archived.
+// Above the high-water mark it goes down linearly to the minimum at the full disk.
+func (p *policy) ageThreshold() (threshold time.Duration, usage float64) {
+	threshold = p.archiveAfter
+	if p.conf.DiskHighWaterPercent <= 0 || p.conf.DiskHighWaterPercent >= 100 {
+		return
+	}
+	path := p.diskPath()
+	usage, err := p.diskUsage(path)
+	if err != nil {
+		log.Warn("can't get disk usage", zap.String("path", path), zap.Error(err))
+		return threshold, 0
+	}
+	if usage <= p.conf.DiskHighWaterPercent {
+		return
+	}
+	fill := (usage - p.conf.DiskHighWaterPercent) / (100 - p.conf.DiskHighWaterPercent)
+	if fill > 1 {
+		fill = 1
+	}
+	threshold -= time.Duration(float64(p.archiveAfter-p.minArchiveAfter) * fill)
+	return
+}
+
+func (p *policy) decide(entry nodestorage.SpaceStatusEntry, size int64, threshold time.Duration) (archive bool, reason string) {
+	switch {
+	case !p.enabled:
+		return false, archiveinfo.ReasonDisabled
+	case entry.Status != nodestorage.SpaceStatusOk:
+		return false, archiveinfo.ReasonStatus
+	}
+	if _, ok := p.deny[entry.SpaceId]; ok {
+		return false, archiveinfo.ReasonDenied
+	}
+	if p.allow != nil {
+		if _, ok := p.allow[entry.SpaceId]; !ok {
+			return false, archiveinfo.ReasonNotAllowed
+		}
+	}
+	if size < p.conf.MinSpaceSizeBytes {
+		return false, archiveinfo.ReasonTooSmall
+	}
+	if time.Since(entry.LastAccess) < threshold {
+		return false, archiveinfo.ReasonRecentlyAccessed
+	}
+	return true, archiveinfo.ReasonEligible
+}
+
+func (a *archive) ExplainPolicy(ctx context.Context, spaceId string) (decision archiveinfo.PolicyDecision, err error) {
+	threshold, usage := a.policy.ageThreshold()
+	decision, err = a.explain(ctx, spaceId, threshold)
+	decision.DiskUsagePercent = usage
+	return
+}
+
+func (a *archive) explain(ctx context.Context, spaceId string, threshold time.Duration) (decision archiveinfo.PolicyDecision, err error) {
+	entry, err := a.storageProvider.IndexStorage().SpaceStatusEntry(ctx, spaceId)
+	if err != nil {
+		if errors.Is(err, anystore.ErrDocNotFound) {
+			err = nodestorage.ErrUnknownSpaceId
+		}
+		return
+	}
+	size := a.spaceSize(spaceId)
+	decision = archiveinfo.PolicyDecision{
+		SpaceId:      spaceId,
+		Status:       entry.Status.String(),
+		LastAccess:   entry.LastAccess,
+		SizeBytes:    size,
+		ArchiveAfter: threshold.String(),
+	}
+	decision.Archive, decision.Reason = a.policy.decide(entry, size, threshold)
+	return
+}
+
+// spaceSize returns the size of the local store, 0 when the space has no local data
+func (a *archive) spaceSize(spaceId string) int64 {
+	info, err := os.Stat(filepath.Join(a.storageProvider.StoreDir(spaceId), "store.db"))
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
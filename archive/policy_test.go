@@ -0,0 +1,111 @@
+package archive
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	anystore "github.com/anyproto/any-store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anyproto/any-sync-node/archive/archiveinfo"
+	"github.com/anyproto/any-sync-node/nodestorage"
+)
+
+func TestPolicy_AgeThreshold(t *testing.T) {
+	newTestPolicy := func(usage float64, err error) *policy {
+		p := newPolicy(Config{
+			Enabled:          true,
+			ArchiveAfterDays: 9,
+			Policy:           PolicyConfig{DiskHighWaterPercent: 80, MinArchiveAfterDays: 1},
+		}, func() string { return "" })
+		p.diskUsage = func(string) (float64, error) {
+			return usage, err
+		}
+		return p
+	}
+	day := time.Hour * 24
+
+	threshold, _ := newTestPolicy(50, nil).ageThreshold()
+	assert.Equal(t, 9*day, threshold)
+	threshold, _ = newTestPolicy(90, nil).ageThreshold()
+	assert.Equal(t, 5*day, threshold)
+	threshold, _ = newTestPolicy(100, nil).ageThreshold()
+	assert.Equal(t, day, threshold)
+	threshold, _ = newTestPolicy(0, errors.New("statfs")).ageThreshold()
+	assert.Equal(t, 9*day, threshold)
+}
+
+func TestPolicy_Decide(t *testing.T) {
+	p := newPolicy(Config{
+		Enabled:          true,
+		ArchiveAfterDays: 7,
+		Policy: PolicyConfig{
+			MinSpaceSizeBytes: 100,
+			Allow:             []string{"allowed", "denied"},
+			Deny:              []string{"denied"},
+		},
+	}, func() string { return "" })
+	threshold := time.Hour * 24 * 7
+	old := time.Now().Add(-threshold * 2)
+
+	for _, tc := range []struct {
+		entry  nodestorage.SpaceStatusEntry
+		size   int64
+		reason string
+	}{
+		{nodestorage.SpaceStatusEntry{SpaceId: "allowed", LastAccess: old}, 100, archiveinfo.ReasonEligible},
+		{nodestorage.SpaceStatusEntry{SpaceId: "allowed", LastAccess: old, Status: nodestorage.SpaceStatusArchived}, 100, archiveinfo.ReasonStatus},
+		{nodestorage.SpaceStatusEntry{SpaceId: "denied", LastAccess: old}, 100, archiveinfo.ReasonDenied},
+		{nodestorage.SpaceStatusEntry{SpaceId: "other", LastAccess: old}, 100, archiveinfo.ReasonNotAllowed},
+		{nodestorage.SpaceStatusEntry{SpaceId: "allowed", LastAccess: old}, 99, archiveinfo.ReasonTooSmall},
+		{nodestorage.SpaceStatusEntry{SpaceId: "allowed", LastAccess: time.Now()}, 100, archiveinfo.ReasonRecentlyAccessed},
+	} {
+		archive, reason := p.decide(tc.entry, tc.size, threshold)
+		assert.Equal(t, tc.reason, reason, tc.entry.SpaceId)
+		assert.Equal(t, tc.reason == archiveinfo.ReasonEligible, archive, tc.entry.SpaceId)
+	}
+
+	p.enabled = false
+	_, reason := p.decide(nodestorage.SpaceStatusEntry{SpaceId: "allowed", LastAccess: old}, 100, threshold)
+	assert.Equal(t, archiveinfo.ReasonDisabled, reason)
+}
+
+func TestArchive_ExplainPolicy(t *testing.T) {
+	fx := newFixtureConf(t, Config{Policy: PolicyConfig{MinSpaceSizeBytes: 10}})
+	fx.Archive.(*archive).policy.enabled = true
+	spaceDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(spaceDir, "store.db"), []byte("small"), 0644))
+	fx.storage.EXPECT().StoreDir("space.id").Return(spaceDir)
+	fx.indexStorage.EXPECT().SpaceStatusEntry(ctx, "space.id").Return(nodestorage.SpaceStatusEntry{
+		SpaceId:    "space.id",
+		LastAccess: time.Now().Add(-time.Hour * 24 * 30),
+	}, nil)
+
+	decision, err := fx.ExplainPolicy(ctx, "space.id")
+	require.NoError(t, err)
+	assert.False(t, decision.Archive)
+	assert.Equal(t, archiveinfo.ReasonTooSmall, decision.Reason)
+	assert.Equal(t, int64(5), decision.SizeBytes)
+	assert.Equal(t, (time.Hour * 24 * 7).String(), decision.ArchiveAfter)
+
+	fx.indexStorage.EXPECT().SpaceStatusEntry(ctx, "unknown").Return(nodestorage.SpaceStatusEntry{}, anystore.ErrDocNotFound)
+	_, err = fx.ExplainPolicy(ctx, "unknown")
+	assert.ErrorIs(t, err, nodestorage.ErrUnknownSpaceId)
+}
+
+func TestArchive_CheckPolicy(t *testing.T) {
+	fx := newFixtureConf(t, Config{Policy: PolicyConfig{Deny: []string{"denied"}}})
+	// enable the policy only, so the background checker doesn't start
+	fx.Archive.(*archive).policy.enabled = true
+	old := time.Now().Add(-time.Hour * 24 * 30)
+	fx.storage.EXPECT().StoreDir("denied").Return(t.TempDir())
+	denied := nodestorage.InactiveSpace{SpaceId: "denied", LastAccess: old}
+	fx.indexStorage.EXPECT().FindInactiveSpaces(ctx, time.Hour*24*7, nodestorage.InactiveSpace{}, inactiveIterPageSize).Return([]nodestorage.InactiveSpace{denied}, nil)
+	fx.indexStorage.EXPECT().SpaceStatusEntry(ctx, "denied").Return(nodestorage.SpaceStatusEntry{SpaceId: "denied", LastAccess: old}, nil)
+	fx.indexStorage.EXPECT().FindInactiveSpaces(ctx, time.Hour*24*7, denied, inactiveIterPageSize).Return(nil, nil)
+	require.NoError(t, fx.Archive.(*archive).check(ctx))
+}
@@ -0,0 +1,219 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	anystore "github.com/anyproto/any-store"
+	"go.uber.org/zap"
+
+	"github.com/anyproto/any-sync-node/archive/archiveinfo"
+	"github.com/anyproto/any-sync-node/archive/archivestore"
+	"github.com/anyproto/any-sync-node/nodestorage"
+)
+
+const reconcilePageSize = 1000
+
+var ErrReconcileRunning = errors.New("archive reconcile is already running")
+
+// errMissingArchive is written to the index for spaces whose archive object is lost
+var errMissingArchive = errors.New("archive object is missing in the store")
+
+// Reconcile compares the objects in the archive store with the archived spaces in the index.
+// Orphaned objects are deleted when the space has local data or was removed; archived spaces without
+// an object are returned to the ok status when the local data exists, otherwise they are marked as errors.
+// Objects modified within the grace period are skipped because they can belong to an archiving in progress.
+func (a *archive) Reconcile(ctx context.Context, dryRun bool) (report archiveinfo.ReconcileReport, err error) {
+	if !a.reconcileMu.TryLock() {
+		return report, ErrReconcileRunning
+	}
+	defer a.reconcileMu.Unlock()
+
+	report = archiveinfo.ReconcileReport{
+		DryRun:    dryRun,
+		StartedAt: time.Now(),
+		Orphans:   []archiveinfo.ReconcileItem{},
+		Dangling:  []archiveinfo.ReconcileItem{},
+	}
+
+	// the store is listed before the index: an object uploaded in between looks like an orphan
+	// and is protected by the grace period, while an archived entry always has its object uploaded before
+	objects := make(map[string]archivestore.ObjectInfo)
+	err = a.archiveStore.List(ctx, func(obj archivestore.ObjectInfo) (bool, error) {
+		objects[obj.Name] = obj
+		return true, nil
+	})
+	if err != nil {
+		return
+	}
+	report.Objects = len(objects)
+
+	indexStorage := a.storageProvider.IndexStorage()
+	var afterId string
+	for {
+		entries, lErr := indexStorage.ListArchived(ctx, nodestorage.ArchivedFilter{}, afterId, reconcilePageSize)
+		if lErr != nil {
+			return report, lErr
+		}
+		for _, entry := range entries {
+			report.Archived++
+			if _, ok := objects[entry.SpaceId]; ok {
+				delete(objects, entry.SpaceId)
+				continue
+			}
+			item := a.reconcileDangling(ctx, entry, dryRun)
+			if item.Action != archiveinfo.ActionSkip && item.Error == "" && !dryRun {
+				report.Repaired++
+			}
+			report.Dangling = append(report.Dangling, item)
+		}
+		if len(entries) < reconcilePageSize {
+			break
+		}
+		afterId = entries[len(entries)-1].SpaceId
+	}
+
+	orphans := make([]archivestore.ObjectInfo, 0, len(objects))
+	for _, obj := range objects {
+		orphans = append(orphans, obj)
+	}
+	slices.SortFunc(orphans, func(a, b archivestore.ObjectInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	graceCutoff := time.Now().Add(-a.reconcileGrace)
+	for _, obj := range orphans {
+		item := a.reconcileOrphan(ctx, obj, graceCutoff, dryRun)
+		if item.Action == archiveinfo.ActionDeleteObject && item.Error == "" && !dryRun {
+			report.Repaired++
+		}
+		report.Orphans = append(report.Orphans, item)
+	}
+
+	a.stat.reconcileOrphans.Store(uint32(len(report.Orphans)))
+	a.stat.reconcileDangling.Store(uint32(len(report.Dangling)))
+	a.stat.reconcileRepaired.Add(uint32(report.Repaired))
+	log.Info("archive reconciled",
+		zap.Bool("dryRun", dryRun),
+		zap.Int("objects", report.Objects),
+		zap.Int("archived", report.Archived),
+		zap.Int("orphans", len(report.Orphans)),
+		zap.Int("dangling", len(report.Dangling)),
+		zap.Int("repaired", report.Repaired),
+		zap.Duration("dur", time.Since(report.StartedAt)),
+	)
+	return
+}
+
+func (a *archive) reconcileDangling(ctx context.Context, entry nodestorage.SpaceStatusEntry, dryRun bool) (item archiveinfo.ReconcileItem) {
+	item = archiveinfo.ReconcileItem{
+		SpaceId: entry.SpaceId,
+		Status:  entry.Status.String(),
+		Size:    entry.ArchiveSizeCompressed,
+		Action:  archiveinfo.ActionSkip,
+	}
+	// check the object first and the status after: restore sets the ok status before deleting the object,
+	// so a missing object with the archived status can't be explained by a concurrent restore
+	exists, err := a.objectExists(ctx, entry.SpaceId)
+	if err != nil {
+		item.Error = err.Error()
+		return
+	}
+	if exists {
+		return
+	}
+	status, err := a.storageProvider.IndexStorage().SpaceStatus(ctx, entry.SpaceId)
+	if err != nil {
+		item.Error = err.Error()
+		return
+	}
+	if status != nodestorage.SpaceStatusArchived {
+		item.Status = status.String()
+		return
+	}
+
+	if a.storageProvider.SpaceExists(entry.SpaceId) {
+		item.Action = archiveinfo.ActionMarkOk
+	} else {
+		item.Action = archiveinfo.ActionMarkError
+	}
+	log.Warn("archived space has no archive object", zap.String("spaceId", entry.SpaceId), zap.String("action", item.Action), zap.Bool("dryRun", dryRun))
+	if dryRun {
+		return
+	}
+	if item.Action == archiveinfo.ActionMarkOk {
+		err = a.storageProvider.IndexStorage().SetSpaceStatus(nodestorage.WithOrigin(ctx, nodestorage.OriginArchive), entry.SpaceId, nodestorage.SpaceStatusOk, "")
+	} else {
+		err = a.storageProvider.IndexStorage().MarkError(nodestorage.WithOrigin(ctx, nodestorage.OriginArchive), entry.SpaceId, errMissingArchive.Error())
+	}
+	if err != nil {
+		item.Error = err.Error()
+	}
+	return
+}
+
+func (a *archive) reconcileOrphan(ctx context.Context, obj archivestore.ObjectInfo, graceCutoff time.Time, dryRun bool) (item archiveinfo.ReconcileItem) {
+	item = archiveinfo.ReconcileItem{
+		SpaceId: obj.Name,
+		Size:    obj.Size,
+		Action:  archiveinfo.ActionSkip,
+	}
+	if obj.Modified.After(graceCutoff) {
+		return
+	}
+	status, err := a.storageProvider.IndexStorage().SpaceStatus(ctx, obj.Name)
+	if err != nil {
+		if errors.Is(err, anystore.ErrDocNotFound) {
+			// the node knows nothing about the space, keep the data for a human decision
+			item.Action = archiveinfo.ActionNone
+			return
+		}
+		item.Error = err.Error()
+		return
+	}
+	item.Status = status.String()
+	switch {
+	case status == nodestorage.SpaceStatusArchived:
+		// archived after the index was read
+		return
+	case status == nodestorage.SpaceStatusRemove:
+		item.Action = archiveinfo.ActionDeleteObject
+	case status == nodestorage.SpaceStatusOk && a.storageProvider.SpaceExists(obj.Name):
+		// leftover of an interrupted archiving or restore, the local data is authoritative
+		item.Action = archiveinfo.ActionDeleteObject
+	default:
+		item.Action = archiveinfo.ActionNone
+		return
+	}
+	log.Warn("orphaned archive object", zap.String("spaceId", obj.Name), zap.String("status", item.Status), zap.Bool("dryRun", dryRun))
+	if dryRun {
+		return
+	}
+	if err = a.archiveStore.Delete(ctx, obj.Name); err != nil {
+		item.Error = err.Error()
+	}
+	return
+}
+
+func (a *archive) objectExists(ctx context.Context, name string) (bool, error) {
+	if _, err := a.archiveStore.Stat(ctx, name); err != nil {
+		if errors.Is(err, archivestore.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (a *archive) reconcile(ctx context.Context) error {
+	_, err := a.Reconcile(ctx, a.config.Reconcile.DryRun)
+	if errors.Is(err, ErrReconcileRunning) {
+		return nil
+	}
+	if err != nil {
+		a.stat.reconcileError.Add(1)
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package archive
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	anystore "github.com/anyproto/any-store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/anyproto/any-sync-node/archive/archiveinfo"
+	"github.com/anyproto/any-sync-node/archive/archivestore"
+	"github.com/anyproto/any-sync-node/nodestorage"
+)
+
+func TestArchive_Reconcile(t *testing.T) {
+	prepare := func(fx *fixture) {
+		old := time.Now().Add(-time.Hour * 24)
+		objects := []archivestore.ObjectInfo{
+			{Name: "ok.space", Size: 10, Modified: old},
+			{Name: "orphan.local", Size: 20, Modified: old},
+			{Name: "orphan.fresh", Size: 30, Modified: time.Now()},
+			{Name: "orphan.unknown", Size: 40, Modified: old},
+		}
+		fx.archiveStore.EXPECT().List(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, iterFunc func(obj archivestore.ObjectInfo) (bool, error)) error {
+			for _, obj := range objects {
+				if _, err := iterFunc(obj); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		fx.indexStorage.EXPECT().ListArchived(ctx, nodestorage.ArchivedFilter{}, "", reconcilePageSize).Return([]nodestorage.SpaceStatusEntry{
+			{SpaceId: "dangling.local", Status: nodestorage.SpaceStatusArchived},
+			{SpaceId: "dangling.lost", Status: nodestorage.SpaceStatusArchived},
+			{SpaceId: "ok.space", Status: nodestorage.SpaceStatusArchived},
+		}, nil)
+
+		fx.archiveStore.EXPECT().Stat(ctx, "dangling.local").Return(archivestore.ObjectInfo{}, archivestore.ErrNotFound)
+		fx.archiveStore.EXPECT().Stat(ctx, "dangling.lost").Return(archivestore.ObjectInfo{}, archivestore.ErrNotFound)
+		fx.indexStorage.EXPECT().SpaceStatus(ctx, "dangling.local").Return(nodestorage.SpaceStatusArchived, nil)
+		fx.indexStorage.EXPECT().SpaceStatus(ctx, "dangling.lost").Return(nodestorage.SpaceStatusArchived, nil)
+		fx.storage.EXPECT().SpaceExists("dangling.local").Return(true)
+		fx.storage.EXPECT().SpaceExists("dangling.lost").Return(false)
+
+		fx.indexStorage.EXPECT().SpaceStatus(ctx, "orphan.local").Return(nodestorage.SpaceStatusOk, nil)
+		fx.storage.EXPECT().SpaceExists("orphan.local").Return(true)
+		fx.indexStorage.EXPECT().SpaceStatus(ctx, "orphan.unknown").Return(nodestorage.SpaceStatus(0), anystore.ErrDocNotFound)
+	}
+	check := func(t *testing.T, report archiveinfo.ReconcileReport) {
+		assert.Equal(t, 4, report.Objects)
+		assert.Equal(t, 3, report.Archived)
+		require.Len(t, report.Dangling, 2)
+		assert.Equal(t, archiveinfo.ActionMarkOk, report.Dangling[0].Action)
+		assert.Equal(t, archiveinfo.ActionMarkError, report.Dangling[1].Action)
+		require.Len(t, report.Orphans, 3)
+		assert.Equal(t, "orphan.fresh", report.Orphans[0].SpaceId)
+		assert.Equal(t, archiveinfo.ActionSkip, report.Orphans[0].Action)
+		assert.Equal(t, archiveinfo.ActionDeleteObject, report.Orphans[1].Action)
+		assert.Equal(t, archiveinfo.ActionNone, report.Orphans[2].Action)
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		fx := newFixture(t)
+		prepare(fx)
+		report, err := fx.Reconcile(ctx, true)
+		require.NoError(t, err)
+		check(t, report)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 0, report.Repaired)
+	})
+	t.Run("repair", func(t *testing.T) {
+		fx := newFixture(t)
+		prepare(fx)
+		fx.indexStorage.EXPECT().SetSpaceStatus(gomock.Any(), "dangling.local", nodestorage.SpaceStatusOk, "")
+		fx.indexStorage.EXPECT().MarkError(gomock.Any(), "dangling.lost", errMissingArchive.Error())
+		fx.archiveStore.EXPECT().Delete(ctx, "orphan.local")
+		report, err := fx.Reconcile(ctx, false)
+		require.NoError(t, err)
+		check(t, report)
+		assert.Equal(t, 3, report.Repaired)
+	})
+	t.Run("restored meanwhile", func(t *testing.T) {
+		fx := newFixture(t)
+		fx.archiveStore.EXPECT().List(ctx, gomock.Any()).Return(nil)
+		fx.indexStorage.EXPECT().ListArchived(ctx, nodestorage.ArchivedFilter{}, "", reconcilePageSize).Return([]nodestorage.SpaceStatusEntry{
+			{SpaceId: "restored", Status: nodestorage.SpaceStatusArchived},
+			{SpaceId: "uploaded", Status: nodestorage.SpaceStatusArchived},
+		}, nil)
+		fx.archiveStore.EXPECT().Stat(ctx, "restored").Return(archivestore.ObjectInfo{}, archivestore.ErrNotFound)
+		fx.indexStorage.EXPECT().SpaceStatus(ctx, "restored").Return(nodestorage.SpaceStatusOk, nil)
+		fx.archiveStore.EXPECT().Stat(ctx, "uploaded").Return(archivestore.ObjectInfo{Name: "uploaded"}, nil)
+		report, err := fx.Reconcile(ctx, false)
+		require.NoError(t, err)
+		require.Len(t, report.Dangling, 2)
+		assert.Equal(t, archiveinfo.ActionSkip, report.Dangling[0].Action)
+		assert.Equal(t, archiveinfo.ActionSkip, report.Dangling[1].Action)
+		assert.Equal(t, 0, report.Repaired)
+	})
+}
@@ -0,0 +1,272 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	anystore "github.com/anyproto/any-store"
+	"go.uber.org/zap"
+
+	"github.com/anyproto/any-sync/commonspace/spacesyncproto"
+	"github.com/anyproto/any-sync/net/rpc/rpcerr"
+
+	"github.com/anyproto/any-sync-node/archive/archiveinfo"
+	"github.com/anyproto/any-sync-node/nodestorage"
+)
+
+// spaceRestoringCode is the last code of the spacesync error group, so it doesn't collide with the codes of any-sync
+const spaceRestoringCode = 99
+
+// ErrSpaceRestoring is returned to clients when the restore takes longer than RestoreWaitSeconds, they should retry later.
+// It's registered in the spacesync error group, because clients decode space request errors there.
+var ErrSpaceRestoring = rpcerr.ErrGroup(spacesyncproto.ErrCodes_ErrorOffset).Register(errors.New("space is being restored, retry later"), spaceRestoringCode)
+
+// restoreJobTTL is how long finished jobs are shown in the restore list
+const restoreJobTTL = time.Minute * 10
+
+// restoreJob is a restore shared by all callers of the same space.
+// Prefetched jobs wait for a free slot of RestoreConcurrency; a client request makes the job urgent,
+// so it takes a free slot of either RestoreConcurrency or UrgentRestoreConcurrency.
+type restoreJob struct {
+	spaceId   string
+	createdAt time.Time
+	urgent    chan struct{}
+	urgentOne sync.Once
+	done      chan struct{}
+	total     atomic.Int64
+	read      atomic.Int64
+
+	// guarded by archive.restoreMu
+	state      string
+	startedAt  time.Time
+	finishedAt time.Time
+	err        error
+}
+
+func newRestoreJob(spaceId string) *restoreJob {
+	return &restoreJob{
+		spaceId:   spaceId,
+		createdAt: time.Now(),
+		state:     archiveinfo.RestoreQueued,
+		urgent:    make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+func (j *restoreJob) makeUrgent() {
+	j.urgentOne.Do(func() {
+		close(j.urgent)
+	})
+}
+
+func (j *restoreJob) finished() bool {
+	select {
+	case <-j.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Restore restores the archived space on a client request.
+// The caller waits up to RestoreWaitSeconds, after that the restore continues in the background and ErrSpaceRestoring is returned.
+func (a *archive) Restore(ctx context.Context, spaceId string) (err error) {
+	job := a.startRestore(spaceId, true)
+	timer := time.NewTimer(a.restoreWait)
+	defer timer.Stop()
+	select {
+	case <-job.done:
+		return job.err
+	case <-timer.C:
+		log.Info("space restore is in progress", zap.String("spaceId", spaceId), zap.Int64("read", job.read.Load()), zap.Int64("total", job.total.Load()))
+		return ErrSpaceRestoring
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *archive) Prefetch(ctx context.Context, spaceIds []string) (result archiveinfo.PrefetchResult, err error) {
+	result.Queued = make([]string, 0, len(spaceIds))
+	for _, spaceId := range spaceIds {
+		status, sErr := a.storageProvider.IndexStorage().SpaceStatus(ctx, spaceId)
+		if sErr != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			if errors.Is(sErr, anystore.ErrDocNotFound) {
+				sErr = nodestorage.ErrUnknownSpaceId
+			}
+			a.addSkipped(&result, spaceId, sErr.Error())
+			continue
+		}
+		if status != nodestorage.SpaceStatusArchived {
+			a.addSkipped(&result, spaceId, fmt.Sprintf("status %s", status))
+			continue
+		}
+		a.startRestore(spaceId, false)
+		result.Queued = append(result.Queued, spaceId)
+	}
+	log.Info("prefetch spaces", zap.Int("queued", len(result.Queued)), zap.Int("skipped", len(result.Skipped)))
+	return
+}
+
+func (a *archive) addSkipped(result *archiveinfo.PrefetchResult, spaceId, reason string) {
+	if result.Skipped == nil {
+		result.Skipped = make(map[string]string)
+	}
+	result.Skipped[spaceId] = reason
+}
+
+func (a *archive) RestoreJobs() (jobs []archiveinfo.RestoreJob) {
+	a.restoreMu.Lock()
+	defer a.restoreMu.Unlock()
+	a.pruneRestoreJobs()
+	jobs = make([]archiveinfo.RestoreJob, 0, len(a.restoreJobs))
+	for _, job := range a.restoreJobs {
+		info := archiveinfo.RestoreJob{
+			SpaceId:    job.spaceId,
+			State:      job.state,
+			CreatedAt:  job.createdAt,
+			StartedAt:  job.startedAt,
+			FinishedAt: job.finishedAt,
+			BytesRead:  job.read.Load(),
+			BytesTotal: job.total.Load(),
+		}
+		if info.BytesTotal > 0 {
+			info.Progress = min(float64(info.BytesRead)/float64(info.BytesTotal), 1)
+		}
+		if job.err != nil {
+			info.Error = job.err.Error()
+		}
+		jobs = append(jobs, info)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+	return
+}
+
+// startRestore returns the unfinished job of the space or starts a new one
+func (a *archive) startRestore(spaceId string, urgent bool) *restoreJob {
+	a.restoreMu.Lock()
+	defer a.restoreMu.Unlock()
+	a.pruneRestoreJobs()
+	job, ok := a.restoreJobs[spaceId]
+	if !ok || job.finished() {
+		job = newRestoreJob(spaceId)
+		a.restoreJobs[spaceId] = job
+		a.stat.restoreQueued.Add(1)
+		a.restoreWg.Add(1)
+		go a.runRestoreJob(job)
+	}
+	if urgent {
+		job.makeUrgent()
+	}
+	return job
+}
+
+func (a *archive) pruneRestoreJobs() {
+	for spaceId, job := range a.restoreJobs {
+		if job.finished() && time.Since(job.finishedAt) > restoreJobTTL {
+			delete(a.restoreJobs, spaceId)
+		}
+	}
+}
+
+func (a *archive) runRestoreJob(job *restoreJob) {
+	defer a.restoreWg.Done()
+	var (
+		err      error
+		acquired chan struct{}
+	)
+	select {
+	case <-job.urgent:
+		select {
+		case a.restoreSem <- struct{}{}:
+			acquired = a.restoreSem
+		case a.urgentSem <- struct{}{}:
+			acquired = a.urgentSem
+		case <-a.runCtx.Done():
+			err = a.runCtx.Err()
+		}
+	case a.restoreSem <- struct{}{}:
+		acquired = a.restoreSem
+	case <-a.runCtx.Done():
+		err = a.runCtx.Err()
+	}
+	a.stat.restoreQueued.Add(-1)
+	if err == nil {
+		a.setRestoreState(job, archiveinfo.RestoreRunning, nil)
+		a.stat.restoreInProgress.Add(1)
+		st := time.Now()
+		err = a.restore(a.runCtx, job)
+		a.stat.restoreInProgress.Add(-1)
+		if err == nil {
+			a.stat.restoreDuration.Observe(time.Since(st).Seconds())
+			log.Info("space is restored", zap.String("spaceId", job.spaceId), zap.Duration("dur", time.Since(st)))
+		} else {
+			log.Warn("space restore failed", zap.String("spaceId", job.spaceId), zap.Error(err))
+		}
+	}
+	if acquired != nil {
+		<-acquired
+	}
+	if err != nil {
+		a.setRestoreState(job, archiveinfo.RestoreFailed, err)
+	} else {
+		a.setRestoreState(job, archiveinfo.RestoreDone, nil)
+	}
+	close(job.done)
+}
+
+func (a *archive) setRestoreState(job *restoreJob, state string, err error) {
+	a.restoreMu.Lock()
+	defer a.restoreMu.Unlock()
+	job.state = state
+	job.err = err
+	switch state {
+	case archiveinfo.RestoreRunning:
+		job.startedAt = time.Now()
+	case archiveinfo.RestoreDone, archiveinfo.RestoreFailed:
+		job.finishedAt = time.Now()
+	}
+}
+
+func (a *archive) restore(ctx context.Context, job *restoreJob) (err error) {
+	spaceId := job.spaceId
+	if err = a.restoreFile(ctx, job); err != nil {
+		_ = os.RemoveAll(a.storageProvider.StoreDir(spaceId))
+		if errors.Is(err, ErrCorrupted) {
+			a.stat.restoreError.Add(1)
+			log.Error("archive is corrupted", zap.String("spaceId", spaceId), zap.Error(err))
+			if mErr := a.storageProvider.IndexStorage().MarkError(nodestorage.WithOrigin(ctx, nodestorage.OriginArchive), spaceId, err.Error()); mErr != nil {
+				return errors.Join(err, mErr)
+			}
+		}
+		return err
+	}
+	if err = a.storageProvider.IndexStorage().SetSpaceStatus(nodestorage.WithOrigin(ctx, nodestorage.OriginArchive), spaceId, nodestorage.SpaceStatusOk, ""); err != nil {
+		return
+	}
+	a.stat.restored.Add(1)
+	return a.archiveStore.Delete(ctx, spaceId)
+}
+
+// progressReader counts the archive bytes read by the restore job
+type progressReader struct {
+	r   io.Reader
+	job *restoreJob
+}
+
+func (p progressReader) Read(b []byte) (n int, err error) {
+	n, err = p.r.Read(b)
+	p.job.read.Add(int64(n))
+	return
+}
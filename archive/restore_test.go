@@ -0,0 +1,168 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	anystore "github.com/anyproto/any-store"
+	"github.com/anyproto/any-sync/commonspace/spacesyncproto"
+	"github.com/anyproto/any-sync/net/rpc/rpcerr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"storj.io/drpc/drpcerr"
+
+	"github.com/anyproto/any-sync-node/archive/archiveinfo"
+	"github.com/anyproto/any-sync-node/archive/archivestore"
+	"github.com/anyproto/any-sync-node/nodestorage"
+)
+
+func TestArchive_RestoreShared(t *testing.T) {
+	fx := newFixture(t)
+	a := fx.Archive.(*archive)
+	a.restoreWait = time.Millisecond * 10
+	var spaceId = "space.id"
+
+	data := bytes.Repeat([]byte("store"), 1000)
+	var arc bytes.Buffer
+	header, err := writeArchive(&arc, bytes.NewReader(data), CodecZstd, 0, nil)
+	require.NoError(t, err)
+	spaceDir := filepath.Join(t.TempDir(), "spaceid")
+	fx.storage.EXPECT().StoreDir(spaceId).Return(spaceDir).AnyTimes()
+
+	release := make(chan struct{})
+	fx.indexStorage.EXPECT().SpaceStatusEntry(gomock.Any(), spaceId).Return(nodestorage.SpaceStatusEntry{
+		ArchiveChecksum:       hex.EncodeToString(header.Checksum[:]),
+		ArchiveSizeCompressed: int64(arc.Len()),
+	}, nil)
+	// the archive is downloaded once for all callers
+	fx.archiveStore.EXPECT().Get(gomock.Any(), spaceId).DoAndReturn(func(_ context.Context, _ string) (io.ReadCloser, error) {
+		<-release
+		return io.NopCloser(bytes.NewReader(arc.Bytes())), nil
+	})
+	fx.indexStorage.EXPECT().SetSpaceStatus(gomock.Any(), spaceId, nodestorage.SpaceStatusOk, "")
+	fx.archiveStore.EXPECT().Delete(gomock.Any(), spaceId)
+
+	assert.ErrorIs(t, fx.Restore(ctx, spaceId), ErrSpaceRestoring)
+	assert.ErrorIs(t, fx.Restore(ctx, spaceId), ErrSpaceRestoring)
+	jobs := fx.RestoreJobs()
+	require.Len(t, jobs, 1)
+	assert.Equal(t, archiveinfo.RestoreRunning, jobs[0].State)
+
+	close(release)
+	fx.indexStorage.EXPECT().SpaceStatus(ctx, spaceId).Return(nodestorage.SpaceStatusArchived, nil)
+	require.NoError(t, fx.RestoreNow(ctx, spaceId))
+	restored, err := os.ReadFile(filepath.Join(spaceDir, "store.db"))
+	require.NoError(t, err)
+	assert.Equal(t, data, restored)
+
+	jobs = fx.RestoreJobs()
+	require.Len(t, jobs, 1)
+	assert.Equal(t, archiveinfo.RestoreDone, jobs[0].State)
+	assert.Equal(t, int64(arc.Len()), jobs[0].BytesRead)
+	assert.Equal(t, float64(1), jobs[0].Progress)
+}
+
+func TestArchive_Prefetch(t *testing.T) {
+	fx := newFixtureConf(t, Config{RestoreConcurrency: 1})
+	tmpDir := t.TempDir()
+	fx.storage.EXPECT().StoreDir(gomock.Any()).DoAndReturn(func(spaceId string) string {
+		return filepath.Join(tmpDir, spaceId)
+	}).AnyTimes()
+
+	fx.indexStorage.EXPECT().SpaceStatus(ctx, "s1").Return(nodestorage.SpaceStatusArchived, nil)
+	fx.indexStorage.EXPECT().SpaceStatus(ctx, "s2").Return(nodestorage.SpaceStatusArchived, nil)
+	fx.indexStorage.EXPECT().SpaceStatus(ctx, "ok").Return(nodestorage.SpaceStatusOk, nil)
+	fx.indexStorage.EXPECT().SpaceStatus(ctx, "unknown").Return(nodestorage.SpaceStatusOk, anystore.ErrDocNotFound)
+	fx.indexStorage.EXPECT().SpaceStatusEntry(gomock.Any(), gomock.Any()).Return(nodestorage.SpaceStatusEntry{}, nil).Times(2)
+
+	var (
+		started = make(chan string, 2)
+		release = make(chan struct{})
+	)
+	fx.archiveStore.EXPECT().Get(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, spaceId string) (io.ReadCloser, error) {
+		started <- spaceId
+		<-release
+		return nil, archivestore.ErrNotFound
+	}).Times(2)
+
+	res, err := fx.Prefetch(ctx, []string{"s1", "s2", "ok", "unknown"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s1", "s2"}, res.Queued)
+	assert.Len(t, res.Skipped, 2)
+	assert.Contains(t, res.Skipped["ok"], "status")
+
+	// only one prefetched restore runs at once
+	first := <-started
+	select {
+	case <-started:
+		t.Fatal("the second restore started before the first one finished")
+	case <-time.After(time.Millisecond * 50):
+	}
+	second := "s2"
+	if first == "s2" {
+		second = "s1"
+	}
+	states := map[string]string{}
+	for _, job := range fx.RestoreJobs() {
+		states[job.SpaceId] = job.State
+	}
+	assert.Equal(t, map[string]string{first: archiveinfo.RestoreRunning, second: archiveinfo.RestoreQueued}, states)
+
+	// a client request doesn't wait for the queue
+	a := fx.Archive.(*archive)
+	a.restoreWait = time.Millisecond * 10
+	assert.ErrorIs(t, fx.Restore(ctx, second), ErrSpaceRestoring)
+	assert.Equal(t, second, <-started)
+	close(release)
+}
+
+func TestArchive_RestoreUrgentLimit(t *testing.T) {
+	fx := newFixtureConf(t, Config{RestoreConcurrency: 1, UrgentRestoreConcurrency: 1})
+	a := fx.Archive.(*archive)
+	a.restoreWait = time.Millisecond * 10
+	tmpDir := t.TempDir()
+	fx.storage.EXPECT().StoreDir(gomock.Any()).DoAndReturn(func(spaceId string) string {
+		return filepath.Join(tmpDir, spaceId)
+	}).AnyTimes()
+	fx.indexStorage.EXPECT().SpaceStatusEntry(gomock.Any(), gomock.Any()).Return(nodestorage.SpaceStatusEntry{}, nil).Times(3)
+
+	var (
+		started = make(chan string, 3)
+		release = make(chan struct{})
+	)
+	fx.archiveStore.EXPECT().Get(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, spaceId string) (io.ReadCloser, error) {
+		started <- spaceId
+		<-release
+		return nil, archivestore.ErrNotFound
+	}).Times(3)
+
+	for _, spaceId := range []string{"s1", "s2", "s3"} {
+		assert.ErrorIs(t, fx.Restore(ctx, spaceId), ErrSpaceRestoring)
+	}
+	// client requests take the common and the urgent slot, the third one waits
+	<-started
+	<-started
+	select {
+	case spaceId := <-started:
+		t.Fatalf("restore of %s started over the limit", spaceId)
+	case <-time.After(time.Millisecond * 50):
+	}
+	close(release)
+	<-started
+}
+
+func TestErrSpaceRestoring(t *testing.T) {
+	// clients decode space errors by the spacesync error group
+	code := rpcerr.Code(ErrSpaceRestoring)
+	assert.Greater(t, code, uint64(spacesyncproto.ErrCodes_ErrorOffset))
+	assert.Less(t, code, uint64(spacesyncproto.ErrCodes_ErrorOffset)+100)
+	assert.ErrorIs(t, rpcerr.Unwrap(drpcerr.WithCode(errors.New("remote"), code)), ErrSpaceRestoring)
+}
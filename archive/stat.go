@@ -10,9 +10,80 @@ type archiveStat struct {
 	archived     atomic.Uint32
 	archiveError atomic.Uint32
 	restored     atomic.Uint32
+	restoreError atomic.Uint32
+	verifyError  atomic.Uint32
+
+	reconcileOrphans  atomic.Uint32
+	reconcileDangling atomic.Uint32
+	reconcileRepaired atomic.Uint32
+	reconcileError    atomic.Uint32
+
+	restoreInProgress atomic.Int32
+	restoreQueued     atomic.Int32
+	restoreDuration   prometheus.Histogram
+
+	inProgress             atomic.Int32
+	archiveDuration        prometheus.Histogram
+	archiveBytes           prometheus.Histogram
+	archiveCompressedBytes prometheus.Histogram
+}
+
+func newArchiveStat() *archiveStat {
+	return &archiveStat{
+		restoreDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "node",
+			Subsystem: "archive",
+			Name:      "restore_duration_seconds",
+			Help:      "time to restore a single space",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 16),
+		}),
+		archiveDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "node",
+			Subsystem: "archive",
+			Name:      "space_duration_seconds",
+			Help:      "time to archive a single space",
+			Buckets:   prometheus.ExponentialBuckets(0.1, 2, 16),
+		}),
+		archiveBytes: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "node",
+			Subsystem: "archive",
+			Name:      "space_bytes",
+			Help:      "uncompressed size of an archived space",
+			Buckets:   prometheus.ExponentialBuckets(1024, 4, 12),
+		}),
+		archiveCompressedBytes: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "node",
+			Subsystem: "archive",
+			Name:      "space_compressed_bytes",
+			Help:      "uploaded size of an archived space",
+			Buckets:   prometheus.ExponentialBuckets(1024, 4, 12),
+		}),
+	}
 }
 
 func registerMetric(s *archiveStat, registry *prometheus.Registry) {
+	registry.MustRegister(s.archiveDuration, s.archiveBytes, s.archiveCompressedBytes, s.restoreDuration)
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "node",
+		Subsystem: "archive",
+		Name:      "restore_in_progress",
+	}, func() float64 {
+		return float64(s.restoreInProgress.Load())
+	}))
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "node",
+		Subsystem: "archive",
+		Name:      "restore_queued",
+	}, func() float64 {
+		return float64(s.restoreQueued.Load())
+	}))
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "node",
+		Subsystem: "archive",
+		Name:      "in_progress",
+	}, func() float64 {
+		return float64(s.inProgress.Load())
+	}))
 	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
 		Namespace: "node",
 		Subsystem: "archive",
@@ -34,4 +105,46 @@ func registerMetric(s *archiveStat, registry *prometheus.Registry) {
 	}, func() float64 {
 		return float64(s.archiveError.Load())
 	}))
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "node",
+		Subsystem: "archive",
+		Name:      "restore_error",
+	}, func() float64 {
+		return float64(s.restoreError.Load())
+	}))
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "node",
+		Subsystem: "archive",
+		Name:      "verify_error",
+	}, func() float64 {
+		return float64(s.verifyError.Load())
+	}))
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "node",
+		Subsystem: "archive",
+		Name:      "reconcile_orphans",
+	}, func() float64 {
+		return float64(s.reconcileOrphans.Load())
+	}))
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "node",
+		Subsystem: "archive",
+		Name:      "reconcile_dangling",
+	}, func() float64 {
+		return float64(s.reconcileDangling.Load())
+	}))
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "node",
+		Subsystem: "archive",
+		Name:      "reconcile_repaired",
+	}, func() float64 {
+		return float64(s.reconcileRepaired.Load())
+	}))
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "node",
+		Subsystem: "archive",
+		Name:      "reconcile_error",
+	}, func() float64 {
+		return float64(s.reconcileError.Load())
+	}))
 }
@@ -0,0 +1,109 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	anystore "github.com/anyproto/any-store"
+	"github.com/anyproto/go-sqlite"
+	"github.com/anyproto/go-sqlite/sqlitex"
+	"golang.org/x/time/rate"
+
+	"github.com/anyproto/any-sync-node/util/ratelimit"
+)
+
+// newLimiter returns a token bucket for bytesPerSec or nil when the limit is disabled
+func newLimiter(bytesPerSec int64) *rate.Limiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	return ratelimit.New(bytesPerSec)
+}
+
+// backupPageStep is the number of store pages copied by one backup step
+const backupPageStep = 256
+
+// backupStore copies the store of the opened db to dstPath. With the disk read limit the copy is stepped by page batches
+// and every batch is paid to the limiter before it's read, so the backup never reads the store at full disk speed.
+// The space must be locked, so the store isn't written during the backup.
+func (a *archive) backupStore(ctx context.Context, db anystore.DB, spaceId, dstPath string) (err error) {
+	if a.diskReadLimiter == nil {
+		return db.Backup(ctx, dstPath)
+	}
+	srcPath := filepath.Join(a.storageProvider.StoreDir(spaceId), "store.db")
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return
+	}
+	src, err := sqlite.OpenConn(srcPath, sqlite.OpenReadWrite)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+	var pageSize int64
+	if err = sqlitex.ExecuteTransient(src, "PRAGMA page_size", &sqlitex.ExecOptions{
+		ResultFunc: func(stmt *sqlite.Stmt) error {
+			pageSize = stmt.ColumnInt64(0)
+			return nil
+		},
+	}); err != nil {
+		return
+	}
+	dst, err := sqlite.OpenConn(dstPath)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = dst.Close()
+	}()
+	backup, err := sqlite.NewBackup(dst, "", src, "")
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = backup.Close()
+	}()
+	// the page count is known after the first step, until then it's estimated by the file size
+	remaining := (info.Size() + pageSize - 1) / pageSize
+	for more := true; more; {
+		if err = ratelimit.WaitBytes(ctx, max(min(remaining, backupPageStep), 1)*pageSize, a.diskReadLimiter); err != nil {
+			return
+		}
+		if more, err = backup.Step(backupPageStep); err != nil {
+			return
+		}
+		remaining = int64(backup.Remaining())
+	}
+	return
+}
+
+// throttledReader limits the read speed with a limiter shared between all workers
+type throttledReader struct {
+	r       io.Reader
+	ctx     context.Context
+	limiter *rate.Limiter
+}
+
+func newThrottledReader(ctx context.Context, r io.Reader, limiter *rate.Limiter) io.Reader {
+	if limiter == nil {
+		return r
+	}
+	return &throttledReader{r: r, ctx: ctx, limiter: limiter}
+}
+
+func (t *throttledReader) Read(p []byte) (n int, err error) {
+	if burst := t.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err = t.r.Read(p)
+	if n > 0 {
+		if wErr := t.limiter.WaitN(t.ctx, n); wErr != nil {
+			return n, wErr
+		}
+	}
+	return
+}
@@ -0,0 +1,125 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	anystore "github.com/anyproto/any-store"
+	"github.com/anyproto/any-store/anyenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
+
+	"github.com/anyproto/any-sync-node/nodestorage"
+)
+
+func TestThrottledReader(t *testing.T) {
+	data := make([]byte, 3000)
+	limiter := newLimiter(10000)
+	// drain the initial burst
+	require.NoError(t, limiter.WaitN(ctx, limiter.Burst()))
+
+	st := time.Now()
+	read, err := io.ReadAll(newThrottledReader(ctx, bytes.NewReader(data), limiter))
+	require.NoError(t, err)
+	assert.Equal(t, data, read)
+	assert.GreaterOrEqual(t, time.Since(st), time.Millisecond*250)
+
+	assert.Nil(t, newLimiter(0))
+	r := bytes.NewReader(data)
+	assert.Equal(t, r, newThrottledReader(ctx, r, nil))
+}
+
+func TestArchive_BackupStore(t *testing.T) {
+	fx := newFixture(t)
+	a := fx.Archive.(*archive)
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "store.db")
+	fx.storage.EXPECT().StoreDir("space.id").Return(dir)
+	db, err := anystore.Open(ctx, srcPath, nil)
+	require.NoError(t, err)
+	defer func() {
+		_ = db.Close()
+	}()
+	coll, err := db.CreateCollection(ctx, "test")
+	require.NoError(t, err)
+	for i := range 200 {
+		require.NoError(t, coll.Insert(ctx, anyenc.MustParseJson(fmt.Sprintf(`{"id":"%d","data":"%s"}`, i, strings.Repeat("x", 1000)))))
+	}
+	info, err := os.Stat(srcPath)
+	require.NoError(t, err)
+
+	// the whole store is paid once and before it's read: with the drained bucket it takes about a second
+	a.diskReadLimiter = rate.NewLimiter(rate.Limit(info.Size()), int(info.Size()))
+	require.NoError(t, a.diskReadLimiter.WaitN(ctx, a.diskReadLimiter.Burst()))
+	dstPath := filepath.Join(dir, "backup.db")
+	st := time.Now()
+	require.NoError(t, a.backupStore(ctx, db, "space.id", dstPath))
+	dur := time.Since(st)
+	assert.GreaterOrEqual(t, dur, time.Millisecond*800)
+	assert.Less(t, dur, time.Second*3)
+
+	backup, err := anystore.Open(ctx, dstPath, nil)
+	require.NoError(t, err)
+	defer func() {
+		_ = backup.Close()
+	}()
+	backupColl, err := backup.OpenCollection(ctx, "test")
+	require.NoError(t, err)
+	count, err := backupColl.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 200, count)
+}
+
+func TestArchive_CheckParallel(t *testing.T) {
+	fx := newFixtureConf(t, Config{Concurrency: 2})
+	fx.Archive.(*archive).policy.enabled = true
+	old := time.Now().Add(-time.Hour * 24 * 30)
+	threshold := time.Hour * 24 * 7
+	ids := []string{"s1", "s2"}
+
+	fx.indexStorage.EXPECT().FindInactiveSpaces(gomock.Any(), threshold, nodestorage.InactiveSpace{}, inactiveIterPageSize).Return([]nodestorage.InactiveSpace{
+		{SpaceId: "s1", LastAccess: old},
+		{SpaceId: "s2", LastAccess: old},
+	}, nil)
+	fx.indexStorage.EXPECT().FindInactiveSpaces(gomock.Any(), threshold, nodestorage.InactiveSpace{SpaceId: "s2", LastAccess: old}, inactiveIterPageSize).Return(nil, nil)
+	for _, id := range ids {
+		fx.indexStorage.EXPECT().SpaceStatusEntry(gomock.Any(), id).Return(nodestorage.SpaceStatusEntry{SpaceId: id, LastAccess: old}, nil)
+		fx.storage.EXPECT().StoreDir(id).Return(t.TempDir())
+	}
+
+	// both workers must be inside Archive at the same time to pass the barrier
+	var (
+		barrier sync.WaitGroup
+		calls   atomic.Int32
+	)
+	barrier.Add(len(ids))
+	fx.storage.EXPECT().TryLockAndOpenDb(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ string, _ nodestorage.DoAfterOpenFunc) error {
+		calls.Add(1)
+		barrier.Done()
+		barrier.Wait()
+		return nodestorage.ErrLocked
+	}).Times(len(ids))
+
+	done := make(chan error)
+	go func() {
+		done <- fx.Archive.(*archive).check(ctx)
+	}()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("check is not parallel")
+	}
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -32,12 +32,16 @@ import (
 	"github.com/anyproto/any-sync-node/archive"
 	"github.com/anyproto/any-sync-node/archive/archivestore"
 	"github.com/anyproto/any-sync-node/nodehead"
+	"github.com/anyproto/any-sync-node/nodehead/headevents"
 	"github.com/anyproto/any-sync-node/nodespace/migrator"
 	"github.com/anyproto/any-sync-node/nodespace/peermanager"
 	"github.com/anyproto/any-sync-node/nodespace/spacedeleter"
 	"github.com/anyproto/any-sync-node/nodesync"
+	"github.com/anyproto/any-sync-node/nodesync/auditor"
+	"github.com/anyproto/any-sync-node/nodesync/bandwidth"
 	"github.com/anyproto/any-sync-node/nodesync/coldsync"
 	"github.com/anyproto/any-sync-node/nodesync/hotsync"
+	"github.com/anyproto/any-sync-node/nodesync/rebalancer"
 	"github.com/anyproto/any-sync-node/oldstorage"
 
 	// import this to keep govvv in go.mod on mod tidy
@@ -143,10 +147,14 @@ func Bootstrap(a *app.App) {
 		Register(nodespace.NewStreamOpener()).
 		Register(streampool.New()).
 		Register(nodehead.New()).
+		Register(headevents.New()).
 		Register(nodecache.New(200)).
 		Register(hotsync.New()).
+		Register(bandwidth.New()).
 		Register(coldsync.New()).
 		Register(nodesync.New()).
+		Register(rebalancer.New()).
+		Register(auditor.New()).
 		Register(secureservice.New()).
 		Register(commonspace.New()).
 		Register(nodespace.New()).
@@ -20,9 +20,15 @@ import (
 
 	"github.com/anyproto/any-sync-node/archive"
 	"github.com/anyproto/any-sync-node/archive/archivestore"
+	"github.com/anyproto/any-sync-node/nodehead"
+	"github.com/anyproto/any-sync-node/nodehead/headevents"
 	"github.com/anyproto/any-sync-node/nodestorage"
 	"github.com/anyproto/any-sync-node/nodesync"
+	"github.com/anyproto/any-sync-node/nodesync/auditor"
+	"github.com/anyproto/any-sync-node/nodesync/bandwidth"
+	"github.com/anyproto/any-sync-node/nodesync/coldsync"
 	"github.com/anyproto/any-sync-node/nodesync/hotsync"
+	"github.com/anyproto/any-sync-node/nodesync/rebalancer"
 )
 
 const CName = "config"
@@ -51,12 +57,17 @@ type Config struct {
 	Metric                   metric.Config          `yaml:"metric"`
 	Log                      logger.Config          `yaml:"log"`
 	NodeSync                 nodesync.Config        `yaml:"nodeSync"`
+	NodeHead                 nodehead.Config        `yaml:"nodeHead"`
+	HeadEvents               headevents.Config      `yaml:"headEvents"`
 	Yamux                    yamux.Config           `yaml:"yamux"`
 	Limiter                  limiter.Config         `yaml:"limiter"`
 	Quic                     quic.Config            `yaml:"quic"`
 	S3Store                  archivestore.Config    `yaml:"s3Store"`
+	ArchiveStore             archivestore.Config    `yaml:"archiveStore"`
 	Archive                  archive.Config         `yaml:"archive"`
 	Secure                   secureservice.Config   `yaml:"secure"`
+	Rebalancer               rebalancer.Config      `yaml:"rebalancer"`
+	Auditor                  auditor.Config         `yaml:"auditor"`
 }
 
 func (c Config) Init(a *app.App) (err error) {
@@ -115,6 +126,30 @@ func (c Config) GetHotSync() hotsync.Config {
 	return c.NodeSync.HotSync
 }
 
+func (c Config) GetColdSync() coldsync.Config {
+	return c.NodeSync.ColdSync
+}
+
+func (c Config) GetBandwidth() bandwidth.Config {
+	return c.NodeSync.Bandwidth
+}
+
+func (c Config) GetNodeHead() nodehead.Config {
+	return c.NodeHead
+}
+
+func (c Config) GetHeadEvents() headevents.Config {
+	return c.HeadEvents
+}
+
+func (c Config) GetRebalancer() rebalancer.Config {
+	return c.Rebalancer
+}
+
+func (c Config) GetAuditor() auditor.Config {
+	return c.Auditor
+}
+
 func (c Config) GetYamux() yamux.Config {
 	return c.Yamux
 }
@@ -135,6 +170,14 @@ func (c Config) GetS3Store() archivestore.Config {
 	return c.S3Store
 }
 
+// GetArchiveStore returns the archiveStore section when it's enabled and falls back to the legacy s3Store section
+func (c Config) GetArchiveStore() archivestore.Config {
+	if c.ArchiveStore.Enabled {
+		return c.ArchiveStore
+	}
+	return c.S3Store
+}
+
 func (c Config) GetArchive() archive.Config {
 	return c.Archive
 }
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/anyproto/any-sync/app"
 	"github.com/anyproto/any-sync/app/debugstat"
@@ -17,11 +19,18 @@ import (
 	"github.com/anyproto/any-sync/nodeconf"
 	"go.uber.org/zap"
 
+	"github.com/anyproto/any-sync-node/archive"
+	"github.com/anyproto/any-sync-node/archive/archiveinfo"
 	"github.com/anyproto/any-sync-node/debug/nodedebugrpc/nodedebugrpcproto"
 	"github.com/anyproto/any-sync-node/debug/spacechecker"
+	"github.com/anyproto/any-sync-node/nodehead/headevents"
 	"github.com/anyproto/any-sync-node/nodespace"
 	nodestorage "github.com/anyproto/any-sync-node/nodestorage"
 	"github.com/anyproto/any-sync-node/nodesync"
+	"github.com/anyproto/any-sync-node/nodesync/auditor"
+	"github.com/anyproto/any-sync-node/nodesync/bandwidth"
+	"github.com/anyproto/any-sync-node/nodesync/hotsync"
+	"github.com/anyproto/any-sync-node/nodesync/rebalancer"
 )
 
 const CName = "node.debug.nodedebugrpc"
@@ -47,6 +56,12 @@ type nodeDebugRpc struct {
 	server           debugserver.DebugServer
 	statService      debugstat.StatService
 	spaceChecker     spacechecker.SpaceChecker
+	archive          archive.Archive
+	bandwidth        bandwidth.Bandwidth
+	hotSync          hotsync.HotSync
+	rebalancer       rebalancer.Rebalancer
+	auditor          auditor.Auditor
+	headEvents       headevents.HeadEvents
 }
 
 type statsError struct {
@@ -64,9 +79,39 @@ func (s *nodeDebugRpc) Init(a *app.App) (err error) {
 	s.server = a.MustComponent(debugserver.CName).(debugserver.DebugServer)
 	s.statService = a.MustComponent(debugstat.CName).(debugstat.StatService)
 	s.spaceChecker = a.MustComponent(spacechecker.CName).(spacechecker.SpaceChecker)
+	s.archive = a.MustComponent(archive.CName).(archive.Archive)
+	s.bandwidth = a.MustComponent(bandwidth.CName).(bandwidth.Bandwidth)
+	s.hotSync = a.MustComponent(hotsync.CName).(hotsync.HotSync)
+	s.rebalancer = a.MustComponent(rebalancer.CName).(rebalancer.Rebalancer)
+	s.auditor = a.MustComponent(auditor.CName).(auditor.Auditor)
+	s.headEvents = a.MustComponent(headevents.CName).(headevents.HeadEvents)
 	http.HandleFunc("/stat/{spaceId}", s.handleSpaceStats)
 	http.HandleFunc("/stats", s.handleStats)
 	http.HandleFunc("/check/{spaceId}", s.handleCheck)
+	http.HandleFunc("GET /archive/{spaceId}", s.handleArchiveStatus)
+	http.HandleFunc("POST /archive/{spaceId}", s.handleArchiveNow)
+	http.HandleFunc("GET /archive/{spaceId}/policy", s.handleArchivePolicy)
+	http.HandleFunc("POST /restore/{spaceId}", s.handleRestoreNow)
+	http.HandleFunc("GET /restores", s.handleRestoreJobs)
+	http.HandleFunc("POST /prefetch", s.handlePrefetch)
+	http.HandleFunc("GET /archived", s.handleListArchived)
+	http.HandleFunc("POST /archived/reconcile", s.handleReconcileArchive)
+	http.HandleFunc("GET /bandwidth", s.handleBandwidth)
+	http.HandleFunc("GET /nodesync/runs", s.handleSyncRuns)
+	http.HandleFunc("POST /nodesync/runs", s.handleStartSync)
+	http.HandleFunc("GET /nodesync/runs/{runId}", s.handleSyncStatus)
+	http.HandleFunc("POST /nodesync/runs/{runId}/cancel", s.handleCancelSync)
+	http.HandleFunc("POST /nodesync/partitions/{partId}", s.handleSyncPartition)
+	http.HandleFunc("POST /nodesync/spaces/{spaceId}", s.handleSyncSpace)
+	http.HandleFunc("POST /bandwidth", s.handleSetBandwidth)
+	http.HandleFunc("GET /hotsync/queue", s.handleHotSyncQueue)
+	http.HandleFunc("POST /hotsync/queue/{spaceId}", s.handleHotSyncPriority)
+	http.HandleFunc("GET /rebalancer", s.handleRebalancer)
+	http.HandleFunc("POST /rebalancer/check", s.handleRebalancerCheck)
+	http.HandleFunc("GET /auditor", s.handleAuditor)
+	http.HandleFunc("POST /auditor/audit", s.handleAudit)
+	http.HandleFunc("GET /headevents", s.handleHeadEvents)
+	http.HandleFunc("GET /journal", s.handleJournal)
 	return nil
 }
 
@@ -182,3 +227,452 @@ func (s *nodeDebugRpc) handleSpaceStats(rw http.ResponseWriter, req *http.Reques
 	rw.WriteHeader(http.StatusOK)
 	_, _ = rw.Write(marshalled)
 }
+
+func (s *nodeDebugRpc) handleArchiveStatus(rw http.ResponseWriter, req *http.Request) {
+	info, err := s.archive.ArchiveStatus(req.Context(), req.PathValue("spaceId"))
+	if err != nil {
+		writeJsonError(rw, archiveErrStatus(err), err)
+		return
+	}
+	writeJson(rw, http.StatusOK, info)
+}
+
+// handleArchivePolicy explains why the periodic check archives the space or keeps it on the local disk
+func (s *nodeDebugRpc) handleArchivePolicy(rw http.ResponseWriter, req *http.Request) {
+	decision, err := s.archive.ExplainPolicy(req.Context(), req.PathValue("spaceId"))
+	if err != nil {
+		writeJsonError(rw, archiveErrStatus(err), err)
+		return
+	}
+	writeJson(rw, http.StatusOK, decision)
+}
+
+func (s *nodeDebugRpc) handleArchiveNow(rw http.ResponseWriter, req *http.Request) {
+	spaceId := req.PathValue("spaceId")
+	if err := s.archive.ArchiveNow(req.Context(), spaceId); err != nil {
+		writeJsonError(rw, archiveErrStatus(err), err)
+		return
+	}
+	s.handleArchiveStatus(rw, req)
+}
+
+func (s *nodeDebugRpc) handleRestoreNow(rw http.ResponseWriter, req *http.Request) {
+	spaceId := req.PathValue("spaceId")
+	if err := s.archive.RestoreNow(req.Context(), spaceId); err != nil {
+		writeJsonError(rw, archiveErrStatus(err), err)
+		return
+	}
+	s.handleArchiveStatus(rw, req)
+}
+
+// handleRestoreJobs returns running, queued and recently finished restores with their progress
+func (s *nodeDebugRpc) handleRestoreJobs(rw http.ResponseWriter, req *http.Request) {
+	writeJson(rw, http.StatusOK, s.archive.RestoreJobs())
+}
+
+type prefetchRequest struct {
+	SpaceIds []string `json:"spaceIds"`
+}
+
+// handlePrefetch queues restores of archived spaces, the body is {"spaceIds": [...]}
+func (s *nodeDebugRpc) handlePrefetch(rw http.ResponseWriter, req *http.Request) {
+	var body prefetchRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeJsonError(rw, http.StatusBadRequest, err)
+		return
+	}
+	result, err := s.archive.Prefetch(req.Context(), body.SpaceIds)
+	if err != nil {
+		writeJsonError(rw, archiveErrStatus(err), err)
+		return
+	}
+	writeJson(rw, http.StatusAccepted, result)
+}
+
+// handleListArchived returns a page of archived spaces, query params: cursor, limit, minSize (uncompressed bytes), lastAccessBefore (unix seconds)
+func (s *nodeDebugRpc) handleListArchived(rw http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	filter := archiveinfo.ListFilter{}
+	limit, err := queryUint(req, "limit")
+	if err != nil {
+		writeJsonError(rw, http.StatusBadRequest, err)
+		return
+	}
+	filter.Limit = int(limit)
+	if filter.MinSizeUncompressed, err = queryUint(req, "minSize"); err != nil {
+		writeJsonError(rw, http.StatusBadRequest, err)
+		return
+	}
+	before, err := queryUint(req, "lastAccessBefore")
+	if err != nil {
+		writeJsonError(rw, http.StatusBadRequest, err)
+		return
+	}
+	if before > 0 {
+		filter.LastAccessBefore = time.Unix(before, 0)
+	}
+	result, err := s.archive.ListArchived(req.Context(), filter, query.Get("cursor"))
+	if err != nil {
+		writeJsonError(rw, archiveErrStatus(err), err)
+		return
+	}
+	writeJson(rw, http.StatusOK, result)
+}
+
+// handleReconcileArchive compares the archive store with the index, query params: dryRun (true by default)
+func (s *nodeDebugRpc) handleReconcileArchive(rw http.ResponseWriter, req *http.Request) {
+	dryRun, err := queryBool(req, "dryRun", true)
+	if err != nil {
+		writeJsonError(rw, http.StatusBadRequest, err)
+		return
+	}
+	report, err := s.archive.Reconcile(req.Context(), dryRun)
+	if err != nil {
+		writeJsonError(rw, archiveErrStatus(err), err)
+		return
+	}
+	writeJson(rw, http.StatusOK, report)
+}
+
+// syncWatchInterval is how often the followed sync run progress is sent
+const syncWatchInterval = time.Second
+
+// handleSyncRuns returns the latest node sync runs without partitions
+func (s *nodeDebugRpc) handleSyncRuns(rw http.ResponseWriter, req *http.Request) {
+	writeJson(rw, http.StatusOK, s.nodeSync.SyncHistory())
+}
+
+func (s *nodeDebugRpc) handleStartSync(rw http.ResponseWriter, req *http.Request) {
+	runId, err := s.nodeSync.StartSync()
+	if err != nil {
+		writeJsonError(rw, syncErrStatus(err), err)
+		return
+	}
+	status, err := s.nodeSync.SyncStatus(runId)
+	if err != nil {
+		writeJsonError(rw, syncErrStatus(err), err)
+		return
+	}
+	writeJson(rw, http.StatusAccepted, status)
+}
+
+// handleSyncStatus returns the run with partitions, "latest" is the latest run.
+// With follow=true the progress is streamed as json lines until the run is finished
+func (s *nodeDebugRpc) handleSyncStatus(rw http.ResponseWriter, req *http.Request) {
+	runId := syncRunId(req)
+	follow, err := queryBool(req, "follow", false)
+	if err != nil {
+		writeJsonError(rw, http.StatusBadRequest, err)
+		return
+	}
+	if !follow {
+		status, err := s.nodeSync.SyncStatus(runId)
+		if err != nil {
+			writeJsonError(rw, syncErrStatus(err), err)
+			return
+		}
+		writeJson(rw, http.StatusOK, status)
+		return
+	}
+	var started bool
+	enc := json.NewEncoder(rw)
+	err = s.nodeSync.WatchSync(req.Context(), runId, syncWatchInterval, func(status nodesync.RunStatus) error {
+		if !started {
+			started = true
+			rw.Header().Set("Content-Type", "application/x-ndjson")
+			rw.WriteHeader(http.StatusOK)
+		}
+		if err := enc.Encode(status); err != nil {
+			return err
+		}
+		if f, ok := rw.(http.Flusher); ok {
+			f.Flush()
+		}
+		return nil
+	})
+	if err != nil && !started {
+		writeJsonError(rw, syncErrStatus(err), err)
+	}
+}
+
+func (s *nodeDebugRpc) handleCancelSync(rw http.ResponseWriter, req *http.Request) {
+	runId := syncRunId(req)
+	if err := s.nodeSync.CancelSync(runId); err != nil {
+		writeJsonError(rw, syncErrStatus(err), err)
+		return
+	}
+	status, err := s.nodeSync.SyncStatus(runId)
+	if err != nil {
+		writeJsonError(rw, syncErrStatus(err), err)
+		return
+	}
+	writeJson(rw, http.StatusOK, status)
+}
+
+// handleSyncPartition syncs one partition and returns the finished run
+func (s *nodeDebugRpc) handleSyncPartition(rw http.ResponseWriter, req *http.Request) {
+	partId, err := strconv.Atoi(req.PathValue("partId"))
+	if err != nil {
+		writeJsonError(rw, http.StatusBadRequest, err)
+		return
+	}
+	status, err := s.nodeSync.SyncPartition(partId)
+	if status.Id == "" {
+		writeJsonError(rw, syncErrStatus(err), err)
+		return
+	}
+	writeJson(rw, http.StatusOK, status)
+}
+
+// handleSyncSpace syncs one space with peers, force=true replaces the local store with the peer's copy
+func (s *nodeDebugRpc) handleSyncSpace(rw http.ResponseWriter, req *http.Request) {
+	force, err := queryBool(req, "force", false)
+	if err != nil {
+		writeJsonError(rw, http.StatusBadRequest, err)
+		return
+	}
+	if err = s.nodeSync.SyncSpace(req.Context(), req.PathValue("spaceId"), force); err != nil {
+		writeJsonError(rw, syncErrStatus(err), err)
+		return
+	}
+	writeJson(rw, http.StatusOK, map[string]any{"spaceId": req.PathValue("spaceId"), "force": force})
+}
+
+// queryBool parses the boolean query param, an absent param is def; invalid values are errors, so a typo doesn't flip a destructive flag
+func queryBool(req *http.Request, name string, def bool) (bool, error) {
+	v := req.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s value %q", name, v)
+	}
+	return b, nil
+}
+
+// queryUint returns the non-negative integer query param, 0 when it's missing
+func queryUint(req *http.Request, name string) (int64, error) {
+	v := req.URL.Query().Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s value %q", name, v)
+	}
+	return n, nil
+}
+
+func syncRunId(req *http.Request) string {
+	if runId := req.PathValue("runId"); runId != "latest" {
+		return runId
+	}
+	return ""
+}
+
+func syncErrStatus(err error) int {
+	switch {
+	case errors.Is(err, nodesync.ErrRunNotFound), errors.Is(err, nodesync.ErrUnknownPartition):
+		return http.StatusNotFound
+	case errors.Is(err, nodesync.ErrNotResponsible):
+		return http.StatusBadRequest
+	case errors.Is(err, nodesync.ErrSyncInProgress), errors.Is(err, nodesync.ErrRunFinished), errors.Is(err, nodestorage.ErrLocked):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (s *nodeDebugRpc) handleBandwidth(rw http.ResponseWriter, req *http.Request) {
+	writeJson(rw, http.StatusOK, s.bandwidth.Status())
+}
+
+// handleSetBandwidth changes sync traffic limits until restart, the body is bandwidth.Limits in bytes per second, 0 is unlimited.
+// Omitted fields keep their current values
+func (s *nodeDebugRpc) handleSetBandwidth(rw http.ResponseWriter, req *http.Request) {
+	var update bandwidth.LimitsUpdate
+	if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+		writeJsonError(rw, http.StatusBadRequest, err)
+		return
+	}
+	s.bandwidth.UpdateLimits(update)
+	s.handleBandwidth(rw, req)
+}
+
+func archiveErrStatus(err error) int {
+	switch {
+	case errors.Is(err, nodestorage.ErrUnknownSpaceId):
+		return http.StatusNotFound
+	case errors.Is(err, archive.ErrSpaceRestoring):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, archive.ErrNotArchived),
+		errors.Is(err, archive.ErrAlreadyArchived),
+		errors.Is(err, archive.ErrReconcileRunning),
+		errors.Is(err, nodestorage.ErrLocked):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJson(rw http.ResponseWriter, status int, v any) {
+	marshalled, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Error("failed to marshal response", zap.Error(err))
+		writeJsonError(rw, http.StatusInternalServerError, errors.New("failed to marshal response"))
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_, _ = rw.Write(marshalled)
+}
+
+func writeJsonError(rw http.ResponseWriter, status int, err error) {
+	marshalledErr, _ := json.MarshalIndent(statsError{Error: err.Error()}, "", "  ")
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_, _ = rw.Write(marshalledErr)
+}
+
+// handleHotSyncQueue returns queued spaces in the order of sync, limit is 100 by default and 0 returns the whole queue
+func (s *nodeDebugRpc) handleHotSyncQueue(rw http.ResponseWriter, req *http.Request) {
+	limit := 100
+	if l := req.URL.Query().Get("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil {
+			writeJsonError(rw, http.StatusBadRequest, err)
+			return
+		}
+	}
+	writeJson(rw, http.StatusOK, s.hotSync.Queue(limit))
+}
+
+// handleHotSyncPriority sets the priority of the queued space, the space is queued when it's missing.
+// The priority is 20 (user) by default
+func (s *nodeDebugRpc) handleHotSyncPriority(rw http.ResponseWriter, req *http.Request) {
+	spaceId := req.PathValue("spaceId")
+	priority := hotsync.PriorityUser
+	if p := req.URL.Query().Get("priority"); p != "" {
+		var err error
+		if priority, err = strconv.Atoi(p); err != nil {
+			writeJsonError(rw, http.StatusBadRequest, err)
+			return
+		}
+	}
+	err := s.hotSync.SetPriority(spaceId, priority)
+	if errors.Is(err, hotsync.ErrNotQueued) {
+		s.hotSync.Enqueue(priority, spaceId)
+	} else if err != nil {
+		writeJsonError(rw, http.StatusInternalServerError, err)
+		return
+	}
+	writeJson(rw, http.StatusOK, map[string]any{"spaceId": spaceId, "priority": priority})
+}
+
+// handleRebalancer returns gained partitions waiting for sync and spaces waiting for the handoff
+func (s *nodeDebugRpc) handleRebalancer(rw http.ResponseWriter, req *http.Request) {
+	status, err := s.rebalancer.Status(req.Context())
+	if err != nil {
+		writeJsonError(rw, http.StatusInternalServerError, err)
+		return
+	}
+	writeJson(rw, http.StatusOK, status)
+}
+
+// handleRebalancerCheck runs the rebalancer check without waiting for the next period
+func (s *nodeDebugRpc) handleRebalancerCheck(rw http.ResponseWriter, req *http.Request) {
+	if err := s.rebalancer.Check(req.Context()); err != nil {
+		writeJsonError(rw, http.StatusInternalServerError, err)
+		return
+	}
+	s.handleRebalancer(rw, req)
+}
+
+// handleAuditor returns audit counters and spaces whose heads differ on other responsible nodes
+func (s *nodeDebugRpc) handleAuditor(rw http.ResponseWriter, req *http.Request) {
+	report, err := s.auditor.Report(req.Context())
+	if err != nil {
+		writeJsonError(rw, http.StatusInternalServerError, err)
+		return
+	}
+	writeJson(rw, http.StatusOK, report)
+}
+
+// handleAudit runs the audit round without waiting for the next period
+func (s *nodeDebugRpc) handleAudit(rw http.ResponseWriter, req *http.Request) {
+	if err := s.auditor.Audit(req.Context()); err != nil {
+		writeJsonError(rw, http.StatusInternalServerError, err)
+		return
+	}
+	s.handleAuditor(rw, req)
+}
+
+// handleHeadEvents returns head changes after the cursor and the cursor for the next request,
+// 410 means events after the cursor are lost and the consumer should start over with the empty cursor
+func (s *nodeDebugRpc) handleHeadEvents(rw http.ResponseWriter, req *http.Request) {
+	limit := 100
+	if l := req.URL.Query().Get("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil {
+			writeJsonError(rw, http.StatusBadRequest, err)
+			return
+		}
+	}
+	events, cursor, err := s.headEvents.Events(req.URL.Query().Get("cursor"), limit)
+	switch {
+	case errors.Is(err, headevents.ErrCursorExpired):
+		writeJsonError(rw, http.StatusGone, err)
+		return
+	case errors.Is(err, headevents.ErrInvalidCursor):
+		writeJsonError(rw, http.StatusBadRequest, err)
+		return
+	case err != nil:
+		writeJsonError(rw, http.StatusInternalServerError, err)
+		return
+	}
+	writeJson(rw, http.StatusOK, map[string]any{"events": events, "cursor": cursor})
+}
+
+const maxJournalLimit = 1000
+
+// handleJournal returns head and status transitions of spaces, query params: spaceId, from and to (unix seconds), cursor, limit (1..1000, 100 by default)
+func (s *nodeDebugRpc) handleJournal(rw http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	filter := nodestorage.JournalFilter{
+		SpaceId: query.Get("spaceId"),
+		AfterId: query.Get("cursor"),
+	}
+	limit := 100
+	for name, dst := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := query.Get(name); v != "" {
+			sec, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				writeJsonError(rw, http.StatusBadRequest, err)
+				return
+			}
+			*dst = time.Unix(sec, 0)
+		}
+	}
+	if l := query.Get("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil {
+			writeJsonError(rw, http.StatusBadRequest, err)
+			return
+		}
+		if limit < 1 || limit > maxJournalLimit {
+			writeJsonError(rw, http.StatusBadRequest, fmt.Errorf("limit must be in 1..%d", maxJournalLimit))
+			return
+		}
+	}
+	entries, err := s.storageService.IndexStorage().Journal(req.Context(), filter, limit)
+	if err != nil {
+		writeJsonError(rw, http.StatusInternalServerError, err)
+		return
+	}
+	cursor := filter.AfterId
+	if len(entries) > 0 {
+		cursor = entries[len(entries)-1].Id
+	}
+	writeJson(rw, http.StatusOK, map[string]any{"entries": entries, "cursor": cursor})
+}
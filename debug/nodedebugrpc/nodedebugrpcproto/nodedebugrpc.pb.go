@@ -479,6 +479,7 @@ func (*ForceNodeSyncRequest) Descriptor() ([]byte, []int) {
 
 type ForceNodeSyncResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	RunId         string                 `protobuf:"bytes,1,opt,name=runId,proto3" json:"runId,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -513,6 +514,638 @@ func (*ForceNodeSyncResponse) Descriptor() ([]byte, []int) {
 	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{10}
 }
 
+func (x *ForceNodeSyncResponse) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+type NodeSyncStatusRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// runId is the run to watch, empty means the latest run
+	RunId string `protobuf:"bytes,1,opt,name=runId,proto3" json:"runId,omitempty"`
+	// follow streams the progress until the run is finished, otherwise the current status is sent once
+	Follow        bool `protobuf:"varint,2,opt,name=follow,proto3" json:"follow,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeSyncStatusRequest) Reset() {
+	*x = NodeSyncStatusRequest{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeSyncStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeSyncStatusRequest) ProtoMessage() {}
+
+func (x *NodeSyncStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeSyncStatusRequest.ProtoReflect.Descriptor instead.
+func (*NodeSyncStatusRequest) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *NodeSyncStatusRequest) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+func (x *NodeSyncStatusRequest) GetFollow() bool {
+	if x != nil {
+		return x.Follow
+	}
+	return false
+}
+
+type NodeSyncStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Run           *NodeSyncRun           `protobuf:"bytes,1,opt,name=run,proto3" json:"run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeSyncStatusResponse) Reset() {
+	*x = NodeSyncStatusResponse{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeSyncStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeSyncStatusResponse) ProtoMessage() {}
+
+func (x *NodeSyncStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeSyncStatusResponse.ProtoReflect.Descriptor instead.
+func (*NodeSyncStatusResponse) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *NodeSyncStatusResponse) GetRun() *NodeSyncRun {
+	if x != nil {
+		return x.Run
+	}
+	return nil
+}
+
+type NodeSyncRun struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	State string                 `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	// startedAt and finishedAt are unix timestamps in seconds
+	StartedAt     int64           `protobuf:"varint,3,opt,name=startedAt,proto3" json:"startedAt,omitempty"`
+	FinishedAt    int64           `protobuf:"varint,4,opt,name=finishedAt,proto3" json:"finishedAt,omitempty"`
+	PartsTotal    uint32          `protobuf:"varint,5,opt,name=partsTotal,proto3" json:"partsTotal,omitempty"`
+	PartsHandled  uint32          `protobuf:"varint,6,opt,name=partsHandled,proto3" json:"partsHandled,omitempty"`
+	PartsErrors   uint32          `protobuf:"varint,7,opt,name=partsErrors,proto3" json:"partsErrors,omitempty"`
+	Error         string          `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	Parts         []*NodeSyncPart `protobuf:"bytes,9,rep,name=parts,proto3" json:"parts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeSyncRun) Reset() {
+	*x = NodeSyncRun{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeSyncRun) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeSyncRun) ProtoMessage() {}
+
+func (x *NodeSyncRun) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeSyncRun.ProtoReflect.Descriptor instead.
+func (*NodeSyncRun) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *NodeSyncRun) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *NodeSyncRun) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *NodeSyncRun) GetStartedAt() int64 {
+	if x != nil {
+		return x.StartedAt
+	}
+	return 0
+}
+
+func (x *NodeSyncRun) GetFinishedAt() int64 {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return 0
+}
+
+func (x *NodeSyncRun) GetPartsTotal() uint32 {
+	if x != nil {
+		return x.PartsTotal
+	}
+	return 0
+}
+
+func (x *NodeSyncRun) GetPartsHandled() uint32 {
+	if x != nil {
+		return x.PartsHandled
+	}
+	return 0
+}
+
+func (x *NodeSyncRun) GetPartsErrors() uint32 {
+	if x != nil {
+		return x.PartsErrors
+	}
+	return 0
+}
+
+func (x *NodeSyncRun) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *NodeSyncRun) GetParts() []*NodeSyncPart {
+	if x != nil {
+		return x.Parts
+	}
+	return nil
+}
+
+type NodeSyncPart struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PartId        uint32                 `protobuf:"varint,1,opt,name=partId,proto3" json:"partId,omitempty"`
+	Peers         []*NodeSyncPeer        `protobuf:"bytes,2,rep,name=peers,proto3" json:"peers,omitempty"`
+	Done          bool                   `protobuf:"varint,3,opt,name=done,proto3" json:"done,omitempty"`
+	DurationMs    int64                  `protobuf:"varint,4,opt,name=durationMs,proto3" json:"durationMs,omitempty"`
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodeSyncPart) Reset() {
+	*x = NodeSyncPart{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeSyncPart) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeSyncPart) ProtoMessage() {}
+
+func (x *NodeSyncPart) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeSyncPart.ProtoReflect.Descriptor instead.
+func (*NodeSyncPart) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *NodeSyncPart) GetPartId() uint32 {
+	if x != nil {
+		return x.PartId
+	}
+	return 0
+}
+
+func (x *NodeSyncPart) GetPeers() []*NodeSyncPeer {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+func (x *NodeSyncPart) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *NodeSyncPart) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *NodeSyncPart) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type NodeSyncPeer struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	PeerId         string                 `protobuf:"bytes,1,opt,name=peerId,proto3" json:"peerId,omitempty"`
+	New            uint32                 `protobuf:"varint,2,opt,name=new,proto3" json:"new,omitempty"`
+	Changed        uint32                 `protobuf:"varint,3,opt,name=changed,proto3" json:"changed,omitempty"`
+	ColdSyncErrors uint32                 `protobuf:"varint,4,opt,name=coldSyncErrors,proto3" json:"coldSyncErrors,omitempty"`
+	DurationMs     int64                  `protobuf:"varint,5,opt,name=durationMs,proto3" json:"durationMs,omitempty"`
+	Error          string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *NodeSyncPeer) Reset() {
+	*x = NodeSyncPeer{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeSyncPeer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeSyncPeer) ProtoMessage() {}
+
+func (x *NodeSyncPeer) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeSyncPeer.ProtoReflect.Descriptor instead.
+func (*NodeSyncPeer) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *NodeSyncPeer) GetPeerId() string {
+	if x != nil {
+		return x.PeerId
+	}
+	return ""
+}
+
+func (x *NodeSyncPeer) GetNew() uint32 {
+	if x != nil {
+		return x.New
+	}
+	return 0
+}
+
+func (x *NodeSyncPeer) GetChanged() uint32 {
+	if x != nil {
+		return x.Changed
+	}
+	return 0
+}
+
+func (x *NodeSyncPeer) GetColdSyncErrors() uint32 {
+	if x != nil {
+		return x.ColdSyncErrors
+	}
+	return 0
+}
+
+func (x *NodeSyncPeer) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *NodeSyncPeer) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type CancelNodeSyncRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// runId is the run to cancel, empty means the latest run
+	RunId         string `protobuf:"bytes,1,opt,name=runId,proto3" json:"runId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelNodeSyncRequest) Reset() {
+	*x = CancelNodeSyncRequest{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelNodeSyncRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelNodeSyncRequest) ProtoMessage() {}
+
+func (x *CancelNodeSyncRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelNodeSyncRequest.ProtoReflect.Descriptor instead.
+func (*CancelNodeSyncRequest) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CancelNodeSyncRequest) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+type CancelNodeSyncResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelNodeSyncResponse) Reset() {
+	*x = CancelNodeSyncResponse{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelNodeSyncResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelNodeSyncResponse) ProtoMessage() {}
+
+func (x *CancelNodeSyncResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelNodeSyncResponse.ProtoReflect.Descriptor instead.
+func (*CancelNodeSyncResponse) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{17}
+}
+
+type SyncPartitionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PartId        uint32                 `protobuf:"varint,1,opt,name=partId,proto3" json:"partId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncPartitionRequest) Reset() {
+	*x = SyncPartitionRequest{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncPartitionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncPartitionRequest) ProtoMessage() {}
+
+func (x *SyncPartitionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncPartitionRequest.ProtoReflect.Descriptor instead.
+func (*SyncPartitionRequest) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SyncPartitionRequest) GetPartId() uint32 {
+	if x != nil {
+		return x.PartId
+	}
+	return 0
+}
+
+type SyncPartitionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Run           *NodeSyncRun           `protobuf:"bytes,1,opt,name=run,proto3" json:"run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncPartitionResponse) Reset() {
+	*x = SyncPartitionResponse{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncPartitionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncPartitionResponse) ProtoMessage() {}
+
+func (x *SyncPartitionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncPartitionResponse.ProtoReflect.Descriptor instead.
+func (*SyncPartitionResponse) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SyncPartitionResponse) GetRun() *NodeSyncRun {
+	if x != nil {
+		return x.Run
+	}
+	return nil
+}
+
+type SyncSpaceRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	SpaceId string                 `protobuf:"bytes,1,opt,name=spaceId,proto3" json:"spaceId,omitempty"`
+	// force downloads the whole space from a peer and replaces the local store
+	Force         bool `protobuf:"varint,2,opt,name=force,proto3" json:"force,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncSpaceRequest) Reset() {
+	*x = SyncSpaceRequest{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncSpaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncSpaceRequest) ProtoMessage() {}
+
+func (x *SyncSpaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncSpaceRequest.ProtoReflect.Descriptor instead.
+func (*SyncSpaceRequest) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *SyncSpaceRequest) GetSpaceId() string {
+	if x != nil {
+		return x.SpaceId
+	}
+	return ""
+}
+
+func (x *SyncSpaceRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+type SyncSpaceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncSpaceResponse) Reset() {
+	*x = SyncSpaceResponse{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncSpaceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncSpaceResponse) ProtoMessage() {}
+
+func (x *SyncSpaceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncSpaceResponse.ProtoReflect.Descriptor instead.
+func (*SyncSpaceResponse) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{21}
+}
+
 type NodesAddressesBySpaceRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	SpaceId       string                 `protobuf:"bytes,1,opt,name=spaceId,proto3" json:"spaceId,omitempty"`
@@ -520,21 +1153,484 @@ type NodesAddressesBySpaceRequest struct {
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NodesAddressesBySpaceRequest) Reset() {
-	*x = NodesAddressesBySpaceRequest{}
-	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[11]
+func (x *NodesAddressesBySpaceRequest) Reset() {
+	*x = NodesAddressesBySpaceRequest{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodesAddressesBySpaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodesAddressesBySpaceRequest) ProtoMessage() {}
+
+func (x *NodesAddressesBySpaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodesAddressesBySpaceRequest.ProtoReflect.Descriptor instead.
+func (*NodesAddressesBySpaceRequest) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *NodesAddressesBySpaceRequest) GetSpaceId() string {
+	if x != nil {
+		return x.SpaceId
+	}
+	return ""
+}
+
+type NodesAddressesBySpaceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeAddresses []string               `protobuf:"bytes,1,rep,name=nodeAddresses,proto3" json:"nodeAddresses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NodesAddressesBySpaceResponse) Reset() {
+	*x = NodesAddressesBySpaceResponse{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodesAddressesBySpaceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodesAddressesBySpaceResponse) ProtoMessage() {}
+
+func (x *NodesAddressesBySpaceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodesAddressesBySpaceResponse.ProtoReflect.Descriptor instead.
+func (*NodesAddressesBySpaceResponse) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *NodesAddressesBySpaceResponse) GetNodeAddresses() []string {
+	if x != nil {
+		return x.NodeAddresses
+	}
+	return nil
+}
+
+type ArchiveSpaceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SpaceId       string                 `protobuf:"bytes,1,opt,name=spaceId,proto3" json:"spaceId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArchiveSpaceRequest) Reset() {
+	*x = ArchiveSpaceRequest{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArchiveSpaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArchiveSpaceRequest) ProtoMessage() {}
+
+func (x *ArchiveSpaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArchiveSpaceRequest.ProtoReflect.Descriptor instead.
+func (*ArchiveSpaceRequest) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ArchiveSpaceRequest) GetSpaceId() string {
+	if x != nil {
+		return x.SpaceId
+	}
+	return ""
+}
+
+type ArchiveSpaceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArchiveSpaceResponse) Reset() {
+	*x = ArchiveSpaceResponse{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArchiveSpaceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArchiveSpaceResponse) ProtoMessage() {}
+
+func (x *ArchiveSpaceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArchiveSpaceResponse.ProtoReflect.Descriptor instead.
+func (*ArchiveSpaceResponse) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{25}
+}
+
+type RestoreSpaceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SpaceId       string                 `protobuf:"bytes,1,opt,name=spaceId,proto3" json:"spaceId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreSpaceRequest) Reset() {
+	*x = RestoreSpaceRequest{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreSpaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreSpaceRequest) ProtoMessage() {}
+
+func (x *RestoreSpaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreSpaceRequest.ProtoReflect.Descriptor instead.
+func (*RestoreSpaceRequest) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *RestoreSpaceRequest) GetSpaceId() string {
+	if x != nil {
+		return x.SpaceId
+	}
+	return ""
+}
+
+type RestoreSpaceResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreSpaceResponse) Reset() {
+	*x = RestoreSpaceResponse{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreSpaceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreSpaceResponse) ProtoMessage() {}
+
+func (x *RestoreSpaceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreSpaceResponse.ProtoReflect.Descriptor instead.
+func (*RestoreSpaceResponse) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{27}
+}
+
+// ArchivedSpace presenting archive state of one space
+type ArchivedSpace struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	SpaceId          string                 `protobuf:"bytes,1,opt,name=spaceId,proto3" json:"spaceId,omitempty"`
+	Status           string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	SizeCompressed   int64                  `protobuf:"varint,3,opt,name=sizeCompressed,proto3" json:"sizeCompressed,omitempty"`
+	SizeUncompressed int64                  `protobuf:"varint,4,opt,name=sizeUncompressed,proto3" json:"sizeUncompressed,omitempty"`
+	Checksum         string                 `protobuf:"bytes,5,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	// lastAccess is unix timestamp in seconds
+	LastAccess    int64  `protobuf:"varint,6,opt,name=lastAccess,proto3" json:"lastAccess,omitempty"`
+	Error         string `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArchivedSpace) Reset() {
+	*x = ArchivedSpace{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArchivedSpace) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArchivedSpace) ProtoMessage() {}
+
+func (x *ArchivedSpace) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArchivedSpace.ProtoReflect.Descriptor instead.
+func (*ArchivedSpace) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ArchivedSpace) GetSpaceId() string {
+	if x != nil {
+		return x.SpaceId
+	}
+	return ""
+}
+
+func (x *ArchivedSpace) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ArchivedSpace) GetSizeCompressed() int64 {
+	if x != nil {
+		return x.SizeCompressed
+	}
+	return 0
+}
+
+func (x *ArchivedSpace) GetSizeUncompressed() int64 {
+	if x != nil {
+		return x.SizeUncompressed
+	}
+	return 0
+}
+
+func (x *ArchivedSpace) GetChecksum() string {
+	if x != nil {
+		return x.Checksum
+	}
+	return ""
+}
+
+func (x *ArchivedSpace) GetLastAccess() int64 {
+	if x != nil {
+		return x.LastAccess
+	}
+	return 0
+}
+
+func (x *ArchivedSpace) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type ListArchivedRequest struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Cursor              string                 `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Limit               uint32                 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	MinSizeUncompressed int64                  `protobuf:"varint,3,opt,name=minSizeUncompressed,proto3" json:"minSizeUncompressed,omitempty"`
+	// lastAccessBefore is unix timestamp in seconds, 0 means no filter
+	LastAccessBefore int64 `protobuf:"varint,4,opt,name=lastAccessBefore,proto3" json:"lastAccessBefore,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ListArchivedRequest) Reset() {
+	*x = ListArchivedRequest{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListArchivedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListArchivedRequest) ProtoMessage() {}
+
+func (x *ListArchivedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListArchivedRequest.ProtoReflect.Descriptor instead.
+func (*ListArchivedRequest) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ListArchivedRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListArchivedRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListArchivedRequest) GetMinSizeUncompressed() int64 {
+	if x != nil {
+		return x.MinSizeUncompressed
+	}
+	return 0
+}
+
+func (x *ListArchivedRequest) GetLastAccessBefore() int64 {
+	if x != nil {
+		return x.LastAccessBefore
+	}
+	return 0
+}
+
+type ListArchivedResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Spaces        []*ArchivedSpace       `protobuf:"bytes,1,rep,name=spaces,proto3" json:"spaces,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,2,opt,name=nextCursor,proto3" json:"nextCursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListArchivedResponse) Reset() {
+	*x = ListArchivedResponse{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListArchivedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListArchivedResponse) ProtoMessage() {}
+
+func (x *ListArchivedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListArchivedResponse.ProtoReflect.Descriptor instead.
+func (*ListArchivedResponse) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ListArchivedResponse) GetSpaces() []*ArchivedSpace {
+	if x != nil {
+		return x.Spaces
+	}
+	return nil
+}
+
+func (x *ListArchivedResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type ArchiveStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SpaceId       string                 `protobuf:"bytes,1,opt,name=spaceId,proto3" json:"spaceId,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArchiveStatusRequest) Reset() {
+	*x = ArchiveStatusRequest{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NodesAddressesBySpaceRequest) String() string {
+func (x *ArchiveStatusRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NodesAddressesBySpaceRequest) ProtoMessage() {}
+func (*ArchiveStatusRequest) ProtoMessage() {}
 
-func (x *NodesAddressesBySpaceRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[11]
+func (x *ArchiveStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -545,40 +1641,40 @@ func (x *NodesAddressesBySpaceRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NodesAddressesBySpaceRequest.ProtoReflect.Descriptor instead.
-func (*NodesAddressesBySpaceRequest) Descriptor() ([]byte, []int) {
-	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use ArchiveStatusRequest.ProtoReflect.Descriptor instead.
+func (*ArchiveStatusRequest) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{31}
 }
 
-func (x *NodesAddressesBySpaceRequest) GetSpaceId() string {
+func (x *ArchiveStatusRequest) GetSpaceId() string {
 	if x != nil {
 		return x.SpaceId
 	}
 	return ""
 }
 
-type NodesAddressesBySpaceResponse struct {
+type ArchiveStatusResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	NodeAddresses []string               `protobuf:"bytes,1,rep,name=nodeAddresses,proto3" json:"nodeAddresses,omitempty"`
+	Space         *ArchivedSpace         `protobuf:"bytes,1,opt,name=space,proto3" json:"space,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NodesAddressesBySpaceResponse) Reset() {
-	*x = NodesAddressesBySpaceResponse{}
-	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[12]
+func (x *ArchiveStatusResponse) Reset() {
+	*x = ArchiveStatusResponse{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NodesAddressesBySpaceResponse) String() string {
+func (x *ArchiveStatusResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NodesAddressesBySpaceResponse) ProtoMessage() {}
+func (*ArchiveStatusResponse) ProtoMessage() {}
 
-func (x *NodesAddressesBySpaceResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[12]
+func (x *ArchiveStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -589,18 +1685,201 @@ func (x *NodesAddressesBySpaceResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NodesAddressesBySpaceResponse.ProtoReflect.Descriptor instead.
-func (*NodesAddressesBySpaceResponse) Descriptor() ([]byte, []int) {
-	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use ArchiveStatusResponse.ProtoReflect.Descriptor instead.
+func (*ArchiveStatusResponse) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{32}
 }
 
-func (x *NodesAddressesBySpaceResponse) GetNodeAddresses() []string {
+func (x *ArchiveStatusResponse) GetSpace() *ArchivedSpace {
 	if x != nil {
-		return x.NodeAddresses
+		return x.Space
+	}
+	return nil
+}
+
+type HeadEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// cursor is the cursor of the last received event, empty means the oldest event kept by the node
+	Cursor string `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// batchSize limits events in one response, 100 by default
+	BatchSize     uint32 `protobuf:"varint,2,opt,name=batchSize,proto3" json:"batchSize,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeadEventsRequest) Reset() {
+	*x = HeadEventsRequest{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeadEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeadEventsRequest) ProtoMessage() {}
+
+func (x *HeadEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeadEventsRequest.ProtoReflect.Descriptor instead.
+func (*HeadEventsRequest) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *HeadEventsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *HeadEventsRequest) GetBatchSize() uint32 {
+	if x != nil {
+		return x.BatchSize
+	}
+	return 0
+}
+
+type HeadEventsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*HeadEvent           `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeadEventsResponse) Reset() {
+	*x = HeadEventsResponse{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeadEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeadEventsResponse) ProtoMessage() {}
+
+func (x *HeadEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeadEventsResponse.ProtoReflect.Descriptor instead.
+func (*HeadEventsResponse) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *HeadEventsResponse) GetEvents() []*HeadEvent {
+	if x != nil {
+		return x.Events
 	}
 	return nil
 }
 
+type HeadEvent struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Cursor    string                 `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	SpaceId   string                 `protobuf:"bytes,2,opt,name=spaceId,proto3" json:"spaceId,omitempty"`
+	Partition uint32                 `protobuf:"varint,3,opt,name=partition,proto3" json:"partition,omitempty"`
+	OldHash   string                 `protobuf:"bytes,4,opt,name=oldHash,proto3" json:"oldHash,omitempty"`
+	NewHash   string                 `protobuf:"bytes,5,opt,name=newHash,proto3" json:"newHash,omitempty"`
+	// ts is unix time in milliseconds
+	Ts            int64 `protobuf:"varint,6,opt,name=ts,proto3" json:"ts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeadEvent) Reset() {
+	*x = HeadEvent{}
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeadEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeadEvent) ProtoMessage() {}
+
+func (x *HeadEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeadEvent.ProtoReflect.Descriptor instead.
+func (*HeadEvent) Descriptor() ([]byte, []int) {
+	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *HeadEvent) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *HeadEvent) GetSpaceId() string {
+	if x != nil {
+		return x.SpaceId
+	}
+	return ""
+}
+
+func (x *HeadEvent) GetPartition() uint32 {
+	if x != nil {
+		return x.Partition
+	}
+	return 0
+}
+
+func (x *HeadEvent) GetOldHash() string {
+	if x != nil {
+		return x.OldHash
+	}
+	return ""
+}
+
+func (x *HeadEvent) GetNewHash() string {
+	if x != nil {
+		return x.NewHash
+	}
+	return ""
+}
+
+func (x *HeadEvent) GetTs() int64 {
+	if x != nil {
+		return x.Ts
+	}
+	return 0
+}
+
 var File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto protoreflect.FileDescriptor
 
 var file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDesc = string([]byte{
@@ -640,49 +1919,225 @@ var file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDesc
 	0x6f, 0x74, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x68, 0x65, 0x61, 0x64, 0x49, 0x64, 0x73, 0x18,
 	0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x68, 0x65, 0x61, 0x64, 0x49, 0x64, 0x73, 0x22, 0x16,
 	0x0a, 0x14, 0x46, 0x6f, 0x72, 0x63, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x17, 0x0a, 0x15, 0x46, 0x6f, 0x72, 0x63, 0x65, 0x4e,
-	0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
-	0x38, 0x0a, 0x1c, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65,
-	0x73, 0x42, 0x79, 0x53, 0x70, 0x61, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
-	0x18, 0x0a, 0x07, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x07, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x22, 0x45, 0x0a, 0x1d, 0x4e, 0x6f, 0x64,
-	0x65, 0x73, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x42, 0x79, 0x53, 0x70, 0x61,
-	0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x6e, 0x6f,
-	0x64, 0x65, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
-	0x09, 0x52, 0x0d, 0x6e, 0x6f, 0x64, 0x65, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73,
-	0x32, 0xce, 0x03, 0x0a, 0x07, 0x4e, 0x6f, 0x64, 0x65, 0x41, 0x70, 0x69, 0x12, 0x3f, 0x0a, 0x08,
-	0x44, 0x75, 0x6d, 0x70, 0x54, 0x72, 0x65, 0x65, 0x12, 0x18, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61,
-	0x70, 0x69, 0x2e, 0x44, 0x75, 0x6d, 0x70, 0x54, 0x72, 0x65, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x75, 0x6d,
-	0x70, 0x54, 0x72, 0x65, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x45, 0x0a,
-	0x0a, 0x54, 0x72, 0x65, 0x65, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12, 0x1a, 0x2e, 0x6e, 0x6f,
-	0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x54, 0x72, 0x65, 0x65, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70,
-	0x69, 0x2e, 0x54, 0x72, 0x65, 0x65, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3f, 0x0a, 0x08, 0x41, 0x6c, 0x6c, 0x54, 0x72, 0x65, 0x65, 0x73,
-	0x12, 0x18, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x6c, 0x6c, 0x54, 0x72,
-	0x65, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6e, 0x6f, 0x64,
-	0x65, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x6c, 0x6c, 0x54, 0x72, 0x65, 0x65, 0x73, 0x52, 0x65, 0x73,
-	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x42, 0x0a, 0x09, 0x41, 0x6c, 0x6c, 0x53, 0x70, 0x61, 0x63,
-	0x65, 0x73, 0x12, 0x19, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x6c, 0x6c,
-	0x53, 0x70, 0x61, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e,
-	0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x6c, 0x6c, 0x53, 0x70, 0x61, 0x63, 0x65,
-	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4e, 0x0a, 0x0d, 0x46, 0x6f, 0x72,
-	0x63, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x12, 0x1d, 0x2e, 0x6e, 0x6f, 0x64,
-	0x65, 0x61, 0x70, 0x69, 0x2e, 0x46, 0x6f, 0x72, 0x63, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79,
-	0x6e, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x6e, 0x6f, 0x64, 0x65,
-	0x61, 0x70, 0x69, 0x2e, 0x46, 0x6f, 0x72, 0x63, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e,
-	0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x66, 0x0a, 0x15, 0x4e, 0x6f, 0x64,
-	0x65, 0x73, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x42, 0x79, 0x53, 0x70, 0x61,
-	0x63, 0x65, 0x12, 0x25, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x4e, 0x6f, 0x64,
-	0x65, 0x73, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x42, 0x79, 0x53, 0x70, 0x61,
-	0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x26, 0x2e, 0x6e, 0x6f, 0x64, 0x65,
-	0x61, 0x70, 0x69, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
-	0x65, 0x73, 0x42, 0x79, 0x53, 0x70, 0x61, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x42, 0x26, 0x5a, 0x24, 0x64, 0x65, 0x62, 0x75, 0x67, 0x2f, 0x6e, 0x6f, 0x64, 0x65, 0x64,
-	0x65, 0x62, 0x75, 0x67, 0x72, 0x70, 0x63, 0x2f, 0x6e, 0x6f, 0x64, 0x65, 0x64, 0x65, 0x62, 0x75,
-	0x67, 0x72, 0x70, 0x63, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x33,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x2d, 0x0a, 0x15, 0x46, 0x6f, 0x72, 0x63, 0x65, 0x4e,
+	0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x72, 0x75, 0x6e, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x72, 0x75, 0x6e, 0x49, 0x64, 0x22, 0x45, 0x0a, 0x15, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e,
+	0x63, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14,
+	0x0a, 0x05, 0x72, 0x75, 0x6e, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x72,
+	0x75, 0x6e, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x22, 0x40, 0x0a, 0x16,
+	0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x26, 0x0a, 0x03, 0x72, 0x75, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x4e, 0x6f,
+	0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x75, 0x6e, 0x52, 0x03, 0x72, 0x75, 0x6e, 0x22, 0x9a,
+	0x02, 0x0a, 0x0b, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x75, 0x6e, 0x12, 0x0e,
+	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14,
+	0x0a, 0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73,
+	0x74, 0x61, 0x74, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x41,
+	0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64,
+	0x41, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64, 0x41, 0x74,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x66, 0x69, 0x6e, 0x69, 0x73, 0x68, 0x65, 0x64,
+	0x41, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x61, 0x72, 0x74, 0x73, 0x54, 0x6f, 0x74, 0x61, 0x6c,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x70, 0x61, 0x72, 0x74, 0x73, 0x54, 0x6f, 0x74,
+	0x61, 0x6c, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x61, 0x72, 0x74, 0x73, 0x48, 0x61, 0x6e, 0x64, 0x6c,
+	0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x70, 0x61, 0x72, 0x74, 0x73, 0x48,
+	0x61, 0x6e, 0x64, 0x6c, 0x65, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x70, 0x61, 0x72, 0x74, 0x73, 0x45,
+	0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x70, 0x61, 0x72,
+	0x74, 0x73, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x2b,
+	0x0a, 0x05, 0x70, 0x61, 0x72, 0x74, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e,
+	0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63,
+	0x50, 0x61, 0x72, 0x74, 0x52, 0x05, 0x70, 0x61, 0x72, 0x74, 0x73, 0x22, 0x9d, 0x01, 0x0a, 0x0c,
+	0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x50, 0x61, 0x72, 0x74, 0x12, 0x16, 0x0a, 0x06,
+	0x70, 0x61, 0x72, 0x74, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x70, 0x61,
+	0x72, 0x74, 0x49, 0x64, 0x12, 0x2b, 0x0a, 0x05, 0x70, 0x65, 0x65, 0x72, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x4e, 0x6f,
+	0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x50, 0x65, 0x65, 0x72, 0x52, 0x05, 0x70, 0x65, 0x65, 0x72,
+	0x73, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x6f, 0x6e, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x04, 0x64, 0x6f, 0x6e, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x4d, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x64, 0x75, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x4d, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0xb0, 0x01, 0x0a, 0x0c,
+	0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x50, 0x65, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06,
+	0x70, 0x65, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x65,
+	0x65, 0x72, 0x49, 0x64, 0x12, 0x10, 0x0a, 0x03, 0x6e, 0x65, 0x77, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x03, 0x6e, 0x65, 0x77, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65,
+	0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x64,
+	0x12, 0x26, 0x0a, 0x0e, 0x63, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x45, 0x72, 0x72, 0x6f,
+	0x72, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0e, 0x63, 0x6f, 0x6c, 0x64, 0x53, 0x79,
+	0x6e, 0x63, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x64, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x64, 0x75,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x2d,
+	0x0a, 0x15, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x75, 0x6e, 0x49, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x72, 0x75, 0x6e, 0x49, 0x64, 0x22, 0x18, 0x0a,
+	0x16, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x2e, 0x0a, 0x14, 0x53, 0x79, 0x6e, 0x63, 0x50,
+	0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x16, 0x0a, 0x06, 0x70, 0x61, 0x72, 0x74, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x06, 0x70, 0x61, 0x72, 0x74, 0x49, 0x64, 0x22, 0x3f, 0x0a, 0x15, 0x53, 0x79, 0x6e, 0x63, 0x50,
+	0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x26, 0x0a, 0x03, 0x72, 0x75, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e,
+	0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63,
+	0x52, 0x75, 0x6e, 0x52, 0x03, 0x72, 0x75, 0x6e, 0x22, 0x42, 0x0a, 0x10, 0x53, 0x79, 0x6e, 0x63,
+	0x53, 0x70, 0x61, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07,
+	0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73,
+	0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x66, 0x6f, 0x72, 0x63, 0x65, 0x22, 0x13, 0x0a, 0x11,
+	0x53, 0x79, 0x6e, 0x63, 0x53, 0x70, 0x61, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x22, 0x38, 0x0a, 0x1c, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x65, 0x73, 0x42, 0x79, 0x53, 0x70, 0x61, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x22, 0x45, 0x0a, 0x1d, 0x4e,
+	0x6f, 0x64, 0x65, 0x73, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x42, 0x79, 0x53,
+	0x70, 0x61, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x24, 0x0a, 0x0d,
+	0x6e, 0x6f, 0x64, 0x65, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x0d, 0x6e, 0x6f, 0x64, 0x65, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x65, 0x73, 0x22, 0x2f, 0x0a, 0x13, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x53, 0x70, 0x61,
+	0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x70, 0x61,
+	0x63, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x70, 0x61, 0x63,
+	0x65, 0x49, 0x64, 0x22, 0x16, 0x0a, 0x14, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x53, 0x70,
+	0x61, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x2f, 0x0a, 0x13, 0x52,
+	0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x53, 0x70, 0x61, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x22, 0x16, 0x0a, 0x14,
+	0x52, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x53, 0x70, 0x61, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x22, 0xe7, 0x01, 0x0a, 0x0d, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65,
+	0x64, 0x53, 0x70, 0x61, 0x63, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64,
+	0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x26, 0x0a, 0x0e, 0x73, 0x69, 0x7a, 0x65,
+	0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0e, 0x73, 0x69, 0x7a, 0x65, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64,
+	0x12, 0x2a, 0x0a, 0x10, 0x73, 0x69, 0x7a, 0x65, 0x55, 0x6e, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65,
+	0x73, 0x73, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x10, 0x73, 0x69, 0x7a, 0x65,
+	0x55, 0x6e, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08,
+	0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x12, 0x1e, 0x0a, 0x0a, 0x6c, 0x61, 0x73, 0x74,
+	0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x6c, 0x61,
+	0x73, 0x74, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0xa1,
+	0x01, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x64, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x12, 0x14,
+	0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x6c,
+	0x69, 0x6d, 0x69, 0x74, 0x12, 0x30, 0x0a, 0x13, 0x6d, 0x69, 0x6e, 0x53, 0x69, 0x7a, 0x65, 0x55,
+	0x6e, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x13, 0x6d, 0x69, 0x6e, 0x53, 0x69, 0x7a, 0x65, 0x55, 0x6e, 0x63, 0x6f, 0x6d, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x65, 0x64, 0x12, 0x2a, 0x0a, 0x10, 0x6c, 0x61, 0x73, 0x74, 0x41, 0x63,
+	0x63, 0x65, 0x73, 0x73, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x10, 0x6c, 0x61, 0x73, 0x74, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x42, 0x65, 0x66, 0x6f,
+	0x72, 0x65, 0x22, 0x66, 0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76,
+	0x65, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2e, 0x0a, 0x06, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6e, 0x6f, 0x64,
+	0x65, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x64, 0x53, 0x70, 0x61,
+	0x63, 0x65, 0x52, 0x06, 0x73, 0x70, 0x61, 0x63, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x6e, 0x65,
+	0x78, 0x74, 0x43, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x6e, 0x65, 0x78, 0x74, 0x43, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x22, 0x30, 0x0a, 0x14, 0x41, 0x72,
+	0x63, 0x68, 0x69, 0x76, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x22, 0x45, 0x0a, 0x15,
+	0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2c, 0x0a, 0x05, 0x73, 0x70, 0x61, 0x63, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x41,
+	0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x64, 0x53, 0x70, 0x61, 0x63, 0x65, 0x52, 0x05, 0x73, 0x70,
+	0x61, 0x63, 0x65, 0x22, 0x49, 0x0a, 0x11, 0x48, 0x65, 0x61, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x75, 0x72, 0x73,
+	0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72,
+	0x12, 0x1c, 0x0a, 0x09, 0x62, 0x61, 0x74, 0x63, 0x68, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x09, 0x62, 0x61, 0x74, 0x63, 0x68, 0x53, 0x69, 0x7a, 0x65, 0x22, 0x40,
+	0x0a, 0x12, 0x48, 0x65, 0x61, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2a, 0x0a, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x48,
+	0x65, 0x61, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73,
+	0x22, 0x9f, 0x01, 0x0a, 0x09, 0x48, 0x65, 0x61, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64,
+	0x12, 0x1c, 0x0a, 0x09, 0x70, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x09, 0x70, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18,
+	0x0a, 0x07, 0x6f, 0x6c, 0x64, 0x48, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x6f, 0x6c, 0x64, 0x48, 0x61, 0x73, 0x68, 0x12, 0x18, 0x0a, 0x07, 0x6e, 0x65, 0x77, 0x48,
+	0x61, 0x73, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6e, 0x65, 0x77, 0x48, 0x61,
+	0x73, 0x68, 0x12, 0x0e, 0x0a, 0x02, 0x74, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02,
+	0x74, 0x73, 0x32, 0x8a, 0x09, 0x0a, 0x07, 0x4e, 0x6f, 0x64, 0x65, 0x41, 0x70, 0x69, 0x12, 0x3f,
+	0x0a, 0x08, 0x44, 0x75, 0x6d, 0x70, 0x54, 0x72, 0x65, 0x65, 0x12, 0x18, 0x2e, 0x6e, 0x6f, 0x64,
+	0x65, 0x61, 0x70, 0x69, 0x2e, 0x44, 0x75, 0x6d, 0x70, 0x54, 0x72, 0x65, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x44,
+	0x75, 0x6d, 0x70, 0x54, 0x72, 0x65, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x45, 0x0a, 0x0a, 0x54, 0x72, 0x65, 0x65, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12, 0x1a, 0x2e,
+	0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x54, 0x72, 0x65, 0x65, 0x50, 0x61, 0x72, 0x61,
+	0x6d, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x6e, 0x6f, 0x64, 0x65,
+	0x61, 0x70, 0x69, 0x2e, 0x54, 0x72, 0x65, 0x65, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3f, 0x0a, 0x08, 0x41, 0x6c, 0x6c, 0x54, 0x72, 0x65,
+	0x65, 0x73, 0x12, 0x18, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x6c, 0x6c,
+	0x54, 0x72, 0x65, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x6e,
+	0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x6c, 0x6c, 0x54, 0x72, 0x65, 0x65, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x42, 0x0a, 0x09, 0x41, 0x6c, 0x6c, 0x53, 0x70,
+	0x61, 0x63, 0x65, 0x73, 0x12, 0x19, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x41,
+	0x6c, 0x6c, 0x53, 0x70, 0x61, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x1a, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x6c, 0x6c, 0x53, 0x70, 0x61,
+	0x63, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4e, 0x0a, 0x0d, 0x46,
+	0x6f, 0x72, 0x63, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x12, 0x1d, 0x2e, 0x6e,
+	0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x46, 0x6f, 0x72, 0x63, 0x65, 0x4e, 0x6f, 0x64, 0x65,
+	0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x6e, 0x6f,
+	0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x46, 0x6f, 0x72, 0x63, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x53,
+	0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x66, 0x0a, 0x15, 0x4e,
+	0x6f, 0x64, 0x65, 0x73, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x42, 0x79, 0x53,
+	0x70, 0x61, 0x63, 0x65, 0x12, 0x25, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x4e,
+	0x6f, 0x64, 0x65, 0x73, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x42, 0x79, 0x53,
+	0x70, 0x61, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x26, 0x2e, 0x6e, 0x6f,
+	0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x73, 0x41, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x65, 0x73, 0x42, 0x79, 0x53, 0x70, 0x61, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x4b, 0x0a, 0x0c, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x53, 0x70,
+	0x61, 0x63, 0x65, 0x12, 0x1c, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x72,
+	0x63, 0x68, 0x69, 0x76, 0x65, 0x53, 0x70, 0x61, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x1d, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x72, 0x63, 0x68,
+	0x69, 0x76, 0x65, 0x53, 0x70, 0x61, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x4b, 0x0a, 0x0c, 0x52, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x53, 0x70, 0x61, 0x63, 0x65,
+	0x12, 0x1c, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x52, 0x65, 0x73, 0x74, 0x6f,
+	0x72, 0x65, 0x53, 0x70, 0x61, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d,
+	0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x52, 0x65, 0x73, 0x74, 0x6f, 0x72, 0x65,
+	0x53, 0x70, 0x61, 0x63, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4b, 0x0a,
+	0x0c, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x64, 0x12, 0x1c, 0x2e,
+	0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x72, 0x63, 0x68,
+	0x69, 0x76, 0x65, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x6e, 0x6f,
+	0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76,
+	0x65, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4e, 0x0a, 0x0d, 0x41, 0x72,
+	0x63, 0x68, 0x69, 0x76, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1d, 0x2e, 0x6e, 0x6f,
+	0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x6e, 0x6f, 0x64,
+	0x65, 0x61, 0x70, 0x69, 0x2e, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x53, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x53, 0x0a, 0x0e, 0x4e, 0x6f,
+	0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1e, 0x2e, 0x6e,
+	0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x6e,
+	0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x12,
+	0x51, 0x0a, 0x0e, 0x43, 0x61, 0x6e, 0x63, 0x65, 0x6c, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e,
+	0x63, 0x12, 0x1e, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x43, 0x61, 0x6e, 0x63,
+	0x65, 0x6c, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x1f, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x43, 0x61, 0x6e, 0x63,
+	0x65, 0x6c, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x4e, 0x0a, 0x0d, 0x53, 0x79, 0x6e, 0x63, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x53, 0x79,
+	0x6e, 0x63, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x53, 0x79, 0x6e,
+	0x63, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x42, 0x0a, 0x09, 0x53, 0x79, 0x6e, 0x63, 0x53, 0x70, 0x61, 0x63, 0x65, 0x12,
+	0x19, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x53, 0x70,
+	0x61, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x6e, 0x6f, 0x64,
+	0x65, 0x61, 0x70, 0x69, 0x2e, 0x53, 0x79, 0x6e, 0x63, 0x53, 0x70, 0x61, 0x63, 0x65, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x47, 0x0a, 0x0a, 0x48, 0x65, 0x61, 0x64, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x73, 0x12, 0x1a, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x48,
+	0x65, 0x61, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x1b, 0x2e, 0x6e, 0x6f, 0x64, 0x65, 0x61, 0x70, 0x69, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x30, 0x01, 0x42,
+	0x26, 0x5a, 0x24, 0x64, 0x65, 0x62, 0x75, 0x67, 0x2f, 0x6e, 0x6f, 0x64, 0x65, 0x64, 0x65, 0x62,
+	0x75, 0x67, 0x72, 0x70, 0x63, 0x2f, 0x6e, 0x6f, 0x64, 0x65, 0x64, 0x65, 0x62, 0x75, 0x67, 0x72,
+	0x70, 0x63, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 })
 
 var (
@@ -697,7 +2152,7 @@ func file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDesc
 	return file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDescData
 }
 
-var file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_msgTypes = make([]protoimpl.MessageInfo, 36)
 var file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_goTypes = []any{
 	(*DumpTreeRequest)(nil),               // 0: nodeapi.DumpTreeRequest
 	(*DumpTreeResponse)(nil),              // 1: nodeapi.DumpTreeResponse
@@ -710,28 +2165,76 @@ var file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_goTypes
 	(*TreeParamsResponse)(nil),            // 8: nodeapi.TreeParamsResponse
 	(*ForceNodeSyncRequest)(nil),          // 9: nodeapi.ForceNodeSyncRequest
 	(*ForceNodeSyncResponse)(nil),         // 10: nodeapi.ForceNodeSyncResponse
-	(*NodesAddressesBySpaceRequest)(nil),  // 11: nodeapi.NodesAddressesBySpaceRequest
-	(*NodesAddressesBySpaceResponse)(nil), // 12: nodeapi.NodesAddressesBySpaceResponse
+	(*NodeSyncStatusRequest)(nil),         // 11: nodeapi.NodeSyncStatusRequest
+	(*NodeSyncStatusResponse)(nil),        // 12: nodeapi.NodeSyncStatusResponse
+	(*NodeSyncRun)(nil),                   // 13: nodeapi.NodeSyncRun
+	(*NodeSyncPart)(nil),                  // 14: nodeapi.NodeSyncPart
+	(*NodeSyncPeer)(nil),                  // 15: nodeapi.NodeSyncPeer
+	(*CancelNodeSyncRequest)(nil),         // 16: nodeapi.CancelNodeSyncRequest
+	(*CancelNodeSyncResponse)(nil),        // 17: nodeapi.CancelNodeSyncResponse
+	(*SyncPartitionRequest)(nil),          // 18: nodeapi.SyncPartitionRequest
+	(*SyncPartitionResponse)(nil),         // 19: nodeapi.SyncPartitionResponse
+	(*SyncSpaceRequest)(nil),              // 20: nodeapi.SyncSpaceRequest
+	(*SyncSpaceResponse)(nil),             // 21: nodeapi.SyncSpaceResponse
+	(*NodesAddressesBySpaceRequest)(nil),  // 22: nodeapi.NodesAddressesBySpaceRequest
+	(*NodesAddressesBySpaceResponse)(nil), // 23: nodeapi.NodesAddressesBySpaceResponse
+	(*ArchiveSpaceRequest)(nil),           // 24: nodeapi.ArchiveSpaceRequest
+	(*ArchiveSpaceResponse)(nil),          // 25: nodeapi.ArchiveSpaceResponse
+	(*RestoreSpaceRequest)(nil),           // 26: nodeapi.RestoreSpaceRequest
+	(*RestoreSpaceResponse)(nil),          // 27: nodeapi.RestoreSpaceResponse
+	(*ArchivedSpace)(nil),                 // 28: nodeapi.ArchivedSpace
+	(*ListArchivedRequest)(nil),           // 29: nodeapi.ListArchivedRequest
+	(*ListArchivedResponse)(nil),          // 30: nodeapi.ListArchivedResponse
+	(*ArchiveStatusRequest)(nil),          // 31: nodeapi.ArchiveStatusRequest
+	(*ArchiveStatusResponse)(nil),         // 32: nodeapi.ArchiveStatusResponse
+	(*HeadEventsRequest)(nil),             // 33: nodeapi.HeadEventsRequest
+	(*HeadEventsResponse)(nil),            // 34: nodeapi.HeadEventsResponse
+	(*HeadEvent)(nil),                     // 35: nodeapi.HeadEvent
 }
 var file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_depIdxs = []int32{
 	3,  // 0: nodeapi.AllTreesResponse.trees:type_name -> nodeapi.Tree
-	0,  // 1: nodeapi.NodeApi.DumpTree:input_type -> nodeapi.DumpTreeRequest
-	7,  // 2: nodeapi.NodeApi.TreeParams:input_type -> nodeapi.TreeParamsRequest
-	2,  // 3: nodeapi.NodeApi.AllTrees:input_type -> nodeapi.AllTreesRequest
-	5,  // 4: nodeapi.NodeApi.AllSpaces:input_type -> nodeapi.AllSpacesRequest
-	9,  // 5: nodeapi.NodeApi.ForceNodeSync:input_type -> nodeapi.ForceNodeSyncRequest
-	11, // 6: nodeapi.NodeApi.NodesAddressesBySpace:input_type -> nodeapi.NodesAddressesBySpaceRequest
-	1,  // 7: nodeapi.NodeApi.DumpTree:output_type -> nodeapi.DumpTreeResponse
-	8,  // 8: nodeapi.NodeApi.TreeParams:output_type -> nodeapi.TreeParamsResponse
-	4,  // 9: nodeapi.NodeApi.AllTrees:output_type -> nodeapi.AllTreesResponse
-	6,  // 10: nodeapi.NodeApi.AllSpaces:output_type -> nodeapi.AllSpacesResponse
-	10, // 11: nodeapi.NodeApi.ForceNodeSync:output_type -> nodeapi.ForceNodeSyncResponse
-	12, // 12: nodeapi.NodeApi.NodesAddressesBySpace:output_type -> nodeapi.NodesAddressesBySpaceResponse
-	7,  // [7:13] is the sub-list for method output_type
-	1,  // [1:7] is the sub-list for method input_type
-	1,  // [1:1] is the sub-list for extension type_name
-	1,  // [1:1] is the sub-list for extension extendee
-	0,  // [0:1] is the sub-list for field type_name
+	13, // 1: nodeapi.NodeSyncStatusResponse.run:type_name -> nodeapi.NodeSyncRun
+	14, // 2: nodeapi.NodeSyncRun.parts:type_name -> nodeapi.NodeSyncPart
+	15, // 3: nodeapi.NodeSyncPart.peers:type_name -> nodeapi.NodeSyncPeer
+	13, // 4: nodeapi.SyncPartitionResponse.run:type_name -> nodeapi.NodeSyncRun
+	28, // 5: nodeapi.ListArchivedResponse.spaces:type_name -> nodeapi.ArchivedSpace
+	28, // 6: nodeapi.ArchiveStatusResponse.space:type_name -> nodeapi.ArchivedSpace
+	35, // 7: nodeapi.HeadEventsResponse.events:type_name -> nodeapi.HeadEvent
+	0,  // 8: nodeapi.NodeApi.DumpTree:input_type -> nodeapi.DumpTreeRequest
+	7,  // 9: nodeapi.NodeApi.TreeParams:input_type -> nodeapi.TreeParamsRequest
+	2,  // 10: nodeapi.NodeApi.AllTrees:input_type -> nodeapi.AllTreesRequest
+	5,  // 11: nodeapi.NodeApi.AllSpaces:input_type -> nodeapi.AllSpacesRequest
+	9,  // 12: nodeapi.NodeApi.ForceNodeSync:input_type -> nodeapi.ForceNodeSyncRequest
+	22, // 13: nodeapi.NodeApi.NodesAddressesBySpace:input_type -> nodeapi.NodesAddressesBySpaceRequest
+	24, // 14: nodeapi.NodeApi.ArchiveSpace:input_type -> nodeapi.ArchiveSpaceRequest
+	26, // 15: nodeapi.NodeApi.RestoreSpace:input_type -> nodeapi.RestoreSpaceRequest
+	29, // 16: nodeapi.NodeApi.ListArchived:input_type -> nodeapi.ListArchivedRequest
+	31, // 17: nodeapi.NodeApi.ArchiveStatus:input_type -> nodeapi.ArchiveStatusRequest
+	11, // 18: nodeapi.NodeApi.NodeSyncStatus:input_type -> nodeapi.NodeSyncStatusRequest
+	16, // 19: nodeapi.NodeApi.CancelNodeSync:input_type -> nodeapi.CancelNodeSyncRequest
+	18, // 20: nodeapi.NodeApi.SyncPartition:input_type -> nodeapi.SyncPartitionRequest
+	20, // 21: nodeapi.NodeApi.SyncSpace:input_type -> nodeapi.SyncSpaceRequest
+	33, // 22: nodeapi.NodeApi.HeadEvents:input_type -> nodeapi.HeadEventsRequest
+	1,  // 23: nodeapi.NodeApi.DumpTree:output_type -> nodeapi.DumpTreeResponse
+	8,  // 24: nodeapi.NodeApi.TreeParams:output_type -> nodeapi.TreeParamsResponse
+	4,  // 25: nodeapi.NodeApi.AllTrees:output_type -> nodeapi.AllTreesResponse
+	6,  // 26: nodeapi.NodeApi.AllSpaces:output_type -> nodeapi.AllSpacesResponse
+	10, // 27: nodeapi.NodeApi.ForceNodeSync:output_type -> nodeapi.ForceNodeSyncResponse
+	23, // 28: nodeapi.NodeApi.NodesAddressesBySpace:output_type -> nodeapi.NodesAddressesBySpaceResponse
+	25, // 29: nodeapi.NodeApi.ArchiveSpace:output_type -> nodeapi.ArchiveSpaceResponse
+	27, // 30: nodeapi.NodeApi.RestoreSpace:output_type -> nodeapi.RestoreSpaceResponse
+	30, // 31: nodeapi.NodeApi.ListArchived:output_type -> nodeapi.ListArchivedResponse
+	32, // 32: nodeapi.NodeApi.ArchiveStatus:output_type -> nodeapi.ArchiveStatusResponse
+	12, // 33: nodeapi.NodeApi.NodeSyncStatus:output_type -> nodeapi.NodeSyncStatusResponse
+	17, // 34: nodeapi.NodeApi.CancelNodeSync:output_type -> nodeapi.CancelNodeSyncResponse
+	19, // 35: nodeapi.NodeApi.SyncPartition:output_type -> nodeapi.SyncPartitionResponse
+	21, // 36: nodeapi.NodeApi.SyncSpace:output_type -> nodeapi.SyncSpaceResponse
+	34, // 37: nodeapi.NodeApi.HeadEvents:output_type -> nodeapi.HeadEventsResponse
+	23, // [23:38] is the sub-list for method output_type
+	8,  // [8:23] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_init() }
@@ -745,7 +2248,7 @@ func file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_init()
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDesc), len(file_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   13,
+			NumMessages:   36,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
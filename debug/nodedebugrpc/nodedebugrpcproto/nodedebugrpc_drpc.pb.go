@@ -39,6 +39,15 @@ type DRPCNodeApiClient interface {
 	AllSpaces(ctx context.Context, in *AllSpacesRequest) (*AllSpacesResponse, error)
 	ForceNodeSync(ctx context.Context, in *ForceNodeSyncRequest) (*ForceNodeSyncResponse, error)
 	NodesAddressesBySpace(ctx context.Context, in *NodesAddressesBySpaceRequest) (*NodesAddressesBySpaceResponse, error)
+	ArchiveSpace(ctx context.Context, in *ArchiveSpaceRequest) (*ArchiveSpaceResponse, error)
+	RestoreSpace(ctx context.Context, in *RestoreSpaceRequest) (*RestoreSpaceResponse, error)
+	ListArchived(ctx context.Context, in *ListArchivedRequest) (*ListArchivedResponse, error)
+	ArchiveStatus(ctx context.Context, in *ArchiveStatusRequest) (*ArchiveStatusResponse, error)
+	NodeSyncStatus(ctx context.Context, in *NodeSyncStatusRequest) (DRPCNodeApi_NodeSyncStatusClient, error)
+	CancelNodeSync(ctx context.Context, in *CancelNodeSyncRequest) (*CancelNodeSyncResponse, error)
+	SyncPartition(ctx context.Context, in *SyncPartitionRequest) (*SyncPartitionResponse, error)
+	SyncSpace(ctx context.Context, in *SyncSpaceRequest) (*SyncSpaceResponse, error)
+	HeadEvents(ctx context.Context, in *HeadEventsRequest) (DRPCNodeApi_HeadEventsClient, error)
 }
 
 type drpcNodeApiClient struct {
@@ -105,6 +114,149 @@ func (c *drpcNodeApiClient) NodesAddressesBySpace(ctx context.Context, in *Nodes
 	return out, nil
 }
 
+func (c *drpcNodeApiClient) ArchiveSpace(ctx context.Context, in *ArchiveSpaceRequest) (*ArchiveSpaceResponse, error) {
+	out := new(ArchiveSpaceResponse)
+	err := c.cc.Invoke(ctx, "/nodeapi.NodeApi/ArchiveSpace", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}, in, out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *drpcNodeApiClient) RestoreSpace(ctx context.Context, in *RestoreSpaceRequest) (*RestoreSpaceResponse, error) {
+	out := new(RestoreSpaceResponse)
+	err := c.cc.Invoke(ctx, "/nodeapi.NodeApi/RestoreSpace", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}, in, out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *drpcNodeApiClient) ListArchived(ctx context.Context, in *ListArchivedRequest) (*ListArchivedResponse, error) {
+	out := new(ListArchivedResponse)
+	err := c.cc.Invoke(ctx, "/nodeapi.NodeApi/ListArchived", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}, in, out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *drpcNodeApiClient) ArchiveStatus(ctx context.Context, in *ArchiveStatusRequest) (*ArchiveStatusResponse, error) {
+	out := new(ArchiveStatusResponse)
+	err := c.cc.Invoke(ctx, "/nodeapi.NodeApi/ArchiveStatus", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}, in, out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *drpcNodeApiClient) NodeSyncStatus(ctx context.Context, in *NodeSyncStatusRequest) (DRPCNodeApi_NodeSyncStatusClient, error) {
+	stream, err := c.cc.NewStream(ctx, "/nodeapi.NodeApi/NodeSyncStatus", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{})
+	if err != nil {
+		return nil, err
+	}
+	x := &drpcNodeApi_NodeSyncStatusClient{stream}
+	if err := x.MsgSend(in, drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}); err != nil {
+		return nil, err
+	}
+	if err := x.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DRPCNodeApi_NodeSyncStatusClient interface {
+	drpc.Stream
+	Recv() (*NodeSyncStatusResponse, error)
+}
+
+type drpcNodeApi_NodeSyncStatusClient struct {
+	drpc.Stream
+}
+
+func (x *drpcNodeApi_NodeSyncStatusClient) GetStream() drpc.Stream {
+	return x.Stream
+}
+
+func (x *drpcNodeApi_NodeSyncStatusClient) Recv() (*NodeSyncStatusResponse, error) {
+	m := new(NodeSyncStatusResponse)
+	if err := x.MsgRecv(m, drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (x *drpcNodeApi_NodeSyncStatusClient) RecvMsg(m *NodeSyncStatusResponse) error {
+	return x.MsgRecv(m, drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{})
+}
+
+func (c *drpcNodeApiClient) CancelNodeSync(ctx context.Context, in *CancelNodeSyncRequest) (*CancelNodeSyncResponse, error) {
+	out := new(CancelNodeSyncResponse)
+	err := c.cc.Invoke(ctx, "/nodeapi.NodeApi/CancelNodeSync", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}, in, out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *drpcNodeApiClient) SyncPartition(ctx context.Context, in *SyncPartitionRequest) (*SyncPartitionResponse, error) {
+	out := new(SyncPartitionResponse)
+	err := c.cc.Invoke(ctx, "/nodeapi.NodeApi/SyncPartition", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}, in, out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *drpcNodeApiClient) SyncSpace(ctx context.Context, in *SyncSpaceRequest) (*SyncSpaceResponse, error) {
+	out := new(SyncSpaceResponse)
+	err := c.cc.Invoke(ctx, "/nodeapi.NodeApi/SyncSpace", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}, in, out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *drpcNodeApiClient) HeadEvents(ctx context.Context, in *HeadEventsRequest) (DRPCNodeApi_HeadEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, "/nodeapi.NodeApi/HeadEvents", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{})
+	if err != nil {
+		return nil, err
+	}
+	x := &drpcNodeApi_HeadEventsClient{stream}
+	if err := x.MsgSend(in, drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}); err != nil {
+		return nil, err
+	}
+	if err := x.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DRPCNodeApi_HeadEventsClient interface {
+	drpc.Stream
+	Recv() (*HeadEventsResponse, error)
+}
+
+type drpcNodeApi_HeadEventsClient struct {
+	drpc.Stream
+}
+
+func (x *drpcNodeApi_HeadEventsClient) GetStream() drpc.Stream {
+	return x.Stream
+}
+
+func (x *drpcNodeApi_HeadEventsClient) Recv() (*HeadEventsResponse, error) {
+	m := new(HeadEventsResponse)
+	if err := x.MsgRecv(m, drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (x *drpcNodeApi_HeadEventsClient) RecvMsg(m *HeadEventsResponse) error {
+	return x.MsgRecv(m, drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{})
+}
+
 type DRPCNodeApiServer interface {
 	DumpTree(context.Context, *DumpTreeRequest) (*DumpTreeResponse, error)
 	TreeParams(context.Context, *TreeParamsRequest) (*TreeParamsResponse, error)
@@ -112,6 +264,15 @@ type DRPCNodeApiServer interface {
 	AllSpaces(context.Context, *AllSpacesRequest) (*AllSpacesResponse, error)
 	ForceNodeSync(context.Context, *ForceNodeSyncRequest) (*ForceNodeSyncResponse, error)
 	NodesAddressesBySpace(context.Context, *NodesAddressesBySpaceRequest) (*NodesAddressesBySpaceResponse, error)
+	ArchiveSpace(context.Context, *ArchiveSpaceRequest) (*ArchiveSpaceResponse, error)
+	RestoreSpace(context.Context, *RestoreSpaceRequest) (*RestoreSpaceResponse, error)
+	ListArchived(context.Context, *ListArchivedRequest) (*ListArchivedResponse, error)
+	ArchiveStatus(context.Context, *ArchiveStatusRequest) (*ArchiveStatusResponse, error)
+	NodeSyncStatus(*NodeSyncStatusRequest, DRPCNodeApi_NodeSyncStatusStream) error
+	CancelNodeSync(context.Context, *CancelNodeSyncRequest) (*CancelNodeSyncResponse, error)
+	SyncPartition(context.Context, *SyncPartitionRequest) (*SyncPartitionResponse, error)
+	SyncSpace(context.Context, *SyncSpaceRequest) (*SyncSpaceResponse, error)
+	HeadEvents(*HeadEventsRequest, DRPCNodeApi_HeadEventsStream) error
 }
 
 type DRPCNodeApiUnimplementedServer struct{}
@@ -140,9 +301,45 @@ func (s *DRPCNodeApiUnimplementedServer) NodesAddressesBySpace(context.Context,
 	return nil, drpcerr.WithCode(errors.New("Unimplemented"), drpcerr.Unimplemented)
 }
 
+func (s *DRPCNodeApiUnimplementedServer) ArchiveSpace(context.Context, *ArchiveSpaceRequest) (*ArchiveSpaceResponse, error) {
+	return nil, drpcerr.WithCode(errors.New("Unimplemented"), drpcerr.Unimplemented)
+}
+
+func (s *DRPCNodeApiUnimplementedServer) RestoreSpace(context.Context, *RestoreSpaceRequest) (*RestoreSpaceResponse, error) {
+	return nil, drpcerr.WithCode(errors.New("Unimplemented"), drpcerr.Unimplemented)
+}
+
+func (s *DRPCNodeApiUnimplementedServer) ListArchived(context.Context, *ListArchivedRequest) (*ListArchivedResponse, error) {
+	return nil, drpcerr.WithCode(errors.New("Unimplemented"), drpcerr.Unimplemented)
+}
+
+func (s *DRPCNodeApiUnimplementedServer) ArchiveStatus(context.Context, *ArchiveStatusRequest) (*ArchiveStatusResponse, error) {
+	return nil, drpcerr.WithCode(errors.New("Unimplemented"), drpcerr.Unimplemented)
+}
+
+func (s *DRPCNodeApiUnimplementedServer) NodeSyncStatus(*NodeSyncStatusRequest, DRPCNodeApi_NodeSyncStatusStream) error {
+	return drpcerr.WithCode(errors.New("Unimplemented"), drpcerr.Unimplemented)
+}
+
+func (s *DRPCNodeApiUnimplementedServer) CancelNodeSync(context.Context, *CancelNodeSyncRequest) (*CancelNodeSyncResponse, error) {
+	return nil, drpcerr.WithCode(errors.New("Unimplemented"), drpcerr.Unimplemented)
+}
+
+func (s *DRPCNodeApiUnimplementedServer) SyncPartition(context.Context, *SyncPartitionRequest) (*SyncPartitionResponse, error) {
+	return nil, drpcerr.WithCode(errors.New("Unimplemented"), drpcerr.Unimplemented)
+}
+
+func (s *DRPCNodeApiUnimplementedServer) SyncSpace(context.Context, *SyncSpaceRequest) (*SyncSpaceResponse, error) {
+	return nil, drpcerr.WithCode(errors.New("Unimplemented"), drpcerr.Unimplemented)
+}
+
+func (s *DRPCNodeApiUnimplementedServer) HeadEvents(*HeadEventsRequest, DRPCNodeApi_HeadEventsStream) error {
+	return drpcerr.WithCode(errors.New("Unimplemented"), drpcerr.Unimplemented)
+}
+
 type DRPCNodeApiDescription struct{}
 
-func (DRPCNodeApiDescription) NumMethods() int { return 6 }
+func (DRPCNodeApiDescription) NumMethods() int { return 15 }
 
 func (DRPCNodeApiDescription) Method(n int) (string, drpc.Encoding, drpc.Receiver, interface{}, bool) {
 	switch n {
@@ -200,6 +397,87 @@ func (DRPCNodeApiDescription) Method(n int) (string, drpc.Encoding, drpc.Receive
 						in1.(*NodesAddressesBySpaceRequest),
 					)
 			}, DRPCNodeApiServer.NodesAddressesBySpace, true
+	case 6:
+		return "/nodeapi.NodeApi/ArchiveSpace", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{},
+			func(srv interface{}, ctx context.Context, in1, in2 interface{}) (drpc.Message, error) {
+				return srv.(DRPCNodeApiServer).
+					ArchiveSpace(
+						ctx,
+						in1.(*ArchiveSpaceRequest),
+					)
+			}, DRPCNodeApiServer.ArchiveSpace, true
+	case 7:
+		return "/nodeapi.NodeApi/RestoreSpace", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{},
+			func(srv interface{}, ctx context.Context, in1, in2 interface{}) (drpc.Message, error) {
+				return srv.(DRPCNodeApiServer).
+					RestoreSpace(
+						ctx,
+						in1.(*RestoreSpaceRequest),
+					)
+			}, DRPCNodeApiServer.RestoreSpace, true
+	case 8:
+		return "/nodeapi.NodeApi/ListArchived", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{},
+			func(srv interface{}, ctx context.Context, in1, in2 interface{}) (drpc.Message, error) {
+				return srv.(DRPCNodeApiServer).
+					ListArchived(
+						ctx,
+						in1.(*ListArchivedRequest),
+					)
+			}, DRPCNodeApiServer.ListArchived, true
+	case 9:
+		return "/nodeapi.NodeApi/ArchiveStatus", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{},
+			func(srv interface{}, ctx context.Context, in1, in2 interface{}) (drpc.Message, error) {
+				return srv.(DRPCNodeApiServer).
+					ArchiveStatus(
+						ctx,
+						in1.(*ArchiveStatusRequest),
+					)
+			}, DRPCNodeApiServer.ArchiveStatus, true
+	case 10:
+		return "/nodeapi.NodeApi/NodeSyncStatus", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{},
+			func(srv interface{}, ctx context.Context, in1, in2 interface{}) (drpc.Message, error) {
+				return nil, srv.(DRPCNodeApiServer).
+					NodeSyncStatus(
+						in1.(*NodeSyncStatusRequest),
+						&drpcNodeApi_NodeSyncStatusStream{in2.(drpc.Stream)},
+					)
+			}, DRPCNodeApiServer.NodeSyncStatus, true
+	case 11:
+		return "/nodeapi.NodeApi/CancelNodeSync", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{},
+			func(srv interface{}, ctx context.Context, in1, in2 interface{}) (drpc.Message, error) {
+				return srv.(DRPCNodeApiServer).
+					CancelNodeSync(
+						ctx,
+						in1.(*CancelNodeSyncRequest),
+					)
+			}, DRPCNodeApiServer.CancelNodeSync, true
+	case 12:
+		return "/nodeapi.NodeApi/SyncPartition", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{},
+			func(srv interface{}, ctx context.Context, in1, in2 interface{}) (drpc.Message, error) {
+				return srv.(DRPCNodeApiServer).
+					SyncPartition(
+						ctx,
+						in1.(*SyncPartitionRequest),
+					)
+			}, DRPCNodeApiServer.SyncPartition, true
+	case 13:
+		return "/nodeapi.NodeApi/SyncSpace", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{},
+			func(srv interface{}, ctx context.Context, in1, in2 interface{}) (drpc.Message, error) {
+				return srv.(DRPCNodeApiServer).
+					SyncSpace(
+						ctx,
+						in1.(*SyncSpaceRequest),
+					)
+			}, DRPCNodeApiServer.SyncSpace, true
+	case 14:
+		return "/nodeapi.NodeApi/HeadEvents", drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{},
+			func(srv interface{}, ctx context.Context, in1, in2 interface{}) (drpc.Message, error) {
+				return nil, srv.(DRPCNodeApiServer).
+					HeadEvents(
+						in1.(*HeadEventsRequest),
+						&drpcNodeApi_HeadEventsStream{in2.(drpc.Stream)},
+					)
+			}, DRPCNodeApiServer.HeadEvents, true
 	default:
 		return "", nil, nil, nil, false
 	}
@@ -304,3 +582,141 @@ func (x *drpcNodeApi_NodesAddressesBySpaceStream) SendAndClose(m *NodesAddresses
 	}
 	return x.CloseSend()
 }
+
+type DRPCNodeApi_ArchiveSpaceStream interface {
+	drpc.Stream
+	SendAndClose(*ArchiveSpaceResponse) error
+}
+
+type drpcNodeApi_ArchiveSpaceStream struct {
+	drpc.Stream
+}
+
+func (x *drpcNodeApi_ArchiveSpaceStream) SendAndClose(m *ArchiveSpaceResponse) error {
+	if err := x.MsgSend(m, drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}); err != nil {
+		return err
+	}
+	return x.CloseSend()
+}
+
+type DRPCNodeApi_RestoreSpaceStream interface {
+	drpc.Stream
+	SendAndClose(*RestoreSpaceResponse) error
+}
+
+type drpcNodeApi_RestoreSpaceStream struct {
+	drpc.Stream
+}
+
+func (x *drpcNodeApi_RestoreSpaceStream) SendAndClose(m *RestoreSpaceResponse) error {
+	if err := x.MsgSend(m, drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}); err != nil {
+		return err
+	}
+	return x.CloseSend()
+}
+
+type DRPCNodeApi_ListArchivedStream interface {
+	drpc.Stream
+	SendAndClose(*ListArchivedResponse) error
+}
+
+type drpcNodeApi_ListArchivedStream struct {
+	drpc.Stream
+}
+
+func (x *drpcNodeApi_ListArchivedStream) SendAndClose(m *ListArchivedResponse) error {
+	if err := x.MsgSend(m, drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}); err != nil {
+		return err
+	}
+	return x.CloseSend()
+}
+
+type DRPCNodeApi_ArchiveStatusStream interface {
+	drpc.Stream
+	SendAndClose(*ArchiveStatusResponse) error
+}
+
+type drpcNodeApi_ArchiveStatusStream struct {
+	drpc.Stream
+}
+
+func (x *drpcNodeApi_ArchiveStatusStream) SendAndClose(m *ArchiveStatusResponse) error {
+	if err := x.MsgSend(m, drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}); err != nil {
+		return err
+	}
+	return x.CloseSend()
+}
+
+type DRPCNodeApi_NodeSyncStatusStream interface {
+	drpc.Stream
+	Send(*NodeSyncStatusResponse) error
+}
+
+type drpcNodeApi_NodeSyncStatusStream struct {
+	drpc.Stream
+}
+
+func (x *drpcNodeApi_NodeSyncStatusStream) Send(m *NodeSyncStatusResponse) error {
+	return x.MsgSend(m, drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{})
+}
+
+type DRPCNodeApi_CancelNodeSyncStream interface {
+	drpc.Stream
+	SendAndClose(*CancelNodeSyncResponse) error
+}
+
+type drpcNodeApi_CancelNodeSyncStream struct {
+	drpc.Stream
+}
+
+func (x *drpcNodeApi_CancelNodeSyncStream) SendAndClose(m *CancelNodeSyncResponse) error {
+	if err := x.MsgSend(m, drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}); err != nil {
+		return err
+	}
+	return x.CloseSend()
+}
+
+type DRPCNodeApi_SyncPartitionStream interface {
+	drpc.Stream
+	SendAndClose(*SyncPartitionResponse) error
+}
+
+type drpcNodeApi_SyncPartitionStream struct {
+	drpc.Stream
+}
+
+func (x *drpcNodeApi_SyncPartitionStream) SendAndClose(m *SyncPartitionResponse) error {
+	if err := x.MsgSend(m, drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}); err != nil {
+		return err
+	}
+	return x.CloseSend()
+}
+
+type DRPCNodeApi_SyncSpaceStream interface {
+	drpc.Stream
+	SendAndClose(*SyncSpaceResponse) error
+}
+
+type drpcNodeApi_SyncSpaceStream struct {
+	drpc.Stream
+}
+
+func (x *drpcNodeApi_SyncSpaceStream) SendAndClose(m *SyncSpaceResponse) error {
+	if err := x.MsgSend(m, drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{}); err != nil {
+		return err
+	}
+	return x.CloseSend()
+}
+
+type DRPCNodeApi_HeadEventsStream interface {
+	drpc.Stream
+	Send(*HeadEventsResponse) error
+}
+
+type drpcNodeApi_HeadEventsStream struct {
+	drpc.Stream
+}
+
+func (x *drpcNodeApi_HeadEventsStream) Send(m *HeadEventsResponse) error {
+	return x.MsgSend(m, drpcEncoding_File_debug_nodedebugrpc_nodedebugrpcproto_protos_nodedebugrpc_proto{})
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"time"
 
+	"github.com/anyproto/any-sync-node/archive/archiveinfo"
 	"github.com/anyproto/any-sync-node/debug/nodedebugrpc/nodedebugrpcproto"
+	"github.com/anyproto/any-sync-node/nodehead/headevents"
+	"github.com/anyproto/any-sync-node/nodesync"
 	"github.com/anyproto/any-sync/commonspace/object/tree/objecttree"
 )
 
@@ -66,17 +69,64 @@ func (r *rpcHandler) TreeParams(ctx context.Context, request *nodedebugrpcproto.
 }
 
 func (r *rpcHandler) ForceNodeSync(ctx context.Context, request *nodedebugrpcproto.ForceNodeSyncRequest) (*nodedebugrpcproto.ForceNodeSyncResponse, error) {
-	var errCh = make(chan error, 1)
-	go func() {
-		errCh <- r.s.nodeSync.Sync()
-	}()
-	select {
-	case <-time.After(time.Millisecond * 100):
-		return &nodedebugrpcproto.ForceNodeSyncResponse{}, nil
-	case err := <-errCh:
+	runId, err := r.s.nodeSync.StartSync()
+	if err != nil {
+		return nil, err
+	}
+	return &nodedebugrpcproto.ForceNodeSyncResponse{RunId: runId}, nil
+}
+
+func (r *rpcHandler) NodeSyncStatus(request *nodedebugrpcproto.NodeSyncStatusRequest, stream nodedebugrpcproto.DRPCNodeApi_NodeSyncStatusStream) error {
+	send := func(status nodesync.RunStatus) error {
+		return stream.Send(&nodedebugrpcproto.NodeSyncStatusResponse{Run: syncRunToProto(status)})
+	}
+	if !request.Follow {
+		status, err := r.s.nodeSync.SyncStatus(request.RunId)
+		if err != nil {
+			return err
+		}
+		return send(status)
+	}
+	return r.s.nodeSync.WatchSync(stream.Context(), request.RunId, syncWatchInterval, send)
+}
+
+func (r *rpcHandler) CancelNodeSync(ctx context.Context, request *nodedebugrpcproto.CancelNodeSyncRequest) (*nodedebugrpcproto.CancelNodeSyncResponse, error) {
+	if err := r.s.nodeSync.CancelSync(request.RunId); err != nil {
 		return nil, err
 	}
+	return &nodedebugrpcproto.CancelNodeSyncResponse{}, nil
+}
 
+func (r *rpcHandler) SyncPartition(ctx context.Context, request *nodedebugrpcproto.SyncPartitionRequest) (*nodedebugrpcproto.SyncPartitionResponse, error) {
+	status, err := r.s.nodeSync.SyncPartition(int(request.PartId))
+	if status.Id == "" {
+		return nil, err
+	}
+	return &nodedebugrpcproto.SyncPartitionResponse{Run: syncRunToProto(status)}, nil
+}
+
+func (r *rpcHandler) SyncSpace(ctx context.Context, request *nodedebugrpcproto.SyncSpaceRequest) (*nodedebugrpcproto.SyncSpaceResponse, error) {
+	if err := r.s.nodeSync.SyncSpace(ctx, request.SpaceId, request.Force); err != nil {
+		return nil, err
+	}
+	return &nodedebugrpcproto.SyncSpaceResponse{}, nil
+}
+
+func (r *rpcHandler) HeadEvents(request *nodedebugrpcproto.HeadEventsRequest, stream nodedebugrpcproto.DRPCNodeApi_HeadEventsStream) error {
+	return r.s.headEvents.Subscribe(stream.Context(), request.Cursor, int(request.BatchSize), func(events []headevents.Event) error {
+		resp := &nodedebugrpcproto.HeadEventsResponse{Events: make([]*nodedebugrpcproto.HeadEvent, len(events))}
+		for i, event := range events {
+			resp.Events[i] = &nodedebugrpcproto.HeadEvent{
+				Cursor:    event.Cursor,
+				SpaceId:   event.SpaceId,
+				Partition: uint32(event.Partition),
+				OldHash:   event.OldHash,
+				NewHash:   event.NewHash,
+				Ts:        event.Ts.UnixMilli(),
+			}
+		}
+		return stream.Send(resp)
+	})
 }
 
 func (r *rpcHandler) NodesAddressesBySpace(ctx context.Context, request *nodedebugrpcproto.NodesAddressesBySpaceRequest) (resp *nodedebugrpcproto.NodesAddressesBySpaceResponse, err error) {
@@ -94,3 +144,96 @@ func (r *rpcHandler) NodesAddressesBySpace(ctx context.Context, request *nodedeb
 
 	return &nodedebugrpcproto.NodesAddressesBySpaceResponse{NodeAddresses: respAddresses}, nil
 }
+
+func (r *rpcHandler) ArchiveSpace(ctx context.Context, request *nodedebugrpcproto.ArchiveSpaceRequest) (*nodedebugrpcproto.ArchiveSpaceResponse, error) {
+	if err := r.s.archive.ArchiveNow(ctx, request.SpaceId); err != nil {
+		return nil, err
+	}
+	return &nodedebugrpcproto.ArchiveSpaceResponse{}, nil
+}
+
+func (r *rpcHandler) RestoreSpace(ctx context.Context, request *nodedebugrpcproto.RestoreSpaceRequest) (*nodedebugrpcproto.RestoreSpaceResponse, error) {
+	if err := r.s.archive.RestoreNow(ctx, request.SpaceId); err != nil {
+		return nil, err
+	}
+	return &nodedebugrpcproto.RestoreSpaceResponse{}, nil
+}
+
+func (r *rpcHandler) ListArchived(ctx context.Context, request *nodedebugrpcproto.ListArchivedRequest) (resp *nodedebugrpcproto.ListArchivedResponse, err error) {
+	filter := archiveinfo.ListFilter{
+		MinSizeUncompressed: request.MinSizeUncompressed,
+		Limit:               int(request.Limit),
+	}
+	if request.LastAccessBefore > 0 {
+		filter.LastAccessBefore = time.Unix(request.LastAccessBefore, 0)
+	}
+	result, err := r.s.archive.ListArchived(ctx, filter, request.Cursor)
+	if err != nil {
+		return
+	}
+	resp = &nodedebugrpcproto.ListArchivedResponse{
+		Spaces:     make([]*nodedebugrpcproto.ArchivedSpace, 0, len(result.Spaces)),
+		NextCursor: result.NextCursor,
+	}
+	for _, info := range result.Spaces {
+		resp.Spaces = append(resp.Spaces, archivedSpaceToProto(info))
+	}
+	return
+}
+
+func (r *rpcHandler) ArchiveStatus(ctx context.Context, request *nodedebugrpcproto.ArchiveStatusRequest) (resp *nodedebugrpcproto.ArchiveStatusResponse, err error) {
+	info, err := r.s.archive.ArchiveStatus(ctx, request.SpaceId)
+	if err != nil {
+		return
+	}
+	return &nodedebugrpcproto.ArchiveStatusResponse{Space: archivedSpaceToProto(info)}, nil
+}
+
+func syncRunToProto(status nodesync.RunStatus) *nodedebugrpcproto.NodeSyncRun {
+	run := &nodedebugrpcproto.NodeSyncRun{
+		Id:           status.Id,
+		State:        status.State,
+		StartedAt:    status.StartedAt.Unix(),
+		PartsTotal:   uint32(status.PartsTotal),
+		PartsHandled: uint32(status.PartsHandled),
+		PartsErrors:  uint32(status.PartsErrors),
+		Error:        status.Error,
+		Parts:        make([]*nodedebugrpcproto.NodeSyncPart, 0, len(status.Parts)),
+	}
+	if !status.FinishedAt.IsZero() {
+		run.FinishedAt = status.FinishedAt.Unix()
+	}
+	for _, ps := range status.Parts {
+		part := &nodedebugrpcproto.NodeSyncPart{
+			PartId:     uint32(ps.PartId),
+			Done:       ps.Done,
+			DurationMs: ps.DurationMs,
+			Error:      ps.Error,
+			Peers:      make([]*nodedebugrpcproto.NodeSyncPeer, 0, len(ps.Peers)),
+		}
+		for _, peer := range ps.Peers {
+			part.Peers = append(part.Peers, &nodedebugrpcproto.NodeSyncPeer{
+				PeerId:         peer.PeerId,
+				New:            uint32(peer.New),
+				Changed:        uint32(peer.Changed),
+				ColdSyncErrors: uint32(peer.ColdSyncErrors),
+				DurationMs:     peer.DurationMs,
+				Error:          peer.Error,
+			})
+		}
+		run.Parts = append(run.Parts, part)
+	}
+	return run
+}
+
+func archivedSpaceToProto(info archiveinfo.SpaceInfo) *nodedebugrpcproto.ArchivedSpace {
+	return &nodedebugrpcproto.ArchivedSpace{
+		SpaceId:          info.SpaceId,
+		Status:           info.Status,
+		SizeCompressed:   info.SizeCompressed,
+		SizeUncompressed: info.SizeUncompressed,
+		Checksum:         info.Checksum,
+		LastAccess:       info.LastAccess.Unix(),
+		Error:            info.Error,
+	}
+}
@@ -106,6 +106,7 @@ func (s *spaceChecker) Fix(ctx context.Context, spaceId string) (Result, error)
 	storageExists := res.SpaceStorageExists
 	isResponsible := res.IsResponsible
 	indexStorage := s.storageService.IndexStorage()
+	ctx = nodestorage.WithOrigin(ctx, nodestorage.OriginDebug)
 
 	switch {
 	// coordStatus: removed, localStatus: not removed or storageExists: true - remove space and switch local status
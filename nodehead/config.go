@@ -0,0 +1,10 @@
+package nodehead
+
+type configGetter interface {
+	GetNodeHead() Config
+}
+
+type Config struct {
+	// SnapshotIntervalMinutes is how often heads are written to the snapshot, it's also written on shutdown, 10 by default
+	SnapshotIntervalMinutes int `yaml:"snapshotIntervalMinutes"`
+}
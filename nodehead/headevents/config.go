@@ -0,0 +1,26 @@
+package headevents
+
+type configGetter interface {
+	GetHeadEvents() Config
+}
+
+type Config struct {
+	// BufferSize is the number of latest events kept for subscribers, 100000 by default
+	BufferSize int           `yaml:"bufferSize"`
+	Webhook    WebhookConfig `yaml:"webhook"`
+	File       FileConfig    `yaml:"file"`
+}
+
+// WebhookConfig enables posting events as NDJSON to the url, empty url disables the webhook
+type WebhookConfig struct {
+	Url string `yaml:"url"`
+	// BatchSize is the max number of events in one request, 100 by default
+	BatchSize int `yaml:"batchSize"`
+	// TimeoutSeconds is the timeout of one request, 10 by default
+	TimeoutSeconds int `yaml:"timeoutSeconds"`
+}
+
+// FileConfig enables appending events as NDJSON to the file, empty path disables the file
+type FileConfig struct {
+	Path string `yaml:"path"`
+}
@@ -0,0 +1,345 @@
+//go:generate mockgen -destination mock_headevents/mock_headevents.go github.com/anyproto/any-sync-node/nodehead/headevents HeadEvents
+package headevents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/anyproto/any-sync/app"
+	"github.com/anyproto/any-sync/app/logger"
+	"github.com/anyproto/any-sync/commonspace/spacestorage"
+	"github.com/anyproto/any-sync/metric"
+	"go.uber.org/zap"
+
+	"github.com/anyproto/any-sync-node/nodehead"
+	"github.com/anyproto/any-sync-node/nodestorage"
+)
+
+const CName = "node.nodehead.headevents"
+
+var log = logger.NewNamed(CName)
+
+const (
+	defaultBufferSize = 100000
+	defaultBatchSize  = 100
+	// drainTimeout limits the delivery of buffered events to sinks on close
+	drainTimeout = time.Second * 10
+	// stateDir starts with the dot, so the storage doesn't take it for a space
+	stateDir  = ".headevents"
+	stateFile = "state.json"
+)
+
+var (
+	// ErrCursorExpired means events after the cursor are lost: they are pushed out of the buffer or the node crashed.
+	// The consumer should rescan spaces it's interested in and continue with the empty cursor
+	ErrCursorExpired = errors.New("cursor is expired, events are lost")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+func New() HeadEvents {
+	return new(headEvents)
+}
+
+// Event is a change of the space head, Cursor points to this event, so the stream resumed with it starts from the next event
+type Event struct {
+	Cursor    string    `json:"cursor"`
+	SpaceId   string    `json:"spaceId"`
+	Partition int       `json:"partition"`
+	OldHash   string    `json:"oldHash"`
+	NewHash   string    `json:"newHash"`
+	Ts        time.Time `json:"ts"`
+}
+
+// HeadEvents keeps latest head changes and streams them to subscribers and configured sinks.
+// Kept events and cursors survive the clean restart of the node, the empty cursor means the oldest kept event
+type HeadEvents interface {
+	// Events returns up to limit events after the cursor and the cursor to continue with
+	Events(cursor string, limit int) (events []Event, next string, err error)
+	// Subscribe calls send with batches of events after the cursor until ctx is done or send fails
+	Subscribe(ctx context.Context, cursor string, batchSize int, send func(events []Event) error) (err error)
+	app.ComponentRunnable
+}
+
+type headEvents struct {
+	conf  Config
+	epoch int64
+	sinks []sink
+	// statePath is the file keeping events between restarts, the empty path disables it
+	statePath string
+
+	mu sync.Mutex
+	// buf is the ring of latest events, the event with seq s is at buf[(s-1)%len(buf)]
+	buf     []Event
+	lastSeq uint64
+	kept    uint64
+	// notify is closed and replaced when events are added
+	notify chan struct{}
+	// sinkSeqs are seqs of the last events delivered to sinks by their names
+	sinkSeqs map[string]uint64
+
+	added      atomic.Uint64
+	sinkErrors atomic.Uint64
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+type sink interface {
+	name() string
+	batchSize() int
+	write(ctx context.Context, events []Event) error
+	close() error
+}
+
+func (h *headEvents) Init(a *app.App) (err error) {
+	h.conf = a.MustComponent("config").(configGetter).GetHeadEvents()
+	size := h.conf.BufferSize
+	if size <= 0 {
+		size = defaultBufferSize
+	}
+	h.buf = make([]Event, size)
+	h.epoch = time.Now().UnixMilli()
+	h.notify = make(chan struct{})
+	h.sinkSeqs = map[string]uint64{}
+	h.statePath = filepath.Join(a.MustComponent(spacestorage.CName).(nodestorage.NodeStorage).StoreDir(stateDir), stateFile)
+	if err = h.loadState(); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Warn("can't load head events, cursors are expired", zap.Error(err))
+		}
+		err = nil
+	}
+	if h.conf.Webhook.Url != "" {
+		h.sinks = append(h.sinks, newWebhookSink(h.conf.Webhook))
+	}
+	if h.conf.File.Path != "" {
+		fs, err := newFileSink(h.conf.File)
+		if err != nil {
+			return err
+		}
+		h.sinks = append(h.sinks, fs)
+	}
+	a.MustComponent(nodehead.CName).(nodehead.NodeHead).OnHeadChange(h.add)
+	if m := a.Component(metric.CName); m != nil {
+		registerMetric(h, m.(metric.Metric).Registry())
+	}
+	return
+}
+
+func (h *headEvents) Name() (name string) {
+	return CName
+}
+
+func (h *headEvents) Run(ctx context.Context) (err error) {
+	h.ctx, h.cancel = context.WithCancel(context.Background())
+	for _, s := range h.sinks {
+		h.wg.Add(1)
+		go h.runSink(s)
+	}
+	return
+}
+
+func (h *headEvents) add(change nodehead.HeadChange) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastSeq++
+	h.buf[(h.lastSeq-1)%uint64(len(h.buf))] = Event{
+		Cursor:    h.cursor(h.lastSeq),
+		SpaceId:   change.SpaceId,
+		Partition: change.Partition,
+		OldHash:   change.OldHead,
+		NewHash:   change.NewHead,
+		Ts:        change.Time,
+	}
+	if h.kept < uint64(len(h.buf)) {
+		h.kept++
+	}
+	h.added.Add(1)
+	close(h.notify)
+	h.notify = make(chan struct{})
+}
+
+func (h *headEvents) Events(cursor string, limit int) (events []Event, next string, err error) {
+	seq, err := h.parseCursor(cursor)
+	if err != nil {
+		return
+	}
+	events, seq, _, err = h.after(seq, limit)
+	return events, h.cursor(seq), err
+}
+
+func (h *headEvents) Subscribe(ctx context.Context, cursor string, batchSize int, send func(events []Event) error) (err error) {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	seq, err := h.parseCursor(cursor)
+	if err != nil {
+		return
+	}
+	for {
+		events, next, notify, err := h.after(seq, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(events) > 0 {
+			if err = send(events); err != nil {
+				return err
+			}
+			seq = next
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+		}
+	}
+}
+
+// after returns events with seq greater than the given one, next is the seq of the last returned event
+func (h *headEvents) after(seq uint64, limit int) (events []Event, next uint64, notify chan struct{}, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if seq > h.lastSeq {
+		return nil, seq, nil, ErrInvalidCursor
+	}
+	if seq < h.lastSeq-h.kept {
+		return nil, seq, nil, ErrCursorExpired
+	}
+	next = h.lastSeq
+	if limit > 0 && next-seq > uint64(limit) {
+		next = seq + uint64(limit)
+	}
+	events = make([]Event, 0, next-seq)
+	for s := seq + 1; s <= next; s++ {
+		events = append(events, h.buf[(s-1)%uint64(len(h.buf))])
+	}
+	return events, next, h.notify, nil
+}
+
+func (h *headEvents) cursor(seq uint64) string {
+	return fmt.Sprintf("%d-%d", h.epoch, seq)
+}
+
+// parseCursor returns the seq of the event the cursor points to, the empty cursor points before the oldest kept event
+func (h *headEvents) parseCursor(cursor string) (seq uint64, err error) {
+	if cursor == "" {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return h.lastSeq - h.kept, nil
+	}
+	epochStr, seqStr, ok := strings.Cut(cursor, "-")
+	if !ok {
+		return 0, ErrInvalidCursor
+	}
+	epoch, err := strconv.ParseInt(epochStr, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	if seq, err = strconv.ParseUint(seqStr, 10, 64); err != nil {
+		return 0, ErrInvalidCursor
+	}
+	if epoch != h.epoch {
+		return 0, ErrCursorExpired
+	}
+	return seq, nil
+}
+
+// runSink delivers events to the sink at least once: failed batches are retried, expired events are skipped
+func (h *headEvents) runSink(s sink) {
+	defer h.wg.Done()
+	h.mu.Lock()
+	var (
+		cursor  string
+		backoff = time.Second
+	)
+	if seq, ok := h.sinkSeqs[s.name()]; ok {
+		cursor = h.cursor(seq)
+	}
+	h.mu.Unlock()
+	for {
+		err := h.Subscribe(h.ctx, cursor, s.batchSize(), func(events []Event) error {
+			if err := s.write(h.ctx, events); err != nil {
+				return err
+			}
+			seq, _ := h.parseCursor(events[len(events)-1].Cursor)
+			h.mu.Lock()
+			h.sinkSeqs[s.name()] = seq
+			h.mu.Unlock()
+			cursor = events[len(events)-1].Cursor
+			backoff = time.Second
+			return nil
+		})
+		if h.ctx.Err() != nil {
+			return
+		}
+		h.sinkErrors.Add(1)
+		if errors.Is(err, ErrCursorExpired) {
+			log.Warn("head events are lost for the sink", zap.String("sink", s.name()))
+			cursor = ""
+			continue
+		}
+		log.Warn("can't write head events", zap.String("sink", s.name()), zap.Error(err))
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+func (h *headEvents) Close(ctx context.Context) (err error) {
+	if h.cancel != nil {
+		h.drain(ctx)
+		h.cancel()
+	}
+	h.wg.Wait()
+	for _, s := range h.sinks {
+		err = errors.Join(err, s.close())
+	}
+	if h.statePath != "" {
+		if sErr := h.saveState(); sErr != nil {
+			log.Warn("can't save head events", zap.Error(sErr))
+		}
+	}
+	return
+}
+
+// drain waits until sinks deliver events added before the close, it gives up after drainTimeout or when ctx is done
+func (h *headEvents) drain(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, drainTimeout)
+	defer cancel()
+	ticker := time.NewTicker(time.Millisecond * 50)
+	defer ticker.Stop()
+	h.mu.Lock()
+	lastSeq := h.lastSeq
+	h.mu.Unlock()
+	for !h.delivered(lastSeq) {
+		select {
+		case <-ctx.Done():
+			log.Warn("head events are not delivered to sinks before close")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// delivered tells whether all sinks have delivered events up to the seq
+func (h *headEvents) delivered(seq uint64) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, s := range h.sinks {
+		if h.sinkSeqs[s.name()] < seq {
+			return false
+		}
+	}
+	return true
+}
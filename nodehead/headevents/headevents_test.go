@@ -0,0 +1,231 @@
+package headevents
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anyproto/any-sync-node/nodehead"
+)
+
+var ctx = context.Background()
+
+func TestHeadEvents_Events(t *testing.T) {
+	h := newTestHeadEvents(3)
+	addChanges(h, 0, 2)
+
+	events, cursor, err := h.Events("", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s0.x", "s1.x"}, spaceIds(events))
+	assert.Equal(t, events[1].Cursor, cursor)
+	assert.Equal(t, 1, events[1].Partition)
+	first := events[0].Cursor
+
+	events, cursor, err = h.Events(events[0].Cursor, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s1.x"}, spaceIds(events))
+
+	// nothing new, the cursor stays the same
+	events, next, err := h.Events(cursor, 0)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+	assert.Equal(t, cursor, next)
+
+	// the buffer is overwritten, the cursor before the oldest kept event is still valid
+	addChanges(h, 2, 5)
+	_, _, err = h.Events(first, 2)
+	require.ErrorIs(t, err, ErrCursorExpired)
+	events, _, err = h.Events(cursor, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s2.x"}, spaceIds(events))
+	events, cursor, err = h.Events("", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s2.x", "s3.x"}, spaceIds(events))
+	events, _, err = h.Events(cursor, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s4.x"}, spaceIds(events))
+
+	_, _, err = h.Events(fmt.Sprintf("%d-1", h.epoch-1), 0)
+	assert.ErrorIs(t, err, ErrCursorExpired)
+	_, _, err = h.Events(fmt.Sprintf("%d-100", h.epoch), 0)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	_, _, err = h.Events("bad", 0)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestHeadEvents_Subscribe(t *testing.T) {
+	h := newTestHeadEvents(100)
+	addChanges(h, 0, 1)
+	first, _, err := h.Events("", 0)
+	require.NoError(t, err)
+
+	sCtx, cancel := context.WithCancel(ctx)
+	received := make(chan []Event, 10)
+	done := make(chan error)
+	go func() {
+		done <- h.Subscribe(sCtx, first[0].Cursor, 2, func(events []Event) error {
+			received <- events
+			return nil
+		})
+	}()
+	addChanges(h, 1, 4)
+
+	var got []Event
+	for len(got) < 3 {
+		select {
+		case events := <-received:
+			assert.LessOrEqual(t, len(events), 2)
+			got = append(got, events...)
+		case <-time.After(time.Second * 5):
+			t.Fatal("events are not received")
+		}
+	}
+	assert.Equal(t, []string{"s1.x", "s2.x", "s3.x"}, spaceIds(got))
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestHeadEvents_Sinks(t *testing.T) {
+	t.Run("webhook", func(t *testing.T) {
+		var (
+			mu       sync.Mutex
+			requests int
+			got      []Event
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			requests++
+			// the first batch is retried
+			if requests == 1 {
+				rw.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			assert.Equal(t, "application/x-ndjson", req.Header.Get("Content-Type"))
+			got = append(got, decodeNDJSON(t, bufio.NewScanner(req.Body))...)
+		}))
+		defer srv.Close()
+
+		h := newTestHeadEvents(100, newWebhookSink(WebhookConfig{Url: srv.URL, BatchSize: 2}))
+		require.NoError(t, h.Run(ctx))
+		addChanges(h, 0, 3)
+		assert.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(got) == 3
+		}, time.Second*5, time.Millisecond*10)
+		require.NoError(t, h.Close(ctx))
+		assert.Equal(t, []string{"s0.x", "s1.x", "s2.x"}, spaceIds(got))
+		assert.Equal(t, uint64(1), h.sinkErrors.Load())
+	})
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "events", "heads.ndjson")
+		fs, err := newFileSink(FileConfig{Path: path})
+		require.NoError(t, err)
+		h := newTestHeadEvents(100, fs)
+		require.NoError(t, h.Run(ctx))
+		addChanges(h, 0, 3)
+		// close delivers buffered events
+		require.NoError(t, h.Close(ctx))
+		events := readFile(t, path)
+		assert.Equal(t, []string{"s0.x", "s1.x", "s2.x"}, spaceIds(events))
+		assert.Equal(t, "new0", events[0].NewHash)
+	})
+}
+
+func TestHeadEvents_State(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events", "heads.ndjson")
+	statePath := filepath.Join(dir, stateDir, stateFile)
+	start := func() *headEvents {
+		fs, err := newFileSink(FileConfig{Path: path})
+		require.NoError(t, err)
+		h := newTestHeadEvents(10, fs)
+		h.statePath = statePath
+		if err = h.loadState(); !errors.Is(err, os.ErrNotExist) {
+			require.NoError(t, err)
+		}
+		require.NoError(t, h.Run(ctx))
+		return h
+	}
+
+	h := start()
+	addChanges(h, 0, 3)
+	events, _, err := h.Events("", 1)
+	require.NoError(t, err)
+	cursor := events[0].Cursor
+	require.NoError(t, h.Close(ctx))
+	require.Len(t, readFile(t, path), 3)
+
+	// the cursor is valid after the restart and the sink continues from its position
+	h = start()
+	events, _, err = h.Events(cursor, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s1.x", "s2.x"}, spaceIds(events))
+	addChanges(h, 3, 4)
+	require.NoError(t, h.Close(ctx))
+	assert.Equal(t, []string{"s0.x", "s1.x", "s2.x", "s3.x"}, spaceIds(readFile(t, path)))
+
+	// the state is taken once, the node crashed after the start expires cursors
+	h = newTestHeadEvents(10)
+	h.statePath = statePath
+	require.NoError(t, h.loadState())
+	assert.ErrorIs(t, h.loadState(), os.ErrNotExist)
+}
+
+func newTestHeadEvents(size int, sinks ...sink) *headEvents {
+	return &headEvents{
+		buf:      make([]Event, size),
+		epoch:    time.Now().UnixMilli(),
+		notify:   make(chan struct{}),
+		sinkSeqs: map[string]uint64{},
+		sinks:    sinks,
+	}
+}
+
+func addChanges(h *headEvents, from, to int) {
+	for i := from; i < to; i++ {
+		h.add(nodehead.HeadChange{
+			SpaceId:   fmt.Sprintf("s%d.x", i),
+			Partition: i,
+			OldHead:   fmt.Sprintf("old%d", i),
+			NewHead:   fmt.Sprintf("new%d", i),
+			Time:      time.Now(),
+		})
+	}
+}
+
+func spaceIds(events []Event) (ids []string) {
+	for _, event := range events {
+		ids = append(ids, event.SpaceId)
+	}
+	return
+}
+
+func decodeNDJSON(t *testing.T, scanner *bufio.Scanner) (events []Event) {
+	for scanner.Scan() {
+		var event Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	return
+}
+
+func readFile(t *testing.T, path string) []Event {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	return decodeNDJSON(t, bufio.NewScanner(f))
+}
@@ -0,0 +1,129 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/anyproto/any-sync-node/nodehead/headevents (interfaces: HeadEvents)
+//
+// Generated by this command:
+//
+//	mockgen -destination mock_headevents/mock_headevents.go github.com/anyproto/any-sync-node/nodehead/headevents HeadEvents
+//
+
+// Package mock_headevents is a generated GoMock package.
+package mock_headevents
+
+import (
+	context "context"
+	reflect "reflect"
+
+	headevents "github.com/anyproto/any-sync-node/nodehead/headevents"
+	app "github.com/anyproto/any-sync/app"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockHeadEvents is a mock of HeadEvents interface.
+type MockHeadEvents struct {
+	ctrl     *gomock.Controller
+	recorder *MockHeadEventsMockRecorder
+	isgomock struct{}
+}
+
+// MockHeadEventsMockRecorder is the mock recorder for MockHeadEvents.
+type MockHeadEventsMockRecorder struct {
+	mock *MockHeadEvents
+}
+
+// NewMockHeadEvents creates a new mock instance.
+func NewMockHeadEvents(ctrl *gomock.Controller) *MockHeadEvents {
+	mock := &MockHeadEvents{ctrl: ctrl}
+	mock.recorder = &MockHeadEventsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHeadEvents) EXPECT() *MockHeadEventsMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockHeadEvents) Close(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockHeadEventsMockRecorder) Close(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockHeadEvents)(nil).Close), ctx)
+}
+
+// Events mocks base method.
+func (m *MockHeadEvents) Events(cursor string, limit int) ([]headevents.Event, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Events", cursor, limit)
+	ret0, _ := ret[0].([]headevents.Event)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Events indicates an expected call of Events.
+func (mr *MockHeadEventsMockRecorder) Events(cursor, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Events", reflect.TypeOf((*MockHeadEvents)(nil).Events), cursor, limit)
+}
+
+// Init mocks base method.
+func (m *MockHeadEvents) Init(a *app.App) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Init", a)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Init indicates an expected call of Init.
+func (mr *MockHeadEventsMockRecorder) Init(a any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockHeadEvents)(nil).Init), a)
+}
+
+// Name mocks base method.
+func (m *MockHeadEvents) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockHeadEventsMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockHeadEvents)(nil).Name))
+}
+
+// Run mocks base method.
+func (m *MockHeadEvents) Run(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Run", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockHeadEventsMockRecorder) Run(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockHeadEvents)(nil).Run), ctx)
+}
+
+// Subscribe mocks base method.
+func (m *MockHeadEvents) Subscribe(ctx context.Context, cursor string, batchSize int, send func([]headevents.Event) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, cursor, batchSize, send)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockHeadEventsMockRecorder) Subscribe(ctx, cursor, batchSize, send any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockHeadEvents)(nil).Subscribe), ctx, cursor, batchSize, send)
+}
@@ -0,0 +1,120 @@
+package headevents
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const defaultWebhookTimeout = time.Second * 10
+
+func encodeNDJSON(w io.Writer, events []Event) (err error) {
+	enc := json.NewEncoder(w)
+	for _, event := range events {
+		if err = enc.Encode(event); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// webhookSink posts batches of events as NDJSON, any non-2xx response is retried
+type webhookSink struct {
+	url    string
+	batch  int
+	client *http.Client
+}
+
+func newWebhookSink(conf WebhookConfig) *webhookSink {
+	timeout := time.Duration(conf.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	batch := conf.BatchSize
+	if batch <= 0 {
+		batch = defaultBatchSize
+	}
+	return &webhookSink{
+		url:    conf.Url,
+		batch:  batch,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (w *webhookSink) name() string {
+	return "webhook"
+}
+
+func (w *webhookSink) batchSize() int {
+	return w.batch
+}
+
+func (w *webhookSink) write(ctx context.Context, events []Event) (err error) {
+	var body bytes.Buffer
+	if err = encodeNDJSON(&body, events); err != nil {
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, &body)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+func (w *webhookSink) close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
+
+// fileSink appends events as NDJSON to the file
+type fileSink struct {
+	f *os.File
+	w *bufio.Writer
+}
+
+func newFileSink(conf FileConfig) (*fileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(conf.Path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(conf.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{f: f, w: bufio.NewWriter(f)}, nil
+}
+
+func (f *fileSink) name() string {
+	return "file"
+}
+
+func (f *fileSink) batchSize() int {
+	return defaultBatchSize
+}
+
+func (f *fileSink) write(ctx context.Context, events []Event) (err error) {
+	if err = encodeNDJSON(f.w, events); err != nil {
+		return
+	}
+	return f.w.Flush()
+}
+
+func (f *fileSink) close() error {
+	return f.f.Close()
+}
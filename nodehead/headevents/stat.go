@@ -0,0 +1,24 @@
+package headevents
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func registerMetric(h *headEvents, registry *prometheus.Registry) {
+	registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: "nodehead",
+		Subsystem: "events",
+		Name:      "added_count",
+		Help:      "head change events",
+	}, func() float64 {
+		return float64(h.added.Load())
+	}))
+	registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: "nodehead",
+		Subsystem: "events",
+		Name:      "sink_errors_count",
+		Help:      "failed or lost deliveries to the webhook and the file",
+	}, func() float64 {
+		return float64(h.sinkErrors.Load())
+	}))
+}
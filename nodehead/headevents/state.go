@@ -0,0 +1,75 @@
+package headevents
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// state is kept events with positions of sinks, it's written on close and read on the next start,
+// so cursors stay valid after the clean restart
+type state struct {
+	Epoch    int64             `json:"epoch"`
+	LastSeq  uint64            `json:"lastSeq"`
+	Events   []Event           `json:"events"`
+	SinkSeqs map[string]uint64 `json:"sinkSeqs"`
+}
+
+func (h *headEvents) saveState() (err error) {
+	h.mu.Lock()
+	st := state{
+		Epoch:    h.epoch,
+		LastSeq:  h.lastSeq,
+		Events:   make([]Event, 0, h.kept),
+		SinkSeqs: h.sinkSeqs,
+	}
+	for seq := h.lastSeq - h.kept + 1; seq <= h.lastSeq; seq++ {
+		st.Events = append(st.Events, h.buf[(seq-1)%uint64(len(h.buf))])
+	}
+	data, err := json.Marshal(st)
+	h.mu.Unlock()
+	if err != nil {
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(h.statePath), 0755); err != nil {
+		return
+	}
+	tmpPath := h.statePath + ".tmp"
+	if err = os.WriteFile(tmpPath, data, 0644); err != nil {
+		return
+	}
+	return os.Rename(tmpPath, h.statePath)
+}
+
+// loadState restores the state saved on close. The file is removed after reading, so the state of the node
+// crashed later isn't taken: events after the save would be lost with valid cursors
+func (h *headEvents) loadState() (err error) {
+	data, err := os.ReadFile(h.statePath)
+	if err != nil {
+		return
+	}
+	if err = os.Remove(h.statePath); err != nil {
+		return
+	}
+	var st state
+	if err = json.Unmarshal(data, &st); err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// the buffer could be reduced by the config
+	if len(st.Events) > len(h.buf) {
+		st.Events = st.Events[len(st.Events)-len(h.buf):]
+	}
+	h.epoch = st.Epoch
+	h.lastSeq = st.LastSeq
+	h.kept = uint64(len(st.Events))
+	for i, event := range st.Events {
+		seq := h.lastSeq - h.kept + uint64(i) + 1
+		h.buf[(seq-1)%uint64(len(h.buf))] = event
+	}
+	if st.SinkSeqs != nil {
+		h.sinkSeqs = st.SinkSeqs
+	}
+	return
+}
@@ -13,6 +13,7 @@ import (
 	context "context"
 	reflect "reflect"
 
+	nodehead "github.com/anyproto/any-sync-node/nodehead"
 	app "github.com/anyproto/any-sync/app"
 	ldiff "github.com/anyproto/any-sync/app/ldiff"
 	gomock "go.uber.org/mock/gomock"
@@ -142,6 +143,18 @@ func (mr *MockNodeHeadMockRecorder) Name() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockNodeHead)(nil).Name))
 }
 
+// OnHeadChange mocks base method.
+func (m *MockNodeHead) OnHeadChange(onChange func(nodehead.HeadChange)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnHeadChange", onChange)
+}
+
+// OnHeadChange indicates an expected call of OnHeadChange.
+func (mr *MockNodeHeadMockRecorder) OnHeadChange(onChange any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnHeadChange", reflect.TypeOf((*MockNodeHead)(nil).OnHeadChange), onChange)
+}
+
 // Ranges mocks base method.
 func (m *MockNodeHead) Ranges(ctx context.Context, part int, ranges []ldiff.Range, resBuf []ldiff.RangeResult) ([]ldiff.RangeResult, error) {
 	m.ctrl.T.Helper()
@@ -4,6 +4,8 @@ package nodehead
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/anyproto/any-sync/commonspace/spacestorage"
 	"github.com/anyproto/any-sync/metric"
 	"github.com/anyproto/any-sync/nodeconf"
+	"github.com/anyproto/any-sync/util/periodicsync"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 
@@ -23,6 +26,8 @@ const CName = "node.nodespace.nodehead"
 
 var log = logger.NewNamed(CName)
 
+const defaultSnapshotInterval = 10
+
 var (
 	ErrSpaceNotFound = errors.New("space not found")
 
@@ -33,9 +38,20 @@ func New() NodeHead {
 	return new(nodeHead)
 }
 
+// HeadChange describes the new head of the space written by the storage
+type HeadChange struct {
+	SpaceId   string
+	Partition int
+	OldHead   string
+	NewHead   string
+	Time      time.Time
+}
+
 // NodeHead keeps current state of all spaces by partitions
 type NodeHead interface {
 	SetHead(spaceId, oldHead, newHead string) (part int, err error)
+	// OnHeadChange sets the callback called when the storage writes the head that differs from the current one
+	OnHeadChange(onChange func(change HeadChange))
 	GetHead(spaceId string) (head string, err error)
 	GetOldHead(spaceId string) (head string, err error)
 	DeleteHeads(spaceId string) error
@@ -51,11 +67,17 @@ type nodeStorage interface {
 }
 
 type nodeHead struct {
-	mu         sync.Mutex
-	partitions map[int]ldiff.Diff
-	oldHashes  map[string]string
-	nodeconf   nodeconf.NodeConf
-	spaceStore nodeStorage
+	mu           sync.Mutex
+	partitions   map[int]ldiff.Diff
+	oldHashes    map[string]string
+	nodeconf     nodeconf.NodeConf
+	spaceStore   nodeStorage
+	onHeadChange func(change HeadChange)
+	periodicSync periodicsync.PeriodicSync
+	// loaded is set when heads are loaded, an empty state must not overwrite the snapshot
+	loaded bool
+	// appliedAt is the highest index change time of applied heads, the snapshot replays index changes from it
+	appliedAt time.Time
 }
 
 func (n *nodeHead) Init(a *app.App) (err error) {
@@ -63,9 +85,29 @@ func (n *nodeHead) Init(a *app.App) (err error) {
 	n.oldHashes = map[string]string{}
 	n.nodeconf = a.MustComponent(nodeconf.CName).(nodeconf.NodeConf)
 	n.spaceStore = a.MustComponent(spacestorage.CName).(nodeStorage)
-	n.spaceStore.OnWriteHash(func(_ context.Context, spaceId, oldHash, newHash string) {
-		if _, e := n.SetHead(spaceId, oldHash, newHash); e != nil {
+	interval := a.MustComponent("config").(configGetter).GetNodeHead().SnapshotIntervalMinutes
+	if interval <= 0 {
+		interval = defaultSnapshotInterval
+	}
+	n.periodicSync = periodicsync.NewPeriodicSync(interval*60, 0, func(ctx context.Context) error {
+		return n.saveSnapshot()
+	}, log)
+	n.spaceStore.OnWriteHash(func(ctx context.Context, spaceId, oldHash, newHash string) {
+		prevHead, _ := n.GetHead(spaceId)
+		part, e := n.SetHead(spaceId, oldHash, newHash)
+		if e != nil {
 			log.Error("can't set head", zap.Error(e))
+			return
+		}
+		n.applied(nodestorage.ChangedAtFromContext(ctx))
+		if n.onHeadChange != nil && prevHead != newHash {
+			n.onHeadChange(HeadChange{
+				SpaceId:   spaceId,
+				Partition: part,
+				OldHead:   oldHash,
+				NewHead:   newHash,
+				Time:      time.Now(),
+			})
 		}
 	})
 	n.spaceStore.OnDeleteStorage(func(_ context.Context, spaceId string) {
@@ -84,6 +126,27 @@ func (n *nodeHead) Name() (name string) {
 }
 
 func (n *nodeHead) Run(ctx context.Context) (err error) {
+	if err = n.loadSnapshot(ctx); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			log.Info("nodehead snapshot not found, loading heads from the index")
+		} else {
+			log.Warn("can't load nodehead snapshot, loading heads from the index", zap.Error(err))
+		}
+		n.mu.Lock()
+		n.partitions = map[int]ldiff.Diff{}
+		n.oldHashes = map[string]string{}
+		n.mu.Unlock()
+		if err = n.loadIndex(ctx); err != nil {
+			return
+		}
+	}
+	n.loaded = true
+	n.periodicSync.Run()
+	return
+}
+
+// loadIndex rebuilds heads from all spaces of the index
+func (n *nodeHead) loadIndex(ctx context.Context) (err error) {
 	st := time.Now()
 	var total int
 	err = n.spaceStore.IndexStorage().ReadHashes(ctx, func(update nodestorage.SpaceUpdate) (bool, error) {
@@ -92,6 +155,7 @@ func (n *nodeHead) Run(ctx context.Context) (err error) {
 			log.Error("can't set head", zap.Error(e))
 			return false, e
 		}
+		n.applied(update.ChangedAt)
 		return true, nil
 	})
 	if err != nil {
@@ -101,6 +165,107 @@ func (n *nodeHead) Run(ctx context.Context) (err error) {
 	return
 }
 
+// loadSnapshot loads heads from the snapshot and applies index changes made after it
+func (n *nodeHead) loadSnapshot(ctx context.Context) (err error) {
+	st := time.Now()
+	appliedAt, entries, err := readSnapshot(n.snapshotPath())
+	if err != nil {
+		return
+	}
+	byPart := make(map[int][]ldiff.Element)
+	n.mu.Lock()
+	for _, entry := range entries {
+		part := n.nodeconf.Partition(entry.spaceId)
+		byPart[part] = append(byPart[part], ldiff.Element{Id: entry.spaceId, Head: entry.newHead})
+		n.oldHashes[entry.spaceId] = entry.oldHead
+	}
+	for part, elements := range byPart {
+		ld := ldiff.New(16, 16)
+		ld.Set(elements...)
+		n.partitions[part] = ld
+	}
+	n.appliedAt = appliedAt
+	n.mu.Unlock()
+
+	var changed int
+	err = n.spaceStore.IndexStorage().ReadChangedHashes(ctx, appliedAt, func(update nodestorage.SpaceUpdate, status nodestorage.SpaceStatus) (bool, error) {
+		changed++
+		n.applied(update.ChangedAt)
+		if status != nodestorage.SpaceStatusOk && status != nodestorage.SpaceStatusArchived {
+			if e := n.DeleteHeads(update.SpaceId); e != nil && !errors.Is(e, ldiff.ErrElementNotFound) {
+				return false, e
+			}
+			return true, nil
+		}
+		prevHead, _ := n.GetHead(update.SpaceId)
+		part, e := n.SetHead(update.SpaceId, update.OldHash, update.NewHash)
+		if e != nil {
+			return false, e
+		}
+		// heads written after the snapshot could be missed by subscribers that were closed before the storage
+		if n.onHeadChange != nil && prevHead != update.NewHash {
+			n.onHeadChange(HeadChange{
+				SpaceId:   update.SpaceId,
+				Partition: part,
+				OldHead:   update.OldHash,
+				NewHead:   update.NewHash,
+				Time:      update.Updated,
+			})
+		}
+		return true, nil
+	})
+	if err != nil {
+		return
+	}
+	log.Info("space heads loaded from the snapshot", zap.Int("spaces", len(entries)), zap.Int("changed", changed), zap.Time("appliedAt", appliedAt), zap.Duration("dur", time.Since(st)))
+	return
+}
+
+// saveSnapshot writes current heads, appliedAt is taken before collecting, so later changes are applied on load
+func (n *nodeHead) saveSnapshot() (err error) {
+	st := time.Now()
+	var entries []snapshotEntry
+	n.mu.Lock()
+	appliedAt := n.appliedAt
+	partitions := make([]ldiff.Diff, 0, len(n.partitions))
+	for _, ld := range n.partitions {
+		partitions = append(partitions, ld)
+	}
+	n.mu.Unlock()
+	for _, ld := range partitions {
+		for _, el := range ld.Elements() {
+			entries = append(entries, snapshotEntry{spaceId: el.Id, newHead: el.Head})
+		}
+	}
+	n.mu.Lock()
+	for i := range entries {
+		entries[i].oldHead = n.oldHashes[entries[i].spaceId]
+	}
+	n.mu.Unlock()
+	if err = writeSnapshot(n.snapshotPath(), appliedAt, entries); err != nil {
+		return
+	}
+	log.Debug("nodehead snapshot saved", zap.Int("spaces", len(entries)), zap.Duration("dur", time.Since(st)))
+	return
+}
+
+// applied moves appliedAt forward, heads are applied in the order of index changes
+func (n *nodeHead) applied(changedAt time.Time) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if changedAt.After(n.appliedAt) {
+		n.appliedAt = changedAt
+	}
+}
+
+func (n *nodeHead) snapshotPath() string {
+	return filepath.Join(n.spaceStore.StoreDir(snapshotDir), snapshotFile)
+}
+
+func (n *nodeHead) OnHeadChange(onChange func(change HeadChange)) {
+	n.onHeadChange = onChange
+}
+
 func (n *nodeHead) DeleteHeads(spaceId string) error {
 	n.mu.Lock()
 	defer n.mu.Unlock()
@@ -208,5 +373,12 @@ func (n *nodeHead) registerMetrics(m metric.Metric) {
 }
 
 func (n *nodeHead) Close(ctx context.Context) (err error) {
+	if !n.loaded {
+		return nil
+	}
+	n.periodicSync.Close()
+	if err = n.saveSnapshot(); err != nil {
+		log.Warn("can't save nodehead snapshot", zap.Error(err))
+	}
 	return nil
 }
@@ -5,7 +5,10 @@ import (
 	"encoding/hex"
 	"math"
 	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/anyproto/any-sync/app"
 	"github.com/anyproto/any-sync/app/ldiff"
@@ -64,6 +67,102 @@ func TestNodeHead_Run(t *testing.T) {
 	})
 }
 
+func TestNodeHead_Snapshot(t *testing.T) {
+	createSpaces := func(t *testing.T, fx *fixture, n int) (ids []string) {
+		store := fx.a.MustComponent(nodestorage.CName).(nodestorage.NodeStorage)
+		for i := 0; i < n; i++ {
+			ss, err := store.CreateSpaceStorage(ctx, nodestorage.NewStorageCreatePayload(t))
+			require.NoError(t, err)
+			require.NoError(t, ss.StateStorage().SetHash(ctx, "123", "456"))
+			ids = append(ids, ss.Id())
+			require.NoError(t, ss.Close(ctx))
+		}
+		return
+	}
+	t.Run("load with changes", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		fx := newFixture(t, tmpDir)
+		ids := createSpaces(t, fx, 3)
+		fx.Finish(t)
+
+		// the space that exists only in the snapshot proves that heads are not read from the index,
+		// created spaces are flushed to the index after the snapshot, so they come with changes
+		path := filepath.Join(tmpDir, snapshotDir, snapshotFile)
+		appliedAt, entries, err := readSnapshot(path)
+		require.NoError(t, err)
+		require.False(t, appliedAt.IsZero())
+		entries = append(entries, snapshotEntry{spaceId: "snapshot.x", newHead: "new", oldHead: "old"})
+		require.NoError(t, writeSnapshot(path, appliedAt, entries))
+
+		// the change stamped before the applied time is already in the snapshot, so it's not replayed
+		index, err := nodestorage.OpenIndexStorage(ctx, tmpDir)
+		require.NoError(t, err)
+		require.NoError(t, index.UpdateHash(ctx, nodestorage.SpaceUpdate{SpaceId: "stale.x", OldHash: "o", NewHash: "n", ChangedAt: appliedAt.Add(-time.Millisecond)}))
+		require.NoError(t, index.UpdateHash(ctx, nodestorage.SpaceUpdate{SpaceId: "changed.x", OldHash: "o", NewHash: "n"}))
+		require.NoError(t, index.SetSpaceStatus(ctx, ids[0], nodestorage.SpaceStatusRemove, ""))
+		var lastChangedAt time.Time
+		require.NoError(t, index.ReadChangedHashes(ctx, appliedAt, func(update nodestorage.SpaceUpdate, _ nodestorage.SpaceStatus) (bool, error) {
+			lastChangedAt = update.ChangedAt
+			return true, nil
+		}))
+		require.NoError(t, index.Close())
+
+		fx = newFixture(t, tmpDir)
+		defer fx.Finish(t)
+		head, err := fx.GetHead("snapshot.x")
+		require.NoError(t, err)
+		assert.Equal(t, "new", head)
+		head, err = fx.GetOldHead("snapshot.x")
+		require.NoError(t, err)
+		assert.Equal(t, "old", head)
+		head, err = fx.GetHead("changed.x")
+		require.NoError(t, err)
+		assert.Equal(t, "n", head)
+		// heads that differ from the snapshot are reported, so subscribers don't miss writes made after it
+		assert.Contains(t, fx.changes.spaceIds(), "changed.x")
+		assert.NotContains(t, fx.changes.spaceIds(), "snapshot.x")
+		_, err = fx.GetHead("stale.x")
+		assert.ErrorIs(t, err, ErrSpaceNotFound)
+		assert.Equal(t, lastChangedAt, fx.NodeHead.(*nodeHead).appliedAt)
+		_, err = fx.GetHead(ids[0])
+		assert.ErrorIs(t, err, ErrSpaceNotFound)
+		for _, id := range ids[1:] {
+			_, err = fx.GetHead(id)
+			assert.NoError(t, err)
+		}
+	})
+	t.Run("corrupted", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		fx := newFixture(t, tmpDir)
+		ids := createSpaces(t, fx, 2)
+		fx.Finish(t)
+
+		path := filepath.Join(tmpDir, snapshotDir, snapshotFile)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		data[len(data)/2] ^= 0xff
+		require.NoError(t, os.WriteFile(path, data, 0644))
+		_, _, err = readSnapshot(path)
+		assert.ErrorIs(t, err, errSnapshotCorrupted)
+
+		fx = newFixture(t, tmpDir)
+		defer fx.Finish(t)
+		for _, id := range ids {
+			_, err = fx.GetHead(id)
+			assert.NoError(t, err)
+		}
+	})
+	t.Run("truncated", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), snapshotFile)
+		require.NoError(t, writeSnapshot(path, time.Now(), []snapshotEntry{{spaceId: "s.x", newHead: "n", oldHead: "o"}}))
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data[:len(data)-6], 0644))
+		_, _, err = readSnapshot(path)
+		assert.ErrorIs(t, err, errSnapshotCorrupted)
+	})
+}
+
 func TestNodeHead_SetHead(t *testing.T) {
 	fx := newFixture(t, "")
 	defer fx.Finish(t)
@@ -178,7 +277,8 @@ func newFixture(t *testing.T, dataPath string) *fixture {
 		Register(confServ.GetAccountService(0)).
 		Register(fx.archive).
 		Register(nodestorage.New()).
-		Register(fx.NodeHead)
+		Register(fx.NodeHead).
+		Register(&fx.changes)
 
 	require.NoError(t, fx.a.Start(ctx))
 	return fx
@@ -192,6 +292,35 @@ type fixture struct {
 	ctrl          *gomock.Controller
 	nodeConf      *mock_nodeconf.MockService
 	archive       *mock_archive.MockArchive
+	changes       headRecorder
+}
+
+// headRecorder keeps head changes reported by the node head
+type headRecorder struct {
+	mu      sync.Mutex
+	changes []HeadChange
+}
+
+func (r *headRecorder) Init(a *app.App) error {
+	a.MustComponent(CName).(NodeHead).OnHeadChange(func(change HeadChange) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.changes = append(r.changes, change)
+	})
+	return nil
+}
+
+func (r *headRecorder) Name() string {
+	return "headRecorder"
+}
+
+func (r *headRecorder) spaceIds() (ids []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, change := range r.changes {
+		ids = append(ids, change.SpaceId)
+	}
+	return
 }
 
 func (fx *fixture) Finish(t *testing.T) {
@@ -213,6 +342,10 @@ func (c *config) GetStorage() nodestorage.Config {
 	}
 }
 
+func (c *config) GetNodeHead() Config {
+	return Config{}
+}
+
 type member struct {
 	id string
 }
@@ -0,0 +1,184 @@
+package nodehead
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// snapshotDir starts with the dot, so the storage doesn't take it for a space
+	snapshotDir     = ".nodehead"
+	snapshotFile    = "heads.snapshot"
+	snapshotVersion = 2
+	// maxSnapshotString protects from huge allocations when the length is corrupted
+	maxSnapshotString = 1 << 16
+)
+
+var (
+	snapshotMagic = [4]byte{'N', 'H', 'S', 'N'}
+
+	errSnapshotCorrupted = errors.New("nodehead snapshot is corrupted")
+)
+
+// snapshotEntry is the state of one space in the snapshot
+type snapshotEntry struct {
+	spaceId string
+	newHead string
+	oldHead string
+}
+
+// The snapshot file is the header followed by entries and the crc32 of all previous bytes:
+//
+//	magic [4]byte | version uint16 | appliedAt int64 (unix ms) | count uint64
+//	count * (uvarint len | spaceId | uvarint len | newHead | uvarint len | oldHead)
+//	crc32 uint32
+//
+// appliedAt is the index change time of the last applied head, index changes from it are replayed on load.
+func writeSnapshot(path string, appliedAt time.Time, entries []snapshotEntry) (err error) {
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	var (
+		crc = crc32.NewIEEE()
+		w   = bufio.NewWriter(io.MultiWriter(f, crc))
+		buf = make([]byte, 0, 22)
+	)
+	buf = append(buf, snapshotMagic[:]...)
+	buf = binary.BigEndian.AppendUint16(buf, snapshotVersion)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(appliedAt.UnixMilli()))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(len(entries)))
+	if _, err = w.Write(buf); err != nil {
+		return
+	}
+	for _, entry := range entries {
+		for _, s := range [...]string{entry.spaceId, entry.newHead, entry.oldHead} {
+			buf = binary.AppendUvarint(buf[:0], uint64(len(s)))
+			if _, err = w.Write(buf); err != nil {
+				return
+			}
+			if _, err = w.WriteString(s); err != nil {
+				return
+			}
+		}
+	}
+	if err = w.Flush(); err != nil {
+		return
+	}
+	if _, err = f.Write(binary.BigEndian.AppendUint32(nil, crc.Sum32())); err != nil {
+		return
+	}
+	if err = f.Sync(); err != nil {
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// readSnapshot reads the whole snapshot, entries are returned only when the checksum matches
+func readSnapshot(path string) (appliedAt time.Time, entries []snapshotEntry, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	r := &crcReader{r: bufio.NewReader(f), crc: crc32.NewIEEE()}
+
+	header := make([]byte, 22)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return appliedAt, nil, corrupted(err)
+	}
+	if [4]byte(header[:4]) != snapshotMagic {
+		return appliedAt, nil, corrupted(errors.New("bad magic"))
+	}
+	if version := binary.BigEndian.Uint16(header[4:6]); version != snapshotVersion {
+		return appliedAt, nil, fmt.Errorf("unsupported nodehead snapshot version %d", version)
+	}
+	appliedAt = time.UnixMilli(int64(binary.BigEndian.Uint64(header[6:14])))
+	count := binary.BigEndian.Uint64(header[14:22])
+
+	entries = make([]snapshotEntry, 0, min(count, 1<<20))
+	for range count {
+		var entry snapshotEntry
+		for _, s := range [...]*string{&entry.spaceId, &entry.newHead, &entry.oldHead} {
+			if *s, err = r.readString(); err != nil {
+				return appliedAt, nil, corrupted(err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	sum := r.crc.Sum32()
+	trailer := make([]byte, 4)
+	if _, err = io.ReadFull(r.r, trailer); err != nil {
+		return appliedAt, nil, corrupted(err)
+	}
+	if binary.BigEndian.Uint32(trailer) != sum {
+		return appliedAt, nil, corrupted(errors.New("checksum mismatch"))
+	}
+	if _, err = r.r.ReadByte(); err != io.EOF {
+		return appliedAt, nil, corrupted(errors.New("unexpected data after the checksum"))
+	}
+	return appliedAt, entries, nil
+}
+
+func corrupted(err error) error {
+	return fmt.Errorf("%w: %w", errSnapshotCorrupted, err)
+}
+
+// crcReader calculates the checksum of consumed bytes
+type crcReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+}
+
+func (c *crcReader) Read(p []byte) (n int, err error) {
+	n, err = c.r.Read(p)
+	c.crc.Write(p[:n])
+	return
+}
+
+func (c *crcReader) ReadByte() (b byte, err error) {
+	if b, err = c.r.ReadByte(); err == nil {
+		c.crc.Write([]byte{b})
+	}
+	return
+}
+
+func (c *crcReader) readString() (s string, err error) {
+	l, err := binary.ReadUvarint(c)
+	if err != nil {
+		return
+	}
+	if l > maxSnapshotString {
+		return "", fmt.Errorf("string length %d is too big", l)
+	}
+	buf := make([]byte, l)
+	if _, err = io.ReadFull(c, buf); err != nil {
+		return
+	}
+	return string(buf), nil
+}
@@ -16,13 +16,16 @@ func checkResponsible(ctx context.Context, confService nodeconf.Service, spaceId
 	if err != nil {
 		return
 	}
-	isClient := len(confService.NodeTypes(peerId)) == 0
-	if isClient && !confService.IsResponsible(spaceId) {
+	if isClient(confService, peerId) && !confService.IsResponsible(spaceId) {
 		return spacesyncproto.ErrPeerIsNotResponsible
 	}
 	return
 }
 
+func isClient(confService nodeconf.Service, peerId string) bool {
+	return len(confService.NodeTypes(peerId)) == 0
+}
+
 func checkReceipt(ctx context.Context, confService nodeconf.Service, spaceId string, credential []byte) (err error) {
 	accountMarshalled, err := peer.CtxIdentity(ctx)
 	if err != nil {
@@ -158,6 +158,18 @@ func (mr *MockServiceMockRecorder) Name() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockService)(nil).Name))
 }
 
+// OnClientHeadSync mocks base method.
+func (m *MockService) OnClientHeadSync(onHeadSync func(string)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnClientHeadSync", onHeadSync)
+}
+
+// OnClientHeadSync indicates an expected call of OnClientHeadSync.
+func (mr *MockServiceMockRecorder) OnClientHeadSync(onHeadSync any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnClientHeadSync", reflect.TypeOf((*MockService)(nil).OnClientHeadSync), onHeadSync)
+}
+
 // PickSpace mocks base method.
 func (m *MockService) PickSpace(ctx context.Context, id string) (nodespace.NodeSpace, error) {
 	m.ctrl.T.Helper()
@@ -265,6 +265,9 @@ func (r *rpcHandler) HeadSync(ctx context.Context, req *spacesyncproto.HeadSyncR
 			zap.String("accountId", accountIdentity.Account()))
 		return nil, spacesyncproto.ErrPeerIsNotResponsible
 	}
+	if peerId, _ := peer.CtxPeerId(ctx); r.s.onClientHeadSync != nil && isClient(r.s.confService, peerId) {
+		r.s.onClientHeadSync(req.SpaceId)
+	}
 	if resp = r.tryNodeHeadSync(req); resp != nil {
 		return
 	}
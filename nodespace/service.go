@@ -44,6 +44,9 @@ type Service interface {
 	EvictSpace(ctx context.Context, id string) error
 	Cache() ocache.OCache
 	GetStats(ctx context.Context, id string, treeTop int) (nodestorage.SpaceStats, error)
+	// OnClientHeadSync sets the callback called when a client requests the head sync of the space,
+	// the request could be answered by the node head without loading the space
+	OnClientHeadSync(onHeadSync func(spaceId string))
 	app.ComponentRunnable
 }
 
@@ -58,6 +61,7 @@ type service struct {
 	nodeHead             nodehead.NodeHead
 	metric               metric.Metric
 	coordClient          coordinatorclient.CoordinatorClient
+	onClientHeadSync     func(spaceId string)
 }
 
 func (s *service) Init(a *app.App) (err error) {
@@ -161,6 +165,10 @@ func (s *service) loadSpace(ctx context.Context, id string) (value ocache.Object
 	return ns, nil
 }
 
+func (s *service) OnClientHeadSync(onHeadSync func(spaceId string)) {
+	s.onClientHeadSync = onHeadSync
+}
+
 func (s *service) Close(ctx context.Context) (err error) {
 	return s.spaceCache.Close()
 }
@@ -111,6 +111,7 @@ func (s *spaceDeleter) delete(ctx context.Context) (err error) {
 
 func (s *spaceDeleter) processDeletionRecord(ctx context.Context, rec *coordinatorproto.DeletionLogRecord) (err error) {
 	log := log.With(zap.String("spaceId", rec.SpaceId), zap.String("deletionLogId", rec.Id), zap.String("status", rec.Status.String()))
+	ctx = nodestorage.WithOrigin(ctx, nodestorage.OriginDeletionLog)
 	deleteSpace := func() error {
 		// deleting space storage
 		err = s.storageProvider.DeleteSpaceStorage(ctx, rec.SpaceId)
@@ -5,6 +5,13 @@ type configGetter interface {
 }
 
 type Config struct {
-	Path         string `yaml:"path"`
-	AnyStorePath string `yaml:"anyStorePath"`
+	Path         string        `yaml:"path"`
+	AnyStorePath string        `yaml:"anyStorePath"`
+	Journal      JournalConfig `yaml:"journal"`
+}
+
+// JournalConfig limits the journal of space changes, zero values use defaults
+type JournalConfig struct {
+	RetentionDays int `yaml:"retentionDays"`
+	MaxEntries    int `yaml:"maxEntries"`
 }
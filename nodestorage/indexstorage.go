@@ -7,6 +7,7 @@ import (
 	"os"
 	"path"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	anystore "github.com/anyproto/any-store"
@@ -26,6 +27,36 @@ type SpaceStatusEntry struct {
 	LastAccess              time.Time
 	ArchiveSizeCompressed   int64
 	ArchiveSizeUncompressed int64
+	// ArchiveChecksum is a hex encoded sha256 of the uncompressed store.db
+	ArchiveChecksum string
+}
+
+// HotSyncEntry is a space waiting in the hot sync queue, times are stored with millisecond precision
+type HotSyncEntry struct {
+	SpaceId   string    `json:"spaceId"`
+	Priority  int       `json:"priority"`
+	AddedAt   time.Time `json:"addedAt"`
+	Attempts  int       `json:"attempts"`
+	NextTry   time.Time `json:"nextTry"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// HandoffEntry is a space the node is not responsible for anymore, it waits for new owners before the deletion
+type HandoffEntry struct {
+	SpaceId  string    `json:"spaceId"`
+	MarkedAt time.Time `json:"markedAt"`
+}
+
+// AuditEntry is a space whose head differs on other responsible nodes, it's kept until replicas agree again
+type AuditEntry struct {
+	SpaceId string `json:"spaceId"`
+	Head    string `json:"head"`
+	// Peers are nodes whose head differs from the local one
+	Peers         []string  `json:"peers"`
+	DivergedSince time.Time `json:"divergedSince"`
+	CheckedAt     time.Time `json:"checkedAt"`
+	// FlaggedAt is set when the divergence has lasted longer than the threshold
+	FlaggedAt time.Time `json:"flaggedAt"`
 }
 
 const (
@@ -37,6 +68,37 @@ const (
 	SpaceStatusNotResponsible
 )
 
+func (s SpaceStatus) String() string {
+	switch s {
+	case SpaceStatusOk:
+		return "ok"
+	case SpaceStatusRemove:
+		return "remove"
+	case SpaceStatusRemovePrepare:
+		return "removePrepare"
+	case SpaceStatusArchived:
+		return "archived"
+	case SpaceStatusError:
+		return "error"
+	case SpaceStatusNotResponsible:
+		return "notResponsible"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(s))
+	}
+}
+
+// ArchivedFilter limits the result of IndexStorage.ListArchived, zero values don't filter
+type ArchivedFilter struct {
+	MinSizeUncompressed int64
+	LastAccessBefore    time.Time
+}
+
+// InactiveSpace is an item of the inactive spaces list ordered by last access and id, it's the cursor of the next page as well
+type InactiveSpace struct {
+	SpaceId    string
+	LastAccess time.Time
+}
+
 var (
 	ErrUnknownSpaceId  = errors.New("unknown space id")
 	ErrNoDeletionLogId = errors.New("no last record id")
@@ -47,6 +109,15 @@ const (
 	migrationStateCollName     = "migrationState"
 	spaceCollName              = "space"
 	settingsCollName           = "settings"
+	syncRunCollName            = "syncRun"
+	hotSyncCollName            = "hotSyncQueue"
+	handoffCollName            = "handoff"
+	headAuditCollName          = "headAudit"
+	priorityKey                = "p"
+	addedAtKey                 = "at"
+	attemptsKey                = "n"
+	nextTryKey                 = "nt"
+	startedAtKey               = "st"
 	newHashKey                 = "nh"
 	oldHashKey                 = "oh"
 	statusKey                  = "s"
@@ -54,7 +125,12 @@ const (
 	valueKey                   = "v"
 	archiveSizeCompressedKey   = "asc"
 	archiveSizeUncompressedKey = "asu"
+	archiveChecksumKey         = "ach"
 	errorKey                   = "err"
+	changedAtKey               = "ut"
+	peersKey                   = "peers"
+	checkedAtKey               = "ch"
+	flaggedAtKey               = "fl"
 	diffMigrationKey           = "diffState"
 	diffVersionKey             = "diffVersion"
 
@@ -64,15 +140,52 @@ const (
 type IndexStorage interface {
 	UpdateHash(ctx context.Context, updates ...SpaceUpdate) (err error)
 	ReadHashes(ctx context.Context, iterFunc func(update SpaceUpdate) (bool, error)) (err error)
+	// ReadChangedHashes iterates spaces with any status whose hash or status was changed since the given time
+	ReadChangedHashes(ctx context.Context, since time.Time, iterFunc func(update SpaceUpdate, status SpaceStatus) (bool, error)) (err error)
 	UpdateHashes(ctx context.Context, updateFunc func(spaceId, newHash, oldHash string) (newNewHash, newOldHash string, shouldUpdate bool)) (err error)
 	SetSpaceStatus(ctx context.Context, spaceId string, status SpaceStatus, recId string) (err error)
 	SpaceStatus(ctx context.Context, spaceId string) (status SpaceStatus, err error)
 	SpaceStatusEntry(ctx context.Context, spaceId string) (entry SpaceStatusEntry, err error)
-	MarkArchived(ctx context.Context, spaceId string, compressedSize, uncompressedSize int64) (err error)
+	MarkArchived(ctx context.Context, spaceId string, compressedSize, uncompressedSize int64, checksum string) (err error)
 	MarkError(ctx context.Context, spaceId string, errString string) (err error)
 	DeletionLogId(ctx context.Context) (id string, err error)
 	SetDeletionLogId(ctx context.Context, id string) (err error)
 	FindOldestInactiveSpace(ctx context.Context, olderThan time.Duration, skip int) (spaceId string, err error)
+	// FindInactiveSpaces returns Ok spaces not accessed for olderThan, the oldest first, starting after the given space; zero after means the start
+	FindInactiveSpaces(ctx context.Context, olderThan time.Duration, after InactiveSpace, limit int) (spaces []InactiveSpace, err error)
+	ListArchived(ctx context.Context, filter ArchivedFilter, afterId string, limit int) (entries []SpaceStatusEntry, err error)
+
+	// SaveSyncRun stores the encoded report of the node sync run and keeps only the latest keep reports
+	SaveSyncRun(ctx context.Context, runId string, startedAt time.Time, data []byte, keep int) (err error)
+	// SyncRuns returns encoded reports of the latest node sync runs, the newest first
+	SyncRuns(ctx context.Context, limit int) (data [][]byte, err error)
+
+	// SaveHotSyncEntries inserts or replaces entries of the hot sync queue
+	SaveHotSyncEntries(ctx context.Context, entries ...HotSyncEntry) (err error)
+	// DeleteHotSyncEntries removes spaces from the hot sync queue
+	DeleteHotSyncEntries(ctx context.Context, spaceIds ...string) (err error)
+	// HotSyncEntries returns the whole hot sync queue in the order of adding
+	HotSyncEntries(ctx context.Context) (entries []HotSyncEntry, err error)
+
+	// SaveHandoff remembers the space that lost the responsibility, the existing entry keeps its mark time
+	SaveHandoff(ctx context.Context, spaceId string, markedAt time.Time) (err error)
+	DeleteHandoff(ctx context.Context, spaceId string) (err error)
+	// Handoffs returns spaces waiting for the handoff, the oldest first
+	Handoffs(ctx context.Context) (entries []HandoffEntry, err error)
+
+	// SaveAuditEntries inserts or replaces entries of the head audit report
+	SaveAuditEntries(ctx context.Context, entries ...AuditEntry) (err error)
+	// DeleteAuditEntries removes spaces from the head audit report
+	DeleteAuditEntries(ctx context.Context, spaceIds ...string) (err error)
+	// AuditEntries returns diverged spaces, the longest diverged first
+	AuditEntries(ctx context.Context) (entries []AuditEntry, err error)
+
+	// Journal returns head and status transitions of spaces in the order of changes
+	Journal(ctx context.Context, filter JournalFilter, limit int) (entries []JournalEntry, err error)
+	// TrimJournal removes journal entries older than before and the oldest entries above maxEntries, zero values don't limit
+	TrimJournal(ctx context.Context, before time.Time, maxEntries int) (removed int, err error)
+	// HadHead tells whether the head was the head of the space according to the journal
+	HadHead(ctx context.Context, spaceId, head string) (ok bool, err error)
 
 	UpdateLastAccess(ctx context.Context, spaceId string) (err error)
 	GetDiffMigrationVersion(ctx context.Context) (version int, err error)
@@ -85,8 +198,10 @@ type indexStorage struct {
 	db              anystore.DB
 	settingsColl    anystore.Collection
 	spaceColl       anystore.Collection
+	journalColl     anystore.Collection
 	arenaPool       *anyenc.ArenaPool
 	lastAccessCache *sync.Map
+	lastJournalNano atomic.Int64
 }
 
 func (d *indexStorage) UpdateHash(ctx context.Context, updates ...SpaceUpdate) (err error) {
@@ -100,22 +215,33 @@ func (d *indexStorage) UpdateHash(ctx context.Context, updates ...SpaceUpdate) (
 	ctx = tx.Context()
 
 	for _, update := range updates {
+		var change *journalChange
 		_, err = d.spaceColl.UpsertId(ctx, update.SpaceId, query.ModifyFunc(func(a *anyenc.Arena, v *anyenc.Value) (result *anyenc.Value, modified bool, err error) {
+			change = newJournalChange(update.SpaceId, v)
 			if update.Updated.IsZero() {
 				update.Updated = time.Now()
 			}
 			v.Set(oldHashKey, a.NewString(update.OldHash))
 			v.Set(newHashKey, a.NewString(update.NewHash))
 			v.Set(lastAccessKey, a.NewNumberFloat64(float64(update.Updated.Unix())))
+			setChangedAt(a, v, update.ChangedAt)
 			if v.Get(statusKey) == nil {
 				v.Set(statusKey, a.NewNumberInt(int(SpaceStatusOk)))
 			}
 			d.lastAccessCache.Store(update.SpaceId, update.Updated)
+			change.done(v)
 			return v, true, nil
 		}))
 		if err != nil {
 			return err
 		}
+		origin := update.Origin
+		if origin == "" {
+			origin = originFromContext(ctx, OriginUnknown)
+		}
+		if err = d.appendJournal(ctx, origin, change); err != nil {
+			return err
+		}
 	}
 	return tx.Commit()
 }
@@ -139,10 +265,11 @@ func (d *indexStorage) ReadHashes(ctx context.Context, iterFunc func(update Spac
 			return err
 		}
 		cont, err := iterFunc(SpaceUpdate{
-			SpaceId: doc.Value().GetString("id"),
-			OldHash: doc.Value().GetString(oldHashKey),
-			NewHash: doc.Value().GetString(newHashKey),
-			Updated: time.Unix(int64(doc.Value().GetInt(lastAccessKey)), 0),
+			SpaceId:   doc.Value().GetString("id"),
+			OldHash:   doc.Value().GetString(oldHashKey),
+			NewHash:   doc.Value().GetString(newHashKey),
+			Updated:   time.Unix(int64(doc.Value().GetInt(lastAccessKey)), 0),
+			ChangedAt: readChangedAt(doc.Value()),
 		})
 		if err != nil || !cont {
 			return err
@@ -151,6 +278,67 @@ func (d *indexStorage) ReadHashes(ctx context.Context, iterFunc func(update Spac
 	return nil
 }
 
+func (d *indexStorage) ReadChangedHashes(ctx context.Context, since time.Time, iterFunc func(update SpaceUpdate, status SpaceStatus) (bool, error)) (err error) {
+	a := d.arenaPool.Get()
+	defer d.arenaPool.Put(a)
+	filter := query.Key{
+		Path:   []string{changedAtKey},
+		Filter: query.NewCompValue(query.CompOpGte, a.NewNumberFloat64(float64(since.UnixMilli()))),
+	}
+	iter, err := d.spaceColl.Find(filter).Sort(changedAtKey).Iter(ctx)
+	if err != nil {
+		return
+	}
+	defer iter.Close()
+	for iter.Next() {
+		doc, err := iter.Doc()
+		if err != nil {
+			return err
+		}
+		v := doc.Value()
+		cont, err := iterFunc(SpaceUpdate{
+			SpaceId:   v.GetString("id"),
+			OldHash:   v.GetString(oldHashKey),
+			NewHash:   v.GetString(newHashKey),
+			Updated:   time.Unix(int64(v.GetInt(lastAccessKey)), 0),
+			ChangedAt: readChangedAt(v),
+		}, SpaceStatus(v.GetInt(statusKey)))
+		if err != nil || !cont {
+			return err
+		}
+	}
+	return nil
+}
+
+// setChanged remembers the time of the hash or status change, nodehead applies changes made after its snapshot
+func setChanged(a *anyenc.Arena, v *anyenc.Value) {
+	setChangedAt(a, v, time.Time{})
+}
+
+// setChangedAt is setChanged with the given time, the zero time means now
+func setChangedAt(a *anyenc.Arena, v *anyenc.Value, t time.Time) {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	v.Set(changedAtKey, a.NewNumberFloat64(float64(t.UnixMilli())))
+}
+
+func readChangedAt(v *anyenc.Value) time.Time {
+	return time.UnixMilli(int64(v.GetFloat64(changedAtKey)))
+}
+
+type changedAtCtxKey struct{}
+
+func withChangedAt(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, changedAtCtxKey{}, t)
+}
+
+// ChangedAtFromContext returns the index change time of the hash passed to the OnWriteHash callback
+func ChangedAtFromContext(ctx context.Context) time.Time {
+	t, _ := ctx.Value(changedAtCtxKey{}).(time.Time)
+	return t
+}
+
 func (d *indexStorage) SpaceStatus(ctx context.Context, spaceId string) (status SpaceStatus, err error) {
 	doc, err := d.spaceColl.FindId(ctx, spaceId)
 	if err != nil {
@@ -169,9 +357,12 @@ func (d *indexStorage) SpaceStatusEntry(ctx context.Context, spaceId string) (en
 	if err != nil {
 		return entry, err
 	}
-	v := doc.Value()
-	entry = SpaceStatusEntry{
-		SpaceId:                 spaceId,
+	return spaceStatusEntryFromValue(doc.Value()), nil
+}
+
+func spaceStatusEntryFromValue(v *anyenc.Value) SpaceStatusEntry {
+	return SpaceStatusEntry{
+		SpaceId:                 v.GetString("id"),
 		Status:                  SpaceStatus(v.GetInt(statusKey)),
 		Error:                   v.GetString(errorKey),
 		NewHash:                 v.GetString(newHashKey),
@@ -179,8 +370,8 @@ func (d *indexStorage) SpaceStatusEntry(ctx context.Context, spaceId string) (en
 		LastAccess:              time.Unix(int64(v.GetInt(lastAccessKey)), 0),
 		ArchiveSizeCompressed:   int64(v.GetInt(archiveSizeCompressedKey)),
 		ArchiveSizeUncompressed: int64(v.GetInt(archiveSizeUncompressedKey)),
+		ArchiveChecksum:         v.GetString(archiveChecksumKey),
 	}
-	return entry, nil
 }
 
 func (d *indexStorage) SetSpaceStatus(ctx context.Context, spaceId string, status SpaceStatus, recId string) (err error) {
@@ -193,18 +384,25 @@ func (d *indexStorage) SetSpaceStatus(ctx context.Context, spaceId string, statu
 	}()
 	ctx = tx.Context()
 
+	var change *journalChange
 	_, err = d.spaceColl.UpsertId(ctx, spaceId, query.ModifyFunc(func(a *anyenc.Arena, v *anyenc.Value) (result *anyenc.Value, modified bool, err error) {
+		change = newJournalChange(spaceId, v)
 		v.Set(statusKey, a.NewNumberInt(int(status)))
 		v.Set(lastAccessKey, a.NewNumberInt(int(time.Now().Unix())))
+		setChanged(a, v)
 		if status == SpaceStatusRemove {
 			v.Set(oldHashKey, a.NewNull())
 			v.Set(newHashKey, a.NewNull())
 		}
+		change.done(v)
 		return v, true, nil
 	}))
 	if err != nil {
 		return
 	}
+	if err = d.appendJournal(ctx, originFromContext(ctx, OriginUnknown), change); err != nil {
+		return
+	}
 	if recId == "" {
 		return tx.Commit()
 	}
@@ -220,22 +418,61 @@ func (d *indexStorage) SetSpaceStatus(ctx context.Context, spaceId string, statu
 }
 
 func (d *indexStorage) MarkError(ctx context.Context, spaceId string, errString string) (err error) {
+	tx, err := d.db.WriteTx(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	ctx = tx.Context()
+
+	var change *journalChange
 	_, err = d.spaceColl.UpdateId(ctx, spaceId, query.ModifyFunc(func(a *anyenc.Arena, v *anyenc.Value) (result *anyenc.Value, modified bool, err error) {
+		change = newJournalChange(spaceId, v)
 		v.Set(statusKey, a.NewNumberInt(int(SpaceStatusError)))
 		v.Set(errorKey, a.NewString(errString))
+		setChanged(a, v)
+		change.done(v)
 		return v, true, nil
 	}))
-	return err
+	if err != nil {
+		return
+	}
+	if err = d.appendJournal(ctx, originFromContext(ctx, OriginUnknown), change); err != nil {
+		return
+	}
+	return tx.Commit()
 }
 
-func (d *indexStorage) MarkArchived(ctx context.Context, spaceId string, compressedSize, uncompressedSize int64) (err error) {
+func (d *indexStorage) MarkArchived(ctx context.Context, spaceId string, compressedSize, uncompressedSize int64, checksum string) (err error) {
+	tx, err := d.db.WriteTx(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	ctx = tx.Context()
+
+	var change *journalChange
 	_, err = d.spaceColl.UpdateId(ctx, spaceId, query.ModifyFunc(func(a *anyenc.Arena, v *anyenc.Value) (result *anyenc.Value, modified bool, err error) {
+		change = newJournalChange(spaceId, v)
 		v.Set(archiveSizeCompressedKey, a.NewNumberInt(int(compressedSize)))
 		v.Set(archiveSizeUncompressedKey, a.NewNumberInt(int(uncompressedSize)))
+		v.Set(archiveChecksumKey, a.NewString(checksum))
 		v.Set(statusKey, a.NewNumberInt(int(SpaceStatusArchived)))
+		setChanged(a, v)
+		change.done(v)
 		return v, true, nil
 	}))
-	return err
+	if err != nil {
+		return
+	}
+	if err = d.appendJournal(ctx, originFromContext(ctx, OriginArchive), change); err != nil {
+		return
+	}
+	return tx.Commit()
 }
 
 func (d *indexStorage) DeletionLogId(ctx context.Context) (id string, err error) {
@@ -320,6 +557,110 @@ func (d *indexStorage) FindOldestInactiveSpace(ctx context.Context, olderThan ti
 	return spaceId, nil
 }
 
+func (d *indexStorage) FindInactiveSpaces(ctx context.Context, olderThan time.Duration, after InactiveSpace, limit int) (spaces []InactiveSpace, err error) {
+	cutoffUnix := time.Now().Add(-olderThan).Unix()
+
+	a := d.arenaPool.Get()
+	defer d.arenaPool.Put(a)
+
+	conds := query.And{
+		query.Key{
+			Path:   []string{statusKey},
+			Filter: query.NewCompValue(query.CompOpEq, a.NewNumberInt(int(SpaceStatusOk))),
+		},
+		query.Key{
+			Path:   []string{lastAccessKey},
+			Filter: query.NewCompValue(query.CompOpLt, a.NewNumberFloat64(float64(cutoffUnix))),
+		},
+	}
+	if after.SpaceId != "" {
+		// (lastAccess, id) > (after.LastAccess, after.SpaceId)
+		afterUnix := a.NewNumberFloat64(float64(after.LastAccess.Unix()))
+		conds = append(conds, query.Or{
+			query.Key{
+				Path:   []string{lastAccessKey},
+				Filter: query.NewCompValue(query.CompOpGt, afterUnix),
+			},
+			query.And{
+				query.Key{
+					Path:   []string{lastAccessKey},
+					Filter: query.NewCompValue(query.CompOpEq, afterUnix),
+				},
+				query.Key{
+					Path:   []string{"id"},
+					Filter: query.NewCompValue(query.CompOpGt, a.NewString(after.SpaceId)),
+				},
+			},
+		})
+	}
+
+	iter, err := d.spaceColl.Find(conds).Sort(lastAccessKey, "id").Limit(uint(limit)).Iter(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = iter.Close()
+	}()
+	for iter.Next() {
+		doc, err := iter.Doc()
+		if err != nil {
+			return nil, err
+		}
+		v := doc.Value()
+		spaces = append(spaces, InactiveSpace{
+			SpaceId:    v.GetString("id"),
+			LastAccess: time.Unix(int64(v.GetInt(lastAccessKey)), 0),
+		})
+	}
+	return spaces, iter.Err()
+}
+
+func (d *indexStorage) ListArchived(ctx context.Context, filter ArchivedFilter, afterId string, limit int) (entries []SpaceStatusEntry, err error) {
+	a := d.arenaPool.Get()
+	defer d.arenaPool.Put(a)
+
+	conds := query.And{
+		query.Key{
+			Path:   []string{statusKey},
+			Filter: query.NewCompValue(query.CompOpEq, a.NewNumberInt(int(SpaceStatusArchived))),
+		},
+	}
+	if afterId != "" {
+		conds = append(conds, query.Key{
+			Path:   []string{"id"},
+			Filter: query.NewCompValue(query.CompOpGt, a.NewString(afterId)),
+		})
+	}
+	if filter.MinSizeUncompressed > 0 {
+		conds = append(conds, query.Key{
+			Path:   []string{archiveSizeUncompressedKey},
+			Filter: query.NewCompValue(query.CompOpGte, a.NewNumberInt(int(filter.MinSizeUncompressed))),
+		})
+	}
+	if !filter.LastAccessBefore.IsZero() {
+		conds = append(conds, query.Key{
+			Path:   []string{lastAccessKey},
+			Filter: query.NewCompValue(query.CompOpLt, a.NewNumberFloat64(float64(filter.LastAccessBefore.Unix()))),
+		})
+	}
+
+	iter, err := d.spaceColl.Find(conds).Sort("id").Limit(uint(limit)).Iter(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = iter.Close()
+	}()
+	for iter.Next() {
+		doc, err := iter.Doc()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, spaceStatusEntryFromValue(doc.Value()))
+	}
+	return entries, iter.Err()
+}
+
 func (d *indexStorage) RunMigrations(ctx context.Context) (err error) {
 	diffMigration, err := newDiffMigration(d, log)
 	if err != nil {
@@ -346,6 +687,7 @@ func (d *indexStorage) UpdateHashes(ctx context.Context, updateFunc func(spaceId
 
 		v.Set(newHashKey, a.NewString(newNewHash))
 		v.Set(oldHashKey, a.NewString(newOldHash))
+		setChanged(a, v)
 		if v.Get(statusKey) == nil {
 			v.Set(statusKey, a.NewNumberInt(int(SpaceStatusOk)))
 		}
@@ -354,6 +696,309 @@ func (d *indexStorage) UpdateHashes(ctx context.Context, updateFunc func(spaceId
 	return
 }
 
+func (d *indexStorage) SaveSyncRun(ctx context.Context, runId string, startedAt time.Time, data []byte, keep int) (err error) {
+	coll, err := d.db.Collection(ctx, syncRunCollName)
+	if err != nil {
+		return
+	}
+	tx, err := d.db.WriteTx(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	ctx = tx.Context()
+	_, err = coll.UpsertId(ctx, runId, query.ModifyFunc(func(a *anyenc.Arena, v *anyenc.Value) (result *anyenc.Value, modified bool, err error) {
+		v.Set(startedAtKey, a.NewNumberFloat64(float64(startedAt.UnixNano())))
+		v.Set(valueKey, a.NewString(string(data)))
+		return v, true, nil
+	}))
+	if err != nil {
+		return
+	}
+	iter, err := coll.Find(nil).Sort("-" + startedAtKey).Offset(uint(keep)).Iter(ctx)
+	if err != nil {
+		return
+	}
+	var oldIds []string
+	for iter.Next() {
+		doc, dErr := iter.Doc()
+		if dErr != nil {
+			_ = iter.Close()
+			return dErr
+		}
+		oldIds = append(oldIds, doc.Value().GetString("id"))
+	}
+	if err = errors.Join(iter.Err(), iter.Close()); err != nil {
+		return
+	}
+	for _, id := range oldIds {
+		if err = coll.DeleteId(ctx, id); err != nil {
+			return
+		}
+	}
+	return tx.Commit()
+}
+
+func (d *indexStorage) SyncRuns(ctx context.Context, limit int) (data [][]byte, err error) {
+	coll, err := d.db.Collection(ctx, syncRunCollName)
+	if err != nil {
+		return
+	}
+	iter, err := coll.Find(nil).Sort("-" + startedAtKey).Limit(uint(limit)).Iter(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = iter.Close()
+	}()
+	for iter.Next() {
+		doc, err := iter.Doc()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, []byte(doc.Value().GetString(valueKey)))
+	}
+	return data, iter.Err()
+}
+
+func (d *indexStorage) SaveHotSyncEntries(ctx context.Context, entries ...HotSyncEntry) (err error) {
+	if len(entries) == 0 {
+		return
+	}
+	coll, err := d.db.Collection(ctx, hotSyncCollName)
+	if err != nil {
+		return
+	}
+	tx, err := d.db.WriteTx(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	ctx = tx.Context()
+	for _, entry := range entries {
+		_, err = coll.UpsertId(ctx, entry.SpaceId, query.ModifyFunc(func(a *anyenc.Arena, v *anyenc.Value) (result *anyenc.Value, modified bool, err error) {
+			v.Set(priorityKey, a.NewNumberInt(entry.Priority))
+			v.Set(addedAtKey, a.NewNumberFloat64(float64(entry.AddedAt.UnixMilli())))
+			v.Set(attemptsKey, a.NewNumberInt(entry.Attempts))
+			v.Set(nextTryKey, a.NewNumberFloat64(float64(entry.NextTry.UnixMilli())))
+			v.Set(errorKey, a.NewString(entry.LastError))
+			return v, true, nil
+		}))
+		if err != nil {
+			return
+		}
+	}
+	return tx.Commit()
+}
+
+func (d *indexStorage) DeleteHotSyncEntries(ctx context.Context, spaceIds ...string) (err error) {
+	if len(spaceIds) == 0 {
+		return
+	}
+	coll, err := d.db.Collection(ctx, hotSyncCollName)
+	if err != nil {
+		return
+	}
+	tx, err := d.db.WriteTx(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	ctx = tx.Context()
+	for _, spaceId := range spaceIds {
+		if err = coll.DeleteId(ctx, spaceId); err != nil && !errors.Is(err, anystore.ErrDocNotFound) {
+			return
+		}
+	}
+	return tx.Commit()
+}
+
+func (d *indexStorage) HotSyncEntries(ctx context.Context) (entries []HotSyncEntry, err error) {
+	coll, err := d.db.Collection(ctx, hotSyncCollName)
+	if err != nil {
+		return
+	}
+	iter, err := coll.Find(nil).Sort(addedAtKey, "id").Iter(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = iter.Close()
+	}()
+	for iter.Next() {
+		doc, err := iter.Doc()
+		if err != nil {
+			return nil, err
+		}
+		v := doc.Value()
+		entries = append(entries, HotSyncEntry{
+			SpaceId:   v.GetString("id"),
+			Priority:  v.GetInt(priorityKey),
+			AddedAt:   time.UnixMilli(int64(v.GetFloat64(addedAtKey))),
+			Attempts:  v.GetInt(attemptsKey),
+			NextTry:   time.UnixMilli(int64(v.GetFloat64(nextTryKey))),
+			LastError: v.GetString(errorKey),
+		})
+	}
+	return entries, iter.Err()
+}
+
+func (d *indexStorage) SaveHandoff(ctx context.Context, spaceId string, markedAt time.Time) (err error) {
+	coll, err := d.db.Collection(ctx, handoffCollName)
+	if err != nil {
+		return
+	}
+	_, err = coll.UpsertId(ctx, spaceId, query.ModifyFunc(func(a *anyenc.Arena, v *anyenc.Value) (result *anyenc.Value, modified bool, err error) {
+		if v.Get(addedAtKey) != nil {
+			return v, false, nil
+		}
+		v.Set(addedAtKey, a.NewNumberFloat64(float64(markedAt.UnixMilli())))
+		return v, true, nil
+	}))
+	return
+}
+
+func (d *indexStorage) DeleteHandoff(ctx context.Context, spaceId string) (err error) {
+	coll, err := d.db.Collection(ctx, handoffCollName)
+	if err != nil {
+		return
+	}
+	if err = coll.DeleteId(ctx, spaceId); errors.Is(err, anystore.ErrDocNotFound) {
+		return nil
+	}
+	return
+}
+
+func (d *indexStorage) Handoffs(ctx context.Context) (entries []HandoffEntry, err error) {
+	coll, err := d.db.Collection(ctx, handoffCollName)
+	if err != nil {
+		return
+	}
+	iter, err := coll.Find(nil).Sort(addedAtKey, "id").Iter(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = iter.Close()
+	}()
+	for iter.Next() {
+		doc, err := iter.Doc()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, HandoffEntry{
+			SpaceId:  doc.Value().GetString("id"),
+			MarkedAt: time.UnixMilli(int64(doc.Value().GetFloat64(addedAtKey))),
+		})
+	}
+	return entries, iter.Err()
+}
+
+func (d *indexStorage) SaveAuditEntries(ctx context.Context, entries ...AuditEntry) (err error) {
+	if len(entries) == 0 {
+		return
+	}
+	coll, err := d.db.Collection(ctx, headAuditCollName)
+	if err != nil {
+		return
+	}
+	tx, err := d.db.WriteTx(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	ctx = tx.Context()
+	for _, entry := range entries {
+		_, err = coll.UpsertId(ctx, entry.SpaceId, query.ModifyFunc(func(a *anyenc.Arena, v *anyenc.Value) (result *anyenc.Value, modified bool, err error) {
+			peers := a.NewArray()
+			for i, peerId := range entry.Peers {
+				peers.SetArrayItem(i, a.NewString(peerId))
+			}
+			v.Set(newHashKey, a.NewString(entry.Head))
+			v.Set(peersKey, peers)
+			v.Set(addedAtKey, a.NewNumberFloat64(float64(entry.DivergedSince.UnixMilli())))
+			v.Set(checkedAtKey, a.NewNumberFloat64(float64(entry.CheckedAt.UnixMilli())))
+			if entry.FlaggedAt.IsZero() {
+				v.Del(flaggedAtKey)
+			} else {
+				v.Set(flaggedAtKey, a.NewNumberFloat64(float64(entry.FlaggedAt.UnixMilli())))
+			}
+			return v, true, nil
+		}))
+		if err != nil {
+			return
+		}
+	}
+	return tx.Commit()
+}
+
+func (d *indexStorage) DeleteAuditEntries(ctx context.Context, spaceIds ...string) (err error) {
+	if len(spaceIds) == 0 {
+		return
+	}
+	coll, err := d.db.Collection(ctx, headAuditCollName)
+	if err != nil {
+		return
+	}
+	tx, err := d.db.WriteTx(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	ctx = tx.Context()
+	for _, spaceId := range spaceIds {
+		if err = coll.DeleteId(ctx, spaceId); err != nil && !errors.Is(err, anystore.ErrDocNotFound) {
+			return
+		}
+	}
+	return tx.Commit()
+}
+
+func (d *indexStorage) AuditEntries(ctx context.Context) (entries []AuditEntry, err error) {
+	coll, err := d.db.Collection(ctx, headAuditCollName)
+	if err != nil {
+		return
+	}
+	iter, err := coll.Find(nil).Sort(addedAtKey, "id").Iter(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = iter.Close()
+	}()
+	for iter.Next() {
+		doc, err := iter.Doc()
+		if err != nil {
+			return nil, err
+		}
+		v := doc.Value()
+		entry := AuditEntry{
+			SpaceId:       v.GetString("id"),
+			Head:          v.GetString(newHashKey),
+			DivergedSince: time.UnixMilli(int64(v.GetFloat64(addedAtKey))),
+			CheckedAt:     time.UnixMilli(int64(v.GetFloat64(checkedAtKey))),
+		}
+		for _, peer := range v.GetArray(peersKey) {
+			entry.Peers = append(entry.Peers, string(peer.GetStringBytes()))
+		}
+		if v.Get(flaggedAtKey) != nil {
+			entry.FlaggedAt = time.UnixMilli(int64(v.GetFloat64(flaggedAtKey)))
+		}
+		entries = append(entries, entry)
+	}
+	return entries, iter.Err()
+}
+
 func (d *indexStorage) GetDiffMigrationVersion(ctx context.Context) (version int, err error) {
 	migrationColl, err := d.db.Collection(ctx, migrationStateCollName)
 	if err != nil {
@@ -419,9 +1064,20 @@ func OpenIndexStorage(ctx context.Context, rootPath string) (ds IndexStorage, er
 	if err != nil {
 		return
 	}
+	journalColl, err := db.Collection(ctx, journalCollName)
+	if err != nil {
+		return
+	}
+	if err = journalColl.EnsureIndex(ctx, anystore.IndexInfo{
+		Fields: []string{spaceIdKey, "id"},
+	}); err != nil {
+		return
+	}
 
 	if err = spaceColl.EnsureIndex(ctx, anystore.IndexInfo{
 		Fields: []string{statusKey, lastAccessKey},
+	}, anystore.IndexInfo{
+		Fields: []string{changedAtKey},
 	}); err != nil {
 		return
 	}
@@ -430,6 +1086,7 @@ func OpenIndexStorage(ctx context.Context, rootPath string) (ds IndexStorage, er
 		db:              db,
 		settingsColl:    settingsColl,
 		spaceColl:       spaceColl,
+		journalColl:     journalColl,
 		arenaPool:       &anyenc.ArenaPool{},
 		lastAccessCache: &sync.Map{},
 	}
@@ -39,6 +39,31 @@ func TestIndexStorage_UpdateLastAccess(t *testing.T) {
 	}))
 }
 
+func TestIndexStorage_ReadChangedHashes(t *testing.T) {
+	fx, err := createTestIndexStorage(ctx, t.TempDir())
+	require.NoError(t, err)
+	defer fx.Close()
+
+	require.NoError(t, fx.UpdateHash(ctx, SpaceUpdate{SpaceId: "before", OldHash: "o", NewHash: "n"}))
+	time.Sleep(time.Millisecond * 5)
+	since := time.Now()
+	require.NoError(t, fx.UpdateHash(ctx, SpaceUpdate{SpaceId: "updated", OldHash: "o", NewHash: "n"}))
+	require.NoError(t, fx.UpdateHash(ctx, SpaceUpdate{SpaceId: "removed", OldHash: "o", NewHash: "n"}))
+	require.NoError(t, fx.SetSpaceStatus(ctx, "removed", SpaceStatusRemove, ""))
+
+	changed := map[string]SpaceStatus{}
+	require.NoError(t, fx.ReadChangedHashes(ctx, since, func(update SpaceUpdate, status SpaceStatus) (bool, error) {
+		changed[update.SpaceId] = status
+		if update.SpaceId == "updated" {
+			assert.Equal(t, "n", update.NewHash)
+			assert.Equal(t, "o", update.OldHash)
+		}
+		assert.False(t, update.ChangedAt.Before(since.Truncate(time.Millisecond)))
+		return true, nil
+	}))
+	assert.Equal(t, map[string]SpaceStatus{"updated": SpaceStatusOk, "removed": SpaceStatusRemove}, changed)
+}
+
 func TestIndexStorage_FindOldestInactiveSpace(t *testing.T) {
 	t.Parallel()
 
@@ -81,6 +106,42 @@ func TestIndexStorage_FindOldestInactiveSpace(t *testing.T) {
 	})
 }
 
+func TestIndexStorage_FindInactiveSpaces(t *testing.T) {
+	tempDir := t.TempDir()
+	fx, err := createTestIndexStorage(ctx, tempDir)
+	require.NoError(t, err)
+	defer fx.Close()
+
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, fx.UpdateHash(ctx,
+		SpaceUpdate{SpaceId: "s_new", Updated: time.Now()},
+		SpaceUpdate{SpaceId: "s_b", Updated: old},
+		SpaceUpdate{SpaceId: "s_a", Updated: old},
+		SpaceUpdate{SpaceId: "s_c", Updated: old.Add(time.Hour)},
+		SpaceUpdate{SpaceId: "s_oldest", Updated: old.Add(-time.Hour)},
+		SpaceUpdate{SpaceId: "s_arch", Updated: old.Add(-2 * time.Hour)},
+	))
+	require.NoError(t, fx.SetSpaceStatus(ctx, "s_arch", SpaceStatusArchived, ""))
+
+	var (
+		ids   []string
+		after InactiveSpace
+	)
+	for {
+		page, err := fx.FindInactiveSpaces(ctx, 24*time.Hour, after, 2)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		for _, sp := range page {
+			ids = append(ids, sp.SpaceId)
+		}
+		after = page[len(page)-1]
+	}
+	// spaces with the same last access are ordered by id, so the cursor doesn't miss them on the page boundary
+	assert.Equal(t, []string{"s_oldest", "s_a", "s_b", "s_c"}, ids)
+}
+
 func Test_migrateToSingleCollection(t *testing.T) {
 	tempDir := t.TempDir()
 	data, err := os.ReadFile("./testdata/index_store_v1.db")
@@ -108,7 +169,7 @@ func TestIndexStorage_MarkArchived(t *testing.T) {
 	defer fx.Close()
 
 	require.NoError(t, fx.SetSpaceStatus(ctx, "space1", SpaceStatusOk, ""))
-	require.NoError(t, fx.MarkArchived(ctx, "space1", 1, 2))
+	require.NoError(t, fx.MarkArchived(ctx, "space1", 1, 2, ""))
 	status, err := fx.SpaceStatus(ctx, "space1")
 	require.NoError(t, err)
 	assert.Equal(t, SpaceStatusArchived, status)
@@ -137,7 +198,7 @@ func TestIndexStorage_SpaceStatusEntry(t *testing.T) {
 			OldHash: "old",
 			NewHash: "new",
 		}))
-		require.NoError(t, fx.MarkArchived(ctx, "space1", 100, 200))
+		require.NoError(t, fx.MarkArchived(ctx, "space1", 100, 200, "checksum"))
 
 		entry, err := fx.(*indexStorage).SpaceStatusEntry(ctx, "space1")
 		require.NoError(t, err)
@@ -147,6 +208,7 @@ func TestIndexStorage_SpaceStatusEntry(t *testing.T) {
 		assert.Equal(t, "old", entry.OldHash)
 		assert.Equal(t, int64(100), entry.ArchiveSizeCompressed)
 		assert.Equal(t, int64(200), entry.ArchiveSizeUncompressed)
+		assert.Equal(t, "checksum", entry.ArchiveChecksum)
 		assert.False(t, entry.LastAccess.IsZero())
 	})
 
@@ -178,3 +240,192 @@ func TestIndexStorage_MarkError(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, SpaceStatusError, status)
 }
+
+func TestIndexStorage_ListArchived(t *testing.T) {
+	tempDir := t.TempDir()
+	fx, err := createTestIndexStorage(ctx, tempDir)
+	require.NoError(t, err)
+	defer fx.Close()
+
+	for i, spaceId := range []string{"space1", "space2", "space3", "space4", "space5"} {
+		require.NoError(t, fx.SetSpaceStatus(ctx, spaceId, SpaceStatusOk, ""))
+		if spaceId != "space3" {
+			require.NoError(t, fx.MarkArchived(ctx, spaceId, int64(i+1), int64((i+1)*10), ""))
+		}
+	}
+
+	ids := func(entries []SpaceStatusEntry) (res []string) {
+		for _, e := range entries {
+			res = append(res, e.SpaceId)
+		}
+		return
+	}
+
+	entries, err := fx.ListArchived(ctx, ArchivedFilter{}, "", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"space1", "space2"}, ids(entries))
+	assert.Equal(t, int64(20), entries[1].ArchiveSizeUncompressed)
+
+	entries, err = fx.ListArchived(ctx, ArchivedFilter{}, "space2", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"space4", "space5"}, ids(entries))
+
+	entries, err = fx.ListArchived(ctx, ArchivedFilter{MinSizeUncompressed: 40}, "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"space4", "space5"}, ids(entries))
+
+	entries, err = fx.ListArchived(ctx, ArchivedFilter{LastAccessBefore: time.Now().Add(-time.Hour)}, "", 10)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestIndexStorage_HotSyncEntries(t *testing.T) {
+	fx, err := createTestIndexStorage(ctx, t.TempDir())
+	require.NoError(t, err)
+	defer fx.Close()
+
+	st := time.UnixMilli(time.Now().UnixMilli())
+	require.NoError(t, fx.SaveHotSyncEntries(ctx,
+		HotSyncEntry{SpaceId: "b", Priority: 1, AddedAt: st.Add(time.Second)},
+		HotSyncEntry{SpaceId: "a", AddedAt: st},
+		HotSyncEntry{SpaceId: "c", AddedAt: st.Add(time.Second * 2)},
+	))
+	require.NoError(t, fx.SaveHotSyncEntries(ctx, HotSyncEntry{SpaceId: "a", AddedAt: st, Attempts: 2, NextTry: st.Add(time.Minute), LastError: "err"}))
+	require.NoError(t, fx.DeleteHotSyncEntries(ctx, "c", "unknown"))
+
+	entries, err := fx.HotSyncEntries(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "a", entries[0].SpaceId)
+	assert.Equal(t, 2, entries[0].Attempts)
+	assert.Equal(t, "err", entries[0].LastError)
+	assert.True(t, st.Add(time.Minute).Equal(entries[0].NextTry))
+	assert.Equal(t, "b", entries[1].SpaceId)
+	assert.Equal(t, 1, entries[1].Priority)
+	assert.True(t, st.Add(time.Second).Equal(entries[1].AddedAt))
+}
+
+func TestIndexStorage_Handoffs(t *testing.T) {
+	fx, err := createTestIndexStorage(ctx, t.TempDir())
+	require.NoError(t, err)
+	defer fx.Close()
+
+	st := time.UnixMilli(time.Now().UnixMilli())
+	require.NoError(t, fx.SaveHandoff(ctx, "b", st.Add(time.Second)))
+	require.NoError(t, fx.SaveHandoff(ctx, "a", st))
+	// the mark time isn't changed
+	require.NoError(t, fx.SaveHandoff(ctx, "a", st.Add(time.Minute)))
+	require.NoError(t, fx.SaveHandoff(ctx, "c", st))
+	require.NoError(t, fx.DeleteHandoff(ctx, "c"))
+	require.NoError(t, fx.DeleteHandoff(ctx, "unknown"))
+
+	entries, err := fx.Handoffs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []HandoffEntry{{SpaceId: "a", MarkedAt: st}, {SpaceId: "b", MarkedAt: st.Add(time.Second)}}, entries)
+}
+
+func TestIndexStorage_AuditEntries(t *testing.T) {
+	fx, err := createTestIndexStorage(ctx, t.TempDir())
+	require.NoError(t, err)
+	defer fx.Close()
+
+	st := time.UnixMilli(time.Now().UnixMilli())
+	require.NoError(t, fx.SaveAuditEntries(ctx,
+		AuditEntry{SpaceId: "b", Head: "h2", Peers: []string{"p1"}, DivergedSince: st.Add(time.Second), CheckedAt: st.Add(time.Second)},
+		AuditEntry{SpaceId: "a", Head: "h1", Peers: []string{"p1", "p2"}, DivergedSince: st, CheckedAt: st, FlaggedAt: st},
+		AuditEntry{SpaceId: "c", Head: "h3", DivergedSince: st, CheckedAt: st},
+	))
+	// the flag is cleared on update
+	require.NoError(t, fx.SaveAuditEntries(ctx, AuditEntry{SpaceId: "a", Head: "h1", Peers: []string{"p2"}, DivergedSince: st, CheckedAt: st.Add(time.Minute)}))
+	require.NoError(t, fx.DeleteAuditEntries(ctx, "c", "unknown"))
+
+	entries, err := fx.AuditEntries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []AuditEntry{
+		{SpaceId: "a", Head: "h1", Peers: []string{"p2"}, DivergedSince: st, CheckedAt: st.Add(time.Minute)},
+		{SpaceId: "b", Head: "h2", Peers: []string{"p1"}, DivergedSince: st.Add(time.Second), CheckedAt: st.Add(time.Second)},
+	}, entries)
+}
+
+func TestIndexStorage_Journal(t *testing.T) {
+	fx, err := createTestIndexStorage(ctx, t.TempDir())
+	require.NoError(t, err)
+	defer fx.Close()
+
+	require.NoError(t, fx.UpdateHash(ctx, SpaceUpdate{SpaceId: "a", OldHash: "o1", NewHash: "h1", Origin: OriginHotSync}))
+	// the same head isn't journaled
+	require.NoError(t, fx.UpdateHash(ctx, SpaceUpdate{SpaceId: "a", OldHash: "o1", NewHash: "h1", Origin: OriginClientPush}))
+	require.NoError(t, fx.UpdateHash(WithOrigin(ctx, OriginColdSync), SpaceUpdate{SpaceId: "a", OldHash: "o2", NewHash: "h2"}))
+	between := time.Now()
+	require.NoError(t, fx.SetSpaceStatus(WithOrigin(ctx, OriginDeletionLog), "b", SpaceStatusRemovePrepare, "rec"))
+	require.NoError(t, fx.MarkArchived(ctx, "a", 1, 2, "sum"))
+
+	entries, err := fx.Journal(ctx, JournalFilter{}, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	for i := range entries {
+		if i > 0 {
+			assert.Greater(t, entries[i].Id, entries[i-1].Id)
+			assert.False(t, entries[i].Time.Before(entries[i-1].Time))
+		}
+	}
+	for i := range entries {
+		entries[i].Id, entries[i].Time = "", time.Time{}
+	}
+	assert.Equal(t, []JournalEntry{
+		{SpaceId: "a", Origin: OriginHotSync, Head: "h1"},
+		{SpaceId: "a", Origin: OriginColdSync, PrevHead: "h1", Head: "h2"},
+		{SpaceId: "b", Origin: OriginDeletionLog, Status: SpaceStatusRemovePrepare},
+		{SpaceId: "a", Origin: OriginArchive, PrevHead: "h2", Head: "h2", Status: SpaceStatusArchived},
+	}, entries)
+
+	entries, err = fx.Journal(ctx, JournalFilter{SpaceId: "a", From: between}, 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, OriginArchive, entries[0].Origin)
+
+	entries, err = fx.Journal(ctx, JournalFilter{SpaceId: "a", To: between}, 1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "h1", entries[0].Head)
+
+	had, err := fx.HadHead(ctx, "a", "h1")
+	require.NoError(t, err)
+	assert.True(t, had)
+	had, err = fx.HadHead(ctx, "b", "h1")
+	require.NoError(t, err)
+	assert.False(t, had)
+	entries, err = fx.Journal(ctx, JournalFilter{SpaceId: "a", To: between, AfterId: entries[0].Id}, 1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "h2", entries[0].Head)
+
+	// the oldest entries above the limit are removed
+	removed, err := fx.TrimJournal(ctx, time.Time{}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	removed, err = fx.TrimJournal(ctx, between, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	entries, err = fx.Journal(ctx, JournalFilter{}, 0)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestIndexStorage_SyncRuns(t *testing.T) {
+	fx, err := createTestIndexStorage(ctx, t.TempDir())
+	require.NoError(t, err)
+	defer fx.Close()
+
+	st := time.Now()
+	for i, id := range []string{"r1", "r2", "r3"} {
+		require.NoError(t, fx.SaveSyncRun(ctx, id, st.Add(time.Duration(i)*time.Second), []byte(id), 2))
+	}
+	runs, err := fx.SyncRuns(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("r3"), []byte("r2")}, runs)
+
+	runs, err = fx.SyncRuns(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("r3")}, runs)
+}
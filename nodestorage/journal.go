@@ -0,0 +1,258 @@
+package nodestorage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/anyproto/any-store/anyenc"
+	"github.com/anyproto/any-store/query"
+)
+
+// Origin is the source of the space change written to the journal
+type Origin string
+
+const (
+	OriginUnknown     Origin = "unknown"
+	OriginHotSync     Origin = "hotSync"
+	OriginColdSync    Origin = "coldSync"
+	OriginClientPush  Origin = "clientPush"
+	OriginDeletionLog Origin = "deletionLog"
+	OriginArchive     Origin = "archive"
+	OriginRebalance   Origin = "rebalance"
+	OriginDebug       Origin = "debug"
+)
+
+const (
+	journalCollName = "journal"
+	spaceIdKey      = "sid"
+	originKey       = "o"
+	prevHashKey     = "ph"
+	prevStatusKey   = "ps"
+)
+
+type originCtxKey struct{}
+
+// WithOrigin marks index changes made with the context, the origin is written to the journal
+func WithOrigin(ctx context.Context, origin Origin) context.Context {
+	return context.WithValue(ctx, originCtxKey{}, origin)
+}
+
+func originFromContext(ctx context.Context, def Origin) Origin {
+	if origin, ok := ctx.Value(originCtxKey{}).(Origin); ok && origin != "" {
+		return origin
+	}
+	return def
+}
+
+// JournalEntry is a head or status transition of the space, both could be changed by one entry
+type JournalEntry struct {
+	// Id grows with the time of the change, it's used as the cursor
+	Id         string      `json:"id"`
+	SpaceId    string      `json:"spaceId"`
+	Time       time.Time   `json:"time"`
+	Origin     Origin      `json:"origin"`
+	PrevHead   string      `json:"prevHead"`
+	Head       string      `json:"head"`
+	PrevStatus SpaceStatus `json:"prevStatus"`
+	Status     SpaceStatus `json:"status"`
+}
+
+// JournalFilter limits the result of IndexStorage.Journal, zero values don't filter
+type JournalFilter struct {
+	SpaceId string
+	From    time.Time
+	// To is exclusive
+	To      time.Time
+	AfterId string
+}
+
+// journalChange is the space state before and after the modification
+type journalChange struct {
+	spaceId    string
+	prevHead   string
+	head       string
+	prevStatus SpaceStatus
+	status     SpaceStatus
+}
+
+func newJournalChange(spaceId string, v *anyenc.Value) *journalChange {
+	return &journalChange{
+		spaceId:    spaceId,
+		prevHead:   v.GetString(newHashKey),
+		prevStatus: SpaceStatus(v.GetInt(statusKey)),
+	}
+}
+
+// done fills the state after the modification
+func (c *journalChange) done(v *anyenc.Value) {
+	c.head = v.GetString(newHashKey)
+	c.status = SpaceStatus(v.GetInt(statusKey))
+}
+
+func (c *journalChange) changed() bool {
+	return c.prevHead != c.head || c.prevStatus != c.status
+}
+
+// journalId returns the id growing with every call, ids are nanoseconds, so they are sorted by time
+func (d *indexStorage) journalId() string {
+	for {
+		last := d.lastJournalNano.Load()
+		next := time.Now().UnixNano()
+		if next <= last {
+			next = last + 1
+		}
+		if d.lastJournalNano.CompareAndSwap(last, next) {
+			return journalTimeId(next)
+		}
+	}
+}
+
+func journalTimeId(nano int64) string {
+	return fmt.Sprintf("%019d", nano)
+}
+
+// appendJournal writes changed states, it must be called inside the write transaction of the change
+func (d *indexStorage) appendJournal(ctx context.Context, origin Origin, changes ...*journalChange) (err error) {
+	a := d.arenaPool.Get()
+	defer d.arenaPool.Put(a)
+	for _, c := range changes {
+		if c == nil || !c.changed() {
+			continue
+		}
+		a.Reset()
+		doc := a.NewObject()
+		doc.Set("id", a.NewString(d.journalId()))
+		doc.Set(spaceIdKey, a.NewString(c.spaceId))
+		doc.Set(originKey, a.NewString(string(origin)))
+		doc.Set(prevHashKey, a.NewString(c.prevHead))
+		doc.Set(newHashKey, a.NewString(c.head))
+		doc.Set(prevStatusKey, a.NewNumberInt(int(c.prevStatus)))
+		doc.Set(statusKey, a.NewNumberInt(int(c.status)))
+		if err = d.journalColl.Insert(ctx, doc); err != nil {
+			return fmt.Errorf("append journal: %w", err)
+		}
+	}
+	return
+}
+
+func (d *indexStorage) Journal(ctx context.Context, filter JournalFilter, limit int) (entries []JournalEntry, err error) {
+	a := d.arenaPool.Get()
+	defer d.arenaPool.Put(a)
+
+	var conds query.And
+	if filter.SpaceId != "" {
+		conds = append(conds, query.Key{
+			Path:   []string{spaceIdKey},
+			Filter: query.NewCompValue(query.CompOpEq, a.NewString(filter.SpaceId)),
+		})
+	}
+	if !filter.From.IsZero() {
+		conds = append(conds, query.Key{
+			Path:   []string{"id"},
+			Filter: query.NewCompValue(query.CompOpGte, a.NewString(journalTimeId(filter.From.UnixNano()))),
+		})
+	}
+	if !filter.To.IsZero() {
+		conds = append(conds, query.Key{
+			Path:   []string{"id"},
+			Filter: query.NewCompValue(query.CompOpLt, a.NewString(journalTimeId(filter.To.UnixNano()))),
+		})
+	}
+	if filter.AfterId != "" {
+		conds = append(conds, query.Key{
+			Path:   []string{"id"},
+			Filter: query.NewCompValue(query.CompOpGt, a.NewString(filter.AfterId)),
+		})
+	}
+	var q any
+	if len(conds) > 0 {
+		q = conds
+	}
+	iter, err := d.journalColl.Find(q).Sort("id").Limit(uint(limit)).Iter(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = iter.Close()
+	}()
+	for iter.Next() {
+		doc, err := iter.Doc()
+		if err != nil {
+			return nil, err
+		}
+		v := doc.Value()
+		id := v.GetString("id")
+		nano, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid journal id %q: %w", id, err)
+		}
+		entries = append(entries, JournalEntry{
+			Id:         id,
+			SpaceId:    v.GetString(spaceIdKey),
+			Time:       time.Unix(0, nano),
+			Origin:     Origin(v.GetString(originKey)),
+			PrevHead:   v.GetString(prevHashKey),
+			Head:       v.GetString(newHashKey),
+			PrevStatus: SpaceStatus(v.GetInt(prevStatusKey)),
+			Status:     SpaceStatus(v.GetInt(statusKey)),
+		})
+	}
+	return entries, iter.Err()
+}
+
+func (d *indexStorage) HadHead(ctx context.Context, spaceId, head string) (ok bool, err error) {
+	a := d.arenaPool.Get()
+	defer d.arenaPool.Put(a)
+	count, err := d.journalColl.Find(query.And{
+		query.Key{
+			Path:   []string{spaceIdKey},
+			Filter: query.NewCompValue(query.CompOpEq, a.NewString(spaceId)),
+		},
+		query.Key{
+			Path:   []string{newHashKey},
+			Filter: query.NewCompValue(query.CompOpEq, a.NewString(head)),
+		},
+	}).Limit(1).Count(ctx)
+	return count > 0, err
+}
+
+func (d *indexStorage) TrimJournal(ctx context.Context, before time.Time, maxEntries int) (removed int, err error) {
+	tx, err := d.db.WriteTx(ctx)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	ctx = tx.Context()
+
+	if !before.IsZero() {
+		a := d.arenaPool.Get()
+		filter := query.Key{
+			Path:   []string{"id"},
+			Filter: query.NewCompValue(query.CompOpLt, a.NewString(journalTimeId(before.UnixNano()))),
+		}
+		res, dErr := d.journalColl.Find(filter).Delete(ctx)
+		d.arenaPool.Put(a)
+		if dErr != nil {
+			return 0, dErr
+		}
+		removed += res.Modified
+	}
+	if maxEntries > 0 {
+		count, cErr := d.journalColl.Count(ctx)
+		if cErr != nil {
+			return 0, cErr
+		}
+		if count > maxEntries {
+			res, dErr := d.journalColl.Find(nil).Sort("id").Limit(uint(count - maxEntries)).Delete(ctx)
+			if dErr != nil {
+				return 0, dErr
+			}
+			removed += res.Modified
+		}
+	}
+	return removed, tx.Commit()
+}
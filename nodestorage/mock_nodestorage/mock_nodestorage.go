@@ -59,6 +59,20 @@ func (mr *MockNodeStorageMockRecorder) AllSpaceIds() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllSpaceIds", reflect.TypeOf((*MockNodeStorage)(nil).AllSpaceIds))
 }
 
+// BackupStorage mocks base method.
+func (m *MockNodeStorage) BackupStorage(ctx context.Context, id, dir string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BackupStorage", ctx, id, dir)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BackupStorage indicates an expected call of BackupStorage.
+func (mr *MockNodeStorageMockRecorder) BackupStorage(ctx, id, dir any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BackupStorage", reflect.TypeOf((*MockNodeStorage)(nil).BackupStorage), ctx, id, dir)
+}
+
 // CreateSpaceStorage mocks base method.
 func (m *MockNodeStorage) CreateSpaceStorage(ctx context.Context, payload spacestorage.SpaceStorageCreatePayload) (spacestorage.SpaceStorage, error) {
 	m.ctrl.T.Helper()
@@ -283,6 +297,20 @@ func (mr *MockNodeStorageMockRecorder) TryLockAndOpenDb(ctx, spaceId, do any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryLockAndOpenDb", reflect.TypeOf((*MockNodeStorage)(nil).TryLockAndOpenDb), ctx, spaceId, do)
 }
 
+// TryRemove mocks base method.
+func (m *MockNodeStorage) TryRemove(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TryRemove", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TryRemove indicates an expected call of TryRemove.
+func (mr *MockNodeStorageMockRecorder) TryRemove(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TryRemove", reflect.TypeOf((*MockNodeStorage)(nil).TryRemove), id)
+}
+
 // WaitSpaceStorage mocks base method.
 func (m *MockNodeStorage) WaitSpaceStorage(ctx context.Context, id string) (spacestorage.SpaceStorage, error) {
 	m.ctrl.T.Helper()
@@ -322,6 +350,21 @@ func (m *MockIndexStorage) EXPECT() *MockIndexStorageMockRecorder {
 	return m.recorder
 }
 
+// AuditEntries mocks base method.
+func (m *MockIndexStorage) AuditEntries(ctx context.Context) ([]nodestorage.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuditEntries", ctx)
+	ret0, _ := ret[0].([]nodestorage.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuditEntries indicates an expected call of AuditEntries.
+func (mr *MockIndexStorageMockRecorder) AuditEntries(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuditEntries", reflect.TypeOf((*MockIndexStorage)(nil).AuditEntries), ctx)
+}
+
 // Close mocks base method.
 func (m *MockIndexStorage) Close() error {
 	m.ctrl.T.Helper()
@@ -336,6 +379,58 @@ func (mr *MockIndexStorageMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockIndexStorage)(nil).Close))
 }
 
+// DeleteAuditEntries mocks base method.
+func (m *MockIndexStorage) DeleteAuditEntries(ctx context.Context, spaceIds ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range spaceIds {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteAuditEntries", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAuditEntries indicates an expected call of DeleteAuditEntries.
+func (mr *MockIndexStorageMockRecorder) DeleteAuditEntries(ctx any, spaceIds ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, spaceIds...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAuditEntries", reflect.TypeOf((*MockIndexStorage)(nil).DeleteAuditEntries), varargs...)
+}
+
+// DeleteHandoff mocks base method.
+func (m *MockIndexStorage) DeleteHandoff(ctx context.Context, spaceId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteHandoff", ctx, spaceId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteHandoff indicates an expected call of DeleteHandoff.
+func (mr *MockIndexStorageMockRecorder) DeleteHandoff(ctx, spaceId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteHandoff", reflect.TypeOf((*MockIndexStorage)(nil).DeleteHandoff), ctx, spaceId)
+}
+
+// DeleteHotSyncEntries mocks base method.
+func (m *MockIndexStorage) DeleteHotSyncEntries(ctx context.Context, spaceIds ...string) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range spaceIds {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteHotSyncEntries", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteHotSyncEntries indicates an expected call of DeleteHotSyncEntries.
+func (mr *MockIndexStorageMockRecorder) DeleteHotSyncEntries(ctx any, spaceIds ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, spaceIds...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteHotSyncEntries", reflect.TypeOf((*MockIndexStorage)(nil).DeleteHotSyncEntries), varargs...)
+}
+
 // DeletionLogId mocks base method.
 func (m *MockIndexStorage) DeletionLogId(ctx context.Context) (string, error) {
 	m.ctrl.T.Helper()
@@ -351,6 +446,21 @@ func (mr *MockIndexStorageMockRecorder) DeletionLogId(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletionLogId", reflect.TypeOf((*MockIndexStorage)(nil).DeletionLogId), ctx)
 }
 
+// FindInactiveSpaces mocks base method.
+func (m *MockIndexStorage) FindInactiveSpaces(ctx context.Context, olderThan time.Duration, after nodestorage.InactiveSpace, limit int) ([]nodestorage.InactiveSpace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindInactiveSpaces", ctx, olderThan, after, limit)
+	ret0, _ := ret[0].([]nodestorage.InactiveSpace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindInactiveSpaces indicates an expected call of FindInactiveSpaces.
+func (mr *MockIndexStorageMockRecorder) FindInactiveSpaces(ctx, olderThan, after, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindInactiveSpaces", reflect.TypeOf((*MockIndexStorage)(nil).FindInactiveSpaces), ctx, olderThan, after, limit)
+}
+
 // FindOldestInactiveSpace mocks base method.
 func (m *MockIndexStorage) FindOldestInactiveSpace(ctx context.Context, olderThan time.Duration, skip int) (string, error) {
 	m.ctrl.T.Helper()
@@ -381,18 +491,93 @@ func (mr *MockIndexStorageMockRecorder) GetDiffMigrationVersion(ctx any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiffMigrationVersion", reflect.TypeOf((*MockIndexStorage)(nil).GetDiffMigrationVersion), ctx)
 }
 
+// HadHead mocks base method.
+func (m *MockIndexStorage) HadHead(ctx context.Context, spaceId, head string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HadHead", ctx, spaceId, head)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HadHead indicates an expected call of HadHead.
+func (mr *MockIndexStorageMockRecorder) HadHead(ctx, spaceId, head any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HadHead", reflect.TypeOf((*MockIndexStorage)(nil).HadHead), ctx, spaceId, head)
+}
+
+// Handoffs mocks base method.
+func (m *MockIndexStorage) Handoffs(ctx context.Context) ([]nodestorage.HandoffEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Handoffs", ctx)
+	ret0, _ := ret[0].([]nodestorage.HandoffEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Handoffs indicates an expected call of Handoffs.
+func (mr *MockIndexStorageMockRecorder) Handoffs(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Handoffs", reflect.TypeOf((*MockIndexStorage)(nil).Handoffs), ctx)
+}
+
+// HotSyncEntries mocks base method.
+func (m *MockIndexStorage) HotSyncEntries(ctx context.Context) ([]nodestorage.HotSyncEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HotSyncEntries", ctx)
+	ret0, _ := ret[0].([]nodestorage.HotSyncEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HotSyncEntries indicates an expected call of HotSyncEntries.
+func (mr *MockIndexStorageMockRecorder) HotSyncEntries(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HotSyncEntries", reflect.TypeOf((*MockIndexStorage)(nil).HotSyncEntries), ctx)
+}
+
+// Journal mocks base method.
+func (m *MockIndexStorage) Journal(ctx context.Context, filter nodestorage.JournalFilter, limit int) ([]nodestorage.JournalEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Journal", ctx, filter, limit)
+	ret0, _ := ret[0].([]nodestorage.JournalEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Journal indicates an expected call of Journal.
+func (mr *MockIndexStorageMockRecorder) Journal(ctx, filter, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Journal", reflect.TypeOf((*MockIndexStorage)(nil).Journal), ctx, filter, limit)
+}
+
+// ListArchived mocks base method.
+func (m *MockIndexStorage) ListArchived(ctx context.Context, filter nodestorage.ArchivedFilter, afterId string, limit int) ([]nodestorage.SpaceStatusEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListArchived", ctx, filter, afterId, limit)
+	ret0, _ := ret[0].([]nodestorage.SpaceStatusEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListArchived indicates an expected call of ListArchived.
+func (mr *MockIndexStorageMockRecorder) ListArchived(ctx, filter, afterId, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListArchived", reflect.TypeOf((*MockIndexStorage)(nil).ListArchived), ctx, filter, afterId, limit)
+}
+
 // MarkArchived mocks base method.
-func (m *MockIndexStorage) MarkArchived(ctx context.Context, spaceId string, compressedSize, uncompressedSize int64) error {
+func (m *MockIndexStorage) MarkArchived(ctx context.Context, spaceId string, compressedSize, uncompressedSize int64, checksum string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MarkArchived", ctx, spaceId, compressedSize, uncompressedSize)
+	ret := m.ctrl.Call(m, "MarkArchived", ctx, spaceId, compressedSize, uncompressedSize, checksum)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // MarkArchived indicates an expected call of MarkArchived.
-func (mr *MockIndexStorageMockRecorder) MarkArchived(ctx, spaceId, compressedSize, uncompressedSize any) *gomock.Call {
+func (mr *MockIndexStorageMockRecorder) MarkArchived(ctx, spaceId, compressedSize, uncompressedSize, checksum any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkArchived", reflect.TypeOf((*MockIndexStorage)(nil).MarkArchived), ctx, spaceId, compressedSize, uncompressedSize)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkArchived", reflect.TypeOf((*MockIndexStorage)(nil).MarkArchived), ctx, spaceId, compressedSize, uncompressedSize, checksum)
 }
 
 // MarkError mocks base method.
@@ -409,6 +594,20 @@ func (mr *MockIndexStorageMockRecorder) MarkError(ctx, spaceId, errString any) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkError", reflect.TypeOf((*MockIndexStorage)(nil).MarkError), ctx, spaceId, errString)
 }
 
+// ReadChangedHashes mocks base method.
+func (m *MockIndexStorage) ReadChangedHashes(ctx context.Context, since time.Time, iterFunc func(nodestorage.SpaceUpdate, nodestorage.SpaceStatus) (bool, error)) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadChangedHashes", ctx, since, iterFunc)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReadChangedHashes indicates an expected call of ReadChangedHashes.
+func (mr *MockIndexStorageMockRecorder) ReadChangedHashes(ctx, since, iterFunc any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadChangedHashes", reflect.TypeOf((*MockIndexStorage)(nil).ReadChangedHashes), ctx, since, iterFunc)
+}
+
 // ReadHashes mocks base method.
 func (m *MockIndexStorage) ReadHashes(ctx context.Context, iterFunc func(nodestorage.SpaceUpdate) (bool, error)) error {
 	m.ctrl.T.Helper()
@@ -437,6 +636,72 @@ func (mr *MockIndexStorageMockRecorder) RunMigrations(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunMigrations", reflect.TypeOf((*MockIndexStorage)(nil).RunMigrations), ctx)
 }
 
+// SaveAuditEntries mocks base method.
+func (m *MockIndexStorage) SaveAuditEntries(ctx context.Context, entries ...nodestorage.AuditEntry) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range entries {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SaveAuditEntries", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAuditEntries indicates an expected call of SaveAuditEntries.
+func (mr *MockIndexStorageMockRecorder) SaveAuditEntries(ctx any, entries ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, entries...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAuditEntries", reflect.TypeOf((*MockIndexStorage)(nil).SaveAuditEntries), varargs...)
+}
+
+// SaveHandoff mocks base method.
+func (m *MockIndexStorage) SaveHandoff(ctx context.Context, spaceId string, markedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveHandoff", ctx, spaceId, markedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveHandoff indicates an expected call of SaveHandoff.
+func (mr *MockIndexStorageMockRecorder) SaveHandoff(ctx, spaceId, markedAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveHandoff", reflect.TypeOf((*MockIndexStorage)(nil).SaveHandoff), ctx, spaceId, markedAt)
+}
+
+// SaveHotSyncEntries mocks base method.
+func (m *MockIndexStorage) SaveHotSyncEntries(ctx context.Context, entries ...nodestorage.HotSyncEntry) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range entries {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SaveHotSyncEntries", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveHotSyncEntries indicates an expected call of SaveHotSyncEntries.
+func (mr *MockIndexStorageMockRecorder) SaveHotSyncEntries(ctx any, entries ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, entries...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveHotSyncEntries", reflect.TypeOf((*MockIndexStorage)(nil).SaveHotSyncEntries), varargs...)
+}
+
+// SaveSyncRun mocks base method.
+func (m *MockIndexStorage) SaveSyncRun(ctx context.Context, runId string, startedAt time.Time, data []byte, keep int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSyncRun", ctx, runId, startedAt, data, keep)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSyncRun indicates an expected call of SaveSyncRun.
+func (mr *MockIndexStorageMockRecorder) SaveSyncRun(ctx, runId, startedAt, data, keep any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSyncRun", reflect.TypeOf((*MockIndexStorage)(nil).SaveSyncRun), ctx, runId, startedAt, data, keep)
+}
+
 // SetDeletionLogId mocks base method.
 func (m *MockIndexStorage) SetDeletionLogId(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -509,6 +774,36 @@ func (mr *MockIndexStorageMockRecorder) SpaceStatusEntry(ctx, spaceId any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpaceStatusEntry", reflect.TypeOf((*MockIndexStorage)(nil).SpaceStatusEntry), ctx, spaceId)
 }
 
+// SyncRuns mocks base method.
+func (m *MockIndexStorage) SyncRuns(ctx context.Context, limit int) ([][]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncRuns", ctx, limit)
+	ret0, _ := ret[0].([][]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncRuns indicates an expected call of SyncRuns.
+func (mr *MockIndexStorageMockRecorder) SyncRuns(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncRuns", reflect.TypeOf((*MockIndexStorage)(nil).SyncRuns), ctx, limit)
+}
+
+// TrimJournal mocks base method.
+func (m *MockIndexStorage) TrimJournal(ctx context.Context, before time.Time, maxEntries int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrimJournal", ctx, before, maxEntries)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TrimJournal indicates an expected call of TrimJournal.
+func (mr *MockIndexStorageMockRecorder) TrimJournal(ctx, before, maxEntries any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrimJournal", reflect.TypeOf((*MockIndexStorage)(nil).TrimJournal), ctx, before, maxEntries)
+}
+
 // UpdateHash mocks base method.
 func (m *MockIndexStorage) UpdateHash(ctx context.Context, updates ...nodestorage.SpaceUpdate) error {
 	m.ctrl.T.Helper()
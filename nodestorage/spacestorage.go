@@ -2,10 +2,13 @@ package nodestorage
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/akrylysov/pogreb"
 	"github.com/anyproto/any-sync/app/logger"
+	"github.com/anyproto/any-sync/commonspace/object/tree/objecttree"
+	"github.com/anyproto/any-sync/commonspace/object/tree/treestorage"
 	"github.com/anyproto/any-sync/commonspace/spacestorage"
 )
 
@@ -67,26 +70,87 @@ type NodeStorageStats interface {
 
 type nodeStorage struct {
 	spacestorage.SpaceStorage
-	cont     *storageContainer
-	observer hashObserver
+	cont        *storageContainer
+	writeOrigin func(ctx context.Context) Origin
+	observer    hashObserver
+	// origin is the origin of the first tree write after the last hash change
+	origin Origin
+	mu     sync.Mutex
 }
 
 func (st *nodeStorage) OnHashChange(oldHash, newHash string) {
-	st.observer(st.Id(), oldHash, newHash)
+	st.mu.Lock()
+	origin := st.origin
+	st.origin = ""
+	st.mu.Unlock()
+	if origin == "" {
+		origin = OriginUnknown
+	}
+	st.observer(st.Id(), oldHash, newHash, origin)
 }
 
-type hashObserver = func(spaceId, oldHash, newHash string)
+type hashObserver = func(spaceId, oldHash, newHash string, origin Origin)
 
-func newNodeStorage(spaceStorage spacestorage.SpaceStorage, cont *storageContainer, observer hashObserver) *nodeStorage {
+func newNodeStorage(spaceStorage spacestorage.SpaceStorage, cont *storageContainer, writeOrigin func(ctx context.Context) Origin, observer hashObserver) *nodeStorage {
 	st := &nodeStorage{
 		SpaceStorage: spaceStorage,
 		cont:         cont,
+		writeOrigin:  writeOrigin,
 		observer:     observer,
 	}
 	st.StateStorage().SetObserver(st)
 	return st
 }
 
+// setOrigin remembers the origin of the write, the hash changes asynchronously and its observer has no context
+func (st *nodeStorage) setOrigin(ctx context.Context) {
+	origin := st.writeOrigin(ctx)
+	st.mu.Lock()
+	if st.origin == "" {
+		st.origin = origin
+	}
+	st.mu.Unlock()
+}
+
+func (st *nodeStorage) TreeStorage(ctx context.Context, id string) (objecttree.Storage, error) {
+	return st.wrapTree(st.SpaceStorage.TreeStorage(ctx, id))
+}
+
+func (st *nodeStorage) CreateTreeStorage(ctx context.Context, payload treestorage.TreeStorageCreatePayload) (objecttree.Storage, error) {
+	ts, err := st.SpaceStorage.CreateTreeStorage(ctx, payload)
+	if err == nil {
+		st.setOrigin(ctx)
+	}
+	return st.wrapTree(ts, err)
+}
+
+func (st *nodeStorage) CreateStorageWithDeferredCreation(ctx context.Context, payload treestorage.TreeStorageCreatePayload) (objecttree.Storage, error) {
+	return st.wrapTree(st.SpaceStorage.CreateStorageWithDeferredCreation(ctx, payload))
+}
+
+func (st *nodeStorage) wrapTree(ts objecttree.Storage, err error) (objecttree.Storage, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &treeStorage{Storage: ts, st: st}, nil
+}
+
+// treeStorage passes the origin of tree writes to the space storage
+type treeStorage struct {
+	objecttree.Storage
+	st *nodeStorage
+}
+
+func (ts *treeStorage) AddAll(ctx context.Context, changes []objecttree.StorageChange, heads []string, commonSnapshot string) error {
+	ts.st.setOrigin(ctx)
+	return ts.Storage.AddAll(ctx, changes, heads, commonSnapshot)
+}
+
+func (ts *treeStorage) AddAllNoError(ctx context.Context, changes []objecttree.StorageChange, heads []string, commonSnapshot string) error {
+	ts.st.setOrigin(ctx)
+	return ts.Storage.AddAllNoError(ctx, changes, heads, commonSnapshot)
+}
+
 func (st *nodeStorage) Close(ctx context.Context) (err error) {
 	defer st.cont.Release()
 	return st.SpaceStorage.Close(ctx)
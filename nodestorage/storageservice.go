@@ -21,12 +21,21 @@ import (
 	"github.com/anyproto/any-sync/commonspace/object/acl/recordverifier"
 	"github.com/anyproto/any-sync/commonspace/spacestorage"
 	"github.com/anyproto/any-sync/metric"
+	"github.com/anyproto/any-sync/net/peer"
+	"github.com/anyproto/any-sync/nodeconf"
+	"github.com/anyproto/any-sync/util/periodicsync"
 	"github.com/anyproto/any-sync/util/slice"
 	"go.uber.org/zap"
 )
 
 const CName = spacestorage.CName
 
+const (
+	defaultJournalRetentionDays = 30
+	defaultJournalMaxEntries    = 1000000
+	journalTrimIntervalSeconds  = 3600
+)
+
 var (
 	ErrClosed                  = errors.New("space storage closed")
 	ErrLocked                  = errors.New("space storage locked")
@@ -70,12 +79,17 @@ type NodeStorage interface {
 	TryLockAndDo(ctx context.Context, spaceId string, do DoFunc) (err error)
 	TryLockAndOpenDb(ctx context.Context, spaceId string, do DoAfterOpenFunc) (err error)
 	DumpStorage(ctx context.Context, id string, do func(path string) error) (err error)
+	// BackupStorage writes a consistent copy of the space store to dir, the copy stays until the caller removes it
+	BackupStorage(ctx context.Context, id string, dir string) (err error)
 	AllSpaceIds() (ids []string, err error)
 	OnDeleteStorage(onDelete func(ctx context.Context, spaceId string))
+	// OnWriteHash sets the callback called after the hash is written to the index, see ChangedAtFromContext
 	OnWriteHash(onWrite func(ctx context.Context, spaceId, oldHash, newHash string))
 	StoreDir(spaceId string) (path string)
 	DeleteSpaceStorage(ctx context.Context, spaceId string) error
 	ForceRemove(id string) (err error)
+	// TryRemove closes the cached storage of the space when nobody uses it, returns ErrLocked otherwise
+	TryRemove(id string) (err error)
 	GetStats(ctx context.Context, id string, treeTop int) (spaceStats SpaceStats, err error)
 }
 
@@ -104,6 +118,9 @@ type storageService struct {
 	updater         *spaceUpdater
 	onWriteHash     func(ctx context.Context, spaceId, oldHash, newHash string)
 	onDeleteStorage func(ctx context.Context, spaceId string)
+	nodeconf        nodeconf.NodeConf
+	journalConf     JournalConfig
+	journalTrim     periodicsync.PeriodicSync
 	currentSpaces   map[string]*storageContainer
 	mu              sync.Mutex
 	statService     debugstat.StatService
@@ -117,16 +134,29 @@ func (s *storageService) Init(a *app.App) (err error) {
 		if s.indexStorage == nil {
 			return
 		}
+		changedAt := time.Now()
+		for i := range updates {
+			updates[i].ChangedAt = changedAt
+		}
 		if err := s.indexStorage.UpdateHash(context.Background(), updates...); err != nil {
 			log.Error("failed to update hashes", zap.Error(err))
 		}
 		if s.onWriteHash != nil {
+			ctx := withChangedAt(context.Background(), changedAt)
 			for _, update := range updates {
-				s.onWriteHash(context.Background(), update.SpaceId, update.OldHash, update.NewHash)
+				s.onWriteHash(ctx, update.SpaceId, update.OldHash, update.NewHash)
 			}
 		}
 	})
 	s.rootPath = cfg.AnyStorePath
+	s.journalConf = cfg.Journal
+	if s.journalConf.RetentionDays <= 0 {
+		s.journalConf.RetentionDays = defaultJournalRetentionDays
+	}
+	if s.journalConf.MaxEntries <= 0 {
+		s.journalConf.MaxEntries = defaultJournalMaxEntries
+	}
+	s.journalTrim = periodicsync.NewPeriodicSync(journalTrimIntervalSeconds, time.Minute, s.trimJournal, log)
 	if _, err = os.Stat(s.rootPath); err != nil {
 		err = os.MkdirAll(s.rootPath, 0755)
 		if err != nil {
@@ -138,6 +168,9 @@ func (s *storageService) Init(a *app.App) (err error) {
 		comp = debugstat.NewNoOp()
 	}
 	s.statService = comp
+	if nc, ok := a.Component(nodeconf.CName).(nodeconf.NodeConf); ok {
+		s.nodeconf = nc
+	}
 	s.statService.AddProvider(s)
 	s.cache = ocache.New(s.loadFunc,
 		ocache.WithLogger(log.Sugar()),
@@ -166,6 +199,7 @@ func (s *storageService) Run(ctx context.Context) (err error) {
 		log.Error("failed to run migrations", zap.Error(err))
 		return err
 	}
+	s.journalTrim.Run()
 	allIds, err := s.AllSpaceIds()
 	if err != nil {
 		log.Error("failed to get all space ids", zap.Error(err))
@@ -224,15 +258,43 @@ func (s *storageService) StatType() string {
 	return CName
 }
 
-func (s *storageService) onHashChange(spaceId, oldHash, newHash string) {
+func (s *storageService) onHashChange(spaceId, oldHash, newHash string, origin Origin) {
 	_ = s.updater.Add(SpaceUpdate{
 		SpaceId: spaceId,
 		OldHash: oldHash,
 		NewHash: newHash,
 		Updated: time.Now(),
+		Origin:  origin,
 	})
 }
 
+// writeOrigin tells where the tree write comes from: the origin of the context or the type of the syncing peer
+func (s *storageService) writeOrigin(ctx context.Context) Origin {
+	if origin := originFromContext(ctx, ""); origin != "" {
+		return origin
+	}
+	peerId, err := peer.CtxPeerId(ctx)
+	if err != nil {
+		return OriginUnknown
+	}
+	if s.nodeconf != nil && len(s.nodeconf.NodeTypes(peerId)) != 0 {
+		return OriginHotSync
+	}
+	return OriginClientPush
+}
+
+func (s *storageService) trimJournal(ctx context.Context) (err error) {
+	before := time.Now().AddDate(0, 0, -s.journalConf.RetentionDays)
+	removed, err := s.indexStorage.TrimJournal(ctx, before, s.journalConf.MaxEntries)
+	if err != nil {
+		return
+	}
+	if removed > 0 {
+		log.Info("space journal trimmed", zap.Int("removed", removed))
+	}
+	return
+}
+
 func (s *storageService) IndexStorage() IndexStorage {
 	return s.indexStorage
 }
@@ -344,6 +406,10 @@ func (s *storageService) checkStatus(ctx context.Context, spaceId string) (err e
 	switch status {
 	case SpaceStatusOk:
 		return nil
+	case SpaceStatusNotResponsible:
+		// the space is kept until new owners have it and they may download it from this node,
+		// clients are rejected by the responsibility check of the space service
+		return nil
 	case SpaceStatusRemove, SpaceStatusRemovePrepare:
 		return spacestorage.ErrSpaceStorageMissing
 	case SpaceStatusArchived:
@@ -372,7 +438,7 @@ func (s *storageService) WaitSpaceStorage(ctx context.Context, id string) (space
 		cont.Release()
 		return nil, err
 	}
-	return newNodeStorage(st, cont, s.onHashChange), nil
+	return newNodeStorage(st, cont, s.writeOrigin, s.onHashChange), nil
 }
 
 func (s *storageService) SpaceExists(id string) bool {
@@ -426,7 +492,7 @@ func (s *storageService) CreateSpaceStorage(ctx context.Context, payload spacest
 		cont.Release()
 		return nil, err
 	}
-	return newNodeStorage(st, cont, s.onHashChange), nil
+	return newNodeStorage(st, cont, s.writeOrigin, s.onHashChange), nil
 }
 
 func (s *storageService) GetStats(ctx context.Context, id string, treeTop int) (spaceStats SpaceStats, err error) {
@@ -486,6 +552,20 @@ func (s *storageService) ForceRemove(id string) (err error) {
 	return
 }
 
+func (s *storageService) TryRemove(id string) (err error) {
+	ok, err := s.cache.TryRemove(id)
+	if errors.Is(err, ocache.ErrNotExists) {
+		return nil
+	}
+	if err != nil {
+		return
+	}
+	if !ok {
+		return ErrLocked
+	}
+	return nil
+}
+
 func (s *storageService) TryLockAndDo(ctx context.Context, spaceId string, do DoFunc) (err error) {
 	var called bool
 	ctx = context.WithValue(ctx, doKeyVal, func() error {
@@ -517,25 +597,31 @@ func (s *storageService) TryLockAndOpenDb(ctx context.Context, spaceId string, d
 }
 
 func (s *storageService) DumpStorage(ctx context.Context, id string, do func(path string) error) (err error) {
-	cont, err := s.get(ctx, id)
+	tempDir, err := os.MkdirTemp("", id)
 	if err != nil {
 		return err
 	}
-	db, err := cont.Acquire()
+	defer os.RemoveAll(tempDir)
+	if err = s.BackupStorage(ctx, id, tempDir); err != nil {
+		return
+	}
+	return do(tempDir)
+}
+
+func (s *storageService) BackupStorage(ctx context.Context, id string, dir string) (err error) {
+	cont, err := s.get(ctx, id)
 	if err != nil {
 		return err
 	}
-	defer cont.Release()
-	tempDir, err := os.MkdirTemp("", id)
+	db, err := cont.Acquire()
 	if err != nil {
 		return err
 	}
-	defer os.RemoveAll(tempDir)
-	err = db.Backup(ctx, filepath.Join(tempDir, "store.db"))
-	if err != nil {
+	defer cont.Release()
+	if err = os.MkdirAll(dir, 0755); err != nil {
 		return
 	}
-	return do(tempDir)
+	return db.Backup(ctx, filepath.Join(dir, "store.db"))
 }
 
 func (s *storageService) DeleteSpaceStorage(ctx context.Context, spaceId string) error {
@@ -581,6 +667,9 @@ func (s *storageService) Close(ctx context.Context) (err error) {
 	if err != nil {
 		log.Error("failed to close updater", zap.Error(err))
 	}
+	if s.journalTrim != nil {
+		s.journalTrim.Close()
+	}
 	if s.indexStorage != nil {
 		return s.indexStorage.Close()
 	}
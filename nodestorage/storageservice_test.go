@@ -17,6 +17,9 @@ import (
 	anystore "github.com/anyproto/any-store"
 	"github.com/anyproto/any-sync/app"
 	"github.com/anyproto/any-sync/commonspace/spacestorage"
+	"github.com/anyproto/any-sync/net/peer"
+	"github.com/anyproto/any-sync/nodeconf"
+	"github.com/anyproto/any-sync/nodeconf/mock_nodeconf"
 	"github.com/anyproto/any-sync/testutil/anymock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -144,6 +147,8 @@ func TestStorageService_SpaceStorage(t *testing.T) {
 			err = storage.StateStorage().SetHash(ctx, fmt.Sprint(i), fmt.Sprint(i))
 			require.NoError(t, err)
 		}
+		// hashes are written to the index asynchronously
+		ss.updater.Close()
 		newDir := filepath.Join(dir, "new")
 		entries, err := os.ReadDir(newDir)
 		require.NoError(t, err)
@@ -279,6 +284,20 @@ func TestStorageService_SpaceStorage(t *testing.T) {
 		require.Equal(t, 999, int(stats.Storage.ChangeSize.Avg))
 		require.Equal(t, 1000285, stats.Storage.ChangeSize.Total)
 	})
+	t.Run("not responsible", func(t *testing.T) {
+		ss := newStorageService(t)
+		defer ss.Close(ctx)
+		st := GenStorage(t, ss, 1, 10)
+		spaceId := st.Id()
+		require.NoError(t, st.Close(ctx))
+		require.NoError(t, ss.ForceRemove(spaceId))
+		require.NoError(t, ss.IndexStorage().SetSpaceStatus(ctx, spaceId, SpaceStatusNotResponsible, ""))
+
+		// the handed off space is readable until it's deleted
+		store, err := ss.WaitSpaceStorage(ctx, spaceId)
+		require.NoError(t, err)
+		require.NoError(t, store.Close(ctx))
+	})
 	t.Run("restore", func(t *testing.T) {
 		ss := newStorageService(t)
 		defer ss.Close(ctx)
@@ -300,7 +319,7 @@ func TestStorageService_SpaceStorage(t *testing.T) {
 		require.NoError(t, os.RemoveAll(spacePath))
 
 		require.NoError(t, ss.IndexStorage().SetSpaceStatus(ctx, spaceId, SpaceStatusOk, ""))
-		require.NoError(t, ss.IndexStorage().MarkArchived(ctx, spaceId, 1, 2))
+		require.NoError(t, ss.IndexStorage().MarkArchived(ctx, spaceId, 1, 2, ""))
 
 		ss.archive.(*mock_archive.MockArchive).EXPECT().Restore(gomock.Any(), spaceId).Do(func(_ context.Context, _ string) error {
 			require.NoError(t, os.MkdirAll(spacePath, 0755))
@@ -314,6 +333,45 @@ func TestStorageService_SpaceStorage(t *testing.T) {
 	})
 }
 
+func TestStorageService_writeOrigin(t *testing.T) {
+	ss := newStorageService(t)
+	defer ss.Close(ctx)
+	nodeConf := mock_nodeconf.NewMockService(gomock.NewController(t))
+	nodeConf.EXPECT().NodeTypes("node").Return([]nodeconf.NodeType{nodeconf.NodeTypeTree}).AnyTimes()
+	nodeConf.EXPECT().NodeTypes("client").Return(nil).AnyTimes()
+	ss.nodeconf = nodeConf
+
+	t.Run("peer type", func(t *testing.T) {
+		assert.Equal(t, OriginHotSync, ss.writeOrigin(peer.CtxWithPeerId(ctx, "node")))
+		assert.Equal(t, OriginClientPush, ss.writeOrigin(peer.CtxWithPeerId(ctx, "client")))
+		assert.Equal(t, OriginUnknown, ss.writeOrigin(ctx))
+	})
+	t.Run("context origin", func(t *testing.T) {
+		assert.Equal(t, OriginDebug, ss.writeOrigin(WithOrigin(peer.CtxWithPeerId(ctx, "node"), OriginDebug)))
+	})
+	t.Run("journal", func(t *testing.T) {
+		payload := NewStorageCreatePayload(t)
+		spaceId := payload.SpaceHeaderWithId.Id
+		store, err := ss.CreateSpaceStorage(ctx, payload)
+		require.NoError(t, err)
+		nodeStore := store.(*nodeStorage)
+		// the first write after the hash change is the origin of the change
+		nodeStore.setOrigin(peer.CtxWithPeerId(ctx, "node"))
+		nodeStore.setOrigin(peer.CtxWithPeerId(ctx, "client"))
+		require.NoError(t, store.StateStorage().SetHash(ctx, "h1", "h1"))
+		assert.Empty(t, nodeStore.origin)
+		require.NoError(t, store.Close(ctx))
+		ss.updater.Close()
+
+		entries, err := ss.IndexStorage().Journal(ctx, JournalFilter{SpaceId: spaceId}, 10)
+		require.NoError(t, err)
+		require.NotEmpty(t, entries)
+		last := entries[len(entries)-1]
+		assert.Equal(t, "h1", last.Head)
+		assert.Equal(t, OriginHotSync, last.Origin)
+	})
+}
+
 func TestStorageService_TryLockAndOpenDb(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		ss := newStorageService(t)
@@ -14,6 +14,10 @@ type SpaceUpdate struct {
 	OldHash string
 	NewHash string
 	Updated time.Time
+	// ChangedAt is the index change time, it's stamped by the storage for the whole batch and filled when hashes are read
+	ChangedAt time.Time
+	// Origin is written to the journal when the head is changed, the origin of the context is used when it's empty
+	Origin Origin
 }
 
 type spaceUpdater struct {
@@ -0,0 +1,407 @@
+//go:generate mockgen -destination mock_auditor/mock_auditor.go github.com/anyproto/any-sync-node/nodesync/auditor Auditor
+package auditor
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+
+	commonaccount "github.com/anyproto/any-sync/accountservice"
+	"github.com/anyproto/any-sync/app"
+	"github.com/anyproto/any-sync/app/logger"
+	"github.com/anyproto/any-sync/commonspace/spacestorage"
+	"github.com/anyproto/any-sync/metric"
+	"github.com/anyproto/any-sync/net/pool"
+	"github.com/anyproto/any-sync/nodeconf"
+	"github.com/anyproto/any-sync/util/periodicsync"
+	"github.com/anyproto/go-chash"
+	"github.com/cespare/xxhash"
+	"go.uber.org/zap"
+	"storj.io/drpc"
+
+	"github.com/anyproto/any-sync-node/nodehead"
+	"github.com/anyproto/any-sync-node/nodestorage"
+	"github.com/anyproto/any-sync-node/nodesync/hotsync"
+	"github.com/anyproto/any-sync-node/nodesync/nodesyncproto"
+)
+
+const CName = "node.nodesync.auditor"
+
+var log = logger.NewNamed(CName)
+
+const (
+	defaultInterval           = 600
+	defaultPartitionsPerRound = 100
+	defaultSpacesPerPartition = 10
+	defaultDivergence         = time.Minute * 30
+)
+
+func New() Auditor {
+	return new(auditor)
+}
+
+// Auditor compares heads of sampled spaces with other responsible nodes,
+// spaces diverged longer than the threshold are queued for hot sync
+type Auditor interface {
+	// Audit runs one round, it's called periodically
+	Audit(ctx context.Context) (err error)
+	Report(ctx context.Context) (report Report, err error)
+	app.ComponentRunnable
+}
+
+type Report struct {
+	Enabled      bool                     `json:"enabled"`
+	LastRound    time.Time                `json:"lastRound"`
+	LastDuration string                   `json:"lastDuration"`
+	Stat         StatSnapshot             `json:"stat"`
+	Diverged     []nodestorage.AuditEntry `json:"diverged"`
+}
+
+type auditor struct {
+	conf               Config
+	nodeconf           nodeconf.Service
+	nodehead           nodehead.NodeHead
+	hotSync            hotsync.HotSync
+	storage            nodestorage.NodeStorage
+	pool               pool.Pool
+	peerId             string
+	partitionsPerRound int
+	spacesPerPartition int
+	divergence         time.Duration
+	periodicSync       periodicsync.PeriodicSync
+	stat               *auditStat
+	now                func() time.Time
+	// remoteRanges sends the partition sync request to the peer
+	remoteRanges func(ctx context.Context, peerId string, req *nodesyncproto.PartitionSyncRequest) (resp *nodesyncproto.PartitionSyncResponse, err error)
+
+	auditMu sync.Mutex
+	// nextPart is the partition the next round starts from
+	nextPart int
+
+	mu           sync.Mutex
+	lastRound    time.Time
+	lastDuration time.Duration
+}
+
+func (au *auditor) Init(a *app.App) (err error) {
+	au.conf = a.MustComponent("config").(configGetter).GetAuditor()
+	au.nodeconf = a.MustComponent(nodeconf.CName).(nodeconf.Service)
+	au.nodehead = a.MustComponent(nodehead.CName).(nodehead.NodeHead)
+	au.hotSync = a.MustComponent(hotsync.CName).(hotsync.HotSync)
+	au.storage = a.MustComponent(spacestorage.CName).(nodestorage.NodeStorage)
+	au.pool = a.MustComponent(pool.CName).(pool.Pool)
+	au.peerId = a.MustComponent(commonaccount.CName).(commonaccount.Service).Account().PeerId
+	au.partitionsPerRound = au.conf.PartitionsPerRound
+	if au.partitionsPerRound <= 0 {
+		au.partitionsPerRound = defaultPartitionsPerRound
+	}
+	au.spacesPerPartition = au.conf.SpacesPerPartition
+	if au.spacesPerPartition <= 0 {
+		au.spacesPerPartition = defaultSpacesPerPartition
+	}
+	au.divergence = time.Duration(au.conf.DivergenceMinutes) * time.Minute
+	if au.divergence <= 0 {
+		au.divergence = defaultDivergence
+	}
+	interval := au.conf.IntervalSeconds
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	au.stat = &auditStat{}
+	if m := a.Component(metric.CName); m != nil {
+		registerMetric(au.stat, m.(metric.Metric).Registry())
+	}
+	au.now = time.Now
+	au.remoteRanges = au.fetchRanges
+	au.periodicSync = periodicsync.NewPeriodicSync(interval, 0, au.Audit, log)
+	return
+}
+
+func (au *auditor) Name() (name string) {
+	return CName
+}
+
+func (au *auditor) Run(ctx context.Context) (err error) {
+	if au.conf.Enabled {
+		au.periodicSync.Run()
+	}
+	return
+}
+
+func (au *auditor) Audit(ctx context.Context) (err error) {
+	au.auditMu.Lock()
+	defer au.auditMu.Unlock()
+	start := au.now()
+
+	index := au.storage.IndexStorage()
+	entries, err := index.AuditEntries(ctx)
+	if err != nil {
+		return
+	}
+	known := make(map[string]nodestorage.AuditEntry, len(entries))
+	// diverged spaces are checked every round until their replicas agree
+	knownByPart := make(map[int][]string)
+	for _, entry := range entries {
+		known[entry.SpaceId] = entry
+		partId := au.nodeconf.Partition(entry.SpaceId)
+		knownByPart[partId] = append(knownByPart[partId], entry.SpaceId)
+	}
+
+	parts, err := au.roundParts(knownByPart)
+	if err != nil {
+		return
+	}
+	var (
+		save    []nodestorage.AuditEntry
+		resolve []string
+		enqueue []string
+	)
+	for _, partId := range parts {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		heads, peers, err := au.auditPart(ctx, partId, knownByPart[partId])
+		if err != nil {
+			return err
+		}
+		for spaceId, head := range heads {
+			entry, isKnown := known[spaceId]
+			if head.local == "" || len(head.diverged) == 0 && len(head.checked) == 0 {
+				// the space is removed locally or no peer answered
+				if isKnown && head.local == "" {
+					resolve = append(resolve, spaceId)
+				}
+				continue
+			}
+			au.stat.checked.Add(1)
+			if len(head.diverged) == 0 {
+				if isKnown {
+					au.stat.resolved.Add(1)
+					resolve = append(resolve, spaceId)
+					log.Info("space heads are consistent again", zap.String("spaceId", spaceId))
+				}
+				continue
+			}
+			if !isKnown {
+				au.stat.diverged.Add(1)
+				entry = nodestorage.AuditEntry{SpaceId: spaceId, DivergedSince: start}
+				log.Info("space heads are diverged", zap.String("spaceId", spaceId), zap.Strings("peers", head.diverged), zap.Int("replicas", len(peers)))
+			}
+			entry.Head, entry.Peers, entry.CheckedAt = head.local, head.diverged, start
+			if start.Sub(entry.DivergedSince) >= au.divergence {
+				if entry.FlaggedAt.IsZero() {
+					au.stat.flagged.Add(1)
+					entry.FlaggedAt = start
+					log.Warn("space heads are diverged longer than the threshold", zap.String("spaceId", spaceId), zap.Time("since", entry.DivergedSince), zap.Strings("peers", head.diverged))
+				}
+				enqueue = append(enqueue, spaceId)
+			}
+			save = append(save, entry)
+		}
+	}
+	if len(save) > 0 {
+		if err = index.SaveAuditEntries(ctx, save...); err != nil {
+			return
+		}
+	}
+	if len(resolve) > 0 {
+		if err = index.DeleteAuditEntries(ctx, resolve...); err != nil {
+			return
+		}
+	}
+	if len(enqueue) > 0 {
+		slices.Sort(enqueue)
+		au.hotSync.Enqueue(hotsync.PriorityDivergence, enqueue...)
+	}
+	for _, entry := range save {
+		known[entry.SpaceId] = entry
+	}
+	for _, spaceId := range resolve {
+		delete(known, spaceId)
+	}
+	au.stat.current.Store(int64(len(known)))
+
+	duration := au.now().Sub(start)
+	au.mu.Lock()
+	au.lastRound, au.lastDuration = start, duration
+	au.mu.Unlock()
+	log.Info("audit round is done", zap.Int("partitions", len(parts)), zap.Int("diverged", len(save)), zap.Int("resolved", len(resolve)), zap.Int("queued", len(enqueue)), zap.Duration("dur", duration))
+	return nil
+}
+
+// roundParts returns partitions of this round: the next partitions the node is responsible for
+// and partitions of already diverged spaces
+func (au *auditor) roundParts(knownByPart map[int][]string) (parts []int, err error) {
+	ch := au.nodeconf.CHash()
+	count := ch.PartitionCount()
+	if count == 0 {
+		return
+	}
+	if au.nextPart >= count {
+		au.nextPart = 0
+	}
+	var (
+		selected = make(map[int]struct{})
+		partId   = au.nextPart
+	)
+	for range count {
+		if len(selected) >= au.partitionsPerRound {
+			break
+		}
+		memb, err := ch.GetPartitionMembers(partId)
+		if err != nil {
+			return nil, err
+		}
+		if slices.ContainsFunc(memb, func(m chash.Member) bool { return m.Id() == au.peerId }) {
+			selected[partId] = struct{}{}
+		}
+		partId = (partId + 1) % count
+	}
+	au.nextPart = partId
+	for partId = range knownByPart {
+		selected[partId] = struct{}{}
+	}
+	for partId = range selected {
+		parts = append(parts, partId)
+	}
+	slices.Sort(parts)
+	return
+}
+
+type spaceHeads struct {
+	local string
+	// checked are peers that have the same head, diverged are peers with the different or missing head
+	checked, diverged []string
+}
+
+// auditPart compares heads of sampled spaces of the partition with other members of the partition
+func (au *auditor) auditPart(ctx context.Context, partId int, known []string) (heads map[string]*spaceHeads, peers []string, err error) {
+	memb, err := au.nodeconf.CHash().GetPartitionMembers(partId)
+	if err != nil {
+		return
+	}
+	for _, m := range memb {
+		if m.Id() != au.peerId {
+			peers = append(peers, m.Id())
+		}
+	}
+
+	ld := au.nodehead.LDiff(partId)
+	elements := ld.Elements()
+	heads = make(map[string]*spaceHeads)
+	for _, i := range sample(len(elements), au.spacesPerPartition) {
+		heads[elements[i].Id] = &spaceHeads{local: elements[i].Head}
+	}
+	for _, spaceId := range known {
+		if _, ok := heads[spaceId]; ok {
+			continue
+		}
+		h := &spaceHeads{}
+		if el, elErr := ld.Element(spaceId); elErr == nil {
+			h.local = el.Head
+		}
+		heads[spaceId] = h
+	}
+
+	var spaceIds []string
+	for spaceId, h := range heads {
+		if h.local != "" {
+			spaceIds = append(spaceIds, spaceId)
+		}
+	}
+	if len(spaceIds) == 0 || len(peers) == 0 {
+		return
+	}
+	slices.Sort(spaceIds)
+	req := &nodesyncproto.PartitionSyncRequest{
+		PartitionId: uint64(partId),
+		Ranges:      elementRanges(spaceIds),
+	}
+	for _, peerId := range peers {
+		resp, rErr := au.remoteRanges(ctx, peerId, req)
+		if rErr != nil {
+			au.stat.peerErrors.Add(1)
+			log.Info("can't get heads from the peer", zap.String("peerId", peerId), zap.Int("partId", partId), zap.Error(rErr))
+			continue
+		}
+		remote := make(map[string]string)
+		for _, res := range resp.Results {
+			for _, el := range res.Elements {
+				remote[el.Id] = el.Head
+			}
+		}
+		for _, spaceId := range spaceIds {
+			h := heads[spaceId]
+			if remote[spaceId] == h.local {
+				h.checked = append(h.checked, peerId)
+			} else {
+				h.diverged = append(h.diverged, peerId)
+			}
+		}
+	}
+	return
+}
+
+// elementRanges makes ranges that contain only given spaces, ldiff keeps elements ordered by the hash of the id
+func elementRanges(spaceIds []string) (ranges []*nodesyncproto.PartitionSyncRange) {
+	ranges = make([]*nodesyncproto.PartitionSyncRange, len(spaceIds))
+	for i, spaceId := range spaceIds {
+		hash := xxhash.Sum64([]byte(spaceId))
+		ranges[i] = &nodesyncproto.PartitionSyncRange{
+			From:     hash,
+			To:       hash,
+			Elements: true,
+		}
+	}
+	return
+}
+
+// sample returns up to n random indexes of the slice with the length l
+func sample(l, n int) []int {
+	if l <= n {
+		idx := make([]int, l)
+		for i := range idx {
+			idx[i] = i
+		}
+		return idx
+	}
+	return rand.Perm(l)[:n]
+}
+
+func (au *auditor) fetchRanges(ctx context.Context, peerId string, req *nodesyncproto.PartitionSyncRequest) (resp *nodesyncproto.PartitionSyncResponse, err error) {
+	pr, err := au.pool.Get(ctx, peerId)
+	if err != nil {
+		return
+	}
+	err = pr.DoDrpc(ctx, func(conn drpc.Conn) error {
+		resp, err = nodesyncproto.NewDRPCNodeSyncClient(conn).PartitionSync(ctx, req)
+		return err
+	})
+	return
+}
+
+func (au *auditor) Report(ctx context.Context) (report Report, err error) {
+	entries, err := au.storage.IndexStorage().AuditEntries(ctx)
+	if err != nil {
+		return
+	}
+	au.mu.Lock()
+	report = Report{
+		Enabled:      au.conf.Enabled,
+		LastRound:    au.lastRound,
+		LastDuration: au.lastDuration.String(),
+	}
+	au.mu.Unlock()
+	report.Stat = au.stat.snapshot()
+	report.Diverged = entries
+	return
+}
+
+func (au *auditor) Close(ctx context.Context) (err error) {
+	if au.conf.Enabled {
+		au.periodicSync.Close()
+	}
+	return
+}
@@ -0,0 +1,171 @@
+package auditor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/anyproto/any-sync/app/ldiff"
+	"github.com/anyproto/any-sync/nodeconf/mock_nodeconf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/anyproto/any-sync-node/nodehead/mock_nodehead"
+	"github.com/anyproto/any-sync-node/nodestorage"
+	"github.com/anyproto/any-sync-node/nodestorage/mock_nodestorage"
+	"github.com/anyproto/any-sync-node/nodesync/hotsync"
+	"github.com/anyproto/any-sync-node/nodesync/hotsync/mock_hotsync"
+	"github.com/anyproto/any-sync-node/nodesync/nodesyncproto"
+	"github.com/anyproto/any-sync-node/util/chashtest"
+)
+
+var ctx = context.Background()
+
+func TestAuditor_Audit(t *testing.T) {
+	fx := newFixture(t)
+	fx.nodeConf.EXPECT().CHash().Return(chashtest.New(t, 3, "self", "p1", "p2")).AnyTimes()
+	fx.nodeConf.EXPECT().Partition("s2.x").Return(0).AnyTimes()
+	fx.local.Set(ldiff.Element{Id: "s1.x", Head: "h1"}, ldiff.Element{Id: "s2.x", Head: "h2"})
+	fx.peers["p1"].Set(ldiff.Element{Id: "s1.x", Head: "h1"}, ldiff.Element{Id: "s2.x", Head: "h3"})
+	fx.peers["p2"].Set(ldiff.Element{Id: "s1.x", Head: "h1"}, ldiff.Element{Id: "s2.x", Head: "h2"})
+	fx.nodeHead.EXPECT().LDiff(0).Return(fx.local).AnyTimes()
+	fx.nodeHead.EXPECT().LDiff(gomock.Not(0)).Return(ldiff.New(16, 16)).AnyTimes()
+	start := fx.now
+
+	// the divergence is found and saved
+	fx.index.EXPECT().AuditEntries(ctx).Return(nil, nil)
+	diverged := nodestorage.AuditEntry{SpaceId: "s2.x", Head: "h2", Peers: []string{"p1"}, DivergedSince: start, CheckedAt: start}
+	fx.index.EXPECT().SaveAuditEntries(ctx, diverged)
+	require.NoError(t, fx.Audit(ctx))
+	assert.Equal(t, uint64(2), fx.stat.checked.Load())
+	assert.Equal(t, uint64(1), fx.stat.diverged.Load())
+	assert.Equal(t, int64(1), fx.stat.current.Load())
+
+	// after the threshold the space is flagged and queued, the peer error doesn't resolve it
+	fx.now = start.Add(fx.divergence)
+	fx.failPeer = "p2"
+	fx.index.EXPECT().AuditEntries(ctx).Return([]nodestorage.AuditEntry{diverged}, nil)
+	flagged := diverged
+	flagged.CheckedAt, flagged.FlaggedAt = fx.now, fx.now
+	fx.index.EXPECT().SaveAuditEntries(ctx, flagged)
+	fx.hotSync.EXPECT().Enqueue(hotsync.PriorityDivergence, "s2.x")
+	require.NoError(t, fx.Audit(ctx))
+	assert.Equal(t, uint64(1), fx.stat.flagged.Load())
+	assert.NotZero(t, fx.stat.peerErrors.Load())
+
+	// replicas agree again
+	fx.failPeer = ""
+	fx.peers["p1"].Set(ldiff.Element{Id: "s2.x", Head: "h2"})
+	fx.index.EXPECT().AuditEntries(ctx).Return([]nodestorage.AuditEntry{flagged}, nil)
+	fx.index.EXPECT().DeleteAuditEntries(ctx, "s2.x")
+	require.NoError(t, fx.Audit(ctx))
+	assert.Equal(t, uint64(1), fx.stat.resolved.Load())
+	assert.Equal(t, int64(0), fx.stat.current.Load())
+}
+
+func TestAuditor_roundParts(t *testing.T) {
+	fx := newFixture(t)
+	ch := chashtest.New(t, 3, "self", "p1", "p2", "p3")
+	fx.nodeConf.EXPECT().CHash().Return(ch).AnyTimes()
+	fx.partitionsPerRound = 5
+
+	var seen []int
+	for range 10 {
+		parts, err := fx.roundParts(map[int][]string{29: {"s.x"}})
+		require.NoError(t, err)
+		assert.Contains(t, parts, 29)
+		seen = append(seen, parts...)
+	}
+	for i := 0; i < ch.PartitionCount(); i++ {
+		memb, err := ch.GetPartitionMembers(i)
+		require.NoError(t, err)
+		for _, m := range memb {
+			if m.Id() == "self" {
+				assert.Contains(t, seen, i)
+			}
+		}
+	}
+}
+
+func TestAuditor_Report(t *testing.T) {
+	fx := newFixture(t)
+	fx.stat.flagged.Store(2)
+	entries := []nodestorage.AuditEntry{{SpaceId: "s.x", Head: "h", Peers: []string{"p1"}}}
+	fx.index.EXPECT().AuditEntries(ctx).Return(entries, nil)
+	report, err := fx.Report(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entries, report.Diverged)
+	assert.Equal(t, uint64(2), report.Stat.Flagged)
+}
+
+type fixture struct {
+	*auditor
+	now      time.Time
+	local    ldiff.Diff
+	peers    map[string]ldiff.Diff
+	failPeer string
+	nodeConf *mock_nodeconf.MockService
+	nodeHead *mock_nodehead.MockNodeHead
+	hotSync  *mock_hotsync.MockHotSync
+	storage  *mock_nodestorage.MockNodeStorage
+	index    *mock_nodestorage.MockIndexStorage
+}
+
+func newFixture(t *testing.T) *fixture {
+	ctrl := gomock.NewController(t)
+	fx := &fixture{
+		now:      time.Now(),
+		local:    ldiff.New(16, 16),
+		peers:    map[string]ldiff.Diff{"p1": ldiff.New(16, 16), "p2": ldiff.New(16, 16)},
+		nodeConf: mock_nodeconf.NewMockService(ctrl),
+		nodeHead: mock_nodehead.NewMockNodeHead(ctrl),
+		hotSync:  mock_hotsync.NewMockHotSync(ctrl),
+		storage:  mock_nodestorage.NewMockNodeStorage(ctrl),
+		index:    mock_nodestorage.NewMockIndexStorage(ctrl),
+	}
+	fx.storage.EXPECT().IndexStorage().Return(fx.index).AnyTimes()
+	fx.auditor = &auditor{
+		nodeconf:           fx.nodeConf,
+		nodehead:           fx.nodeHead,
+		hotSync:            fx.hotSync,
+		storage:            fx.storage,
+		peerId:             "self",
+		partitionsPerRound: 1,
+		spacesPerPartition: 10,
+		divergence:         time.Hour,
+		stat:               &auditStat{},
+	}
+	fx.auditor.now = func() time.Time { return fx.now }
+	fx.remoteRanges = fx.serveRanges
+	return fx
+}
+
+// serveRanges answers like the partition sync handler of the peer
+func (fx *fixture) serveRanges(ctx context.Context, peerId string, req *nodesyncproto.PartitionSyncRequest) (*nodesyncproto.PartitionSyncResponse, error) {
+	if peerId == fx.failPeer {
+		return nil, errors.New("peer is unavailable")
+	}
+	ld, ok := fx.peers[peerId]
+	if !ok {
+		ld = ldiff.New(16, 16)
+	}
+	ranges := make([]ldiff.Range, len(req.Ranges))
+	for i, r := range req.Ranges {
+		ranges[i] = ldiff.Range{From: r.From, To: r.To, Elements: r.Elements}
+	}
+	res, err := ld.Ranges(ctx, ranges, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp := &nodesyncproto.PartitionSyncResponse{}
+	for _, r := range res {
+		result := &nodesyncproto.PartitionSyncResult{Hash: r.Hash, Count: uint32(r.Count)}
+		for _, el := range r.Elements {
+			result.Elements = append(result.Elements, &nodesyncproto.PartitionSyncResultElement{Id: el.Id, Head: el.Head})
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
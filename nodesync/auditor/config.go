@@ -0,0 +1,17 @@
+package auditor
+
+type configGetter interface {
+	GetAuditor() Config
+}
+
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+	// IntervalSeconds is the pause between audit rounds, 600 by default
+	IntervalSeconds int `yaml:"intervalSeconds"`
+	// PartitionsPerRound limits partitions checked by one round, the next round continues from the next partition, 100 by default
+	PartitionsPerRound int `yaml:"partitionsPerRound"`
+	// SpacesPerPartition is the number of random spaces compared in every partition, 10 by default
+	SpacesPerPartition int `yaml:"spacesPerPartition"`
+	// DivergenceMinutes is how long heads may differ before the space is flagged and queued for hot sync, 30 by default
+	DivergenceMinutes int `yaml:"divergenceMinutes"`
+}
@@ -0,0 +1,69 @@
+package auditor
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type auditStat struct {
+	checked    atomic.Uint64
+	diverged   atomic.Uint64
+	flagged    atomic.Uint64
+	resolved   atomic.Uint64
+	peerErrors atomic.Uint64
+	current    atomic.Int64
+}
+
+// StatSnapshot is the state of audit counters since the start of the node
+type StatSnapshot struct {
+	Checked        uint64 `json:"checked"`
+	Diverged       uint64 `json:"diverged"`
+	Flagged        uint64 `json:"flagged"`
+	Resolved       uint64 `json:"resolved"`
+	PeerErrors     uint64 `json:"peerErrors"`
+	DivergedSpaces int64  `json:"divergedSpaces"`
+}
+
+func (s *auditStat) snapshot() StatSnapshot {
+	return StatSnapshot{
+		Checked:        s.checked.Load(),
+		Diverged:       s.diverged.Load(),
+		Flagged:        s.flagged.Load(),
+		Resolved:       s.resolved.Load(),
+		PeerErrors:     s.peerErrors.Load(),
+		DivergedSpaces: s.current.Load(),
+	}
+}
+
+func registerMetric(s *auditStat, registry *prometheus.Registry) {
+	counters := []struct {
+		name string
+		help string
+		v    *atomic.Uint64
+	}{
+		{"checked_count", "spaces compared with other replicas", &s.checked},
+		{"diverged_count", "spaces found with different heads", &s.diverged},
+		{"flagged_count", "spaces diverged longer than the threshold", &s.flagged},
+		{"resolved_count", "diverged spaces that became consistent", &s.resolved},
+		{"peer_errors_count", "failed requests to other replicas", &s.peerErrors},
+	}
+	for _, c := range counters {
+		registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: "nodesync",
+			Subsystem: "audit",
+			Name:      c.name,
+			Help:      c.help,
+		}, func() float64 {
+			return float64(c.v.Load())
+		}))
+	}
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "nodesync",
+		Subsystem: "audit",
+		Name:      "diverged_spaces",
+		Help:      "spaces in the divergence report",
+	}, func() float64 {
+		return float64(s.current.Load())
+	}))
+}
@@ -0,0 +1,193 @@
+//go:generate mockgen -destination mock_bandwidth/mock_bandwidth.go github.com/anyproto/any-sync-node/nodesync/bandwidth Bandwidth
+package bandwidth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anyproto/any-sync/app"
+	"github.com/anyproto/any-sync/app/logger"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"github.com/anyproto/any-sync-node/util/ratelimit"
+)
+
+const CName = "node.nodesync.bandwidth"
+
+var log = logger.NewNamed(CName)
+
+// peerIdleTTL is how long limiters of a silent peer are kept
+const peerIdleTTL = time.Minute * 10
+
+func New() Bandwidth {
+	return new(bandwidth)
+}
+
+// Bandwidth shapes node-to-node sync traffic with global and per-peer limits.
+// Only cold sync streams are shaped, they carry whole stores. Partition diffs and hot sync go through
+// the common space sync which is shared with clients; their messages are small and delaying them
+// would delay the client-facing sync, so they are not limited.
+type Bandwidth interface {
+	// WaitUpload blocks until n bytes may be sent to the peer
+	WaitUpload(ctx context.Context, peerId string, n int) error
+	// WaitDownload blocks until n bytes may be received from the peer
+	WaitDownload(ctx context.Context, peerId string, n int) error
+	// SetLimits changes limits at runtime, they are not persisted
+	SetLimits(limits Limits)
+	// UpdateLimits changes only the given limits at runtime and returns the result, they are not persisted
+	UpdateLimits(update LimitsUpdate) Limits
+	Status() Status
+	app.Component
+}
+
+type Status struct {
+	Limits            Limits `json:"limits"`
+	MaintenanceWindow Window `json:"maintenanceWindow"`
+	// InWindow tells that the traffic is not limited right now
+	InWindow bool `json:"inWindow"`
+	Peers    int  `json:"peers"`
+}
+
+type peerLimiters struct {
+	upload   *rate.Limiter
+	download *rate.Limiter
+	lastUsed time.Time
+}
+
+type bandwidth struct {
+	window    Window
+	winStart  time.Duration
+	winEnd    time.Duration
+	upload    *rate.Limiter
+	download  *rate.Limiter
+	now       func() time.Time
+	mu        sync.Mutex
+	limits    Limits
+	peers     map[string]*peerLimiters
+	lastPrune time.Time
+}
+
+func (b *bandwidth) Init(a *app.App) (err error) {
+	conf := a.MustComponent("config").(configGetter).GetBandwidth()
+	b.now = time.Now
+	b.peers = make(map[string]*peerLimiters)
+	b.upload = ratelimit.New(0)
+	b.download = ratelimit.New(0)
+	if conf.MaintenanceWindow.Start != "" || conf.MaintenanceWindow.End != "" {
+		if b.winStart, err = parseTimeOfDay(conf.MaintenanceWindow.Start); err != nil {
+			return
+		}
+		if b.winEnd, err = parseTimeOfDay(conf.MaintenanceWindow.End); err != nil {
+			return
+		}
+		b.window = conf.MaintenanceWindow
+	}
+	b.SetLimits(conf.Limits)
+	return
+}
+
+func (b *bandwidth) Name() (name string) {
+	return CName
+}
+
+func (b *bandwidth) SetLimits(limits Limits) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setLimits(limits)
+}
+
+func (b *bandwidth) UpdateLimits(update LimitsUpdate) Limits {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setLimits(update.apply(b.limits))
+	return b.limits
+}
+
+func (b *bandwidth) setLimits(limits Limits) {
+	b.limits = limits
+	ratelimit.SetLimit(b.upload, limits.UploadBytesPerSec)
+	ratelimit.SetLimit(b.download, limits.DownloadBytesPerSec)
+	for _, pl := range b.peers {
+		ratelimit.SetLimit(pl.upload, limits.PeerUploadBytesPerSec)
+		ratelimit.SetLimit(pl.download, limits.PeerDownloadBytesPerSec)
+	}
+	log.Info("bandwidth limits", zap.Int64("upload", limits.UploadBytesPerSec), zap.Int64("download", limits.DownloadBytesPerSec),
+		zap.Int64("peerUpload", limits.PeerUploadBytesPerSec), zap.Int64("peerDownload", limits.PeerDownloadBytesPerSec))
+}
+
+func (b *bandwidth) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return Status{
+		Limits:            b.limits,
+		MaintenanceWindow: b.window,
+		InWindow:          b.inWindow(),
+		Peers:             len(b.peers),
+	}
+}
+
+func (b *bandwidth) WaitUpload(ctx context.Context, peerId string, n int) error {
+	if b.inWindow() {
+		return nil
+	}
+	pl := b.peer(peerId)
+	return ratelimit.WaitBytes(ctx, int64(n), pl.upload, b.upload)
+}
+
+func (b *bandwidth) WaitDownload(ctx context.Context, peerId string, n int) error {
+	if b.inWindow() {
+		return nil
+	}
+	pl := b.peer(peerId)
+	return ratelimit.WaitBytes(ctx, int64(n), pl.download, b.download)
+}
+
+func (b *bandwidth) peer(peerId string) *peerLimiters {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	if now.Sub(b.lastPrune) > time.Minute {
+		b.lastPrune = now
+		for id, pl := range b.peers {
+			if now.Sub(pl.lastUsed) > peerIdleTTL {
+				delete(b.peers, id)
+			}
+		}
+	}
+	pl, ok := b.peers[peerId]
+	if !ok {
+		pl = &peerLimiters{
+			upload:   ratelimit.New(b.limits.PeerUploadBytesPerSec),
+			download: ratelimit.New(b.limits.PeerDownloadBytesPerSec),
+		}
+		b.peers[peerId] = pl
+	}
+	pl.lastUsed = now
+	return pl
+}
+
+// inWindow tells whether the current local time is in the maintenance window
+func (b *bandwidth) inWindow() bool {
+	if b.window.Start == "" || b.winStart == b.winEnd {
+		return false
+	}
+	now := b.now()
+	year, month, day := now.Date()
+	sinceMidnight := now.Sub(time.Date(year, month, day, 0, 0, 0, 0, now.Location()))
+	if b.winStart < b.winEnd {
+		return sinceMidnight >= b.winStart && sinceMidnight < b.winEnd
+	}
+	// the window crosses midnight
+	return sinceMidnight >= b.winStart || sinceMidnight < b.winEnd
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid maintenance window time %q: %w", s, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
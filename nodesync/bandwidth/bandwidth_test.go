@@ -0,0 +1,117 @@
+package bandwidth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anyproto/any-sync/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ctx = context.Background()
+
+func TestBandwidth_Wait(t *testing.T) {
+	b := newFixture(t, Config{Limits: Limits{PeerUploadBytesPerSec: 1000}})
+	// the bucket is full at start
+	require.NoError(t, b.WaitUpload(ctx, "p1", 1000))
+	st := time.Now()
+	require.NoError(t, b.WaitUpload(ctx, "p1", 100))
+	assert.GreaterOrEqual(t, time.Since(st), time.Millisecond*80)
+
+	// other peers have their own limit
+	st = time.Now()
+	require.NoError(t, b.WaitUpload(ctx, "p2", 1000))
+	assert.Less(t, time.Since(st), time.Millisecond*50)
+
+	// the limit is changed for known peers
+	b.SetLimits(Limits{})
+	st = time.Now()
+	require.NoError(t, b.WaitUpload(ctx, "p1", 10000))
+	assert.Less(t, time.Since(st), time.Millisecond*50)
+	assert.Equal(t, 2, b.Status().Peers)
+}
+
+func TestBandwidth_UpdateLimits(t *testing.T) {
+	b := newFixture(t, Config{Limits: Limits{UploadBytesPerSec: 100, PeerDownloadBytesPerSec: 200}})
+	download := int64(300)
+	limits := b.UpdateLimits(LimitsUpdate{DownloadBytesPerSec: &download})
+	assert.Equal(t, Limits{UploadBytesPerSec: 100, DownloadBytesPerSec: 300, PeerDownloadBytesPerSec: 200}, limits)
+
+	unlimited := int64(0)
+	b.UpdateLimits(LimitsUpdate{UploadBytesPerSec: &unlimited})
+	assert.Equal(t, Limits{DownloadBytesPerSec: 300, PeerDownloadBytesPerSec: 200}, b.Status().Limits)
+}
+
+func TestBandwidth_Canceled(t *testing.T) {
+	b := newFixture(t, Config{Limits: Limits{DownloadBytesPerSec: 10}})
+	require.NoError(t, b.WaitDownload(ctx, "p1", 10))
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+	assert.Error(t, b.WaitDownload(cctx, "p1", 100))
+}
+
+func TestBandwidth_MaintenanceWindow(t *testing.T) {
+	at := func(hour, minute int) func() time.Time {
+		return func() time.Time {
+			return time.Date(2024, 1, 1, hour, minute, 0, 0, time.Local)
+		}
+	}
+	t.Run("night", func(t *testing.T) {
+		b := newFixture(t, Config{MaintenanceWindow: Window{Start: "23:00", End: "06:00"}})
+		for _, tc := range []struct {
+			hour, minute int
+			in           bool
+		}{{23, 0, true}, {2, 30, true}, {5, 59, true}, {6, 0, false}, {12, 0, false}, {22, 59, false}} {
+			b.now = at(tc.hour, tc.minute)
+			assert.Equal(t, tc.in, b.Status().InWindow, "%02d:%02d", tc.hour, tc.minute)
+		}
+	})
+	t.Run("day", func(t *testing.T) {
+		b := newFixture(t, Config{MaintenanceWindow: Window{Start: "01:00", End: "03:00"}})
+		b.now = at(2, 0)
+		assert.True(t, b.Status().InWindow)
+		b.now = at(3, 0)
+		assert.False(t, b.Status().InWindow)
+	})
+	t.Run("full speed", func(t *testing.T) {
+		b := newFixture(t, Config{Limits: Limits{UploadBytesPerSec: 10}, MaintenanceWindow: Window{Start: "00:00", End: "23:59"}})
+		b.now = at(12, 0)
+		st := time.Now()
+		require.NoError(t, b.WaitUpload(ctx, "p1", 1000))
+		assert.Less(t, time.Since(st), time.Millisecond*50)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		a := new(app.App)
+		a.Register(testConfig{Config{MaintenanceWindow: Window{Start: "25:00", End: "06:00"}}}).Register(New())
+		assert.Error(t, a.Start(ctx))
+	})
+}
+
+func newFixture(t *testing.T, conf Config) *bandwidth {
+	b := New()
+	a := new(app.App)
+	a.Register(testConfig{conf}).Register(b)
+	require.NoError(t, a.Start(ctx))
+	t.Cleanup(func() {
+		require.NoError(t, a.Close(ctx))
+	})
+	return b.(*bandwidth)
+}
+
+type testConfig struct {
+	conf Config
+}
+
+func (c testConfig) Init(a *app.App) (err error) {
+	return nil
+}
+
+func (c testConfig) Name() (name string) {
+	return "config"
+}
+
+func (c testConfig) GetBandwidth() Config {
+	return c.conf
+}
@@ -0,0 +1,50 @@
+package bandwidth
+
+type Config struct {
+	Limits `yaml:",inline"`
+	// MaintenanceWindow is the time of day when limits are lifted, empty means limits apply all the time
+	MaintenanceWindow Window `yaml:"maintenanceWindow"`
+}
+
+// Limits are in bytes per second, 0 means no limit
+type Limits struct {
+	UploadBytesPerSec       int64 `yaml:"uploadBytesPerSec" json:"uploadBytesPerSec"`
+	DownloadBytesPerSec     int64 `yaml:"downloadBytesPerSec" json:"downloadBytesPerSec"`
+	PeerUploadBytesPerSec   int64 `yaml:"peerUploadBytesPerSec" json:"peerUploadBytesPerSec"`
+	PeerDownloadBytesPerSec int64 `yaml:"peerDownloadBytesPerSec" json:"peerDownloadBytesPerSec"`
+}
+
+// LimitsUpdate changes the limits partially, nil fields keep the current value
+type LimitsUpdate struct {
+	UploadBytesPerSec       *int64 `json:"uploadBytesPerSec"`
+	DownloadBytesPerSec     *int64 `json:"downloadBytesPerSec"`
+	PeerUploadBytesPerSec   *int64 `json:"peerUploadBytesPerSec"`
+	PeerDownloadBytesPerSec *int64 `json:"peerDownloadBytesPerSec"`
+}
+
+func (u LimitsUpdate) apply(l Limits) Limits {
+	for _, f := range []struct {
+		value *int64
+		dst   *int64
+	}{
+		{u.UploadBytesPerSec, &l.UploadBytesPerSec},
+		{u.DownloadBytesPerSec, &l.DownloadBytesPerSec},
+		{u.PeerUploadBytesPerSec, &l.PeerUploadBytesPerSec},
+		{u.PeerDownloadBytesPerSec, &l.PeerDownloadBytesPerSec},
+	} {
+		if f.value != nil {
+			*f.dst = *f.value
+		}
+	}
+	return l
+}
+
+// Window is a daily range of the local time in the "15:04" format, it may cross midnight, e.g. 23:00 - 06:00
+type Window struct {
+	Start string `yaml:"start" json:"start"`
+	End   string `yaml:"end" json:"end"`
+}
+
+type configGetter interface {
+	GetBandwidth() Config
+}
@@ -0,0 +1,139 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/anyproto/any-sync-node/nodesync/bandwidth (interfaces: Bandwidth)
+//
+// Generated by this command:
+//
+//	mockgen -destination mock_bandwidth/mock_bandwidth.go github.com/anyproto/any-sync-node/nodesync/bandwidth Bandwidth
+//
+
+// Package mock_bandwidth is a generated GoMock package.
+package mock_bandwidth
+
+import (
+	context "context"
+	reflect "reflect"
+
+	bandwidth "github.com/anyproto/any-sync-node/nodesync/bandwidth"
+	app "github.com/anyproto/any-sync/app"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBandwidth is a mock of Bandwidth interface.
+type MockBandwidth struct {
+	ctrl     *gomock.Controller
+	recorder *MockBandwidthMockRecorder
+	isgomock struct{}
+}
+
+// MockBandwidthMockRecorder is the mock recorder for MockBandwidth.
+type MockBandwidthMockRecorder struct {
+	mock *MockBandwidth
+}
+
+// NewMockBandwidth creates a new mock instance.
+func NewMockBandwidth(ctrl *gomock.Controller) *MockBandwidth {
+	mock := &MockBandwidth{ctrl: ctrl}
+	mock.recorder = &MockBandwidthMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBandwidth) EXPECT() *MockBandwidthMockRecorder {
+	return m.recorder
+}
+
+// Init mocks base method.
+func (m *MockBandwidth) Init(a *app.App) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Init", a)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Init indicates an expected call of Init.
+func (mr *MockBandwidthMockRecorder) Init(a any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockBandwidth)(nil).Init), a)
+}
+
+// Name mocks base method.
+func (m *MockBandwidth) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockBandwidthMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockBandwidth)(nil).Name))
+}
+
+// SetLimits mocks base method.
+func (m *MockBandwidth) SetLimits(limits bandwidth.Limits) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetLimits", limits)
+}
+
+// SetLimits indicates an expected call of SetLimits.
+func (mr *MockBandwidthMockRecorder) SetLimits(limits any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLimits", reflect.TypeOf((*MockBandwidth)(nil).SetLimits), limits)
+}
+
+// Status mocks base method.
+func (m *MockBandwidth) Status() bandwidth.Status {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Status")
+	ret0, _ := ret[0].(bandwidth.Status)
+	return ret0
+}
+
+// Status indicates an expected call of Status.
+func (mr *MockBandwidthMockRecorder) Status() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockBandwidth)(nil).Status))
+}
+
+// UpdateLimits mocks base method.
+func (m *MockBandwidth) UpdateLimits(update bandwidth.LimitsUpdate) bandwidth.Limits {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLimits", update)
+	ret0, _ := ret[0].(bandwidth.Limits)
+	return ret0
+}
+
+// UpdateLimits indicates an expected call of UpdateLimits.
+func (mr *MockBandwidthMockRecorder) UpdateLimits(update any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLimits", reflect.TypeOf((*MockBandwidth)(nil).UpdateLimits), update)
+}
+
+// WaitDownload mocks base method.
+func (m *MockBandwidth) WaitDownload(ctx context.Context, peerId string, n int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitDownload", ctx, peerId, n)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitDownload indicates an expected call of WaitDownload.
+func (mr *MockBandwidthMockRecorder) WaitDownload(ctx, peerId, n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitDownload", reflect.TypeOf((*MockBandwidth)(nil).WaitDownload), ctx, peerId, n)
+}
+
+// WaitUpload mocks base method.
+func (m *MockBandwidth) WaitUpload(ctx context.Context, peerId string, n int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitUpload", ctx, peerId, n)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitUpload indicates an expected call of WaitUpload.
+func (mr *MockBandwidthMockRecorder) WaitUpload(ctx, peerId, n any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitUpload", reflect.TypeOf((*MockBandwidth)(nil).WaitUpload), ctx, peerId, n)
+}
@@ -0,0 +1,69 @@
+package coldsync
+
+import (
+	"context"
+	"time"
+
+	"github.com/anyproto/any-sync-node/nodesync/nodesyncproto"
+)
+
+// ErrColdSyncBusy is returned to peers when all dump slots are taken and the queue is full
+var ErrColdSyncBusy = nodesyncproto.ErrColdSyncBusy
+
+// admission limits store dumps served to peers: a request takes a free slot or waits in the bounded queue
+type admission struct {
+	slots    chan struct{}
+	maxQueue int32
+	wait     time.Duration
+	stat     *coldSyncStat
+}
+
+func newAdmission(conf Config, stat *coldSyncStat) *admission {
+	if conf.MaxConcurrentDumps <= 0 {
+		conf.MaxConcurrentDumps = 4
+	}
+	if conf.MaxQueuedDumps <= 0 {
+		conf.MaxQueuedDumps = 16
+	}
+	if conf.QueueWaitSeconds <= 0 {
+		conf.QueueWaitSeconds = 60
+	}
+	return &admission{
+		slots:    make(chan struct{}, conf.MaxConcurrentDumps),
+		maxQueue: int32(conf.MaxQueuedDumps),
+		wait:     time.Duration(conf.QueueWaitSeconds) * time.Second,
+		stat:     stat,
+	}
+}
+
+func (a *admission) acquire(ctx context.Context) (err error) {
+	select {
+	case a.slots <- struct{}{}:
+		a.stat.dumpsActive.Add(1)
+		return nil
+	default:
+	}
+	if a.stat.dumpsQueued.Add(1) > a.maxQueue {
+		a.stat.dumpsQueued.Add(-1)
+		a.stat.dumpsRejected.Add(1)
+		return ErrColdSyncBusy
+	}
+	defer a.stat.dumpsQueued.Add(-1)
+	timer := time.NewTimer(a.wait)
+	defer timer.Stop()
+	select {
+	case a.slots <- struct{}{}:
+		a.stat.dumpsActive.Add(1)
+		return nil
+	case <-timer.C:
+		a.stat.dumpsRejected.Add(1)
+		return ErrColdSyncBusy
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *admission) release() {
+	a.stat.dumpsActive.Add(-1)
+	<-a.slots
+}
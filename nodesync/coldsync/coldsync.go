@@ -4,19 +4,27 @@ package coldsync
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 
+	anystore "github.com/anyproto/any-store"
 	"github.com/anyproto/any-sync/app"
 	"github.com/anyproto/any-sync/app/logger"
+	"github.com/anyproto/any-sync/app/ocache"
 	"github.com/anyproto/any-sync/commonspace/spacestorage"
 	"github.com/anyproto/any-sync/commonspace/spacesyncproto"
+	"github.com/anyproto/any-sync/metric"
+	"github.com/anyproto/any-sync/net/peer"
 	"github.com/anyproto/any-sync/net/pool"
+	"github.com/anyproto/any-sync/net/rpc/rpcerr"
 	"go.uber.org/zap"
 	"storj.io/drpc"
 
 	"github.com/anyproto/any-sync-node/nodespace"
 	"github.com/anyproto/any-sync-node/nodestorage"
+	"github.com/anyproto/any-sync-node/nodesync/bandwidth"
 	"github.com/anyproto/any-sync-node/nodesync/nodesyncproto"
 )
 
@@ -42,6 +50,10 @@ func New() ColdSync {
 
 type ColdSync interface {
 	Sync(ctx context.Context, spaceId string, peerId string) (err error)
+	// ForceSync downloads the whole space even if it exists locally and replaces the local store
+	ForceSync(ctx context.Context, spaceId string, peerId string) (err error)
+	// SyncDelta updates the existing local space from the peer with changed database pages only
+	SyncDelta(ctx context.Context, spaceId string, peerId string) (err error)
 	ColdSyncHandle(req *nodesyncproto.ColdSyncRequest, stream nodesyncproto.DRPCNodeSync_ColdSyncStream) error
 	app.Component
 }
@@ -50,12 +62,26 @@ type coldSync struct {
 	pool      pool.Pool
 	storage   nodestorage.NodeStorage
 	nodespace nodespace.Service
+	snapshots *snapshots
+	accept    []*nodesyncproto.ColdSyncCodec
+	admission *admission
+	stat      *coldSyncStat
+	bandwidth bandwidth.Bandwidth
 }
 
 func (c *coldSync) Init(a *app.App) (err error) {
 	c.pool = a.MustComponent(pool.CName).(pool.Pool)
 	c.storage = a.MustComponent(nodestorage.CName).(nodestorage.NodeStorage)
 	c.nodespace = a.MustComponent(nodespace.CName).(nodespace.Service)
+	c.bandwidth = a.MustComponent(bandwidth.CName).(bandwidth.Bandwidth)
+	c.snapshots = newSnapshots(c.storage.StoreDir(".coldsync"))
+	conf := a.MustComponent("config").(configGetter).GetColdSync()
+	c.stat = new(coldSyncStat)
+	c.admission = newAdmission(conf, c.stat)
+	if m := a.Component(metric.CName); m != nil {
+		registerMetric(c.stat, m.(metric.Metric).Registry())
+	}
+	c.accept, err = parseAccept(conf)
 	return
 }
 
@@ -68,29 +94,85 @@ func (c *coldSync) Sync(ctx context.Context, spaceId, peerId string) (err error)
 		if c.storage.SpaceExists(spaceId) {
 			return ErrSpaceExistsLocally
 		}
-		return c.coldSync(ctx, spaceId, peerId)
+		dir, err := c.download(ctx, spaceId, peerId)
+		if err != nil {
+			return err
+		}
+		return os.Rename(dir, c.storage.StoreDir(spaceId))
 	})
 }
 
-func (c *coldSync) coldSync(ctx context.Context, spaceId, peerId string) (err error) {
+// ForceSync downloads the space even if it exists locally, the validated copy replaces the local store.
+// The cached space is evicted first, the store can't be replaced while it's in use
+func (c *coldSync) ForceSync(ctx context.Context, spaceId, peerId string) (err error) {
+	if err = c.nodespace.EvictSpace(ctx, spaceId); err != nil && !errors.Is(err, ocache.ErrNotExists) {
+		return
+	}
+	if err = c.storage.TryRemove(spaceId); err == nil {
+		err = c.storage.TryLockAndOpenDb(ctx, spaceId, func(db anystore.DB) error {
+			dir, err := c.download(ctx, spaceId, peerId)
+			if err != nil {
+				return err
+			}
+			_ = db.Close()
+			if err = c.replaceStore(spaceId, dir); err != nil {
+				_ = os.RemoveAll(dir)
+				return err
+			}
+			return errStoreReplaced
+		})
+	}
+	switch {
+	case errors.Is(err, errStoreReplaced):
+		return nil
+	case errors.Is(err, spacestorage.ErrSpaceStorageMissing):
+		return c.Sync(ctx, spaceId, peerId)
+	case errors.Is(err, nodestorage.ErrLocked):
+		// the storage is used by a request or was opened again after the eviction
+		return fmt.Errorf("%w: the space is in use, retry later", err)
+	default:
+		return err
+	}
+}
+
+// download receives the space store from the peer to the side directory and validates it
+func (c *coldSync) download(ctx context.Context, spaceId, peerId string) (dir string, err error) {
 	p, err := c.pool.GetOneOf(ctx, []string{peerId})
 	if err != nil {
 		return
 	}
-	return p.DoDrpc(ctx, func(conn drpc.Conn) error {
-		stream, err := nodesyncproto.NewDRPCNodeSyncClient(conn).ColdSync(ctx, &nodesyncproto.ColdSyncRequest{
-			SpaceId:      spaceId,
-			ProtocolType: currentReqProtocol,
-		})
+	dir = c.storage.StoreDir("." + spaceId)
+	req := &nodesyncproto.ColdSyncRequest{
+		SpaceId:      spaceId,
+		ProtocolType: currentReqProtocol,
+		Accept:       c.accept,
+	}
+	// files of the interrupted attempt are continued from their sizes
+	if m := readManifest(dir); m.Token != "" {
+		if req.Offsets, err = partialOffsets(dir); err == nil {
+			req.ResumeToken = m.Token
+		} else {
+			req.Offsets = nil
+		}
+	}
+	err = p.DoDrpc(ctx, func(conn drpc.Conn) error {
+		stream, err := nodesyncproto.NewDRPCNodeSyncClient(conn).ColdSync(ctx, req)
 		if err != nil {
 			return err
 		}
 		rd := &streamReader{
-			dir:    c.storage.StoreDir("." + spaceId),
-			stream: stream,
+			dir:         dir,
+			stream:      stream,
+			resumeToken: req.ResumeToken,
+			peerId:      peerId,
+			bandwidth:   c.bandwidth,
 		}
 		if err = rd.Read(ctx); err != nil {
-			_ = os.RemoveAll(rd.dir)
+			if !rd.resumable(err) || errors.Is(rpcerr.Unwrap(err), spacesyncproto.ErrSpaceMissing) {
+				_ = os.RemoveAll(rd.dir)
+			} else {
+				log.Info("cold sync is interrupted, it will be resumed", zap.String("spaceId", spaceId), zap.Error(err))
+			}
 			_ = stream.Close()
 			if err == io.EOF {
 				return ErrRemoteSpaceLocked
@@ -98,17 +180,33 @@ func (c *coldSync) coldSync(ctx context.Context, spaceId, peerId string) (err er
 				return err
 			}
 		}
-		return os.Rename(rd.dir, c.storage.StoreDir(spaceId))
+		if err = os.Remove(filepath.Join(rd.dir, manifestName)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err = validateStore(ctx, rd.dir, spaceId); err != nil {
+			_ = os.RemoveAll(rd.dir)
+			return err
+		}
+		return nil
 	})
+	return
 }
 
-func (c *coldSync) ColdSyncHandle(req *nodesyncproto.ColdSyncRequest, stream nodesyncproto.DRPCNodeSync_ColdSyncStream) error {
-	if req.ProtocolType != currentStorageProtocol {
+func (c *coldSync) ColdSyncHandle(req *nodesyncproto.ColdSyncRequest, stream nodesyncproto.DRPCNodeSync_ColdSyncStream) (err error) {
+	if req.ProtocolType != currentStorageProtocol && req.ProtocolType != deltaProtocol {
 		return nodesyncproto.ErrUnsupportedStorageType
 	}
-	err := c.storage.DumpStorage(context.Background(), req.SpaceId, func(path string) error {
-		return c.coldSyncHandle(req.SpaceId, path, stream)
-	})
+	// every request makes a backup of the store, so their number is limited
+	if err = c.admission.acquire(stream.Context()); err != nil {
+		log.Info("cold sync request is rejected", zap.String("spaceId", req.SpaceId), zap.Error(err))
+		return err
+	}
+	if req.ProtocolType == deltaProtocol {
+		err = c.deltaSyncHandle(req, stream)
+	} else {
+		err = c.fullSyncHandle(req, stream)
+	}
+	c.admission.release()
 	if err != nil {
 		log.Info("handle error", zap.Error(err))
 		if errors.Is(err, spacestorage.ErrSpaceStorageMissing) {
@@ -119,10 +217,38 @@ func (c *coldSync) ColdSyncHandle(req *nodesyncproto.ColdSyncRequest, stream nod
 	return nil
 }
 
-func (c *coldSync) coldSyncHandle(spaceId, path string, stream nodesyncproto.DRPCNodeSync_ColdSyncStream) error {
+func (c *coldSync) fullSyncHandle(req *nodesyncproto.ColdSyncRequest, stream nodesyncproto.DRPCNodeSync_ColdSyncStream) error {
+	ctx := context.Background()
+	snap, resumed, err := c.snapshots.acquire(ctx, req.SpaceId, req.ResumeToken, func(ctx context.Context, dir string) error {
+		return c.storage.BackupStorage(ctx, req.SpaceId, dir)
+	})
+	if err == nil {
+		var offsets map[string]uint64
+		if resumed {
+			offsets = make(map[string]uint64, len(req.Offsets))
+			for _, off := range req.Offsets {
+				offsets[filepath.Clean(off.Filename)] = off.Offset
+			}
+			log.Info("resume cold sync", zap.String("spaceId", req.SpaceId), zap.Int("files", len(offsets)))
+		}
+		err = c.coldSyncHandle(snap, offsets, pickCodec(req.Accept), stream)
+		c.snapshots.release(snap, err == nil)
+	}
+	return err
+}
+
+func (c *coldSync) coldSyncHandle(snap *snapshot, offsets map[string]uint64, codec *nodesyncproto.ColdSyncCodec, stream nodesyncproto.DRPCNodeSync_ColdSyncStream) error {
+	peerId, _ := peer.CtxPeerId(stream.Context())
 	sw := &streamWriter{
-		dir:    path,
-		stream: stream,
+		dir:       snap.dir,
+		stream:    stream,
+		token:     snap.token,
+		codec:     codec,
+		protocol:  currentRespProtocol,
+		offsets:   offsets,
+		ctx:       stream.Context(),
+		peerId:    peerId,
+		bandwidth: c.bandwidth,
 	}
 	return sw.Write()
 }
@@ -1,18 +1,24 @@
 package coldsync
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/anyproto/any-sync/app"
+	"github.com/anyproto/any-sync/app/ocache"
 	"github.com/anyproto/any-sync/commonspace/headsync/headstorage"
+	"github.com/anyproto/any-sync/commonspace/spacestorage"
 	"github.com/anyproto/any-sync/commonspace/spacesyncproto"
 	"github.com/anyproto/any-sync/net/peer"
 	"github.com/anyproto/any-sync/net/rpc/rpcerr"
 	"github.com/anyproto/any-sync/net/rpc/rpctest"
 	"github.com/anyproto/any-sync/testutil/anymock"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
@@ -20,24 +26,75 @@ import (
 	"github.com/anyproto/any-sync-node/nodespace"
 	"github.com/anyproto/any-sync-node/nodespace/mock_nodespace"
 	"github.com/anyproto/any-sync-node/nodestorage"
+	"github.com/anyproto/any-sync-node/nodesync/bandwidth"
 	"github.com/anyproto/any-sync-node/nodesync/nodesyncproto"
 )
 
 var ctx = context.Background()
 
-func TestColdSync_Sync(t *testing.T) {
-	var makeClientServer = func(t *testing.T) (fxC, fxS *fixture, peerId string) {
-		fxC = newFixture(t)
-		fxS = newFixture(t)
-		peerId = "peer"
-		mcS, mcC := rpctest.MultiConnPair(peerId, peerId+"client")
-		pS, err := peer.NewPeer(mcS, fxC.ts)
+func makeClientServer(t *testing.T) (fxC, fxS *fixture, peerId string) {
+	return makeClientServerConf(t, Config{}, Config{})
+}
+
+func makeClientServerConf(t *testing.T, confC, confS Config) (fxC, fxS *fixture, peerId string) {
+	fxC = newFixtureConf(t, confC)
+	fxS = newFixtureConf(t, confS)
+	peerId = "peer"
+	mcS, mcC := rpctest.MultiConnPair(peerId, peerId+"client")
+	pS, err := peer.NewPeer(mcS, fxC.ts)
+	require.NoError(t, err)
+	fxC.tp.AddPeer(ctx, pS)
+	_, err = peer.NewPeer(mcC, fxS.ts)
+	require.NoError(t, err)
+	return
+}
+
+func TestColdSync_Compression(t *testing.T) {
+	syncAndCheck := func(t *testing.T, fxC, fxS *fixture, peerId string) {
+		store := nodestorage.GenStorage(t, fxS.store, 10, 10)
+		require.NoError(t, fxC.Sync(ctx, store.Id(), peerId))
+		spaceStore, err := fxC.store.SpaceStorage(ctx, store.Id())
 		require.NoError(t, err)
-		fxC.tp.AddPeer(ctx, pS)
-		_, err = peer.NewPeer(mcC, fxS.ts)
+		state, err := spaceStore.StateStorage().GetState(ctx)
 		require.NoError(t, err)
-		return
+		assert.Equal(t, store.Id(), state.SpaceId)
+	}
+	for _, compression := range []string{"none", "gzip", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			fxC, fxS, peerId := makeClientServerConf(t, Config{Compression: []string{compression}, ZstdLevel: 5}, Config{})
+			defer fxC.Finish(t)
+			defer fxS.Finish(t)
+			syncAndCheck(t, fxC, fxS, peerId)
+		})
 	}
+	t.Run("requester without negotiation", func(t *testing.T) {
+		fxC, fxS, peerId := makeClientServerConf(t, Config{}, Config{})
+		defer fxC.Finish(t)
+		defer fxS.Finish(t)
+		fxC.ColdSync.(*coldSync).accept = nil
+		syncAndCheck(t, fxC, fxS, peerId)
+	})
+}
+
+func TestPickCodec(t *testing.T) {
+	assert.Equal(t, nodesyncproto.ColdSyncCompression_Gzip, pickCodec(nil).Compression)
+	codec := pickCodec([]*nodesyncproto.ColdSyncCodec{
+		{Compression: 42},
+		{Compression: nodesyncproto.ColdSyncCompression_Zstd, Level: 7},
+		{Compression: nodesyncproto.ColdSyncCompression_Gzip},
+	})
+	assert.Equal(t, nodesyncproto.ColdSyncCompression_Zstd, codec.Compression)
+	assert.Equal(t, int32(7), codec.Level)
+
+	accept, err := parseAccept(Config{ZstdLevel: 3})
+	require.NoError(t, err)
+	require.Len(t, accept, 2)
+	assert.Equal(t, int32(3), accept[0].Level)
+	_, err = parseAccept(Config{Compression: []string{"lz4"}})
+	assert.Error(t, err)
+}
+
+func TestColdSync_Sync(t *testing.T) {
 	t.Run("sync", func(t *testing.T) {
 		// whether the space in cache or not doesn't matter
 		// because we do backup
@@ -57,6 +114,95 @@ func TestColdSync_Sync(t *testing.T) {
 		// 100 trees + acl + settings
 		require.Equal(t, 102, cnt)
 	})
+	t.Run("resume", func(t *testing.T) {
+		fxC, fxS, peerId := makeClientServer(t)
+		defer fxC.Finish(t)
+		defer fxS.Finish(t)
+		store := nodestorage.GenStorage(t, fxS.store, 100, 100)
+
+		// the interrupted attempt has left a snapshot on the server and a half of the file on the client
+		snaps := fxS.ColdSync.(*coldSync).snapshots
+		snap, _, err := snaps.acquire(ctx, store.Id(), "", func(ctx context.Context, dir string) error {
+			return fxS.store.BackupStorage(ctx, store.Id(), dir)
+		})
+		require.NoError(t, err)
+		snaps.release(snap, false)
+		data, err := os.ReadFile(filepath.Join(snap.dir, "store.db"))
+		require.NoError(t, err)
+		partialDir := fxC.store.StoreDir("." + store.Id())
+		require.NoError(t, writeManifest(partialDir, manifest{Token: snap.token}))
+		require.NoError(t, os.WriteFile(filepath.Join(partialDir, "store.db"), data[:len(data)/2], 0644))
+
+		require.NoError(t, fxC.Sync(ctx, store.Id(), peerId))
+		spaceStore, err := fxC.store.SpaceStorage(ctx, store.Id())
+		require.NoError(t, err)
+		state, err := spaceStore.StateStorage().GetState(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, store.Id(), state.SpaceId)
+		_, err = os.Stat(filepath.Join(fxC.store.StoreDir(store.Id()), manifestName))
+		assert.True(t, os.IsNotExist(err))
+		// the completed snapshot is removed
+		_, err = os.Stat(snap.dir)
+		assert.True(t, os.IsNotExist(err))
+	})
+	t.Run("resume corrupted", func(t *testing.T) {
+		fxC, fxS, peerId := makeClientServer(t)
+		defer fxC.Finish(t)
+		defer fxS.Finish(t)
+		store := nodestorage.GenStorage(t, fxS.store, 10, 10)
+
+		snaps := fxS.ColdSync.(*coldSync).snapshots
+		snap, _, err := snaps.acquire(ctx, store.Id(), "", func(ctx context.Context, dir string) error {
+			return fxS.store.BackupStorage(ctx, store.Id(), dir)
+		})
+		require.NoError(t, err)
+		snaps.release(snap, false)
+		data, err := os.ReadFile(filepath.Join(snap.dir, "store.db"))
+		require.NoError(t, err)
+		// the received part is continued, so the damage is found by the whole-file checksum
+		partial := bytes.Clone(data[:len(data)/2])
+		partial[len(partial)-1] ^= 0xff
+		partialDir := fxC.store.StoreDir("." + store.Id())
+		require.NoError(t, writeManifest(partialDir, manifest{Token: snap.token}))
+		require.NoError(t, os.WriteFile(filepath.Join(partialDir, "store.db"), partial, 0644))
+
+		require.ErrorIs(t, fxC.Sync(ctx, store.Id(), peerId), errChecksumMismatch)
+		_, err = os.Stat(partialDir)
+		assert.True(t, os.IsNotExist(err))
+		assert.False(t, fxC.store.SpaceExists(store.Id()))
+	})
+	t.Run("invalid store", func(t *testing.T) {
+		fxC, fxS, peerId := makeClientServer(t)
+		defer fxC.Finish(t)
+		defer fxS.Finish(t)
+		// the remote has the store of another space under the requested id
+		spaceId := nodestorage.NewStorageCreatePayload(t).SpaceHeaderWithId.Id
+		other := nodestorage.GenStorage(t, fxS.store, 10, 10)
+		require.NoError(t, fxS.store.DumpStorage(ctx, other.Id(), func(path string) error {
+			return os.Rename(path, fxS.store.StoreDir(spaceId))
+		}))
+
+		require.ErrorIs(t, fxC.Sync(ctx, spaceId, peerId), ErrInvalidStore)
+		assert.False(t, fxC.store.SpaceExists(spaceId))
+		_, err := os.Stat(fxC.store.StoreDir("." + spaceId))
+		assert.True(t, os.IsNotExist(err))
+	})
+	t.Run("resume expired", func(t *testing.T) {
+		fxC, fxS, peerId := makeClientServer(t)
+		defer fxC.Finish(t)
+		defer fxS.Finish(t)
+		store := nodestorage.GenStorage(t, fxS.store, 100, 100)
+
+		partialDir := fxC.store.StoreDir("." + store.Id())
+		require.NoError(t, writeManifest(partialDir, manifest{Token: "expired"}))
+		require.NoError(t, os.WriteFile(filepath.Join(partialDir, "store.db"), []byte("stale"), 0644))
+
+		require.NoError(t, fxC.Sync(ctx, store.Id(), peerId))
+		spaceStore, err := fxC.store.SpaceStorage(ctx, store.Id())
+		require.NoError(t, err)
+		_, err = spaceStore.StateStorage().GetState(ctx)
+		require.NoError(t, err)
+	})
 	t.Run("space missing", func(t *testing.T) {
 		fxC, fxS, peerId := makeClientServer(t)
 		defer fxC.Finish(t)
@@ -70,6 +216,9 @@ func TestColdSync_Sync(t *testing.T) {
 		defer fxS.Finish(t)
 		store := nodestorage.GenStorage(t, fxS.store, 100, 100)
 		currentReqProtocol = nodesyncproto.ColdSyncProtocolType_Pogreb
+		defer func() {
+			currentReqProtocol = currentStorageProtocol
+		}()
 		err := fxC.Sync(ctx, store.Id(), peerId)
 		require.ErrorIs(t, rpcerr.Unwrap(err), nodesyncproto.ErrUnsupportedStorageType)
 	})
@@ -79,12 +228,219 @@ func TestColdSync_Sync(t *testing.T) {
 		defer fxS.Finish(t)
 		store := nodestorage.GenStorage(t, fxS.store, 100, 100)
 		currentRespProtocol = nodesyncproto.ColdSyncProtocolType_Pogreb
+		defer func() {
+			currentRespProtocol = currentStorageProtocol
+		}()
 		err := fxC.Sync(ctx, store.Id(), peerId)
 		require.ErrorIs(t, nodesyncproto.ErrUnsupportedStorageType, rpcerr.Unwrap(err))
 	})
 }
 
+func TestColdSync_SyncDelta(t *testing.T) {
+	// copyStore puts the current store of the server space to the client
+	copyStore := func(t *testing.T, fxC, fxS *fixture, srcId, dstId string) {
+		require.NoError(t, fxS.store.DumpStorage(ctx, srcId, func(path string) error {
+			return os.Rename(path, fxC.store.StoreDir(dstId))
+		}))
+	}
+	countHeads := func(t *testing.T, fx *fixture, spaceId string) (cnt int) {
+		store, err := fx.store.SpaceStorage(ctx, spaceId)
+		require.NoError(t, err)
+		err = store.HeadStorage().IterateEntries(ctx, headstorage.IterOpts{}, func(entry headstorage.HeadsEntry) (bool, error) {
+			cnt++
+			return true, nil
+		})
+		require.NoError(t, err)
+		return
+	}
+	t.Run("delta", func(t *testing.T) {
+		fxC, fxS, peerId := makeClientServer(t)
+		defer fxC.Finish(t)
+		defer fxS.Finish(t)
+		store := nodestorage.GenStorage(t, fxS.store, 0, 10)
+		copyStore(t, fxC, fxS, store.Id(), store.Id())
+		nodestorage.CreateTreeStorage(t, store, 50, 10)
+
+		require.NoError(t, fxC.SyncDelta(ctx, store.Id(), peerId))
+		// 50 trees + acl + settings
+		assert.Equal(t, 52, countHeads(t, fxC, store.Id()))
+		_, err := os.Stat(fxC.store.StoreDir("." + store.Id() + ".delta"))
+		assert.True(t, os.IsNotExist(err))
+	})
+	t.Run("invalid store", func(t *testing.T) {
+		fxC, fxS, peerId := makeClientServer(t)
+		defer fxC.Finish(t)
+		defer fxS.Finish(t)
+		fxO := newFixture(t)
+		defer fxO.Finish(t)
+		store := nodestorage.GenStorage(t, fxO.store, 10, 10)
+		spaceId := store.Id()
+		copyStore(t, fxC, fxO, spaceId, spaceId)
+		// the remote has the store of another space under the requested id
+		other := nodestorage.GenStorage(t, fxS.store, 20, 10)
+		copyStore(t, fxS, fxS, other.Id(), spaceId)
+
+		require.ErrorIs(t, fxC.SyncDelta(ctx, spaceId, peerId), ErrInvalidStore)
+		// the local store is kept
+		assert.Equal(t, 12, countHeads(t, fxC, spaceId))
+	})
+	t.Run("space missing locally", func(t *testing.T) {
+		fxC, fxS, peerId := makeClientServer(t)
+		defer fxC.Finish(t)
+		defer fxS.Finish(t)
+		store := nodestorage.GenStorage(t, fxS.store, 10, 10)
+		require.ErrorIs(t, fxC.SyncDelta(ctx, store.Id(), peerId), spacestorage.ErrSpaceStorageMissing)
+	})
+	t.Run("diverged", func(t *testing.T) {
+		fxC, fxS, peerId := makeClientServer(t)
+		defer fxC.Finish(t)
+		defer fxS.Finish(t)
+		fxO := newFixture(t)
+		defer fxO.Finish(t)
+		store := nodestorage.GenStorage(t, fxO.store, 0, 10)
+		spaceId := store.Id()
+		copyStore(t, fxS, fxO, spaceId, spaceId)
+		// the local store has changes the remote doesn't have
+		nodestorage.CreateTreeStorage(t, store, 5, 10)
+		copyStore(t, fxC, fxO, spaceId, spaceId)
+
+		require.ErrorIs(t, fxC.SyncDelta(ctx, spaceId, peerId), ErrDiverged)
+		// 5 trees + acl + settings are kept
+		assert.Equal(t, 7, countHeads(t, fxC, spaceId))
+		_, err := os.Stat(fxC.store.StoreDir("." + spaceId + ".delta"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestColdSync_ForceSync(t *testing.T) {
+	t.Run("replace", func(t *testing.T) {
+		fxC, fxS, peerId := makeClientServer(t)
+		defer fxC.Finish(t)
+		defer fxS.Finish(t)
+		store := nodestorage.GenStorage(t, fxS.store, 0, 10)
+		require.NoError(t, fxS.store.DumpStorage(ctx, store.Id(), func(path string) error {
+			return os.Rename(path, fxC.store.StoreDir(store.Id()))
+		}))
+		nodestorage.CreateTreeStorage(t, store, 20, 10)
+		// the space is cached locally, but nobody uses it
+		cached, err := fxC.store.SpaceStorage(ctx, store.Id())
+		require.NoError(t, err)
+		require.NoError(t, cached.Close(ctx))
+		fxC.space.EXPECT().EvictSpace(gomock.Any(), store.Id()).Return(nil)
+
+		require.NoError(t, fxC.ForceSync(ctx, store.Id(), peerId))
+		local, err := fxC.store.SpaceStorage(ctx, store.Id())
+		require.NoError(t, err)
+		var cnt int
+		require.NoError(t, local.HeadStorage().IterateEntries(ctx, headstorage.IterOpts{}, func(entry headstorage.HeadsEntry) (bool, error) {
+			cnt++
+			return true, nil
+		}))
+		// 20 trees + acl + settings
+		assert.Equal(t, 22, cnt)
+		_, err = os.Stat(fxC.store.StoreDir("." + store.Id() + ".old"))
+		assert.True(t, os.IsNotExist(err))
+	})
+	t.Run("space missing locally", func(t *testing.T) {
+		fxC, fxS, peerId := makeClientServer(t)
+		defer fxC.Finish(t)
+		defer fxS.Finish(t)
+		store := nodestorage.GenStorage(t, fxS.store, 10, 10)
+		fxC.space.EXPECT().EvictSpace(gomock.Any(), store.Id()).Return(ocache.ErrNotExists)
+		require.NoError(t, fxC.ForceSync(ctx, store.Id(), peerId))
+		_, err := os.Stat(fxC.store.StoreDir(store.Id()))
+		assert.NoError(t, err)
+	})
+	t.Run("in use", func(t *testing.T) {
+		fxC, fxS, peerId := makeClientServer(t)
+		defer fxC.Finish(t)
+		defer fxS.Finish(t)
+		store := nodestorage.GenStorage(t, fxS.store, 0, 10)
+		require.NoError(t, fxS.store.DumpStorage(ctx, store.Id(), func(path string) error {
+			return os.Rename(path, fxC.store.StoreDir(store.Id()))
+		}))
+		local, err := fxC.store.SpaceStorage(ctx, store.Id())
+		require.NoError(t, err)
+		defer local.Close(ctx)
+		fxC.space.EXPECT().EvictSpace(gomock.Any(), store.Id()).Return(ocache.ErrNotExists)
+
+		require.ErrorIs(t, fxC.ForceSync(ctx, store.Id(), peerId), nodestorage.ErrLocked)
+	})
+}
+
+func TestColdSync_Busy(t *testing.T) {
+	fxC, fxS, peerId := makeClientServerConf(t, Config{}, Config{MaxConcurrentDumps: 1})
+	defer fxC.Finish(t)
+	defer fxS.Finish(t)
+	store := nodestorage.GenStorage(t, fxS.store, 10, 10)
+	adm := fxS.ColdSync.(*coldSync).admission
+	adm.wait = time.Millisecond * 10
+	require.NoError(t, adm.acquire(ctx))
+
+	err := fxC.Sync(ctx, store.Id(), peerId)
+	require.ErrorIs(t, rpcerr.Unwrap(err), nodesyncproto.ErrColdSyncBusy)
+	assert.False(t, fxC.store.SpaceExists(store.Id()))
+	assert.Equal(t, uint32(1), adm.stat.dumpsRejected.Load())
+
+	adm.release()
+	require.NoError(t, fxC.Sync(ctx, store.Id(), peerId))
+	assert.Equal(t, int32(0), adm.stat.dumpsActive.Load())
+}
+
+func TestAdmission(t *testing.T) {
+	adm := newAdmission(Config{MaxConcurrentDumps: 1, MaxQueuedDumps: 1}, new(coldSyncStat))
+	require.NoError(t, adm.acquire(ctx))
+
+	queued := make(chan error)
+	go func() {
+		queued <- adm.acquire(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		return adm.stat.dumpsQueued.Load() == 1
+	}, time.Second, time.Millisecond)
+	// the queue is full
+	assert.ErrorIs(t, adm.acquire(ctx), ErrColdSyncBusy)
+
+	adm.release()
+	require.NoError(t, <-queued)
+	assert.Equal(t, int32(1), adm.stat.dumpsActive.Load())
+	assert.Equal(t, int32(0), adm.stat.dumpsQueued.Load())
+	assert.Equal(t, uint32(1), adm.stat.dumpsRejected.Load())
+	adm.release()
+}
+
+func TestDeltaWriter_pages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+	data := bytes.Repeat([]byte{1}, minPageSize*4)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	hashes, err := pageHashes(path, minPageSize)
+	require.NoError(t, err)
+	require.Len(t, hashes, 4*pageHashSize)
+
+	// the second page is changed and the file has grown by a page
+	data[minPageSize] = 2
+	data = append(data, bytes.Repeat([]byte{3}, minPageSize)...)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+	dw := &deltaWriter{pageSize: minPageSize, hashes: hashes, enc: nopWriteCloser{io.Discard}}
+	page := make([]byte, minPageSize)
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	for i := 0; i < 5; i++ {
+		_, err = io.ReadFull(f, page)
+		require.NoError(t, err)
+		require.NoError(t, dw.addPage(int64(i*minPageSize), page))
+	}
+	assert.Equal(t, 5, dw.pages)
+	assert.Equal(t, 2, dw.changed)
+	assert.Equal(t, int64(4*minPageSize), dw.runOff)
+}
+
 func newFixture(t *testing.T) (fx *fixture) {
+	return newFixtureConf(t, Config{})
+}
+
+func newFixtureConf(t *testing.T, conf Config) (fx *fixture) {
 	ts := rpctest.NewTestServer()
 	fx = &fixture{
 		ColdSync: New(),
@@ -97,12 +453,13 @@ func newFixture(t *testing.T) (fx *fixture) {
 	tempDir := fx.tmpDir
 	fx.store = nodestorage.New()
 	fx.space = mock_nodespace.NewMockService(fx.ctrl)
-	configGetter := mockConfigGetter{tempStoreNew: filepath.Join(tempDir, "new"), tempStoreOld: filepath.Join(tempDir, "old")}
+	configGetter := mockConfigGetter{tempStoreNew: filepath.Join(tempDir, "new"), tempStoreOld: filepath.Join(tempDir, "old"), coldSync: conf}
 	archive := mock_archive.NewMockArchive(fx.ctrl)
 	anymock.ExpectComp(archive.EXPECT(), "node.archive")
 	anymock.ExpectComp(fx.space.EXPECT(), nodespace.CName)
 	fx.a.Register(configGetter).
 		Register(fx.store).
+		Register(bandwidth.New()).
 		Register(fx.ColdSync).
 		Register(fx.tp).
 		Register(fx.ts).
@@ -144,6 +501,7 @@ func (t *testServer) ColdSync(req *nodesyncproto.ColdSyncRequest, stream nodesyn
 type mockConfigGetter struct {
 	tempStoreNew string
 	tempStoreOld string
+	coldSync     Config
 }
 
 func (m mockConfigGetter) Init(a *app.App) (err error) {
@@ -154,6 +512,14 @@ func (m mockConfigGetter) Name() (name string) {
 	return "config"
 }
 
+func (m mockConfigGetter) GetColdSync() Config {
+	return m.coldSync
+}
+
+func (m mockConfigGetter) GetBandwidth() bandwidth.Config {
+	return bandwidth.Config{}
+}
+
 func (m mockConfigGetter) GetStorage() nodestorage.Config {
 	return nodestorage.Config{
 		Path:         m.tempStoreOld,
@@ -0,0 +1,105 @@
+package coldsync
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/anyproto/any-sync-node/nodesync/nodesyncproto"
+)
+
+var defaultAccept = []*nodesyncproto.ColdSyncCodec{
+	{Compression: nodesyncproto.ColdSyncCompression_Zstd},
+	{Compression: nodesyncproto.ColdSyncCompression_Gzip},
+}
+
+// parseAccept converts configured codec names to the list sent in the request
+func parseAccept(conf Config) (accept []*nodesyncproto.ColdSyncCodec, err error) {
+	if len(conf.Compression) == 0 {
+		accept = make([]*nodesyncproto.ColdSyncCodec, 0, len(defaultAccept))
+		for _, codec := range defaultAccept {
+			accept = append(accept, codecWithLevel(codec.Compression, conf.ZstdLevel))
+		}
+		return
+	}
+	for _, name := range conf.Compression {
+		var compression nodesyncproto.ColdSyncCompression
+		switch strings.ToLower(name) {
+		case "none":
+			compression = nodesyncproto.ColdSyncCompression_None
+		case "gzip":
+			compression = nodesyncproto.ColdSyncCompression_Gzip
+		case "zstd":
+			compression = nodesyncproto.ColdSyncCompression_Zstd
+		default:
+			return nil, fmt.Errorf("unknown cold sync compression: %q", name)
+		}
+		accept = append(accept, codecWithLevel(compression, conf.ZstdLevel))
+	}
+	return
+}
+
+func codecWithLevel(compression nodesyncproto.ColdSyncCompression, zstdLevel int) *nodesyncproto.ColdSyncCodec {
+	codec := &nodesyncproto.ColdSyncCodec{Compression: compression}
+	if compression == nodesyncproto.ColdSyncCompression_Zstd {
+		codec.Level = int32(zstdLevel)
+	}
+	return codec
+}
+
+// pickCodec returns the first codec of the requester known by the sender, gzip for requesters without the list
+func pickCodec(accept []*nodesyncproto.ColdSyncCodec) *nodesyncproto.ColdSyncCodec {
+	for _, codec := range accept {
+		if _, ok := nodesyncproto.ColdSyncCompression_name[int32(codec.Compression)]; ok {
+			return codec
+		}
+	}
+	return &nodesyncproto.ColdSyncCodec{Compression: nodesyncproto.ColdSyncCompression_Gzip}
+}
+
+func newEncoder(w io.Writer, codec *nodesyncproto.ColdSyncCodec) (io.WriteCloser, error) {
+	switch codec.Compression {
+	case nodesyncproto.ColdSyncCompression_None:
+		return nopWriteCloser{w}, nil
+	case nodesyncproto.ColdSyncCompression_Zstd:
+		level := zstd.SpeedDefault
+		if codec.Level > 0 {
+			level = zstd.EncoderLevelFromZstd(int(codec.Level))
+		}
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(level))
+	default:
+		level := gzip.DefaultCompression
+		if codec.Level >= gzip.HuffmanOnly && codec.Level <= gzip.BestCompression && codec.Level != 0 {
+			level = int(codec.Level)
+		}
+		return gzip.NewWriterLevel(w, level)
+	}
+}
+
+func newDecoder(r io.Reader, compression nodesyncproto.ColdSyncCompression) (io.ReadCloser, error) {
+	switch compression {
+	case nodesyncproto.ColdSyncCompression_None:
+		return io.NopCloser(r), nil
+	case nodesyncproto.ColdSyncCompression_Zstd:
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	case nodesyncproto.ColdSyncCompression_Gzip:
+		return gzip.NewReader(r)
+	default:
+		return nil, fmt.Errorf("unsupported cold sync compression: %v", compression)
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
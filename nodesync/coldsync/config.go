@@ -0,0 +1,21 @@
+package coldsync
+
+type Config struct {
+	// Compression lists accepted transport codecs in the order of preference: zstd, gzip, none.
+	// zstd and gzip by default, peers without the negotiation always use gzip
+	Compression []string `yaml:"compression"`
+	// ZstdLevel is a level asked from the sender for zstd, 0 means the default level
+	ZstdLevel int `yaml:"zstdLevel"`
+	// DeltaOnChange catches up existing spaces that differ from the peer with the page-level delta instead of hot sync
+	DeltaOnChange bool `yaml:"deltaOnChange"`
+	// MaxConcurrentDumps limits store dumps served to peers at once, 4 by default
+	MaxConcurrentDumps int `yaml:"maxConcurrentDumps"`
+	// MaxQueuedDumps is how many requests wait for a free dump slot, others are rejected as busy. 16 by default
+	MaxQueuedDumps int `yaml:"maxQueuedDumps"`
+	// QueueWaitSeconds is how long a request waits in the queue before it's rejected as busy, 60 by default
+	QueueWaitSeconds int `yaml:"queueWaitSeconds"`
+}
+
+type configGetter interface {
+	GetColdSync() Config
+}
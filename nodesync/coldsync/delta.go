@@ -0,0 +1,527 @@
+package coldsync
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	anystore "github.com/anyproto/any-store"
+	"github.com/anyproto/any-sync/commonspace/headsync/headstorage"
+	"github.com/anyproto/any-sync/commonspace/object/tree/objecttree"
+	"github.com/anyproto/any-sync/net/peer"
+	"go.uber.org/zap"
+	"storj.io/drpc"
+
+	"github.com/anyproto/any-sync-node/nodesync/bandwidth"
+	"github.com/anyproto/any-sync-node/nodesync/nodesyncproto"
+)
+
+// The delta protocol updates an existing store: the requester sends hashes of its database pages,
+// the remote streams only the pages that differ as frames of [offset uint64][length uint32][data]
+// through the negotiated codec, followed by the trailer with the size and the checksum of the whole file.
+const deltaProtocol = nodesyncproto.ColdSyncProtocolType_AnystoreSqliteDelta
+
+const (
+	// pageHashSize is the length of the truncated sha256 of a page
+	pageHashSize    = 8
+	minPageSize     = 512
+	maxPageSize     = 65536
+	deltaHeaderSize = 12
+	deltaFilename   = "/store.db"
+	// maxDeltaFrame bounds the frame length, runs of pages are cut at chunkSize
+	maxDeltaFrame = chunkSize + maxPageSize
+)
+
+var (
+	// ErrDiverged is returned by SyncDelta when the local store has changes the peer doesn't have, the space needs a hot sync to merge them
+	ErrDiverged = errors.New("local store has changes missing on the peer")
+
+	// errStoreReplaced stops the storage loading after the store directory is swapped
+	errStoreReplaced = errors.New("store replaced")
+	errDeltaFrame    = errors.New("invalid delta frame")
+)
+
+// SyncDelta brings the existing local store of the space up to the store of the peer by downloading changed pages only.
+// The updated copy is validated like a full cold sync, and it replaces the store only when it contains every local head,
+// i.e. the local state is an ancestor of the peer's one. Otherwise ErrDiverged is returned and the local store is kept.
+func (c *coldSync) SyncDelta(ctx context.Context, spaceId, peerId string) (err error) {
+	err = c.storage.TryLockAndOpenDb(ctx, spaceId, func(db anystore.DB) error {
+		dir := c.storage.StoreDir("." + spaceId + ".delta")
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		if err := db.Backup(ctx, filepath.Join(dir, "store.db")); err != nil {
+			_ = os.RemoveAll(dir)
+			return err
+		}
+		if err := c.deltaSync(ctx, spaceId, peerId, dir); err != nil {
+			_ = os.RemoveAll(dir)
+			return err
+		}
+		if err := containsLocalHeads(ctx, db, filepath.Join(dir, "store.db")); err != nil {
+			_ = os.RemoveAll(dir)
+			return err
+		}
+		_ = db.Close()
+		if err := c.replaceStore(spaceId, dir); err != nil {
+			_ = os.RemoveAll(dir)
+			return err
+		}
+		return errStoreReplaced
+	})
+	if errors.Is(err, errStoreReplaced) {
+		return nil
+	}
+	return
+}
+
+func (c *coldSync) deltaSync(ctx context.Context, spaceId, peerId, dir string) (err error) {
+	path := filepath.Join(dir, "store.db")
+	pageSize, err := sqlitePageSize(path)
+	if err != nil {
+		return
+	}
+	hashes, err := pageHashes(path, pageSize)
+	if err != nil {
+		return
+	}
+	p, err := c.pool.GetOneOf(ctx, []string{peerId})
+	if err != nil {
+		return
+	}
+	req := &nodesyncproto.ColdSyncRequest{
+		SpaceId:      spaceId,
+		ProtocolType: deltaProtocol,
+		Accept:       c.accept,
+		PageSize:     pageSize,
+		PageHashes:   hashes,
+	}
+	err = p.DoDrpc(ctx, func(conn drpc.Conn) error {
+		stream, err := nodesyncproto.NewDRPCNodeSyncClient(conn).ColdSync(ctx, req)
+		if err != nil {
+			return err
+		}
+		dr := &deltaReader{path: path, stream: stream, peerId: peerId, bandwidth: c.bandwidth}
+		err = dr.Read(ctx)
+		_ = stream.Close()
+		if err == io.EOF {
+			return ErrRemoteSpaceLocked
+		}
+		return err
+	})
+	if err != nil {
+		return
+	}
+	return validateStore(ctx, dir, spaceId)
+}
+
+// containsLocalHeads checks that the store at path has every head of the local store. Changes are immutable and
+// a head is stored with all its ancestors, so then the peer's store has every local change.
+// Tree changes are in the changes collection, acl records are in the collection named by the acl id.
+func containsLocalHeads(ctx context.Context, local anystore.DB, path string) (err error) {
+	localHeads, err := headstorage.New(ctx, local)
+	if err != nil {
+		return
+	}
+	peerDb, err := anystore.Open(ctx, path, &anystore.Config{ReadConnections: 1})
+	if err != nil {
+		return fmt.Errorf("%w: open: %w", ErrInvalidStore, err)
+	}
+	defer func() {
+		if cErr := peerDb.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}()
+	hasDoc := func(collName, id string) (bool, error) {
+		coll, err := peerDb.OpenCollection(ctx, collName)
+		if errors.Is(err, anystore.ErrCollectionNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		defer func() {
+			_ = coll.Close()
+		}()
+		if _, err = coll.FindId(ctx, id); errors.Is(err, anystore.ErrDocNotFound) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	return localHeads.IterateEntries(ctx, headstorage.IterOpts{}, func(entry headstorage.HeadsEntry) (bool, error) {
+		for _, head := range entry.Heads {
+			ok, err := hasDoc(objecttree.CollName, head)
+			if err == nil && !ok {
+				ok, err = hasDoc(entry.Id, head)
+			}
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				return false, fmt.Errorf("%w: object %s, head %s", ErrDiverged, entry.Id, head)
+			}
+		}
+		return true, nil
+	})
+}
+
+// replaceStore swaps the space directory with dir, the previous store is returned back on failure
+func (c *coldSync) replaceStore(spaceId, dir string) (err error) {
+	storeDir := c.storage.StoreDir(spaceId)
+	oldDir := c.storage.StoreDir("." + spaceId + ".old")
+	if err = os.RemoveAll(oldDir); err != nil {
+		return
+	}
+	if err = os.Rename(storeDir, oldDir); err != nil {
+		return
+	}
+	if err = os.Rename(dir, storeDir); err != nil {
+		if rErr := os.Rename(oldDir, storeDir); rErr != nil {
+			log.Error("can't return the store back", zap.String("spaceId", spaceId), zap.Error(rErr))
+		}
+		return
+	}
+	return os.RemoveAll(oldDir)
+}
+
+func (c *coldSync) deltaSyncHandle(req *nodesyncproto.ColdSyncRequest, stream nodesyncproto.DRPCNodeSync_ColdSyncStream) error {
+	if !validPageSize(req.PageSize) || len(req.PageHashes)%pageHashSize != 0 {
+		return nodesyncproto.ErrUnexpected
+	}
+	peerId, _ := peer.CtxPeerId(stream.Context())
+	return c.storage.DumpStorage(context.Background(), req.SpaceId, func(dir string) error {
+		dw := &deltaWriter{
+			stream:    stream,
+			codec:     pickCodec(req.Accept),
+			pageSize:  int(req.PageSize),
+			hashes:    req.PageHashes,
+			ctx:       stream.Context(),
+			peerId:    peerId,
+			bandwidth: c.bandwidth,
+		}
+		if err := dw.Write(filepath.Join(dir, "store.db")); err != nil {
+			return err
+		}
+		log.Info("delta cold sync", zap.String("spaceId", req.SpaceId), zap.Int("pages", dw.pages), zap.Int("changed", dw.changed))
+		return nil
+	})
+}
+
+func validPageSize(pageSize uint32) bool {
+	return pageSize >= minPageSize && pageSize <= maxPageSize && pageSize&(pageSize-1) == 0
+}
+
+// sqlitePageSize reads the page size from the database header
+func sqlitePageSize(path string) (pageSize uint32, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	var header [18]byte
+	if _, err = io.ReadFull(f, header[:]); err != nil {
+		return
+	}
+	pageSize = uint32(binary.BigEndian.Uint16(header[16:18]))
+	// 1 stands for 65536, it doesn't fit into two bytes
+	if pageSize == 1 {
+		pageSize = maxPageSize
+	}
+	if !validPageSize(pageSize) {
+		return 0, fmt.Errorf("invalid sqlite page size: %d", pageSize)
+	}
+	return
+}
+
+func pageHash(page []byte) []byte {
+	sum := sha256.Sum256(page)
+	return sum[:pageHashSize]
+}
+
+// pageHashes returns concatenated hashes of all pages of the file
+func pageHashes(path string, pageSize uint32) (hashes []byte, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	info, err := f.Stat()
+	if err != nil {
+		return
+	}
+	hashes = make([]byte, 0, (info.Size()/int64(pageSize)+1)*pageHashSize)
+	br := bufio.NewReaderSize(f, chunkSize)
+	page := make([]byte, pageSize)
+	for {
+		n, rErr := io.ReadFull(br, page)
+		if n > 0 {
+			hashes = append(hashes, pageHash(page[:n])...)
+		}
+		if rErr == io.EOF || rErr == io.ErrUnexpectedEOF {
+			return hashes, nil
+		}
+		if rErr != nil {
+			return nil, rErr
+		}
+	}
+}
+
+type deltaWriter struct {
+	stream   nodesyncproto.DRPCNodeSync_ColdSyncStream
+	codec    *nodesyncproto.ColdSyncCodec
+	pageSize int
+	hashes   []byte
+	enc      io.WriteCloser
+	run      []byte
+	runOff   int64
+	pages    int
+	changed  int
+
+	ctx       context.Context
+	peerId    string
+	bandwidth bandwidth.Bandwidth
+}
+
+func (dw *deltaWriter) Write(path string) (err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	sw := &streamWriter{
+		stream:    dw.stream,
+		codec:     dw.codec,
+		protocol:  deltaProtocol,
+		ctx:       dw.ctx,
+		peerId:    dw.peerId,
+		bandwidth: dw.bandwidth,
+	}
+	bw := bufio.NewWriterSize(sw.newFileWriter(deltaFilename, 0), chunkSize)
+	if dw.enc, err = newEncoder(bw, dw.codec); err != nil {
+		return
+	}
+	hasher := sha256.New()
+	br := bufio.NewReaderSize(f, chunkSize)
+	page := make([]byte, dw.pageSize)
+	var size int64
+	for {
+		n, rErr := io.ReadFull(br, page)
+		if n > 0 {
+			hasher.Write(page[:n])
+			if err = dw.addPage(size, page[:n]); err != nil {
+				_ = dw.enc.Close()
+				return
+			}
+			size += int64(n)
+		}
+		if rErr == io.EOF || rErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if rErr != nil {
+			_ = dw.enc.Close()
+			return rErr
+		}
+	}
+	if err = dw.flush(); err != nil {
+		_ = dw.enc.Close()
+		return
+	}
+	if err = dw.enc.Close(); err != nil {
+		return
+	}
+	if err = bw.Flush(); err != nil {
+		return
+	}
+	return dw.stream.Send(&nodesyncproto.ColdSyncResponse{
+		Filename:     deltaFilename,
+		ProtocolType: deltaProtocol,
+		Sha256:       hasher.Sum(nil),
+		FileSize:     uint64(size),
+		Compression:  dw.codec.Compression,
+	})
+}
+
+// addPage appends the changed page to the current run, equal pages end the run
+func (dw *deltaWriter) addPage(offset int64, page []byte) (err error) {
+	idx := dw.pages * pageHashSize
+	dw.pages++
+	if idx+pageHashSize <= len(dw.hashes) && bytes.Equal(dw.hashes[idx:idx+pageHashSize], pageHash(page)) {
+		return dw.flush()
+	}
+	dw.changed++
+	if len(dw.run) == 0 {
+		dw.runOff = offset
+	}
+	dw.run = append(dw.run, page...)
+	if len(dw.run) >= chunkSize {
+		return dw.flush()
+	}
+	return
+}
+
+func (dw *deltaWriter) flush() (err error) {
+	if len(dw.run) == 0 {
+		return
+	}
+	var header [deltaHeaderSize]byte
+	binary.BigEndian.PutUint64(header[:8], uint64(dw.runOff))
+	binary.BigEndian.PutUint32(header[8:], uint32(len(dw.run)))
+	if _, err = dw.enc.Write(header[:]); err != nil {
+		return
+	}
+	if _, err = dw.enc.Write(dw.run); err != nil {
+		return
+	}
+	dw.run = dw.run[:0]
+	return
+}
+
+// deltaReader applies the received frames to the copy of the local store
+type deltaReader struct {
+	path      string
+	stream    nodesyncproto.DRPCNodeSync_ColdSyncClient
+	f         *os.File
+	pw        *io.PipeWriter
+	applyDone chan error
+	peerId    string
+	bandwidth bandwidth.Bandwidth
+}
+
+func (dr *deltaReader) Read(ctx context.Context) (err error) {
+	if dr.f, err = os.OpenFile(dr.path, os.O_RDWR, 0644); err != nil {
+		return
+	}
+	defer func() {
+		if dr.pw != nil {
+			_ = dr.pw.CloseWithError(err)
+			select {
+			case <-dr.applyDone:
+			case <-ctx.Done():
+			}
+		}
+		if cErr := dr.f.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}()
+	for {
+		var msg *nodesyncproto.ColdSyncResponse
+		if msg, err = dr.stream.Recv(); err != nil {
+			// the stream always ends with the trailer
+			return
+		}
+		if err = dr.bandwidth.WaitDownload(ctx, dr.peerId, len(msg.Data)); err != nil {
+			return
+		}
+		if msg.ProtocolType != deltaProtocol {
+			return nodesyncproto.ErrUnsupportedStorageType
+		}
+		if len(msg.Sha256) != 0 {
+			return dr.finish(ctx, msg)
+		}
+		if crc := crc32.ChecksumIEEE(msg.Data); crc != msg.Crc32 {
+			return errCrcMismatch
+		}
+		if dr.pw == nil {
+			dr.startApply(msg.Compression)
+		}
+		if _, err = dr.pw.Write(msg.Data); err != nil {
+			return
+		}
+	}
+}
+
+func (dr *deltaReader) startApply(compression nodesyncproto.ColdSyncCompression) {
+	var pr *io.PipeReader
+	pr, dr.pw = io.Pipe()
+	dr.applyDone = make(chan error, 1)
+	go func() {
+		err := dr.apply(pr, compression)
+		// unblock the sender of the chunks
+		_ = pr.CloseWithError(err)
+		dr.applyDone <- err
+	}()
+}
+
+func (dr *deltaReader) apply(r io.Reader, compression nodesyncproto.ColdSyncCompression) (err error) {
+	dec, err := newDecoder(r, compression)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = dec.Close()
+	}()
+	var (
+		header [deltaHeaderSize]byte
+		buf    []byte
+	)
+	for {
+		if _, err = io.ReadFull(dec, header[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return
+		}
+		offset := binary.BigEndian.Uint64(header[:8])
+		length := binary.BigEndian.Uint32(header[8:])
+		if length == 0 || length > maxDeltaFrame {
+			return fmt.Errorf("%w: length %d", errDeltaFrame, length)
+		}
+		if cap(buf) < int(length) {
+			buf = make([]byte, length)
+		}
+		buf = buf[:length]
+		if _, err = io.ReadFull(dec, buf); err != nil {
+			return
+		}
+		if _, err = dr.f.WriteAt(buf, int64(offset)); err != nil {
+			return
+		}
+	}
+}
+
+// finish waits for the frames to be written and checks the result against the remote file
+func (dr *deltaReader) finish(ctx context.Context, msg *nodesyncproto.ColdSyncResponse) (err error) {
+	if dr.pw != nil {
+		_ = dr.pw.Close()
+		select {
+		case err = <-dr.applyDone:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		dr.pw = nil
+		if err != nil {
+			return
+		}
+	}
+	if err = dr.f.Truncate(int64(msg.FileSize)); err != nil {
+		return
+	}
+	if _, err = dr.f.Seek(0, io.SeekStart); err != nil {
+		return
+	}
+	hasher := sha256.New()
+	if _, err = io.Copy(hasher, dr.f); err != nil {
+		return
+	}
+	if !bytes.Equal(hasher.Sum(nil), msg.Sha256) {
+		return fmt.Errorf("%w: %s", errChecksumMismatch, msg.Filename)
+	}
+	return
+}
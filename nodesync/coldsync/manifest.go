@@ -0,0 +1,63 @@
+package coldsync
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/anyproto/any-sync-node/nodesync/nodesyncproto"
+)
+
+// manifestName is a file in the partial space directory with the resume token of the interrupted sync
+const manifestName = ".coldsync.json"
+
+type manifest struct {
+	Token string `json:"token"`
+}
+
+func readManifest(dir string) (m manifest) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestName))
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(data, &m)
+	return
+}
+
+func writeManifest(dir string, m manifest) (err error) {
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	return os.WriteFile(filepath.Join(dir, manifestName), data, 0644)
+}
+
+// partialOffsets returns sizes of the received files, they are offsets to continue from
+func partialOffsets(dir string) (offsets []*nodesyncproto.ColdSyncFileOffset, err error) {
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() == manifestName {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		offsets = append(offsets, &nodesyncproto.ColdSyncFileOffset{
+			Filename: filepath.Join(string(filepath.Separator), rel),
+			Offset:   uint64(info.Size()),
+		})
+		return nil
+	})
+	return
+}
@@ -56,6 +56,20 @@ func (mr *MockColdSyncMockRecorder) ColdSyncHandle(req, stream any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ColdSyncHandle", reflect.TypeOf((*MockColdSync)(nil).ColdSyncHandle), req, stream)
 }
 
+// ForceSync mocks base method.
+func (m *MockColdSync) ForceSync(ctx context.Context, spaceId, peerId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceSync", ctx, spaceId, peerId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForceSync indicates an expected call of ForceSync.
+func (mr *MockColdSyncMockRecorder) ForceSync(ctx, spaceId, peerId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceSync", reflect.TypeOf((*MockColdSync)(nil).ForceSync), ctx, spaceId, peerId)
+}
+
 // Init mocks base method.
 func (m *MockColdSync) Init(a *app.App) error {
 	m.ctrl.T.Helper()
@@ -97,3 +111,17 @@ func (mr *MockColdSyncMockRecorder) Sync(ctx, spaceId, peerId any) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sync", reflect.TypeOf((*MockColdSync)(nil).Sync), ctx, spaceId, peerId)
 }
+
+// SyncDelta mocks base method.
+func (m *MockColdSync) SyncDelta(ctx context.Context, spaceId, peerId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncDelta", ctx, spaceId, peerId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncDelta indicates an expected call of SyncDelta.
+func (mr *MockColdSyncMockRecorder) SyncDelta(ctx, spaceId, peerId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncDelta", reflect.TypeOf((*MockColdSync)(nil).SyncDelta), ctx, spaceId, peerId)
+}
@@ -2,8 +2,9 @@ package coldsync
 
 import (
 	"bytes"
-	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"hash/crc32"
 	"io"
@@ -11,20 +12,38 @@ import (
 	"path/filepath"
 
 	"go.uber.org/multierr"
+	"go.uber.org/zap"
 
+	"github.com/anyproto/any-sync-node/nodesync/bandwidth"
 	"github.com/anyproto/any-sync-node/nodesync/nodesyncproto"
 )
 
+var (
+	errCrcMismatch      = errors.New("crc32 mismatched")
+	errChecksumMismatch = errors.New("file checksum mismatched")
+)
+
 type streamReader struct {
 	dir    string
 	stream nodesyncproto.DRPCNodeSync_ColdSyncClient
 	saver  *fileSaver
+	// resumeToken is the token of the snapshot whose files are in dir
+	resumeToken string
+	started     bool
+	peerId      string
+	bandwidth   bandwidth.Bandwidth
 }
 
 func (sr *streamReader) Read(ctx context.Context) (err error) {
 	defer func() {
-		if err == io.EOF && sr.saver != nil {
-			err = sr.saver.Close(ctx)
+		if err == io.EOF && sr.started {
+			err = nil
+			if sr.saver != nil {
+				err = sr.saver.Close(ctx)
+			}
+		} else if sr.saver != nil {
+			// keep the data received so far for the resumed sync
+			sr.saver.Abort(ctx, err)
 		}
 	}()
 	for {
@@ -33,45 +52,118 @@ func (sr *streamReader) Read(ctx context.Context) (err error) {
 		if err != nil {
 			return
 		}
+		if err = sr.bandwidth.WaitDownload(ctx, sr.peerId, len(msg.Data)); err != nil {
+			return
+		}
 		if msg.ProtocolType != currentStorageProtocol {
 			return nodesyncproto.ErrUnsupportedStorageType
 		}
-		if err = sr.writeChunk(ctx, msg); err != nil {
+		if msg.ResumeToken != sr.resumeToken {
+			if err = sr.startOver(msg.ResumeToken); err != nil {
+				return
+			}
+		}
+		sr.started = true
+		if len(msg.Sha256) != 0 {
+			err = sr.finishFile(ctx, msg)
+		} else {
+			err = sr.writeChunk(ctx, msg)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// startOver drops files of the previous attempt, the remote streams a new snapshot from the beginning
+func (sr *streamReader) startOver(token string) (err error) {
+	if sr.started {
+		return fmt.Errorf("resume token changed during the stream")
+	}
+	if sr.resumeToken != "" {
+		log.Info("cold sync can't be resumed, starting over", zap.String("dir", sr.dir))
+	}
+	if err = os.RemoveAll(sr.dir); err != nil {
+		return
+	}
+	sr.resumeToken = token
+	return writeManifest(sr.dir, manifest{Token: token})
+}
+
+// resumable tells whether the files in dir can be continued by the next attempt after the error
+func (sr *streamReader) resumable(err error) bool {
+	return sr.resumeToken != "" &&
+		!errors.Is(err, errCrcMismatch) &&
+		!errors.Is(err, errChecksumMismatch) &&
+		!errors.Is(err, nodesyncproto.ErrUnsupportedStorageType)
+}
+
+// finishFile closes the file and compares it with the size and the checksum of the remote file
+func (sr *streamReader) finishFile(ctx context.Context, msg *nodesyncproto.ColdSyncResponse) (err error) {
+	if sr.saver != nil {
+		err = sr.saver.Close(ctx)
+		sr.saver = nil
+		if err != nil {
 			return
 		}
 	}
+	f, err := os.Open(filepath.Join(sr.dir, msg.Filename))
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, f)
+	if err != nil {
+		return
+	}
+	if uint64(size) != msg.FileSize || !bytes.Equal(hasher.Sum(nil), msg.Sha256) {
+		return fmt.Errorf("%w: %s", errChecksumMismatch, msg.Filename)
+	}
+	return
 }
 
 func (sr *streamReader) writeChunk(ctx context.Context, msg *nodesyncproto.ColdSyncResponse) (err error) {
 	if sr.saver == nil {
-		if sr.saver, err = sr.newFileSaver(msg.Filename); err != nil {
+		if sr.saver, err = sr.newFileSaver(msg.Filename, msg.Offset, msg.Compression); err != nil {
 			return
 		}
 	} else if sr.saver.name != msg.Filename {
-		if err = sr.saver.Close(ctx); err != nil {
+		err = sr.saver.Close(ctx)
+		sr.saver = nil
+		if err != nil {
 			return
 		}
-		if sr.saver, err = sr.newFileSaver(msg.Filename); err != nil {
+		if sr.saver, err = sr.newFileSaver(msg.Filename, msg.Offset, msg.Compression); err != nil {
 			return
 		}
 	}
 	return sr.saver.AddChunk(msg)
 }
 
-func (sr *streamReader) newFileSaver(name string) (fs *fileSaver, err error) {
+func (sr *streamReader) newFileSaver(name string, offset uint64, compression nodesyncproto.ColdSyncCompression) (fs *fileSaver, err error) {
 	fs = &fileSaver{
-		name: name,
-		sr:   sr,
+		name:        name,
+		offset:      int64(offset),
+		compression: compression,
+		sr:          sr,
 	}
-	return fs, fs.init()
+	if err = fs.init(); err != nil {
+		return nil, err
+	}
+	return fs, nil
 }
 
 type fileSaver struct {
-	name string
-	sr   *streamReader
-	f    *os.File
-	pr   *io.PipeReader
-	pw   *io.PipeWriter
+	name        string
+	offset      int64
+	compression nodesyncproto.ColdSyncCompression
+	sr          *streamReader
+	f           *os.File
+	pr          *io.PipeReader
+	pw          *io.PipeWriter
 
 	copierDone chan error
 }
@@ -83,7 +175,16 @@ func (fs *fileSaver) init() (err error) {
 			return mkdirErr
 		}
 	}
-	if fs.f, err = os.Create(fpath); err != nil {
+	if fs.f, err = os.OpenFile(fpath, os.O_CREATE|os.O_WRONLY, 0644); err != nil {
+		return err
+	}
+	// the remote continues from the offset, anything after it is dropped
+	if err = fs.f.Truncate(fs.offset); err != nil {
+		_ = fs.f.Close()
+		return err
+	}
+	if _, err = fs.f.Seek(fs.offset, io.SeekStart); err != nil {
+		_ = fs.f.Close()
 		return err
 	}
 	fs.pr, fs.pw = io.Pipe()
@@ -94,7 +195,7 @@ func (fs *fileSaver) init() (err error) {
 
 func (fs *fileSaver) AddChunk(msg *nodesyncproto.ColdSyncResponse) (err error) {
 	if crc := crc32.ChecksumIEEE(msg.Data); crc != msg.Crc32 {
-		return fmt.Errorf("crc32 mismatched")
+		return errCrcMismatch
 	}
 	if _, err = io.Copy(fs.pw, bytes.NewReader(msg.Data)); err != nil {
 		return
@@ -103,15 +204,28 @@ func (fs *fileSaver) AddChunk(msg *nodesyncproto.ColdSyncResponse) (err error) {
 }
 
 func (fs *fileSaver) copier() {
-	gr, err := gzip.NewReader(fs.pr)
+	dec, err := newDecoder(fs.pr, fs.compression)
 	if err != nil {
+		// unblock the sender of the chunks
+		_ = fs.pr.CloseWithError(err)
 		fs.copierDone <- err
 		return
 	}
-	_, err = io.Copy(fs.f, gr)
+	_, err = io.Copy(fs.f, dec)
+	_ = dec.Close()
 	fs.copierDone <- err
 }
 
+// Abort stops the file at the last received data
+func (fs *fileSaver) Abort(ctx context.Context, err error) {
+	_ = fs.pw.CloseWithError(err)
+	select {
+	case <-fs.copierDone:
+	case <-ctx.Done():
+	}
+	_ = fs.f.Close()
+}
+
 func (fs *fileSaver) Close(ctx context.Context) error {
 	var errs []error
 
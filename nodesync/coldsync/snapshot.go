@@ -0,0 +1,105 @@
+package coldsync
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// snapshotTTL is how long a snapshot of an interrupted sync waits for the resumed request
+const snapshotTTL = time.Minute * 30
+
+// snapshot is a backup of the space that is streamed to the remote, it doesn't change between resumed requests
+type snapshot struct {
+	token   string
+	spaceId string
+	dir     string
+	refs    int
+	timer   *time.Timer
+}
+
+type snapshots struct {
+	root    string
+	mu      sync.Mutex
+	byToken map[string]*snapshot
+}
+
+func newSnapshots(root string) *snapshots {
+	// snapshots of the previous run can't be resumed, tokens are kept in memory
+	_ = os.RemoveAll(root)
+	return &snapshots{
+		root:    root,
+		byToken: make(map[string]*snapshot),
+	}
+}
+
+// acquire returns the snapshot of the token or makes a new one with backup
+func (s *snapshots) acquire(ctx context.Context, spaceId, token string, backup func(ctx context.Context, dir string) error) (snap *snapshot, resumed bool, err error) {
+	s.mu.Lock()
+	if snap = s.byToken[token]; snap != nil && snap.spaceId == spaceId {
+		snap.refs++
+		if snap.timer != nil {
+			snap.timer.Stop()
+			snap.timer = nil
+		}
+		s.mu.Unlock()
+		return snap, true, nil
+	}
+	s.mu.Unlock()
+
+	snap = &snapshot{
+		token:   newToken(),
+		spaceId: spaceId,
+		refs:    1,
+	}
+	snap.dir = filepath.Join(s.root, snap.token)
+	if err = backup(ctx, snap.dir); err != nil {
+		_ = os.RemoveAll(snap.dir)
+		return nil, false, err
+	}
+	s.mu.Lock()
+	s.byToken[snap.token] = snap
+	s.mu.Unlock()
+	return snap, false, nil
+}
+
+// release removes the snapshot after a completed sync, otherwise it is kept for snapshotTTL
+func (s *snapshots) release(snap *snapshot, completed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap.refs--
+	if snap.refs > 0 {
+		return
+	}
+	if completed {
+		s.remove(snap)
+		return
+	}
+	snap.timer = time.AfterFunc(snapshotTTL, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if snap.refs == 0 && s.byToken[snap.token] == snap {
+			log.Debug("cold sync snapshot is expired", zap.String("spaceId", snap.spaceId))
+			s.remove(snap)
+		}
+	})
+}
+
+func (s *snapshots) remove(snap *snapshot) {
+	delete(s.byToken, snap.token)
+	if err := os.RemoveAll(snap.dir); err != nil {
+		log.Warn("can't remove cold sync snapshot", zap.String("spaceId", snap.spaceId), zap.Error(err))
+	}
+}
+
+func newToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,37 @@
+package coldsync
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type coldSyncStat struct {
+	dumpsActive   atomic.Int32
+	dumpsQueued   atomic.Int32
+	dumpsRejected atomic.Uint32
+}
+
+func registerMetric(s *coldSyncStat, registry *prometheus.Registry) {
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "nodesync",
+		Subsystem: "coldsync",
+		Name:      "dumps_active",
+	}, func() float64 {
+		return float64(s.dumpsActive.Load())
+	}))
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "nodesync",
+		Subsystem: "coldsync",
+		Name:      "dumps_queued",
+	}, func() float64 {
+		return float64(s.dumpsQueued.Load())
+	}))
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "nodesync",
+		Subsystem: "coldsync",
+		Name:      "dumps_rejected_count",
+	}, func() float64 {
+		return float64(s.dumpsRejected.Load())
+	}))
+}
@@ -0,0 +1,51 @@
+package coldsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	anystore "github.com/anyproto/any-store"
+	"github.com/anyproto/any-sync/commonspace/spacepayloads"
+	"github.com/anyproto/any-sync/commonspace/spacestorage"
+	"github.com/anyproto/any-sync/commonspace/spacesyncproto"
+)
+
+var ErrInvalidStore = errors.New("received space store is invalid")
+
+// validateStore checks the received store before it replaces the space directory:
+// sqlite quick check, the space state and the signed space header must belong to spaceId
+func validateStore(ctx context.Context, dir, spaceId string) (err error) {
+	db, err := anystore.Open(ctx, filepath.Join(dir, "store.db"), &anystore.Config{ReadConnections: 1})
+	if err != nil {
+		return fmt.Errorf("%w: open: %w", ErrInvalidStore, err)
+	}
+	defer func() {
+		if cErr := db.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}()
+	if err = db.QuickCheck(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidStore, err)
+	}
+	store, err := spacestorage.New(ctx, spaceId, db)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidStore, err)
+	}
+	state, err := store.StateStorage().GetState(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: state: %w", ErrInvalidStore, err)
+	}
+	if state.SpaceId != spaceId {
+		return fmt.Errorf("%w: state of the space %s", ErrInvalidStore, state.SpaceId)
+	}
+	_, err = spacepayloads.ValidateSpaceHeader(&spacesyncproto.RawSpaceHeaderWithId{
+		RawHeader: state.SpaceHeader,
+		Id:        spaceId,
+	}, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("%w: space header: %w", ErrInvalidStore, err)
+	}
+	return
+}
@@ -2,13 +2,15 @@ package coldsync
 
 import (
 	"bufio"
-	"compress/gzip"
+	"context"
+	"crypto/sha256"
 	"hash/crc32"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
 
+	"github.com/anyproto/any-sync-node/nodesync/bandwidth"
 	"github.com/anyproto/any-sync-node/nodesync/nodesyncproto"
 )
 
@@ -18,6 +20,16 @@ type streamWriter struct {
 	dir    string
 	stream nodesyncproto.DRPCNodeSync_ColdSyncStream
 	fw     *fileWriter
+	token  string
+	codec  *nodesyncproto.ColdSyncCodec
+	// protocol is recorded in every response
+	protocol nodesyncproto.ColdSyncProtocolType
+	// offsets are sizes of files already received by the remote, keyed by the cleaned filename
+	offsets map[string]uint64
+	// the upload to peerId is shaped by bandwidth
+	ctx       context.Context
+	peerId    string
+	bandwidth bandwidth.Bandwidth
 }
 
 func (sw *streamWriter) Write() (err error) {
@@ -44,10 +56,27 @@ func (sw *streamWriter) writeFile(path string) (err error) {
 		_ = f.Close()
 	}()
 	filename := path[len(sw.dir):]
-	fw := sw.newFileWriter(filename)
+	info, err := f.Stat()
+	if err != nil {
+		return
+	}
+	// received files are sent as empty streams, so the remote knows they are complete
+	offset := sw.offsets[filepath.Clean(filename)]
+	if offset > uint64(info.Size()) {
+		offset = 0
+	}
+	// the checksum covers the whole file, so the part received before is hashed without sending
+	hasher := sha256.New()
+	if _, err = io.CopyN(hasher, f, int64(offset)); err != nil {
+		return
+	}
+	fw := sw.newFileWriter(filename, offset)
 	bw := bufio.NewWriterSize(fw, chunkSize)
-	gw := gzip.NewWriter(bw)
-	if _, err = io.Copy(gw, f); err != nil {
+	gw, err := newEncoder(bw, sw.codec)
+	if err != nil {
+		return
+	}
+	if _, err = io.Copy(gw, io.TeeReader(f, hasher)); err != nil {
 		_ = gw.Close()
 		return
 	}
@@ -57,28 +86,44 @@ func (sw *streamWriter) writeFile(path string) (err error) {
 	if err = bw.Flush(); err != nil {
 		return
 	}
-	return
+	return sw.stream.Send(&nodesyncproto.ColdSyncResponse{
+		Filename:     filename,
+		ProtocolType: sw.protocol,
+		ResumeToken:  sw.token,
+		Offset:       offset,
+		Sha256:       hasher.Sum(nil),
+		FileSize:     uint64(info.Size()),
+		Compression:  sw.codec.Compression,
+	})
 }
 
-func (sw *streamWriter) newFileWriter(filename string) *fileWriter {
+func (sw *streamWriter) newFileWriter(filename string, offset uint64) *fileWriter {
 	if sw.fw == nil {
 		sw.fw = &fileWriter{sw: sw}
 	}
 	sw.fw.filename = filename
+	sw.fw.offset = offset
 	return sw.fw
 }
 
 type fileWriter struct {
 	filename string
+	offset   uint64
 	sw       *streamWriter
 }
 
 func (f *fileWriter) Write(p []byte) (n int, err error) {
+	if err = f.sw.bandwidth.WaitUpload(f.sw.ctx, f.sw.peerId, len(p)); err != nil {
+		return
+	}
 	if err = f.sw.stream.Send(&nodesyncproto.ColdSyncResponse{
 		Filename:     f.filename,
 		Data:         p,
 		Crc32:        crc32.ChecksumIEEE(p),
-		ProtocolType: currentRespProtocol,
+		ProtocolType: f.sw.protocol,
+		ResumeToken:  f.sw.token,
+		Offset:       f.offset,
+		Compression:  f.sw.codec.Compression,
 	}); err != nil {
 		return
 	}
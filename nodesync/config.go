@@ -1,13 +1,24 @@
 package nodesync
 
-import "github.com/anyproto/any-sync-node/nodesync/hotsync"
+import (
+	"github.com/anyproto/any-sync-node/nodesync/bandwidth"
+	"github.com/anyproto/any-sync-node/nodesync/coldsync"
+	"github.com/anyproto/any-sync-node/nodesync/hotsync"
+)
 
 type configGetter interface {
 	GetNodeSync() Config
 }
 
 type Config struct {
-	SyncOnStart       bool           `yaml:"syncOnStart"`
-	PeriodicSyncHours int            `yaml:"periodicSyncHours"`
-	HotSync           hotsync.Config `yaml:"hotSync"`
+	SyncOnStart       bool             `yaml:"syncOnStart"`
+	PeriodicSyncHours int              `yaml:"periodicSyncHours"`
+	HotSync           hotsync.Config   `yaml:"hotSync"`
+	ColdSync          coldsync.Config  `yaml:"coldSync"`
+	Bandwidth         bandwidth.Config `yaml:"bandwidth"`
+	// RunHistory is the number of sync run reports kept in memory, 20 by default
+	RunHistory int `yaml:"runHistory"`
+	// PersistRuns saves sync run reports to the index storage, so they survive restarts
+	PersistRuns bool             `yaml:"persistRuns"`
+	PeerHealth  PeerHealthConfig `yaml:"peerHealth"`
 }
@@ -2,6 +2,12 @@ package hotsync
 
 type Config struct {
 	SimultaneousRequests int `yaml:"simultaneousRequests"`
+	// RetryBackoffSeconds is the delay before the next try of the space that failed to load, it doubles with every attempt, 30 by default
+	RetryBackoffSeconds int `yaml:"retryBackoffSeconds"`
+	// MaxRetryBackoffSeconds limits the retry delay, 3600 by default
+	MaxRetryBackoffSeconds int `yaml:"maxRetryBackoffSeconds"`
+	// MaxAttempts is the number of failed loads after that the space is dropped from the queue, 10 by default
+	MaxAttempts int `yaml:"maxAttempts"`
 }
 
 type configGetter interface {
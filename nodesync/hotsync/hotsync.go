@@ -2,30 +2,55 @@
 package hotsync
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"slices"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/anyproto/any-sync/app"
 	"github.com/anyproto/any-sync/app/logger"
 	"github.com/anyproto/any-sync/app/ocache"
+	"github.com/anyproto/any-sync/commonspace/spacestorage"
 	"github.com/anyproto/any-sync/util/periodicsync"
-	"github.com/anyproto/any-sync/util/slice"
 	"go.uber.org/zap"
 
 	"github.com/anyproto/any-sync-node/nodespace"
+	"github.com/anyproto/any-sync-node/nodestorage"
 )
 
 var log = logger.NewNamed(CName)
 
+var ErrNotQueued = errors.New("space is not in the hot sync queue")
+
 const (
-	defaultSimRequests = 300
-	CName              = "node.nodesync.hotsync"
+	defaultSimRequests     = 300
+	defaultRetryBackoff    = time.Second * 30
+	defaultMaxRetryBackoff = time.Hour
+	defaultMaxAttempts     = 10
+	CName                  = "node.nodesync.hotsync"
+)
+
+const (
+	PriorityNormal = 0
+	// PriorityDivergence is given to spaces whose heads the auditor found diverged from other owners
+	// and to spaces reported as changed again by the node sync while they wait in the queue
+	PriorityDivergence = 10
+	// PriorityUser is for queued spaces clients sync with and for spaces requested by operators
+	PriorityUser = 20
 )
 
 type HotSync interface {
 	app.ComponentRunnable
 	UpdateQueue(changedIds []string)
+	// Enqueue adds spaces with the priority, already queued spaces get the priority when it's higher
+	Enqueue(priority int, spaceIds ...string)
+	// SetPriority changes the priority of the queued space
+	SetPriority(spaceId string, priority int) (err error)
+	// Queue returns queued spaces in the order they are going to be synced, limit 0 means all
+	Queue(limit int) (entries []nodestorage.HotSyncEntry)
 	SetMetric(hit, miss *atomic.Uint32)
 }
 
@@ -38,24 +63,48 @@ type idProvider interface {
 }
 
 type hotSync struct {
-	spaceQueue       []string
+	// spaceQueue keeps the order of adding, queued indexes it by space id
+	spaceQueue       []*nodestorage.HotSyncEntry
+	queued           map[string]*nodestorage.HotSyncEntry
 	syncQueue        map[string]struct{}
 	simultaneousSync int
+	retryBackoff     time.Duration
+	maxRetryBackoff  time.Duration
+	maxAttempts      int
 	hit              *atomic.Uint32
 	miss             *atomic.Uint32
+	now              func() time.Time
 
 	spaceService nodespace.Service
+	storage      nodestorage.NodeStorage
 	periodicSync periodicsync.PeriodicSync
 	mx           sync.Mutex
 }
 
 func (h *hotSync) Init(a *app.App) (err error) {
-	h.simultaneousSync = a.MustComponent("config").(configGetter).GetHotSync().SimultaneousRequests
+	conf := a.MustComponent("config").(configGetter).GetHotSync()
+	h.simultaneousSync = conf.SimultaneousRequests
 	if h.simultaneousSync == 0 {
 		h.simultaneousSync = defaultSimRequests
 	}
+	h.retryBackoff = time.Duration(conf.RetryBackoffSeconds) * time.Second
+	if h.retryBackoff <= 0 {
+		h.retryBackoff = defaultRetryBackoff
+	}
+	h.maxRetryBackoff = time.Duration(conf.MaxRetryBackoffSeconds) * time.Second
+	if h.maxRetryBackoff <= 0 {
+		h.maxRetryBackoff = defaultMaxRetryBackoff
+	}
+	h.maxAttempts = conf.MaxAttempts
+	if h.maxAttempts <= 0 {
+		h.maxAttempts = defaultMaxAttempts
+	}
+	h.now = time.Now
+	h.queued = map[string]*nodestorage.HotSyncEntry{}
 	h.syncQueue = map[string]struct{}{}
 	h.spaceService = a.MustComponent(nodespace.CName).(nodespace.Service)
+	h.storage = a.MustComponent(spacestorage.CName).(nodestorage.NodeStorage)
+	h.spaceService.OnClientHeadSync(h.clientHeadSync)
 	h.periodicSync = periodicsync.NewPeriodicSync(10, 0, h.checkCache, log)
 	return
 }
@@ -65,8 +114,11 @@ func (h *hotSync) Name() (name string) {
 }
 
 func (h *hotSync) Run(ctx context.Context) (err error) {
+	if err = h.loadQueue(ctx); err != nil {
+		log.Warn("can't load hot sync queue", zap.Error(err))
+	}
 	h.periodicSync.Run()
-	return
+	return nil
 }
 
 func (h *hotSync) Close(ctx context.Context) (err error) {
@@ -79,39 +131,212 @@ func (h *hotSync) SetMetric(hit, miss *atomic.Uint32) {
 }
 
 func (h *hotSync) UpdateQueue(changedIds []string) {
+	h.enqueue(changedIds, PriorityNormal, PriorityDivergence)
+}
+
+func (h *hotSync) Enqueue(priority int, spaceIds ...string) {
+	h.enqueue(spaceIds, priority, priority)
+}
+
+// enqueue adds new spaces with the priority, spaces that are already queued get requeuedPriority when it's higher.
+// Spaces that are loaded for sync right now are skipped
+func (h *hotSync) enqueue(spaceIds []string, priority, requeuedPriority int) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	var (
+		changed []nodestorage.HotSyncEntry
+		added   int
+		now     = h.now()
+	)
+	for _, id := range spaceIds {
+		if _, ok := h.syncQueue[id]; ok {
+			continue
+		}
+		if entry, ok := h.queued[id]; ok {
+			if entry.Priority < requeuedPriority {
+				entry.Priority = requeuedPriority
+				changed = append(changed, *entry)
+			}
+			continue
+		}
+		entry := &nodestorage.HotSyncEntry{SpaceId: id, Priority: priority, AddedAt: now}
+		h.queued[id] = entry
+		h.spaceQueue = append(h.spaceQueue, entry)
+		changed = append(changed, *entry)
+		added++
+	}
+	h.save(changed, nil)
+	log.Info("updated queue", zap.Int("added", added), zap.Int("queue len", len(h.spaceQueue)))
+}
+
+// clientHeadSync raises the priority of the queued space, the client could get its stale head from the node head.
+// Spaces that aren't queued are not added: clients sync all their spaces and those are loaded on changes anyway
+func (h *hotSync) clientHeadSync(spaceId string) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if entry, ok := h.queued[spaceId]; ok && entry.Priority < PriorityUser {
+		entry.Priority = PriorityUser
+		h.save([]nodestorage.HotSyncEntry{*entry}, nil)
+	}
+}
+
+func (h *hotSync) SetPriority(spaceId string, priority int) (err error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	entry, ok := h.queued[spaceId]
+	if !ok {
+		return ErrNotQueued
+	}
+	entry.Priority = priority
+	h.save([]nodestorage.HotSyncEntry{*entry}, nil)
+	return nil
+}
+
+func (h *hotSync) Queue(limit int) (entries []nodestorage.HotSyncEntry) {
 	h.mx.Lock()
 	defer h.mx.Unlock()
-	added := slice.Difference(changedIds, h.spaceQueue)
-	h.spaceQueue = append(h.spaceQueue, added...)
-	log.Info("updated queue", zap.Int("added", len(added)), zap.Int("queue len", len(h.spaceQueue)))
+	sorted := h.sortedQueue()
+	if limit > 0 && limit < len(sorted) {
+		sorted = sorted[:limit]
+	}
+	entries = make([]nodestorage.HotSyncEntry, len(sorted))
+	for i, entry := range sorted {
+		entries[i] = *entry
+	}
+	return
+}
+
+// sortedQueue returns entries with higher priority first, entries with equal priority keep the order of adding
+func (h *hotSync) sortedQueue() []*nodestorage.HotSyncEntry {
+	sorted := slices.Clone(h.spaceQueue)
+	slices.SortStableFunc(sorted, func(a, b *nodestorage.HotSyncEntry) int {
+		return cmp.Compare(b.Priority, a.Priority)
+	})
+	return sorted
 }
 
 func (h *hotSync) checkCache(ctx context.Context) (err error) {
-	log.Debug("checking cache", zap.Int("space queue len", len(h.spaceQueue)), zap.Int("sync queue len", len(h.syncQueue)))
 	removed := h.checkRemoved(ctx)
 	log.Debug("removed inactive", zap.Int("removed", removed))
 
 	h.mx.Lock()
-	newBatchLen := min(h.simultaneousSync-len(h.syncQueue), len(h.spaceQueue))
-	var cp []string
-	cp = append(cp, h.spaceQueue[:newBatchLen]...)
-	h.spaceQueue = h.spaceQueue[newBatchLen:]
+	log.Debug("checking cache", zap.Int("space queue len", len(h.spaceQueue)), zap.Int("sync queue len", len(h.syncQueue)))
+	var (
+		batch    []nodestorage.HotSyncEntry
+		batchLen = h.simultaneousSync - len(h.syncQueue)
+		now      = h.now()
+	)
+	for _, entry := range h.sortedQueue() {
+		if len(batch) >= batchLen {
+			break
+		}
+		if !entry.NextTry.After(now) {
+			batch = append(batch, *entry)
+		}
+	}
 	h.mx.Unlock()
 
-	for _, id := range cp {
-		_, err = h.spaceService.GetSpace(ctx, id)
-		if err != nil {
-			log.Warn("can't get space", zap.String("spaceId", id), zap.Error(err))
-			h.miss.Add(1)
+	var (
+		loaded  []string
+		dropped []string
+		retries []nodestorage.HotSyncEntry
+	)
+	for _, entry := range batch {
+		if _, err = h.spaceService.GetSpace(ctx, entry.SpaceId); err == nil {
+			h.hit.Add(1)
+			loaded = append(loaded, entry.SpaceId)
 			continue
 		}
-		log.Debug("got space", zap.String("spaceId", id), zap.Int("space queue len", len(h.spaceQueue)), zap.Int("sync queue len", len(h.syncQueue)))
-		h.hit.Add(1)
+		log.Warn("can't get space", zap.String("spaceId", entry.SpaceId), zap.Int("attempt", entry.Attempts+1), zap.Error(err))
+		h.miss.Add(1)
+		if entry.Attempts+1 >= h.maxAttempts {
+			log.Warn("space is dropped from hot sync queue", zap.String("spaceId", entry.SpaceId), zap.Int("attempts", entry.Attempts+1))
+			dropped = append(dropped, entry.SpaceId)
+			continue
+		}
+		entry.Attempts++
+		entry.LastError = err.Error()
+		entry.NextTry = now.Add(h.backoff(entry.Attempts))
+		retries = append(retries, entry)
+	}
+
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	for _, id := range loaded {
 		h.syncQueue[id] = struct{}{}
 	}
+	removedIds := append(loaded, dropped...)
+	h.remove(removedIds)
+	for i, entry := range retries {
+		// the priority could be changed while the space was loading
+		if queued, ok := h.queued[entry.SpaceId]; ok {
+			queued.Attempts, queued.LastError, queued.NextTry = entry.Attempts, entry.LastError, entry.NextTry
+			retries[i] = *queued
+		}
+	}
+	h.save(retries, removedIds)
+	log.Debug("checked cache", zap.Int("loaded", len(loaded)), zap.Int("retries", len(retries)), zap.Int("space queue len", len(h.spaceQueue)), zap.Int("sync queue len", len(h.syncQueue)))
 	return nil
 }
 
+// backoff returns the delay before the next try, it doubles with every attempt
+func (h *hotSync) backoff(attempts int) time.Duration {
+	if n := attempts - 1; n < 32 && h.retryBackoff<<n < h.maxRetryBackoff {
+		return h.retryBackoff << n
+	}
+	return h.maxRetryBackoff
+}
+
+func (h *hotSync) remove(spaceIds []string) {
+	if len(spaceIds) == 0 {
+		return
+	}
+	for _, id := range spaceIds {
+		delete(h.queued, id)
+	}
+	h.spaceQueue = slices.DeleteFunc(h.spaceQueue, func(entry *nodestorage.HotSyncEntry) bool {
+		_, ok := h.queued[entry.SpaceId]
+		return !ok
+	})
+}
+
+// save writes queue changes to the index storage, it must be called under the lock to keep the order of writes
+func (h *hotSync) save(entries []nodestorage.HotSyncEntry, removedIds []string) {
+	if h.storage == nil || len(entries) == 0 && len(removedIds) == 0 {
+		return
+	}
+	index := h.storage.IndexStorage()
+	err := errors.Join(
+		index.DeleteHotSyncEntries(context.Background(), removedIds...),
+		index.SaveHotSyncEntries(context.Background(), entries...),
+	)
+	if err != nil {
+		log.Warn("can't save hot sync queue", zap.Error(err))
+	}
+}
+
+// loadQueue restores the queue saved before the restart
+func (h *hotSync) loadQueue(ctx context.Context) (err error) {
+	if h.storage == nil {
+		return
+	}
+	entries, err := h.storage.IndexStorage().HotSyncEntries(ctx)
+	if err != nil {
+		return
+	}
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	for _, entry := range entries {
+		if _, ok := h.queued[entry.SpaceId]; ok {
+			continue
+		}
+		h.queued[entry.SpaceId] = &entry
+		h.spaceQueue = append(h.spaceQueue, &entry)
+	}
+	log.Info("hot sync queue loaded", zap.Int("queue len", len(h.spaceQueue)))
+	return
+}
+
 func (h *hotSync) checkRemoved(ctx context.Context) (removed int) {
 	cache := h.spaceService.Cache()
 	allIds := map[string]struct{}{}
@@ -120,6 +345,8 @@ func (h *hotSync) checkRemoved(ctx context.Context) (removed int) {
 		allIds[spc.Id()] = struct{}{}
 		return true
 	})
+	h.mx.Lock()
+	defer h.mx.Unlock()
 	for id := range h.syncQueue {
 		if _, exists := allIds[id]; !exists {
 			removed++
@@ -128,11 +355,3 @@ func (h *hotSync) checkRemoved(ctx context.Context) (removed int) {
 	}
 	return
 }
-
-func min(x int, y int) int {
-	if x < y {
-		return x
-	} else {
-		return y
-	}
-}
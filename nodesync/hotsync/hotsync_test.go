@@ -12,8 +12,12 @@ import (
 	"go.uber.org/mock/gomock"
 
 	"github.com/anyproto/any-sync-node/nodespace/mock_nodespace"
+	"github.com/anyproto/any-sync-node/nodestorage"
+	"github.com/anyproto/any-sync-node/nodestorage/mock_nodestorage"
 )
 
+var ctx = context.Background()
+
 type space struct {
 	id string
 }
@@ -54,6 +58,11 @@ func newFixture(t *testing.T, simReq int) *fixture {
 	sync.simultaneousSync = simReq
 	sync.spaceService = mockSpaceService
 	sync.syncQueue = map[string]struct{}{}
+	sync.queued = map[string]*nodestorage.HotSyncEntry{}
+	sync.retryBackoff = time.Second * 30
+	sync.maxRetryBackoff = time.Hour
+	sync.maxAttempts = 3
+	sync.now = time.Now
 	cache := ocache.New(func(ctx context.Context, id string) (value ocache.Object, err error) {
 		return newSpace(id), nil
 	})
@@ -65,15 +74,51 @@ func newFixture(t *testing.T, simReq int) *fixture {
 	}
 }
 
+func (fx *fixture) queuedIds() (ids []string) {
+	for _, entry := range fx.hotSync.Queue(0) {
+		ids = append(ids, entry.SpaceId)
+	}
+	return
+}
+
 func TestHotSync_UpdateQueue(t *testing.T) {
 	fx := newFixture(t, 10)
 	defer fx.stop()
 	fx.hotSync.UpdateQueue([]string{"b"})
-	require.Equal(t, []string{"b"}, fx.hotSync.spaceQueue)
-	fx.hotSync.UpdateQueue([]string{"a", "b", "c"})
-	require.Equal(t, []string{"b", "a", "c"}, fx.hotSync.spaceQueue)
+	require.Equal(t, []string{"b"}, fx.queuedIds())
+	fx.hotSync.UpdateQueue([]string{"a", "c"})
+	require.Equal(t, []string{"b", "a", "c"}, fx.queuedIds())
 	fx.hotSync.UpdateQueue([]string{"d", "e"})
-	require.Equal(t, []string{"b", "a", "c", "d", "e"}, fx.hotSync.spaceQueue)
+	require.Equal(t, []string{"b", "a", "c", "d", "e"}, fx.queuedIds())
+	// changed again while waiting in the queue
+	fx.hotSync.UpdateQueue([]string{"c"})
+	require.Equal(t, []string{"c", "b", "a", "d", "e"}, fx.queuedIds())
+	// spaces loaded for sync are not queued
+	fx.hotSync.syncQueue["f"] = struct{}{}
+	fx.hotSync.UpdateQueue([]string{"f"})
+	require.Len(t, fx.queuedIds(), 5)
+}
+
+func TestHotSync_Priority(t *testing.T) {
+	fx := newFixture(t, 10)
+	defer fx.stop()
+	fx.hotSync.UpdateQueue([]string{"a", "b", "c"})
+	fx.hotSync.Enqueue(PriorityUser, "d", "a")
+	require.Equal(t, []string{"a", "d", "b", "c"}, fx.queuedIds())
+	// enqueue doesn't lower the priority
+	fx.hotSync.Enqueue(PriorityNormal, "a")
+	require.Equal(t, []string{"a", "d", "b", "c"}, fx.queuedIds())
+
+	require.NoError(t, fx.hotSync.SetPriority("a", PriorityNormal))
+	require.NoError(t, fx.hotSync.SetPriority("c", PriorityDivergence))
+	require.Equal(t, []string{"d", "c", "a", "b"}, fx.queuedIds())
+	require.ErrorIs(t, fx.hotSync.SetPriority("unknown", PriorityUser), ErrNotQueued)
+	require.Len(t, fx.hotSync.Queue(2), 2)
+
+	// queued spaces clients sync with go first, other spaces are not added
+	fx.hotSync.clientHeadSync("b")
+	fx.hotSync.clientHeadSync("unknown")
+	require.Equal(t, []string{"b", "d", "c", "a"}, fx.queuedIds())
 }
 
 func TestHotSync_checkCache(t *testing.T) {
@@ -87,11 +132,11 @@ func TestHotSync_checkCache(t *testing.T) {
 		fx.hotSync.syncQueue["a"] = struct{}{}
 		fx.hotSync.syncQueue["b"] = struct{}{}
 		fx.hotSync.syncQueue["c"] = struct{}{}
-		fx.hotSync.spaceQueue = []string{"d", "e"}
+		fx.hotSync.UpdateQueue([]string{"d", "e"})
 
 		err := fx.hotSync.checkCache(context.Background())
 		require.NoError(t, err)
-		require.Equal(t, []string{"e"}, fx.hotSync.spaceQueue)
+		require.Equal(t, []string{"e"}, fx.queuedIds())
 		require.Contains(t, fx.hotSync.syncQueue, "d")
 		require.NotContains(t, fx.hotSync.syncQueue, "e")
 	})
@@ -105,11 +150,17 @@ func TestHotSync_checkCache(t *testing.T) {
 		fx.hotSync.syncQueue["a"] = struct{}{}
 		fx.hotSync.syncQueue["b"] = struct{}{}
 		fx.hotSync.syncQueue["c"] = struct{}{}
-		fx.hotSync.spaceQueue = []string{"d", "e"}
+		fx.hotSync.UpdateQueue([]string{"d", "e"})
 
 		err := fx.hotSync.checkCache(context.Background())
 		require.NoError(t, err)
-		require.Equal(t, []string{"e"}, fx.hotSync.spaceQueue)
+		// d is kept for the retry
+		queue := fx.hotSync.Queue(0)
+		require.Len(t, queue, 2)
+		require.Equal(t, "d", queue[0].SpaceId)
+		require.Equal(t, 1, queue[0].Attempts)
+		require.Equal(t, "some error", queue[0].LastError)
+		require.True(t, queue[0].NextTry.After(time.Now()))
 		require.NotContains(t, fx.hotSync.syncQueue, "d")
 		require.NotContains(t, fx.hotSync.syncQueue, "e")
 	})
@@ -145,9 +196,64 @@ func TestHotSync_checkCache(t *testing.T) {
 		fx.cache.Remove(context.Background(), "b")
 		err = fx.hotSync.checkCache(context.Background())
 		require.NoError(t, err)
-		require.Empty(t, fx.hotSync.spaceQueue)
+		require.Empty(t, fx.queuedIds())
 		require.Contains(t, fx.hotSync.syncQueue, "d")
 		require.Contains(t, fx.hotSync.syncQueue, "e")
 		require.Len(t, fx.hotSync.syncQueue, 3)
 	})
 }
+
+func TestHotSync_retry(t *testing.T) {
+	fx := newFixture(t, 3)
+	defer fx.stop()
+	now := time.Now()
+	fx.hotSync.now = func() time.Time { return now }
+	fx.mockSpaceService.EXPECT().Cache().Return(fx.cache).AnyTimes()
+	fx.mockSpaceService.EXPECT().GetSpace(gomock.Any(), "a").Return(nil, fmt.Errorf("some error")).Times(3)
+	fx.hotSync.UpdateQueue([]string{"a"})
+
+	require.NoError(t, fx.hotSync.checkCache(ctx))
+	require.Equal(t, now.Add(time.Second*30), fx.hotSync.Queue(0)[0].NextTry)
+	// the space waits for the backoff
+	require.NoError(t, fx.hotSync.checkCache(ctx))
+
+	now = now.Add(time.Minute)
+	require.NoError(t, fx.hotSync.checkCache(ctx))
+	require.Equal(t, now.Add(time.Minute), fx.hotSync.Queue(0)[0].NextTry)
+
+	// dropped after max attempts
+	now = now.Add(time.Hour)
+	require.NoError(t, fx.hotSync.checkCache(ctx))
+	require.Empty(t, fx.queuedIds())
+}
+
+func TestHotSync_persist(t *testing.T) {
+	fx := newFixture(t, 3)
+	defer fx.stop()
+	storage := mock_nodestorage.NewMockNodeStorage(fx.ctrl)
+	index := mock_nodestorage.NewMockIndexStorage(fx.ctrl)
+	storage.EXPECT().IndexStorage().Return(index).AnyTimes()
+	fx.hotSync.storage = storage
+
+	st := time.Now()
+	index.EXPECT().HotSyncEntries(ctx).Return([]nodestorage.HotSyncEntry{
+		{SpaceId: "a", AddedAt: st},
+		{SpaceId: "b", AddedAt: st, Priority: PriorityUser},
+	}, nil)
+	require.NoError(t, fx.hotSync.loadQueue(ctx))
+	require.Equal(t, []string{"b", "a"}, fx.queuedIds())
+
+	index.EXPECT().DeleteHotSyncEntries(gomock.Any())
+	index.EXPECT().SaveHotSyncEntries(gomock.Any(), gomock.Any()).Do(func(_ context.Context, entries ...nodestorage.HotSyncEntry) {
+		require.Len(t, entries, 1)
+		require.Equal(t, "c", entries[0].SpaceId)
+	})
+	fx.hotSync.UpdateQueue([]string{"c"})
+
+	fx.mockSpaceService.EXPECT().Cache().Return(fx.cache).AnyTimes()
+	fx.mockSpaceService.EXPECT().GetSpace(gomock.Any(), gomock.Any()).Return(nil, nil).Times(3)
+	index.EXPECT().DeleteHotSyncEntries(gomock.Any(), "b", "a", "c")
+	index.EXPECT().SaveHotSyncEntries(gomock.Any())
+	require.NoError(t, fx.hotSync.checkCache(ctx))
+	require.Empty(t, fx.queuedIds())
+}
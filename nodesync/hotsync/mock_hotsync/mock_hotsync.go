@@ -14,6 +14,7 @@ import (
 	reflect "reflect"
 	atomic "sync/atomic"
 
+	nodestorage "github.com/anyproto/any-sync-node/nodestorage"
 	app "github.com/anyproto/any-sync/app"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -56,6 +57,23 @@ func (mr *MockHotSyncMockRecorder) Close(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockHotSync)(nil).Close), ctx)
 }
 
+// Enqueue mocks base method.
+func (m *MockHotSync) Enqueue(priority int, spaceIds ...string) {
+	m.ctrl.T.Helper()
+	varargs := []any{priority}
+	for _, a := range spaceIds {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "Enqueue", varargs...)
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockHotSyncMockRecorder) Enqueue(priority any, spaceIds ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{priority}, spaceIds...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockHotSync)(nil).Enqueue), varargs...)
+}
+
 // Init mocks base method.
 func (m *MockHotSync) Init(a *app.App) error {
 	m.ctrl.T.Helper()
@@ -84,6 +102,20 @@ func (mr *MockHotSyncMockRecorder) Name() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockHotSync)(nil).Name))
 }
 
+// Queue mocks base method.
+func (m *MockHotSync) Queue(limit int) []nodestorage.HotSyncEntry {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Queue", limit)
+	ret0, _ := ret[0].([]nodestorage.HotSyncEntry)
+	return ret0
+}
+
+// Queue indicates an expected call of Queue.
+func (mr *MockHotSyncMockRecorder) Queue(limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Queue", reflect.TypeOf((*MockHotSync)(nil).Queue), limit)
+}
+
 // Run mocks base method.
 func (m *MockHotSync) Run(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -110,6 +142,20 @@ func (mr *MockHotSyncMockRecorder) SetMetric(hit, miss any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMetric", reflect.TypeOf((*MockHotSync)(nil).SetMetric), hit, miss)
 }
 
+// SetPriority mocks base method.
+func (m *MockHotSync) SetPriority(spaceId string, priority int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPriority", spaceId, priority)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPriority indicates an expected call of SetPriority.
+func (mr *MockHotSyncMockRecorder) SetPriority(spaceId, priority any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPriority", reflect.TypeOf((*MockHotSync)(nil).SetPriority), spaceId, priority)
+}
+
 // UpdateQueue mocks base method.
 func (m *MockHotSync) UpdateQueue(changedIds []string) {
 	m.ctrl.T.Helper()
@@ -12,7 +12,9 @@ package mock_nodesync
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
+	nodesync "github.com/anyproto/any-sync-node/nodesync"
 	app "github.com/anyproto/any-sync/app"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -41,6 +43,20 @@ func (m *MockNodeSync) EXPECT() *MockNodeSyncMockRecorder {
 	return m.recorder
 }
 
+// CancelSync mocks base method.
+func (m *MockNodeSync) CancelSync(runId string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelSync", runId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelSync indicates an expected call of CancelSync.
+func (mr *MockNodeSyncMockRecorder) CancelSync(runId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelSync", reflect.TypeOf((*MockNodeSync)(nil).CancelSync), runId)
+}
+
 // Close mocks base method.
 func (m *MockNodeSync) Close(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -97,6 +113,21 @@ func (mr *MockNodeSyncMockRecorder) Run(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockNodeSync)(nil).Run), ctx)
 }
 
+// StartSync mocks base method.
+func (m *MockNodeSync) StartSync() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartSync")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartSync indicates an expected call of StartSync.
+func (mr *MockNodeSyncMockRecorder) StartSync() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartSync", reflect.TypeOf((*MockNodeSync)(nil).StartSync))
+}
+
 // Sync mocks base method.
 func (m *MockNodeSync) Sync() error {
 	m.ctrl.T.Helper()
@@ -111,6 +142,64 @@ func (mr *MockNodeSyncMockRecorder) Sync() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sync", reflect.TypeOf((*MockNodeSync)(nil).Sync))
 }
 
+// SyncHistory mocks base method.
+func (m *MockNodeSync) SyncHistory() []nodesync.RunStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncHistory")
+	ret0, _ := ret[0].([]nodesync.RunStatus)
+	return ret0
+}
+
+// SyncHistory indicates an expected call of SyncHistory.
+func (mr *MockNodeSyncMockRecorder) SyncHistory() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncHistory", reflect.TypeOf((*MockNodeSync)(nil).SyncHistory))
+}
+
+// SyncPartition mocks base method.
+func (m *MockNodeSync) SyncPartition(partId int) (nodesync.RunStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncPartition", partId)
+	ret0, _ := ret[0].(nodesync.RunStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncPartition indicates an expected call of SyncPartition.
+func (mr *MockNodeSyncMockRecorder) SyncPartition(partId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncPartition", reflect.TypeOf((*MockNodeSync)(nil).SyncPartition), partId)
+}
+
+// SyncSpace mocks base method.
+func (m *MockNodeSync) SyncSpace(ctx context.Context, spaceId string, force bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncSpace", ctx, spaceId, force)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SyncSpace indicates an expected call of SyncSpace.
+func (mr *MockNodeSyncMockRecorder) SyncSpace(ctx, spaceId, force any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncSpace", reflect.TypeOf((*MockNodeSync)(nil).SyncSpace), ctx, spaceId, force)
+}
+
+// SyncStatus mocks base method.
+func (m *MockNodeSync) SyncStatus(runId string) (nodesync.RunStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncStatus", runId)
+	ret0, _ := ret[0].(nodesync.RunStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncStatus indicates an expected call of SyncStatus.
+func (mr *MockNodeSyncMockRecorder) SyncStatus(runId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncStatus", reflect.TypeOf((*MockNodeSync)(nil).SyncStatus), runId)
+}
+
 // WaitSyncOnStart mocks base method.
 func (m *MockNodeSync) WaitSyncOnStart() <-chan struct{} {
 	m.ctrl.T.Helper()
@@ -124,3 +213,17 @@ func (mr *MockNodeSyncMockRecorder) WaitSyncOnStart() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitSyncOnStart", reflect.TypeOf((*MockNodeSync)(nil).WaitSyncOnStart))
 }
+
+// WatchSync mocks base method.
+func (m *MockNodeSync) WatchSync(ctx context.Context, runId string, interval time.Duration, onStatus func(nodesync.RunStatus) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchSync", ctx, runId, interval, onStatus)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WatchSync indicates an expected call of WatchSync.
+func (mr *MockNodeSyncMockRecorder) WatchSync(ctx, runId, interval, onStatus any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchSync", reflect.TypeOf((*MockNodeSync)(nil).WatchSync), ctx, runId, interval, onStatus)
+}
@@ -2,11 +2,13 @@ package nodesync
 
 import (
 	"context"
+	"errors"
 
 	"github.com/anyproto/any-sync/app/ldiff"
 	"golang.org/x/exp/slices"
 
 	"github.com/anyproto/any-sync-node/nodehead"
+	"github.com/anyproto/any-sync-node/nodestorage"
 	"github.com/anyproto/any-sync-node/nodesync/nodesyncproto"
 )
 
@@ -56,6 +58,45 @@ func (n nodeRemoteDiff) Ranges(ctx context.Context, ranges []ldiff.Range, resBuf
 
 type nodeRemoteDiffHandler struct {
 	nodehead nodehead.NodeHead
+	// storage is optional, heads are compared with the current ones only without it
+	storage nodestorage.NodeStorage
+}
+
+func (n *nodeRemoteDiffHandler) SpaceHeads(ctx context.Context, req *nodesyncproto.SpaceHeadsRequest) (*nodesyncproto.SpaceHeadsResponse, error) {
+	resp := &nodesyncproto.SpaceHeadsResponse{
+		Heads:    make([]*nodesyncproto.PartitionSyncResultElement, len(req.SpaceIds)),
+		Contains: make([]bool, len(req.SpaceIds)),
+	}
+	for i, spaceId := range req.SpaceIds {
+		head, err := n.nodehead.GetHead(spaceId)
+		if err != nil && !errors.Is(err, nodehead.ErrSpaceNotFound) {
+			return nil, err
+		}
+		resp.Heads[i] = &nodesyncproto.PartitionSyncResultElement{
+			Id:   spaceId,
+			Head: head,
+		}
+		if head == "" {
+			continue
+		}
+		if i < len(req.Heads) && req.Heads[i] != "" {
+			if resp.Contains[i], err = n.hadHead(ctx, spaceId, req.Heads[i], head); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return resp, nil
+}
+
+// hadHead tells whether the head is the current or a previous head of the space, the state contains all its changes then
+func (n *nodeRemoteDiffHandler) hadHead(ctx context.Context, spaceId, head, current string) (bool, error) {
+	if head == current {
+		return true, nil
+	}
+	if n.storage == nil {
+		return false, nil
+	}
+	return n.storage.IndexStorage().HadHead(ctx, spaceId, head)
 }
 
 func (n *nodeRemoteDiffHandler) PartitionSync(ctx context.Context, req *nodesyncproto.PartitionSyncRequest) (*nodesyncproto.PartitionSyncResponse, error) {
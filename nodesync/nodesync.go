@@ -3,15 +3,19 @@ package nodesync
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	commonaccount "github.com/anyproto/any-sync/accountservice"
 	"github.com/anyproto/any-sync/app"
+	"github.com/anyproto/any-sync/app/debugstat"
 	"github.com/anyproto/any-sync/app/logger"
+	"github.com/anyproto/any-sync/commonspace/spacestorage"
 	"github.com/anyproto/any-sync/metric"
 	"github.com/anyproto/any-sync/net/pool"
+	"github.com/anyproto/any-sync/net/rpc/rpcerr"
 	"github.com/anyproto/any-sync/net/rpc/server"
 	"github.com/anyproto/any-sync/nodeconf"
 	"github.com/anyproto/go-chash"
@@ -20,6 +24,7 @@ import (
 
 	"github.com/anyproto/any-sync-node/nodehead"
 	"github.com/anyproto/any-sync-node/nodespace"
+	"github.com/anyproto/any-sync-node/nodestorage"
 	"github.com/anyproto/any-sync-node/nodesync/coldsync"
 	"github.com/anyproto/any-sync-node/nodesync/hotsync"
 	"github.com/anyproto/any-sync-node/nodesync/nodesyncproto"
@@ -34,7 +39,23 @@ func New() NodeSync {
 }
 
 type NodeSync interface {
+	// Sync runs the node sync and waits until it's done
 	Sync() (err error)
+	// StartSync starts the node sync in the background and returns the id of the run
+	StartSync() (runId string, err error)
+	// CancelSync stops the run, the empty id means the latest run
+	CancelSync(runId string) (err error)
+	// SyncStatus returns the progress of the run with partitions, the empty id means the latest run
+	SyncStatus(runId string) (status RunStatus, err error)
+	// WatchSync calls onStatus with the run progress every interval until the run is finished
+	WatchSync(ctx context.Context, runId string, interval time.Duration, onStatus func(status RunStatus) error) (err error)
+	// SyncPartition runs the node sync of one partition and waits for it, the run is shown in the history
+	SyncPartition(partId int) (status RunStatus, err error)
+	// SyncSpace syncs one space with peers of its partition.
+	// A missing space is cold synced and an existing one is queued for hot sync, force downloads the whole space again and replaces the local store
+	SyncSpace(ctx context.Context, spaceId string, force bool) (err error)
+	// SyncHistory returns the latest runs without partitions, the newest first
+	SyncHistory() (runs []RunStatus)
 	WaitSyncOnStart() <-chan struct{}
 	app.ComponentRunnable
 }
@@ -54,6 +75,12 @@ type nodeSync struct {
 	syncCtx         context.Context
 	syncCtxCancel   context.CancelFunc
 	syncStat        *SyncStat
+	busyBackoff     time.Duration
+	storage         nodestorage.NodeStorage
+	runs            []*syncRun
+	runSeq          int
+	runHistory      int
+	health          *peerHealth
 }
 
 func (n *nodeSync) Init(a *app.App) (err error) {
@@ -66,16 +93,33 @@ func (n *nodeSync) Init(a *app.App) (err error) {
 	n.pool = a.MustComponent(pool.CName).(pool.Pool)
 	n.conf = a.MustComponent("config").(configGetter).GetNodeSync()
 	n.syncStat = new(SyncStat)
+	n.health = newPeerHealth(n.conf.PeerHealth)
+	if statService, ok := a.Component(debugstat.CName).(debugstat.StatService); ok {
+		statService.AddProvider(n.health)
+	}
+	n.busyBackoff = time.Second * 5
+	n.runHistory = n.conf.RunHistory
+	if n.runHistory <= 0 {
+		n.runHistory = defaultRunHistory
+	}
+	if n.conf.PersistRuns {
+		n.storage = a.MustComponent(spacestorage.CName).(nodestorage.NodeStorage)
+	}
 	n.hotsync.SetMetric(&n.syncStat.HotSyncHandled, &n.syncStat.HotSyncErrors)
 	n.syncCtx, n.syncCtxCancel = context.WithCancel(context.Background())
 	if m := a.Component(metric.CName); m != nil {
 		registerMetric(n.syncStat, m.(metric.Metric).Registry())
+		registerPeerHealthMetric(n.health, m.(metric.Metric).Registry())
 	}
 
+	storage, _ := a.Component(spacestorage.CName).(nodestorage.NodeStorage)
 	return nodesyncproto.DRPCRegisterNodeSync(a.MustComponent(server.CName).(server.DRPCServer), &rpcHandler{
-		nodeRemoteDiffHandler: &nodeRemoteDiffHandler{nodehead: n.nodehead},
-		coldSync:              n.coldsync,
-		nodeSpace:             n.nodespace,
+		nodeRemoteDiffHandler: &nodeRemoteDiffHandler{
+			nodehead: n.nodehead,
+			storage:  storage,
+		},
+		coldSync:  n.coldsync,
+		nodeSpace: n.nodespace,
 	})
 }
 
@@ -88,6 +132,9 @@ func (n *nodeSync) WaitSyncOnStart() <-chan struct{} {
 }
 
 func (n *nodeSync) Run(ctx context.Context) (err error) {
+	if err = n.loadRuns(ctx); err != nil {
+		log.Warn("can't load sync runs", zap.Error(err))
+	}
 	if n.conf.SyncOnStart {
 		go func() {
 			if e := n.Sync(); e != nil {
@@ -112,17 +159,73 @@ func (n *nodeSync) Run(ctx context.Context) (err error) {
 	return nil
 }
 
+// Sync runs the node sync and waits for it
 func (n *nodeSync) Sync() (err error) {
-	ctx := n.syncCtx
-	n.syncMu.Lock()
-	if n.syncInProgress != nil {
-		n.syncMu.Unlock()
-		return fmt.Errorf("sync in progress")
-	} else {
-		n.syncInProgress = make(chan struct{})
+	run, err := n.startRun()
+	if err != nil {
+		return
 	}
-	n.syncMu.Unlock()
+	return n.runSync(run, n.getRelatePartitions)
+}
+
+func (n *nodeSync) SyncPartition(partId int) (status RunStatus, err error) {
+	p, err := n.getRelatePartition(partId)
+	if err != nil {
+		return
+	}
+	run, err := n.startRun()
+	if err != nil {
+		return
+	}
+	err = n.runSync(run, func() ([]part, error) {
+		return []part{p}, nil
+	})
+	return run.snapshot(true), err
+}
+
+func (n *nodeSync) SyncSpace(ctx context.Context, spaceId string, force bool) (err error) {
+	p, err := n.getRelatePartition(n.nodeconf.Partition(spaceId))
+	if err != nil {
+		return
+	}
+	log.Info("sync space", zap.String("spaceId", spaceId), zap.Bool("force", force), zap.Strings("peers", p.peers))
+	peers, _ := n.health.order(p.peers)
+	if len(peers) == 0 {
+		return ErrPeerBackoff
+	}
+	if force {
+		return n.forceSyncWithFallback(ctx, spaceId, peers)
+	}
+	err = n.coldSyncWithFallback(ctx, spaceId, peers[0], peers)
+	if errors.Is(err, coldsync.ErrSpaceExistsLocally) {
+		n.hotsync.Enqueue(hotsync.PriorityUser, spaceId)
+		return nil
+	}
+	return
+}
+
+// forceSyncWithFallback replaces the local store with the store of the first peer that sends a valid one
+func (n *nodeSync) forceSyncWithFallback(ctx context.Context, spaceId string, peers []string) (err error) {
+	for _, peerId := range peers {
+		end := n.health.begin(peerId)
+		err = n.coldsync.ForceSync(ctx, spaceId, peerId)
+		end(0, err)
+		if err == nil {
+			return n.nodehead.ReloadHeadFromStore(nodestorage.WithOrigin(ctx, nodestorage.OriginColdSync), spaceId)
+		}
+		if !needColdSyncFallback(err) {
+			return
+		}
+		log.Info("force sync failed", zap.String("spaceId", spaceId), zap.String("peerId", peerId), zap.Error(err))
+	}
+	return
+}
+
+func (n *nodeSync) runSync(run *syncRun, getParts func() ([]part, error)) (err error) {
+	ctx := run.ctx
 	defer func() {
+		run.finish(err)
+		n.saveRun(run)
 		n.syncMu.Lock()
 		defer n.syncMu.Unlock()
 		close(n.syncInProgress)
@@ -131,18 +234,19 @@ func (n *nodeSync) Sync() (err error) {
 		n.syncStat.SyncsDone.Add(1)
 	}()
 
-	st := time.Now()
+	st := run.status.StartedAt
 	n.syncStat.InProgress.Store(true)
 	n.syncStat.LastStartTime.Store(uint64(st.Unix()))
 
-	parts, err := n.getRelatePartitions()
+	parts, err := getParts()
 	if err != nil {
 		return err
 	}
+	run.setParts(parts)
 	n.syncStat.PartsTotal.Store(uint32(len(parts)))
 	n.syncStat.PartsHandled.Store(0)
 
-	log.Info("nodesync started...", zap.Int("partitions", len(parts)))
+	log.Info("nodesync started...", zap.String("runId", run.status.Id), zap.Int("partitions", len(parts)))
 	var limiter = make(chan struct{}, 10)
 	var wg sync.WaitGroup
 	for _, p := range parts {
@@ -152,29 +256,48 @@ func (n *nodeSync) Sync() (err error) {
 			defer func() { <-limiter }()
 			defer wg.Done()
 			defer n.syncStat.PartsHandled.Add(1)
-			if e := n.syncPart(ctx, p); e != nil {
+			pst := time.Now()
+			e := n.syncPart(ctx, run, p)
+			if e != nil {
 				log.Warn("can't sync part", zap.Int("part", p.partId), zap.Error(e))
 				n.syncStat.PartsErrors.Add(1)
 			}
+			run.partDone(p.partId, time.Since(pst), e)
 		}(p)
 	}
 	wg.Wait()
 	dur := time.Since(st)
 	n.syncStat.LastDuration.Store(uint64(dur))
-	log.Info("nodesync done", zap.Duration("dur", dur))
+	log.Info("nodesync done", zap.String("runId", run.status.Id), zap.Duration("dur", dur))
 	return nil
 }
 
-func (n *nodeSync) syncPart(ctx context.Context, p part) (err error) {
+func (n *nodeSync) syncPart(ctx context.Context, run *syncRun, p part) (err error) {
 	var (
 		hasSuccess bool
 	)
-	for _, peerId := range p.peers {
-		if err = n.syncPeer(ctx, peerId, p.partId); err != nil {
+	peers, skipped := n.health.order(p.peers)
+	for _, peerId := range skipped {
+		err = ErrPeerBackoff
+		log.Debug("syncPeer skipped", zap.String("peerId", peerId), zap.Int("part", p.partId), zap.Error(err))
+		run.peerDone(p.partId, PeerStatus{PeerId: peerId, Error: err.Error()})
+	}
+	for _, peerId := range peers {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		st := time.Now()
+		ps, pErr := n.syncPeer(ctx, peerId, p)
+		ps.PeerId = peerId
+		ps.DurationMs = time.Since(st).Milliseconds()
+		if pErr != nil {
+			err = pErr
+			ps.Error = pErr.Error()
 			log.Info("syncPeer failed", zap.String("peerId", peerId), zap.Int("part", p.partId), zap.Error(err))
 		} else {
 			hasSuccess = true
 		}
+		run.peerDone(p.partId, ps)
 	}
 	if hasSuccess {
 		return nil
@@ -182,13 +305,20 @@ func (n *nodeSync) syncPart(ctx context.Context, p part) (err error) {
 	return
 }
 
-func (n *nodeSync) syncPeer(ctx context.Context, peerId string, partId int) (err error) {
-	p, err := n.pool.Get(ctx, peerId)
+func (n *nodeSync) syncPeer(ctx context.Context, peerId string, p part) (ps PeerStatus, err error) {
+	partId := p.partId
+	var diffDur time.Duration
+	end := n.health.begin(peerId)
+	defer func() {
+		end(diffDur, err)
+	}()
+	pr, err := n.pool.Get(ctx, peerId)
 	if err != nil {
 		return
 	}
-	return p.DoDrpc(ctx, func(conn drpc.Conn) error {
+	err = pr.DoDrpc(ctx, func(conn drpc.Conn) error {
 		ld := n.nodehead.LDiff(partId)
+		st := time.Now()
 		newIds, changedIds, _, err := ld.Diff(ctx, nodeRemoteDiff{
 			partId: partId,
 			cl:     nodesyncproto.NewDRPCNodeSyncClient(conn),
@@ -196,26 +326,102 @@ func (n *nodeSync) syncPeer(ctx context.Context, peerId string, partId int) (err
 		if err != nil {
 			return err
 		}
+		diffDur = time.Since(st)
 		log.Debug("syncing with peer", zap.String("peerId", peerId), zap.Int("changed", len(changedIds)), zap.Int("new", len(newIds)))
+		ps.New, ps.Changed = len(newIds), len(changedIds)
 		for _, newId := range newIds {
-			if e := n.coldSync(ctx, newId, peerId); e != nil {
+			if e := n.coldSyncWithFallback(ctx, newId, peerId, p.peers); e != nil {
 				log.Warn("can't coldSync space with peer", zap.String("spaceId", newId), zap.String("peerId", peerId), zap.Error(e))
 				n.syncStat.ColdSyncErrors.Add(1)
+				ps.ColdSyncErrors++
 			}
 			n.syncStat.ColdSyncHandled.Add(1)
 		}
+		if n.conf.ColdSync.DeltaOnChange {
+			changedIds = n.deltaSync(ctx, changedIds, peerId)
+		}
 		if len(changedIds) > 0 {
 			n.hotsync.UpdateQueue(changedIds)
 		}
 		return nil
 	})
+	return
+}
+
+// deltaSync catches up changed spaces with the delta cold sync, returns spaces left for hot sync.
+// The delta replaces the local store, so it's applied only when the peer has every local change;
+// spaces changed on both sides are left for hot sync, which merges them.
+func (n *nodeSync) deltaSync(ctx context.Context, spaceIds []string, peerId string) (failedIds []string) {
+	for _, spaceId := range spaceIds {
+		err := n.coldsync.SyncDelta(ctx, spaceId, peerId)
+		if err == nil {
+			err = n.nodehead.ReloadHeadFromStore(nodestorage.WithOrigin(ctx, nodestorage.OriginColdSync), spaceId)
+		}
+		if errors.Is(err, coldsync.ErrDiverged) {
+			log.Debug("space changed on both sides, fallback to hot sync", zap.String("spaceId", spaceId), zap.String("peerId", peerId))
+			failedIds = append(failedIds, spaceId)
+			continue
+		}
+		if err != nil {
+			log.Debug("delta sync failed, fallback to hot sync", zap.String("spaceId", spaceId), zap.String("peerId", peerId), zap.Error(err))
+			failedIds = append(failedIds, spaceId)
+		}
+	}
+	return
+}
+
+// coldSyncWithFallback downloads the space from peerId, and from other healthy peers of the partition when the peer fails or sends an invalid store
+func (n *nodeSync) coldSyncWithFallback(ctx context.Context, spaceId, peerId string, peers []string) (err error) {
+	if err = n.coldSync(ctx, spaceId, peerId); !needColdSyncFallback(err) {
+		return
+	}
+	peers, _ = n.health.order(peers)
+	for _, otherId := range peers {
+		if otherId == peerId || ctx.Err() != nil {
+			continue
+		}
+		log.Info("coldSync fallback to another peer", zap.String("spaceId", spaceId), zap.String("failedPeerId", peerId), zap.String("peerId", otherId), zap.Error(err))
+		if err = n.coldSync(ctx, spaceId, otherId); !needColdSyncFallback(err) {
+			return
+		}
+	}
+	return
+}
+
+func needColdSyncFallback(err error) bool {
+	return err != nil &&
+		!errors.Is(err, coldsync.ErrSpaceExistsLocally) &&
+		!errors.Is(err, nodestorage.ErrLocked) &&
+		!errors.Is(err, context.Canceled)
 }
 
+const coldSyncBusyRetries = 4
+
+// coldSync retries with growing delays while the peer is busy with other dumps
 func (n *nodeSync) coldSync(ctx context.Context, spaceId, peerId string) (err error) {
-	if err = n.coldsync.Sync(ctx, spaceId, peerId); err != nil {
+	delay := n.busyBackoff
+	end := n.health.begin(peerId)
+	for attempt := 0; ; attempt++ {
+		err = n.coldsync.Sync(ctx, spaceId, peerId)
+		if attempt == coldSyncBusyRetries || !errors.Is(rpcerr.Unwrap(err), nodesyncproto.ErrColdSyncBusy) {
+			break
+		}
+		log.Debug("peer is busy, coldSync is postponed", zap.String("spaceId", spaceId), zap.String("peerId", peerId), zap.Duration("delay", delay))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			break
+		}
+		delay *= 2
+	}
+	end(0, err)
+	if err != nil {
 		return
 	}
-	return n.nodehead.ReloadHeadFromStore(ctx, spaceId)
+	return n.nodehead.ReloadHeadFromStore(nodestorage.WithOrigin(ctx, nodestorage.OriginColdSync), spaceId)
 }
 
 func (n *nodeSync) getRelatePartitions() (parts []part, err error) {
@@ -235,6 +441,23 @@ func (n *nodeSync) getRelatePartitions() (parts []part, err error) {
 	return
 }
 
+// getRelatePartition returns the partition with other peers, the node must be one of its members
+func (n *nodeSync) getRelatePartition(partId int) (p part, err error) {
+	ch := n.nodeconf.CHash()
+	if partId < 0 || partId >= ch.PartitionCount() {
+		return p, fmt.Errorf("%w: %d", ErrUnknownPartition, partId)
+	}
+	memb, err := ch.GetPartitionMembers(partId)
+	if err != nil {
+		return
+	}
+	peers := n.getRelateMembers(memb)
+	if len(peers) == 0 {
+		return p, fmt.Errorf("%w: %d", ErrNotResponsible, partId)
+	}
+	return part{partId: partId, peers: peers}, nil
+}
+
 func (n *nodeSync) getRelateMembers(memb []chash.Member) (ids []string) {
 	var isRelates bool
 	for _, m := range memb {
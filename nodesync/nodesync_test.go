@@ -2,12 +2,18 @@ package nodesync
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/anyproto/any-sync/accountservice"
 	"github.com/anyproto/any-sync/app"
 	"github.com/anyproto/any-sync/app/ldiff"
+	"github.com/anyproto/any-sync/commonspace/spacesyncproto"
+	anynet "github.com/anyproto/any-sync/net"
 	"github.com/anyproto/any-sync/net/peer"
 	"github.com/anyproto/any-sync/net/rpc"
 	"github.com/anyproto/any-sync/net/rpc/rpctest"
@@ -19,15 +25,18 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"storj.io/drpc"
 
 	"github.com/anyproto/any-sync-node/nodehead"
 	"github.com/anyproto/any-sync-node/nodehead/mock_nodehead"
 	"github.com/anyproto/any-sync-node/nodespace"
 	"github.com/anyproto/any-sync-node/nodespace/mock_nodespace"
+	"github.com/anyproto/any-sync-node/nodestorage/mock_nodestorage"
 	"github.com/anyproto/any-sync-node/nodesync/coldsync"
 	"github.com/anyproto/any-sync-node/nodesync/coldsync/mock_coldsync"
 	"github.com/anyproto/any-sync-node/nodesync/hotsync"
 	"github.com/anyproto/any-sync-node/nodesync/hotsync/mock_hotsync"
+	"github.com/anyproto/any-sync-node/nodesync/nodesyncproto"
 )
 
 var ctx = context.Background()
@@ -107,6 +116,296 @@ func TestNodeSync_Sync(t *testing.T) {
 	})
 }
 
+func TestNodeSync_Runs(t *testing.T) {
+	t.Run("progress", func(t *testing.T) {
+		fx := newFixture(t, 3)
+		defer fx.Finish(t)
+		runId, err := fx.StartSync()
+		require.NoError(t, err)
+		var last RunStatus
+		require.NoError(t, fx.WatchSync(ctx, runId, time.Millisecond*10, func(status RunStatus) error {
+			last = status
+			return nil
+		}))
+		assert.Equal(t, RunDone, last.State)
+		assert.NotEmpty(t, last.PartsTotal)
+		assert.Equal(t, last.PartsTotal, last.PartsHandled)
+		assert.Equal(t, last.PartsTotal, last.PartsErrors)
+		require.Len(t, last.Parts, last.PartsTotal)
+		// all peers are offline
+		for _, ps := range last.Parts {
+			assert.True(t, ps.Done)
+			require.Len(t, ps.Peers, 2)
+			assert.NotEmpty(t, ps.Peers[0].Error)
+		}
+
+		history := fx.SyncHistory()
+		require.Len(t, history, 1)
+		assert.Equal(t, runId, history[0].Id)
+		assert.Empty(t, history[0].Parts)
+		assert.ErrorIs(t, fx.CancelSync(runId), ErrRunFinished)
+		_, err = fx.SyncStatus("unknown")
+		assert.ErrorIs(t, err, ErrRunNotFound)
+	})
+	t.Run("cancel", func(t *testing.T) {
+		fx := newFixture(t, 3)
+		defer fx.Finish(t)
+		ns := fx.NodeSync.(*nodeSync)
+		run, err := ns.startRun()
+		require.NoError(t, err)
+		_, err = fx.StartSync()
+		assert.ErrorIs(t, err, ErrSyncInProgress)
+
+		require.NoError(t, fx.CancelSync(""))
+		require.NoError(t, ns.runSync(run, ns.getRelatePartitions))
+		status, err := fx.SyncStatus(run.status.Id)
+		require.NoError(t, err)
+		assert.Equal(t, RunCanceled, status.State)
+		for _, ps := range status.Parts {
+			assert.Empty(t, ps.Peers)
+		}
+	})
+	t.Run("persist", func(t *testing.T) {
+		fx := newFixture(t, 3)
+		defer fx.Finish(t)
+		ns := fx.NodeSync.(*nodeSync)
+		storage := mock_nodestorage.NewMockNodeStorage(fx.ctrl)
+		index := mock_nodestorage.NewMockIndexStorage(fx.ctrl)
+		storage.EXPECT().IndexStorage().Return(index).AnyTimes()
+		ns.storage = storage
+
+		var saved []byte
+		index.EXPECT().SaveSyncRun(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), defaultRunHistory).
+			DoAndReturn(func(_ context.Context, _ string, _ time.Time, data []byte, _ int) error {
+				saved = data
+				return nil
+			})
+		require.NoError(t, fx.Sync())
+		runId := fx.SyncHistory()[0].Id
+
+		// the next launch shows the run
+		fx2 := newFixture(t, 3)
+		defer fx2.Finish(t)
+		ns2 := fx2.NodeSync.(*nodeSync)
+		ns2.storage = storage
+		index.EXPECT().SyncRuns(gomock.Any(), defaultRunHistory).Return([][]byte{saved}, nil)
+		require.NoError(t, ns2.loadRuns(ctx))
+		status, err := fx2.SyncStatus(runId)
+		require.NoError(t, err)
+		assert.Equal(t, RunDone, status.State)
+		assert.NotEmpty(t, status.Parts)
+	})
+}
+
+func TestNodeSync_coldSyncWithFallback(t *testing.T) {
+	t.Run("next peer", func(t *testing.T) {
+		fx := newFixture(t, 3)
+		defer fx.Finish(t)
+		// p3 failed before, so p2 is healthier
+		fx.NodeSync.(*nodeSync).health.begin("p3")(0, coldsync.ErrInvalidStore)
+		fx.coldSync.EXPECT().Sync(gomock.Any(), "spaceId", "p1").Return(coldsync.ErrInvalidStore)
+		fx.coldSync.EXPECT().Sync(gomock.Any(), "spaceId", "p2").Return(nil)
+		fx.nodeHead.EXPECT().ReloadHeadFromStore(gomock.Any(), "spaceId").Return(nil)
+		assert.NoError(t, fx.NodeSync.(*nodeSync).coldSyncWithFallback(ctx, "spaceId", "p1", []string{"p1", "p2", "p3"}))
+	})
+	t.Run("exists locally", func(t *testing.T) {
+		fx := newFixture(t, 3)
+		defer fx.Finish(t)
+		fx.coldSync.EXPECT().Sync(gomock.Any(), "spaceId", "p1").Return(coldsync.ErrSpaceExistsLocally)
+		assert.ErrorIs(t, fx.NodeSync.(*nodeSync).coldSyncWithFallback(ctx, "spaceId", "p1", []string{"p1", "p2"}), coldsync.ErrSpaceExistsLocally)
+	})
+	t.Run("all failed", func(t *testing.T) {
+		fx := newFixture(t, 3)
+		defer fx.Finish(t)
+		fx.coldSync.EXPECT().Sync(gomock.Any(), "spaceId", gomock.Any()).Return(coldsync.ErrInvalidStore).Times(2)
+		assert.ErrorIs(t, fx.NodeSync.(*nodeSync).coldSyncWithFallback(ctx, "spaceId", "p2", []string{"p1", "p2"}), coldsync.ErrInvalidStore)
+	})
+}
+
+func TestPeerHealth(t *testing.T) {
+	now := time.Now()
+	h := newPeerHealth(PeerHealthConfig{FailuresToBackoff: 2, BackoffSeconds: 10, MaxBackoffSeconds: 30})
+	h.now = func() time.Time { return now }
+
+	t.Run("latency", func(t *testing.T) {
+		h.begin("fast")(time.Millisecond*10, nil)
+		h.begin("slow")(time.Millisecond*20, nil)
+		ready, skipped := h.order([]string{"slow", "fast"})
+		assert.Equal(t, []string{"fast", "slow"}, ready)
+		assert.Empty(t, skipped)
+	})
+	t.Run("inflight", func(t *testing.T) {
+		end := h.begin("fast")
+		end2 := h.begin("fast")
+		ready, _ := h.order([]string{"slow", "fast"})
+		assert.Equal(t, []string{"slow", "fast"}, ready)
+		end(0, nil)
+		end2(0, nil)
+	})
+	t.Run("backoff", func(t *testing.T) {
+		h.begin("bad")(0, anynet.ErrUnableToConnect)
+		_, skipped := h.order([]string{"bad"})
+		assert.Empty(t, skipped)
+		h.begin("bad")(0, anynet.ErrUnableToConnect)
+		ready, skipped := h.order([]string{"bad", "fast"})
+		assert.Equal(t, []string{"fast"}, ready)
+		assert.Equal(t, []string{"bad"}, skipped)
+
+		// the backoff doubles and is limited by the max
+		h.begin("bad")(0, anynet.ErrUnableToConnect)
+		h.begin("bad")(0, anynet.ErrUnableToConnect)
+		stats := h.stat()
+		require.Len(t, stats, 3)
+		assert.Equal(t, "bad", stats[0].PeerId)
+		assert.Equal(t, now.Add(time.Second*30), stats[0].BackoffUntil)
+		assert.Equal(t, 4, stats[0].ConsecutiveFailures)
+
+		now = now.Add(time.Minute)
+		_, skipped = h.order([]string{"bad"})
+		assert.Empty(t, skipped)
+		h.begin("bad")(time.Millisecond, nil)
+		assert.Equal(t, 0, h.stat()[0].ConsecutiveFailures)
+	})
+	t.Run("local errors", func(t *testing.T) {
+		h.begin("local")(0, coldsync.ErrSpaceExistsLocally)
+		h.begin("local")(0, context.Canceled)
+		// errors of one space don't back off the peer
+		h.begin("local")(0, coldsync.ErrInvalidStore)
+		h.begin("local")(0, spacesyncproto.ErrSpaceMissing)
+		h.begin("local")(0, nodesyncproto.ErrColdSyncBusy)
+		stats := h.stat()
+		require.Len(t, stats, 4)
+		assert.Equal(t, "local", stats[2].PeerId)
+		assert.Zero(t, stats[2].Failures)
+	})
+	t.Run("transport errors", func(t *testing.T) {
+		for _, err := range []error{
+			context.DeadlineExceeded,
+			io.ErrUnexpectedEOF,
+			fmt.Errorf("dial: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}),
+			drpc.ClosedError.New("connection closed"),
+		} {
+			assert.True(t, isPeerFailure(err), err.Error())
+		}
+	})
+}
+
+func TestNodeSync_syncPartBackoff(t *testing.T) {
+	fx := newFixture(t, 3)
+	defer fx.Finish(t)
+	ns := fx.NodeSync.(*nodeSync)
+	p, err := ns.getRelatePartition(0)
+	require.NoError(t, err)
+	for _, peerId := range p.peers {
+		for i := 0; i < defaultHealthFailures; i++ {
+			ns.health.begin(peerId)(0, anynet.ErrUnableToConnect)
+		}
+	}
+	status, err := fx.SyncPartition(0)
+	require.NoError(t, err)
+	require.Len(t, status.Parts, 1)
+	assert.Equal(t, ErrPeerBackoff.Error(), status.Parts[0].Error)
+	for _, ps := range status.Parts[0].Peers {
+		assert.Equal(t, ErrPeerBackoff.Error(), ps.Error)
+	}
+	fx.nodeConf.EXPECT().Partition("spaceId").Return(0)
+	assert.ErrorIs(t, fx.SyncSpace(ctx, "spaceId", false), ErrPeerBackoff)
+}
+
+func TestNodeSync_coldSyncBusy(t *testing.T) {
+	t.Run("retry", func(t *testing.T) {
+		fx := newFixture(t, 3)
+		defer fx.Finish(t)
+		fx.NodeSync.(*nodeSync).busyBackoff = time.Millisecond
+		fx.coldSync.EXPECT().Sync(gomock.Any(), "spaceId", "p1").Return(nodesyncproto.ErrColdSyncBusy).Times(2)
+		fx.coldSync.EXPECT().Sync(gomock.Any(), "spaceId", "p1").Return(nil)
+		fx.nodeHead.EXPECT().ReloadHeadFromStore(gomock.Any(), "spaceId").Return(nil)
+		assert.NoError(t, fx.NodeSync.(*nodeSync).coldSync(ctx, "spaceId", "p1"))
+	})
+	t.Run("give up", func(t *testing.T) {
+		fx := newFixture(t, 3)
+		defer fx.Finish(t)
+		fx.NodeSync.(*nodeSync).busyBackoff = time.Millisecond
+		fx.coldSync.EXPECT().Sync(gomock.Any(), "spaceId", "p1").Return(nodesyncproto.ErrColdSyncBusy).Times(coldSyncBusyRetries + 1)
+		assert.ErrorIs(t, fx.NodeSync.(*nodeSync).coldSync(ctx, "spaceId", "p1"), nodesyncproto.ErrColdSyncBusy)
+	})
+}
+
+func TestNodeSync_deltaSync(t *testing.T) {
+	fx := newFixture(t, 3)
+	defer fx.Finish(t)
+	fx.coldSync.EXPECT().SyncDelta(gomock.Any(), "s1", "p1").Return(nil)
+	fx.nodeHead.EXPECT().ReloadHeadFromStore(gomock.Any(), "s1").Return(nil)
+	fx.coldSync.EXPECT().SyncDelta(gomock.Any(), "s2", "p1").Return(coldsync.ErrInvalidStore)
+	assert.Equal(t, []string{"s2"}, fx.NodeSync.(*nodeSync).deltaSync(ctx, []string{"s1", "s2"}, "p1"))
+}
+
+func TestNodeSync_SyncSpace(t *testing.T) {
+	t.Run("exists locally", func(t *testing.T) {
+		fx := newFixture(t, 3)
+		defer fx.Finish(t)
+		fx.nodeConf.EXPECT().Partition("spaceId").Return(0)
+		fx.coldSync.EXPECT().Sync(gomock.Any(), "spaceId", gomock.Any()).Return(coldsync.ErrSpaceExistsLocally)
+		fx.hotSync.EXPECT().Enqueue(hotsync.PriorityUser, "spaceId")
+		assert.NoError(t, fx.SyncSpace(ctx, "spaceId", false))
+	})
+	t.Run("force", func(t *testing.T) {
+		fx := newFixture(t, 3)
+		defer fx.Finish(t)
+		fx.nodeConf.EXPECT().Partition("spaceId").Return(0)
+		gomock.InOrder(
+			fx.coldSync.EXPECT().ForceSync(gomock.Any(), "spaceId", gomock.Any()).Return(coldsync.ErrInvalidStore),
+			fx.coldSync.EXPECT().ForceSync(gomock.Any(), "spaceId", gomock.Any()).Return(nil),
+		)
+		fx.nodeHead.EXPECT().ReloadHeadFromStore(gomock.Any(), "spaceId").Return(nil)
+		assert.NoError(t, fx.SyncSpace(ctx, "spaceId", true))
+	})
+}
+
+func TestNodeSync_SyncPartition(t *testing.T) {
+	fx := newFixture(t, 3)
+	defer fx.Finish(t)
+	_, err := fx.SyncPartition(-1)
+	assert.ErrorIs(t, err, ErrUnknownPartition)
+
+	// peers are not reachable, the run is finished with the partition error
+	status, err := fx.SyncPartition(0)
+	require.NoError(t, err)
+	assert.Equal(t, RunDone, status.State)
+	assert.Equal(t, 1, status.PartsTotal)
+	require.Len(t, status.Parts, 1)
+	assert.Equal(t, 0, status.Parts[0].PartId)
+	assert.Len(t, status.Parts[0].Peers, 2)
+	assert.NotEmpty(t, status.Parts[0].Error)
+	assert.Len(t, fx.SyncHistory(), 1)
+}
+
+func TestNodeSync_SpaceHeads(t *testing.T) {
+	fx := newFixture(t, 3)
+	defer fx.Finish(t)
+	storage := mock_nodestorage.NewMockNodeStorage(fx.ctrl)
+	index := mock_nodestorage.NewMockIndexStorage(fx.ctrl)
+	storage.EXPECT().IndexStorage().Return(index).AnyTimes()
+	fx.nodeHead.EXPECT().GetHead("s1").Return("h1", nil)
+	fx.nodeHead.EXPECT().GetHead("s2").Return("", nodehead.ErrSpaceNotFound)
+	fx.nodeHead.EXPECT().GetHead("s3").Return("h3", nil)
+	index.EXPECT().HadHead(ctx, "s3", "old").Return(true, nil)
+	h := &nodeRemoteDiffHandler{
+		nodehead: fx.nodeHead,
+		storage:  storage,
+	}
+	resp, err := h.SpaceHeads(ctx, &nodesyncproto.SpaceHeadsRequest{
+		SpaceIds: []string{"s1", "s2", "s3"},
+		Heads:    []string{"h1", "h2", "old"},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Heads, 3)
+	assert.Equal(t, "h1", resp.Heads[0].Head)
+	assert.Equal(t, "s2", resp.Heads[1].Id)
+	assert.Empty(t, resp.Heads[1].Head)
+	assert.Equal(t, []bool{true, false, true}, resp.Contains)
+}
+
 func TestNodeSync_getRelatePartitions(t *testing.T) {
 	fx := newFixture(t, 8)
 	defer fx.Finish(t)
@@ -12,4 +12,6 @@ var (
 	ErrUnexpected             = errGroup.Register(errors.New("unexpected error"), uint64(ErrCodes_Unexpected))
 	ErrExpectedCoordinator    = errGroup.Register(errors.New("this request should be sent by coordinator"), uint64(ErrCodes_ExpectedCoordinator))
 	ErrUnsupportedStorageType = errGroup.Register(errors.New("unsupported storage"), uint64(ErrCodes_UnsupportedStorage))
+	// ErrColdSyncBusy is returned when the peer has no free dump slots and its queue is full, the requester should back off
+	ErrColdSyncBusy = errGroup.Register(errors.New("cold sync is busy, retry later"), uint64(ErrCodes_ColdSyncBusy))
 )
@@ -27,6 +27,7 @@ const (
 	ErrCodes_Unexpected          ErrCodes = 0
 	ErrCodes_ExpectedCoordinator ErrCodes = 1
 	ErrCodes_UnsupportedStorage  ErrCodes = 2
+	ErrCodes_ColdSyncBusy        ErrCodes = 4
 	ErrCodes_ErrorOffset         ErrCodes = 1000
 )
 
@@ -36,12 +37,14 @@ var (
 		0:    "Unexpected",
 		1:    "ExpectedCoordinator",
 		2:    "UnsupportedStorage",
+		4:    "ColdSyncBusy",
 		1000: "ErrorOffset",
 	}
 	ErrCodes_value = map[string]int32{
 		"Unexpected":          0,
 		"ExpectedCoordinator": 1,
 		"UnsupportedStorage":  2,
+		"ColdSyncBusy":        4,
 		"ErrorOffset":         1000,
 	}
 )
@@ -73,11 +76,63 @@ func (ErrCodes) EnumDescriptor() ([]byte, []int) {
 	return file_nodesync_nodesyncproto_protos_nodesync_proto_rawDescGZIP(), []int{0}
 }
 
+// ColdSyncCompression is a transport compression of files, gzip is zero for peers without the negotiation
+type ColdSyncCompression int32
+
+const (
+	ColdSyncCompression_Gzip ColdSyncCompression = 0
+	ColdSyncCompression_None ColdSyncCompression = 1
+	ColdSyncCompression_Zstd ColdSyncCompression = 2
+)
+
+// Enum value maps for ColdSyncCompression.
+var (
+	ColdSyncCompression_name = map[int32]string{
+		0: "Gzip",
+		1: "None",
+		2: "Zstd",
+	}
+	ColdSyncCompression_value = map[string]int32{
+		"Gzip": 0,
+		"None": 1,
+		"Zstd": 2,
+	}
+)
+
+func (x ColdSyncCompression) Enum() *ColdSyncCompression {
+	p := new(ColdSyncCompression)
+	*p = x
+	return p
+}
+
+func (x ColdSyncCompression) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ColdSyncCompression) Descriptor() protoreflect.EnumDescriptor {
+	return file_nodesync_nodesyncproto_protos_nodesync_proto_enumTypes[1].Descriptor()
+}
+
+func (ColdSyncCompression) Type() protoreflect.EnumType {
+	return &file_nodesync_nodesyncproto_protos_nodesync_proto_enumTypes[1]
+}
+
+func (x ColdSyncCompression) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ColdSyncCompression.Descriptor instead.
+func (ColdSyncCompression) EnumDescriptor() ([]byte, []int) {
+	return file_nodesync_nodesyncproto_protos_nodesync_proto_rawDescGZIP(), []int{1}
+}
+
 type ColdSyncProtocolType int32
 
 const (
 	ColdSyncProtocolType_Pogreb         ColdSyncProtocolType = 0
 	ColdSyncProtocolType_AnystoreSqlite ColdSyncProtocolType = 1
+	// AnystoreSqliteDelta sends pages of store.db that differ from the pages of the requester
+	ColdSyncProtocolType_AnystoreSqliteDelta ColdSyncProtocolType = 2
 )
 
 // Enum value maps for ColdSyncProtocolType.
@@ -85,10 +140,12 @@ var (
 	ColdSyncProtocolType_name = map[int32]string{
 		0: "Pogreb",
 		1: "AnystoreSqlite",
+		2: "AnystoreSqliteDelta",
 	}
 	ColdSyncProtocolType_value = map[string]int32{
-		"Pogreb":         0,
-		"AnystoreSqlite": 1,
+		"Pogreb":              0,
+		"AnystoreSqlite":      1,
+		"AnystoreSqliteDelta": 2,
 	}
 )
 
@@ -103,11 +160,11 @@ func (x ColdSyncProtocolType) String() string {
 }
 
 func (ColdSyncProtocolType) Descriptor() protoreflect.EnumDescriptor {
-	return file_nodesync_nodesyncproto_protos_nodesync_proto_enumTypes[1].Descriptor()
+	return file_nodesync_nodesyncproto_protos_nodesync_proto_enumTypes[2].Descriptor()
 }
 
 func (ColdSyncProtocolType) Type() protoreflect.EnumType {
-	return &file_nodesync_nodesyncproto_protos_nodesync_proto_enumTypes[1]
+	return &file_nodesync_nodesyncproto_protos_nodesync_proto_enumTypes[2]
 }
 
 func (x ColdSyncProtocolType) Number() protoreflect.EnumNumber {
@@ -116,7 +173,7 @@ func (x ColdSyncProtocolType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ColdSyncProtocolType.Descriptor instead.
 func (ColdSyncProtocolType) EnumDescriptor() ([]byte, []int) {
-	return file_nodesync_nodesyncproto_protos_nodesync_proto_rawDescGZIP(), []int{1}
+	return file_nodesync_nodesyncproto_protos_nodesync_proto_rawDescGZIP(), []int{2}
 }
 
 // PartitionSyncRange presenting a request for one range
@@ -400,17 +457,135 @@ func (x *PartitionSyncResponse) GetResults() []*PartitionSyncResult {
 	return nil
 }
 
+type SpaceHeadsRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	SpaceIds []string               `protobuf:"bytes,1,rep,name=spaceIds,proto3" json:"spaceIds,omitempty"`
+	// heads are heads of the requester in the order of spaceIds, they are checked when given
+	Heads         []string `protobuf:"bytes,2,rep,name=heads,proto3" json:"heads,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SpaceHeadsRequest) Reset() {
+	*x = SpaceHeadsRequest{}
+	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpaceHeadsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpaceHeadsRequest) ProtoMessage() {}
+
+func (x *SpaceHeadsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpaceHeadsRequest.ProtoReflect.Descriptor instead.
+func (*SpaceHeadsRequest) Descriptor() ([]byte, []int) {
+	return file_nodesync_nodesyncproto_protos_nodesync_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SpaceHeadsRequest) GetSpaceIds() []string {
+	if x != nil {
+		return x.SpaceIds
+	}
+	return nil
+}
+
+func (x *SpaceHeadsRequest) GetHeads() []string {
+	if x != nil {
+		return x.Heads
+	}
+	return nil
+}
+
+// SpaceHeadsResponse contains heads in the order of requested spaces, the head is empty when the space is missing
+type SpaceHeadsResponse struct {
+	state protoimpl.MessageState        `protogen:"open.v1"`
+	Heads []*PartitionSyncResultElement `protobuf:"bytes,1,rep,name=heads,proto3" json:"heads,omitempty"`
+	// contains tells in the order of requested spaces whether the requester's head is the current or a previous head of the peer,
+	// the state of the peer contains every change of the requester then
+	Contains      []bool `protobuf:"varint,2,rep,packed,name=contains,proto3" json:"contains,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SpaceHeadsResponse) Reset() {
+	*x = SpaceHeadsResponse{}
+	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SpaceHeadsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpaceHeadsResponse) ProtoMessage() {}
+
+func (x *SpaceHeadsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpaceHeadsResponse.ProtoReflect.Descriptor instead.
+func (*SpaceHeadsResponse) Descriptor() ([]byte, []int) {
+	return file_nodesync_nodesyncproto_protos_nodesync_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SpaceHeadsResponse) GetHeads() []*PartitionSyncResultElement {
+	if x != nil {
+		return x.Heads
+	}
+	return nil
+}
+
+func (x *SpaceHeadsResponse) GetContains() []bool {
+	if x != nil {
+		return x.Contains
+	}
+	return nil
+}
+
 type ColdSyncRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	SpaceId       string                 `protobuf:"bytes,1,opt,name=spaceId,proto3" json:"spaceId,omitempty"`
-	ProtocolType  ColdSyncProtocolType   `protobuf:"varint,2,opt,name=protocolType,proto3,enum=anyNodeSync.ColdSyncProtocolType" json:"protocolType,omitempty"`
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	SpaceId      string                 `protobuf:"bytes,1,opt,name=spaceId,proto3" json:"spaceId,omitempty"`
+	ProtocolType ColdSyncProtocolType   `protobuf:"varint,2,opt,name=protocolType,proto3,enum=anyNodeSync.ColdSyncProtocolType" json:"protocolType,omitempty"`
+	// resumeToken is a token of the interrupted sync, the remote continues it when the snapshot still exists
+	ResumeToken string `protobuf:"bytes,3,opt,name=resumeToken,proto3" json:"resumeToken,omitempty"`
+	// offsets are sizes of files already received by the previous attempt
+	Offsets []*ColdSyncFileOffset `protobuf:"bytes,4,rep,name=offsets,proto3" json:"offsets,omitempty"`
+	// accept lists codecs supported by the requester in the order of preference, gzip is used when empty
+	Accept []*ColdSyncCodec `protobuf:"bytes,5,rep,name=accept,proto3" json:"accept,omitempty"`
+	// pageSize and pageHashes describe the local store for AnystoreSqliteDelta:
+	// truncated sha256 of each page, pageHashSize bytes per page in the order of pages
+	PageSize      uint32 `protobuf:"varint,6,opt,name=pageSize,proto3" json:"pageSize,omitempty"`
+	PageHashes    []byte `protobuf:"bytes,7,opt,name=pageHashes,proto3" json:"pageHashes,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ColdSyncRequest) Reset() {
 	*x = ColdSyncRequest{}
-	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[5]
+	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -422,7 +597,7 @@ func (x *ColdSyncRequest) String() string {
 func (*ColdSyncRequest) ProtoMessage() {}
 
 func (x *ColdSyncRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[5]
+	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -435,7 +610,7 @@ func (x *ColdSyncRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ColdSyncRequest.ProtoReflect.Descriptor instead.
 func (*ColdSyncRequest) Descriptor() ([]byte, []int) {
-	return file_nodesync_nodesyncproto_protos_nodesync_proto_rawDescGZIP(), []int{5}
+	return file_nodesync_nodesyncproto_protos_nodesync_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ColdSyncRequest) GetSpaceId() string {
@@ -452,19 +627,169 @@ func (x *ColdSyncRequest) GetProtocolType() ColdSyncProtocolType {
 	return ColdSyncProtocolType_Pogreb
 }
 
-type ColdSyncResponse struct {
+func (x *ColdSyncRequest) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+func (x *ColdSyncRequest) GetOffsets() []*ColdSyncFileOffset {
+	if x != nil {
+		return x.Offsets
+	}
+	return nil
+}
+
+func (x *ColdSyncRequest) GetAccept() []*ColdSyncCodec {
+	if x != nil {
+		return x.Accept
+	}
+	return nil
+}
+
+func (x *ColdSyncRequest) GetPageSize() uint32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ColdSyncRequest) GetPageHashes() []byte {
+	if x != nil {
+		return x.PageHashes
+	}
+	return nil
+}
+
+// ColdSyncCodec is a transport compression with the codec-specific level, 0 means the default level
+type ColdSyncCodec struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Compression   ColdSyncCompression    `protobuf:"varint,1,opt,name=compression,proto3,enum=anyNodeSync.ColdSyncCompression" json:"compression,omitempty"`
+	Level         int32                  `protobuf:"varint,2,opt,name=level,proto3" json:"level,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ColdSyncCodec) Reset() {
+	*x = ColdSyncCodec{}
+	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ColdSyncCodec) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ColdSyncCodec) ProtoMessage() {}
+
+func (x *ColdSyncCodec) ProtoReflect() protoreflect.Message {
+	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ColdSyncCodec.ProtoReflect.Descriptor instead.
+func (*ColdSyncCodec) Descriptor() ([]byte, []int) {
+	return file_nodesync_nodesyncproto_protos_nodesync_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ColdSyncCodec) GetCompression() ColdSyncCompression {
+	if x != nil {
+		return x.Compression
+	}
+	return ColdSyncCompression_Gzip
+}
+
+func (x *ColdSyncCodec) GetLevel() int32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+// ColdSyncFileOffset is a position in the uncompressed file data
+type ColdSyncFileOffset struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Filename      string                 `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`
-	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
-	Crc32         uint32                 `protobuf:"varint,4,opt,name=crc32,proto3" json:"crc32,omitempty"`
-	ProtocolType  ColdSyncProtocolType   `protobuf:"varint,5,opt,name=protocolType,proto3,enum=anyNodeSync.ColdSyncProtocolType" json:"protocolType,omitempty"`
+	Filename      string                 `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Offset        uint64                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ColdSyncFileOffset) Reset() {
+	*x = ColdSyncFileOffset{}
+	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ColdSyncFileOffset) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ColdSyncFileOffset) ProtoMessage() {}
+
+func (x *ColdSyncFileOffset) ProtoReflect() protoreflect.Message {
+	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ColdSyncFileOffset.ProtoReflect.Descriptor instead.
+func (*ColdSyncFileOffset) Descriptor() ([]byte, []int) {
+	return file_nodesync_nodesyncproto_protos_nodesync_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ColdSyncFileOffset) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *ColdSyncFileOffset) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ColdSyncResponse struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Filename     string                 `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`
+	Data         []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Crc32        uint32                 `protobuf:"varint,4,opt,name=crc32,proto3" json:"crc32,omitempty"`
+	ProtocolType ColdSyncProtocolType   `protobuf:"varint,5,opt,name=protocolType,proto3,enum=anyNodeSync.ColdSyncProtocolType" json:"protocolType,omitempty"`
+	// resumeToken identifies the snapshot being streamed, it differs from the requested one when the sync starts over
+	ResumeToken string `protobuf:"bytes,6,opt,name=resumeToken,proto3" json:"resumeToken,omitempty"`
+	// offset is a position in the uncompressed file where the data of this file starts
+	Offset uint64 `protobuf:"varint,7,opt,name=offset,proto3" json:"offset,omitempty"`
+	// sha256 and fileSize are sent in the last message of the file without data, they describe the whole file
+	Sha256   []byte `protobuf:"bytes,8,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	FileSize uint64 `protobuf:"varint,9,opt,name=fileSize,proto3" json:"fileSize,omitempty"`
+	// compression is the codec of data picked by the sender
+	Compression   ColdSyncCompression `protobuf:"varint,10,opt,name=compression,proto3,enum=anyNodeSync.ColdSyncCompression" json:"compression,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ColdSyncResponse) Reset() {
 	*x = ColdSyncResponse{}
-	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[6]
+	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -476,7 +801,7 @@ func (x *ColdSyncResponse) String() string {
 func (*ColdSyncResponse) ProtoMessage() {}
 
 func (x *ColdSyncResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[6]
+	mi := &file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -489,7 +814,7 @@ func (x *ColdSyncResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ColdSyncResponse.ProtoReflect.Descriptor instead.
 func (*ColdSyncResponse) Descriptor() ([]byte, []int) {
-	return file_nodesync_nodesyncproto_protos_nodesync_proto_rawDescGZIP(), []int{6}
+	return file_nodesync_nodesyncproto_protos_nodesync_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *ColdSyncResponse) GetFilename() string {
@@ -520,6 +845,41 @@ func (x *ColdSyncResponse) GetProtocolType() ColdSyncProtocolType {
 	return ColdSyncProtocolType_Pogreb
 }
 
+func (x *ColdSyncResponse) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+func (x *ColdSyncResponse) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ColdSyncResponse) GetSha256() []byte {
+	if x != nil {
+		return x.Sha256
+	}
+	return nil
+}
+
+func (x *ColdSyncResponse) GetFileSize() uint64 {
+	if x != nil {
+		return x.FileSize
+	}
+	return 0
+}
+
+func (x *ColdSyncResponse) GetCompression() ColdSyncCompression {
+	if x != nil {
+		return x.Compression
+	}
+	return ColdSyncCompression_Gzip
+}
+
 var File_nodesync_nodesyncproto_protos_nodesync_proto protoreflect.FileDescriptor
 
 var file_nodesync_nodesyncproto_protos_nodesync_proto_rawDesc = string([]byte{
@@ -558,47 +918,105 @@ var file_nodesync_nodesyncproto_protos_nodesync_proto_rawDesc = string([]byte{
 	0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e,
 	0x61, 0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x2e, 0x50, 0x61, 0x72, 0x74,
 	0x69, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52,
-	0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0x72, 0x0a, 0x0f, 0x43, 0x6f, 0x6c, 0x64,
-	0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73,
-	0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x70,
-	0x61, 0x63, 0x65, 0x49, 0x64, 0x12, 0x45, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
-	0x6c, 0x54, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x21, 0x2e, 0x61, 0x6e,
-	0x79, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x2e, 0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79,
-	0x6e, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0c,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x22, 0x9f, 0x01, 0x0a,
-	0x10, 0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a,
-	0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74,
-	0x61, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x72, 0x63, 0x33, 0x32, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d,
-	0x52, 0x05, 0x63, 0x72, 0x63, 0x33, 0x32, 0x12, 0x45, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x63, 0x6f, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x21, 0x2e,
-	0x61, 0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x2e, 0x43, 0x6f, 0x6c, 0x64,
-	0x53, 0x79, 0x6e, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x54, 0x79, 0x70, 0x65,
-	0x52, 0x0c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x2a, 0x5d,
-	0x0a, 0x08, 0x45, 0x72, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x0e, 0x0a, 0x0a, 0x55, 0x6e,
-	0x65, 0x78, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x45, 0x78,
-	0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x61, 0x74, 0x6f,
-	0x72, 0x10, 0x01, 0x12, 0x16, 0x0a, 0x12, 0x55, 0x6e, 0x73, 0x75, 0x70, 0x70, 0x6f, 0x72, 0x74,
-	0x65, 0x64, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x10, 0x02, 0x12, 0x10, 0x0a, 0x0b, 0x45,
-	0x72, 0x72, 0x6f, 0x72, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x10, 0xe8, 0x07, 0x2a, 0x36, 0x0a,
-	0x14, 0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
-	0x6c, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0a, 0x0a, 0x06, 0x50, 0x6f, 0x67, 0x72, 0x65, 0x62, 0x10,
-	0x00, 0x12, 0x12, 0x0a, 0x0e, 0x41, 0x6e, 0x79, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x53, 0x71, 0x6c,
-	0x69, 0x74, 0x65, 0x10, 0x01, 0x32, 0xad, 0x01, 0x0a, 0x08, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79,
-	0x6e, 0x63, 0x12, 0x56, 0x0a, 0x0d, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x53,
-	0x79, 0x6e, 0x63, 0x12, 0x21, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e,
+	0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0x45, 0x0a, 0x11, 0x53, 0x70, 0x61, 0x63,
+	0x65, 0x48, 0x65, 0x61, 0x64, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a,
+	0x08, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x08, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x68, 0x65, 0x61,
+	0x64, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x68, 0x65, 0x61, 0x64, 0x73, 0x22,
+	0x6f, 0x0a, 0x12, 0x53, 0x70, 0x61, 0x63, 0x65, 0x48, 0x65, 0x61, 0x64, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3d, 0x0a, 0x05, 0x68, 0x65, 0x61, 0x64, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79,
+	0x6e, 0x63, 0x2e, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x79, 0x6e, 0x63,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x05, 0x68,
+	0x65, 0x61, 0x64, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x73,
+	0x18, 0x02, 0x20, 0x03, 0x28, 0x08, 0x52, 0x08, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x73,
+	0x22, 0xbf, 0x02, 0x0a, 0x0f, 0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x70, 0x61, 0x63, 0x65, 0x49, 0x64, 0x12, 0x45,
+	0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x21, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79,
+	0x6e, 0x63, 0x2e, 0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x50, 0x72, 0x6f, 0x74, 0x6f,
+	0x63, 0x6f, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x54, 0x79, 0x70, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x54,
+	0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x72, 0x65, 0x73, 0x75,
+	0x6d, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x39, 0x0a, 0x07, 0x6f, 0x66, 0x66, 0x73, 0x65,
+	0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f,
+	0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x2e, 0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x46,
+	0x69, 0x6c, 0x65, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x52, 0x07, 0x6f, 0x66, 0x66, 0x73, 0x65,
+	0x74, 0x73, 0x12, 0x32, 0x0a, 0x06, 0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x18, 0x05, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63,
+	0x2e, 0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x43, 0x6f, 0x64, 0x65, 0x63, 0x52, 0x06,
+	0x61, 0x63, 0x63, 0x65, 0x70, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x67, 0x65, 0x53, 0x69,
+	0x7a, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65, 0x53, 0x69,
+	0x7a, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x70, 0x61, 0x67, 0x65, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x70, 0x61, 0x67, 0x65, 0x48, 0x61, 0x73, 0x68,
+	0x65, 0x73, 0x22, 0x69, 0x0a, 0x0d, 0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x43, 0x6f,
+	0x64, 0x65, 0x63, 0x12, 0x42, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x20, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f,
+	0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x2e, 0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x43,
+	0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x22, 0x48, 0x0a,
+	0x12, 0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x46, 0x69, 0x6c, 0x65, 0x4f, 0x66, 0x66,
+	0x73, 0x65, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x12,
+	0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x22, 0xd1, 0x02, 0x0a, 0x10, 0x43, 0x6f, 0x6c, 0x64,
+	0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08,
+	0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x14, 0x0a, 0x05,
+	0x63, 0x72, 0x63, 0x33, 0x32, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x63, 0x72, 0x63,
+	0x33, 0x32, 0x12, 0x45, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x54, 0x79,
+	0x70, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x21, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f,
+	0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x2e, 0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x50,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0c, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x72, 0x65, 0x73,
+	0x75, 0x6d, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x72, 0x65, 0x73, 0x75, 0x6d, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x6f,
+	0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x6f, 0x66, 0x66,
+	0x73, 0x65, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x68, 0x61, 0x32, 0x35, 0x36, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x68, 0x61, 0x32, 0x35, 0x36, 0x12, 0x1a, 0x0a, 0x08, 0x66,
+	0x69, 0x6c, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x66,
+	0x69, 0x6c, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x42, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x20, 0x2e, 0x61,
+	0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x2e, 0x43, 0x6f, 0x6c, 0x64, 0x53,
+	0x79, 0x6e, 0x63, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x0b,
+	0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x2a, 0x6f, 0x0a, 0x08, 0x45,
+	0x72, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x0e, 0x0a, 0x0a, 0x55, 0x6e, 0x65, 0x78, 0x70,
+	0x65, 0x63, 0x74, 0x65, 0x64, 0x10, 0x00, 0x12, 0x17, 0x0a, 0x13, 0x45, 0x78, 0x70, 0x65, 0x63,
+	0x74, 0x65, 0x64, 0x43, 0x6f, 0x6f, 0x72, 0x64, 0x69, 0x6e, 0x61, 0x74, 0x6f, 0x72, 0x10, 0x01,
+	0x12, 0x16, 0x0a, 0x12, 0x55, 0x6e, 0x73, 0x75, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x64, 0x53,
+	0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x10, 0x02, 0x12, 0x10, 0x0a, 0x0c, 0x43, 0x6f, 0x6c, 0x64,
+	0x53, 0x79, 0x6e, 0x63, 0x42, 0x75, 0x73, 0x79, 0x10, 0x04, 0x12, 0x10, 0x0a, 0x0b, 0x45, 0x72,
+	0x72, 0x6f, 0x72, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x10, 0xe8, 0x07, 0x2a, 0x33, 0x0a, 0x13,
+	0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x08, 0x0a, 0x04, 0x47, 0x7a, 0x69, 0x70, 0x10, 0x00, 0x12, 0x08, 0x0a,
+	0x04, 0x4e, 0x6f, 0x6e, 0x65, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x5a, 0x73, 0x74, 0x64, 0x10,
+	0x02, 0x2a, 0x4f, 0x0a, 0x14, 0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x50, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0a, 0x0a, 0x06, 0x50, 0x6f, 0x67,
+	0x72, 0x65, 0x62, 0x10, 0x00, 0x12, 0x12, 0x0a, 0x0e, 0x41, 0x6e, 0x79, 0x73, 0x74, 0x6f, 0x72,
+	0x65, 0x53, 0x71, 0x6c, 0x69, 0x74, 0x65, 0x10, 0x01, 0x12, 0x17, 0x0a, 0x13, 0x41, 0x6e, 0x79,
+	0x73, 0x74, 0x6f, 0x72, 0x65, 0x53, 0x71, 0x6c, 0x69, 0x74, 0x65, 0x44, 0x65, 0x6c, 0x74, 0x61,
+	0x10, 0x02, 0x32, 0xfc, 0x01, 0x0a, 0x08, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x12,
+	0x56, 0x0a, 0x0d, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x79, 0x6e, 0x63,
+	0x12, 0x21, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x2e, 0x50,
+	0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e,
 	0x63, 0x2e, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x79, 0x6e, 0x63, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x22, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65,
-	0x53, 0x79, 0x6e, 0x63, 0x2e, 0x50, 0x61, 0x72, 0x74, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x79,
-	0x6e, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x49, 0x0a, 0x08, 0x43, 0x6f,
-	0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x12, 0x1c, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65,
-	0x53, 0x79, 0x6e, 0x63, 0x2e, 0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x71,
-	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79,
-	0x6e, 0x63, 0x2e, 0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x30, 0x01, 0x42, 0x18, 0x5a, 0x16, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x79, 0x6e,
-	0x63, 0x2f, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x79, 0x6e, 0x63, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x49, 0x0a, 0x08, 0x43, 0x6f, 0x6c, 0x64, 0x53,
+	0x79, 0x6e, 0x63, 0x12, 0x1c, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e,
+	0x63, 0x2e, 0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x1d, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x2e,
+	0x43, 0x6f, 0x6c, 0x64, 0x53, 0x79, 0x6e, 0x63, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x30, 0x01, 0x12, 0x4d, 0x0a, 0x0a, 0x53, 0x70, 0x61, 0x63, 0x65, 0x48, 0x65, 0x61, 0x64, 0x73,
+	0x12, 0x1e, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x2e, 0x53,
+	0x70, 0x61, 0x63, 0x65, 0x48, 0x65, 0x61, 0x64, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x1f, 0x2e, 0x61, 0x6e, 0x79, 0x4e, 0x6f, 0x64, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x2e, 0x53,
+	0x70, 0x61, 0x63, 0x65, 0x48, 0x65, 0x61, 0x64, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x42, 0x18, 0x5a, 0x16, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x79, 0x6e, 0x63, 0x2f, 0x6e, 0x6f,
+	0x64, 0x65, 0x73, 0x79, 0x6e, 0x63, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 })
 
 var (
@@ -613,34 +1031,46 @@ func file_nodesync_nodesyncproto_protos_nodesync_proto_rawDescGZIP() []byte {
 	return file_nodesync_nodesyncproto_protos_nodesync_proto_rawDescData
 }
 
-var file_nodesync_nodesyncproto_protos_nodesync_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_nodesync_nodesyncproto_protos_nodesync_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_nodesync_nodesyncproto_protos_nodesync_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
 var file_nodesync_nodesyncproto_protos_nodesync_proto_goTypes = []any{
 	(ErrCodes)(0),                      // 0: anyNodeSync.ErrCodes
-	(ColdSyncProtocolType)(0),          // 1: anyNodeSync.ColdSyncProtocolType
-	(*PartitionSyncRange)(nil),         // 2: anyNodeSync.PartitionSyncRange
-	(*PartitionSyncResult)(nil),        // 3: anyNodeSync.PartitionSyncResult
-	(*PartitionSyncResultElement)(nil), // 4: anyNodeSync.PartitionSyncResultElement
-	(*PartitionSyncRequest)(nil),       // 5: anyNodeSync.PartitionSyncRequest
-	(*PartitionSyncResponse)(nil),      // 6: anyNodeSync.PartitionSyncResponse
-	(*ColdSyncRequest)(nil),            // 7: anyNodeSync.ColdSyncRequest
-	(*ColdSyncResponse)(nil),           // 8: anyNodeSync.ColdSyncResponse
+	(ColdSyncCompression)(0),           // 1: anyNodeSync.ColdSyncCompression
+	(ColdSyncProtocolType)(0),          // 2: anyNodeSync.ColdSyncProtocolType
+	(*PartitionSyncRange)(nil),         // 3: anyNodeSync.PartitionSyncRange
+	(*PartitionSyncResult)(nil),        // 4: anyNodeSync.PartitionSyncResult
+	(*PartitionSyncResultElement)(nil), // 5: anyNodeSync.PartitionSyncResultElement
+	(*PartitionSyncRequest)(nil),       // 6: anyNodeSync.PartitionSyncRequest
+	(*PartitionSyncResponse)(nil),      // 7: anyNodeSync.PartitionSyncResponse
+	(*SpaceHeadsRequest)(nil),          // 8: anyNodeSync.SpaceHeadsRequest
+	(*SpaceHeadsResponse)(nil),         // 9: anyNodeSync.SpaceHeadsResponse
+	(*ColdSyncRequest)(nil),            // 10: anyNodeSync.ColdSyncRequest
+	(*ColdSyncCodec)(nil),              // 11: anyNodeSync.ColdSyncCodec
+	(*ColdSyncFileOffset)(nil),         // 12: anyNodeSync.ColdSyncFileOffset
+	(*ColdSyncResponse)(nil),           // 13: anyNodeSync.ColdSyncResponse
 }
 var file_nodesync_nodesyncproto_protos_nodesync_proto_depIdxs = []int32{
-	4, // 0: anyNodeSync.PartitionSyncResult.elements:type_name -> anyNodeSync.PartitionSyncResultElement
-	2, // 1: anyNodeSync.PartitionSyncRequest.ranges:type_name -> anyNodeSync.PartitionSyncRange
-	3, // 2: anyNodeSync.PartitionSyncResponse.results:type_name -> anyNodeSync.PartitionSyncResult
-	1, // 3: anyNodeSync.ColdSyncRequest.protocolType:type_name -> anyNodeSync.ColdSyncProtocolType
-	1, // 4: anyNodeSync.ColdSyncResponse.protocolType:type_name -> anyNodeSync.ColdSyncProtocolType
-	5, // 5: anyNodeSync.NodeSync.PartitionSync:input_type -> anyNodeSync.PartitionSyncRequest
-	7, // 6: anyNodeSync.NodeSync.ColdSync:input_type -> anyNodeSync.ColdSyncRequest
-	6, // 7: anyNodeSync.NodeSync.PartitionSync:output_type -> anyNodeSync.PartitionSyncResponse
-	8, // 8: anyNodeSync.NodeSync.ColdSync:output_type -> anyNodeSync.ColdSyncResponse
-	7, // [7:9] is the sub-list for method output_type
-	5, // [5:7] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	5,  // 0: anyNodeSync.PartitionSyncResult.elements:type_name -> anyNodeSync.PartitionSyncResultElement
+	3,  // 1: anyNodeSync.PartitionSyncRequest.ranges:type_name -> anyNodeSync.PartitionSyncRange
+	4,  // 2: anyNodeSync.PartitionSyncResponse.results:type_name -> anyNodeSync.PartitionSyncResult
+	5,  // 3: anyNodeSync.SpaceHeadsResponse.heads:type_name -> anyNodeSync.PartitionSyncResultElement
+	2,  // 4: anyNodeSync.ColdSyncRequest.protocolType:type_name -> anyNodeSync.ColdSyncProtocolType
+	12, // 5: anyNodeSync.ColdSyncRequest.offsets:type_name -> anyNodeSync.ColdSyncFileOffset
+	11, // 6: anyNodeSync.ColdSyncRequest.accept:type_name -> anyNodeSync.ColdSyncCodec
+	1,  // 7: anyNodeSync.ColdSyncCodec.compression:type_name -> anyNodeSync.ColdSyncCompression
+	2,  // 8: anyNodeSync.ColdSyncResponse.protocolType:type_name -> anyNodeSync.ColdSyncProtocolType
+	1,  // 9: anyNodeSync.ColdSyncResponse.compression:type_name -> anyNodeSync.ColdSyncCompression
+	6,  // 10: anyNodeSync.NodeSync.PartitionSync:input_type -> anyNodeSync.PartitionSyncRequest
+	10, // 11: anyNodeSync.NodeSync.ColdSync:input_type -> anyNodeSync.ColdSyncRequest
+	8,  // 12: anyNodeSync.NodeSync.SpaceHeads:input_type -> anyNodeSync.SpaceHeadsRequest
+	7,  // 13: anyNodeSync.NodeSync.PartitionSync:output_type -> anyNodeSync.PartitionSyncResponse
+	13, // 14: anyNodeSync.NodeSync.ColdSync:output_type -> anyNodeSync.ColdSyncResponse
+	9,  // 15: anyNodeSync.NodeSync.SpaceHeads:output_type -> anyNodeSync.SpaceHeadsResponse
+	13, // [13:16] is the sub-list for method output_type
+	10, // [10:13] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_nodesync_nodesyncproto_protos_nodesync_proto_init() }
@@ -653,8 +1083,8 @@ func file_nodesync_nodesyncproto_protos_nodesync_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_nodesync_nodesyncproto_protos_nodesync_proto_rawDesc), len(file_nodesync_nodesyncproto_protos_nodesync_proto_rawDesc)),
-			NumEnums:      2,
-			NumMessages:   7,
+			NumEnums:      3,
+			NumMessages:   11,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -35,6 +35,7 @@ type DRPCNodeSyncClient interface {
 
 	PartitionSync(ctx context.Context, in *PartitionSyncRequest) (*PartitionSyncResponse, error)
 	ColdSync(ctx context.Context, in *ColdSyncRequest) (DRPCNodeSync_ColdSyncClient, error)
+	SpaceHeads(ctx context.Context, in *SpaceHeadsRequest) (*SpaceHeadsResponse, error)
 }
 
 type drpcNodeSyncClient struct {
@@ -96,9 +97,19 @@ func (x *drpcNodeSync_ColdSyncClient) RecvMsg(m *ColdSyncResponse) error {
 	return x.MsgRecv(m, drpcEncoding_File_nodesync_nodesyncproto_protos_nodesync_proto{})
 }
 
+func (c *drpcNodeSyncClient) SpaceHeads(ctx context.Context, in *SpaceHeadsRequest) (*SpaceHeadsResponse, error) {
+	out := new(SpaceHeadsResponse)
+	err := c.cc.Invoke(ctx, "/anyNodeSync.NodeSync/SpaceHeads", drpcEncoding_File_nodesync_nodesyncproto_protos_nodesync_proto{}, in, out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 type DRPCNodeSyncServer interface {
 	PartitionSync(context.Context, *PartitionSyncRequest) (*PartitionSyncResponse, error)
 	ColdSync(*ColdSyncRequest, DRPCNodeSync_ColdSyncStream) error
+	SpaceHeads(context.Context, *SpaceHeadsRequest) (*SpaceHeadsResponse, error)
 }
 
 type DRPCNodeSyncUnimplementedServer struct{}
@@ -111,9 +122,13 @@ func (s *DRPCNodeSyncUnimplementedServer) ColdSync(*ColdSyncRequest, DRPCNodeSyn
 	return drpcerr.WithCode(errors.New("Unimplemented"), drpcerr.Unimplemented)
 }
 
+func (s *DRPCNodeSyncUnimplementedServer) SpaceHeads(context.Context, *SpaceHeadsRequest) (*SpaceHeadsResponse, error) {
+	return nil, drpcerr.WithCode(errors.New("Unimplemented"), drpcerr.Unimplemented)
+}
+
 type DRPCNodeSyncDescription struct{}
 
-func (DRPCNodeSyncDescription) NumMethods() int { return 2 }
+func (DRPCNodeSyncDescription) NumMethods() int { return 3 }
 
 func (DRPCNodeSyncDescription) Method(n int) (string, drpc.Encoding, drpc.Receiver, interface{}, bool) {
 	switch n {
@@ -135,6 +150,15 @@ func (DRPCNodeSyncDescription) Method(n int) (string, drpc.Encoding, drpc.Receiv
 						&drpcNodeSync_ColdSyncStream{in2.(drpc.Stream)},
 					)
 			}, DRPCNodeSyncServer.ColdSync, true
+	case 2:
+		return "/anyNodeSync.NodeSync/SpaceHeads", drpcEncoding_File_nodesync_nodesyncproto_protos_nodesync_proto{},
+			func(srv interface{}, ctx context.Context, in1, in2 interface{}) (drpc.Message, error) {
+				return srv.(DRPCNodeSyncServer).
+					SpaceHeads(
+						ctx,
+						in1.(*SpaceHeadsRequest),
+					)
+			}, DRPCNodeSyncServer.SpaceHeads, true
 	default:
 		return "", nil, nil, nil, false
 	}
@@ -172,3 +196,19 @@ type drpcNodeSync_ColdSyncStream struct {
 func (x *drpcNodeSync_ColdSyncStream) Send(m *ColdSyncResponse) error {
 	return x.MsgSend(m, drpcEncoding_File_nodesync_nodesyncproto_protos_nodesync_proto{})
 }
+
+type DRPCNodeSync_SpaceHeadsStream interface {
+	drpc.Stream
+	SendAndClose(*SpaceHeadsResponse) error
+}
+
+type drpcNodeSync_SpaceHeadsStream struct {
+	drpc.Stream
+}
+
+func (x *drpcNodeSync_SpaceHeadsStream) SendAndClose(m *SpaceHeadsResponse) error {
+	if err := x.MsgSend(m, drpcEncoding_File_nodesync_nodesyncproto_protos_nodesync_proto{}); err != nil {
+		return err
+	}
+	return x.CloseSend()
+}
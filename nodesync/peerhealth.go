@@ -0,0 +1,248 @@
+package nodesync
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	anynet "github.com/anyproto/any-sync/net"
+	"github.com/anyproto/any-sync/net/peerservice"
+	"github.com/anyproto/any-sync/net/secureservice/handshake"
+	"github.com/anyproto/any-sync/net/transport"
+	"storj.io/drpc"
+)
+
+var ErrPeerBackoff = errors.New("peer is in backoff after failures")
+
+const (
+	defaultHealthBackoff    = time.Second * 10
+	defaultHealthMaxBackoff = time.Minute * 10
+	defaultHealthFailures   = 2
+	// latencyWeight is the weight of the new sample in the moving average
+	latencyWeight = 0.2
+)
+
+// PeerHealthConfig tunes when failing peers are skipped by the node sync
+type PeerHealthConfig struct {
+	// FailuresToBackoff is the number of failures in a row after that the peer is skipped, 2 by default
+	FailuresToBackoff int `yaml:"failuresToBackoff"`
+	// BackoffSeconds is the first backoff, it doubles with every next failure, 10 by default
+	BackoffSeconds int `yaml:"backoffSeconds"`
+	// MaxBackoffSeconds limits the backoff, 600 by default
+	MaxBackoffSeconds int `yaml:"maxBackoffSeconds"`
+}
+
+// PeerHealthStat is the health of one peer shown in debug stats
+type PeerHealthStat struct {
+	PeerId              string    `json:"peerId"`
+	Score               float64   `json:"score"`
+	LatencyMs           float64   `json:"latencyMs"`
+	Successes           uint64    `json:"successes"`
+	Failures            uint64    `json:"failures"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	Inflight            int       `json:"inflight"`
+	LastError           string    `json:"lastError,omitempty"`
+	LastFailure         time.Time `json:"lastFailure"`
+	BackoffUntil        time.Time `json:"backoffUntil"`
+	InBackoff           bool      `json:"inBackoff"`
+}
+
+type peerState struct {
+	latency             float64
+	successes           uint64
+	failures            uint64
+	consecutiveFailures int
+	inflight            int
+	lastError           string
+	lastFailure         time.Time
+	backoffUntil        time.Time
+}
+
+// score is lower for faster, less loaded and less failing peers, unknown peers go first
+func (s *peerState) score() float64 {
+	var errRate float64
+	if total := s.successes + s.failures; total > 0 {
+		errRate = float64(s.failures) / float64(total)
+	}
+	return (s.latency + 1) * float64(1+s.inflight) * (1 + 4*errRate)
+}
+
+// peerHealth remembers results of requests to peers and orders peers by their health
+type peerHealth struct {
+	mu         sync.Mutex
+	peers      map[string]*peerState
+	failures   int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	now        func() time.Time
+}
+
+func newPeerHealth(conf PeerHealthConfig) *peerHealth {
+	h := &peerHealth{
+		peers:      make(map[string]*peerState),
+		failures:   conf.FailuresToBackoff,
+		backoff:    time.Duration(conf.BackoffSeconds) * time.Second,
+		maxBackoff: time.Duration(conf.MaxBackoffSeconds) * time.Second,
+		now:        time.Now,
+	}
+	if h.failures <= 0 {
+		h.failures = defaultHealthFailures
+	}
+	if h.backoff <= 0 {
+		h.backoff = defaultHealthBackoff
+	}
+	if h.maxBackoff <= 0 {
+		h.maxBackoff = defaultHealthMaxBackoff
+	}
+	return h
+}
+
+func (h *peerHealth) peer(peerId string) *peerState {
+	s, ok := h.peers[peerId]
+	if !ok {
+		s = &peerState{}
+		h.peers[peerId] = s
+	}
+	return s
+}
+
+// begin marks the request to the peer as inflight, end records its result.
+// latency is the duration of the request when it's comparable between peers, zero means no sample
+func (h *peerHealth) begin(peerId string) (end func(latency time.Duration, err error)) {
+	h.mu.Lock()
+	h.peer(peerId).inflight++
+	h.mu.Unlock()
+	return func(latency time.Duration, err error) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		s := h.peer(peerId)
+		s.inflight--
+		if err != nil && !isPeerFailure(err) {
+			return
+		}
+		if err != nil {
+			now := h.now()
+			s.failures++
+			s.consecutiveFailures++
+			s.lastError = err.Error()
+			s.lastFailure = now
+			if n := s.consecutiveFailures - h.failures; n >= 0 {
+				backoff := h.maxBackoff
+				if n < 16 && h.backoff<<n < h.maxBackoff {
+					backoff = h.backoff << n
+				}
+				s.backoffUntil = now.Add(backoff)
+			}
+			return
+		}
+		s.successes++
+		s.consecutiveFailures = 0
+		s.backoffUntil = time.Time{}
+		if latency > 0 {
+			ms := float64(latency) / float64(time.Millisecond)
+			if s.latency == 0 {
+				s.latency = ms
+			} else {
+				s.latency = s.latency*(1-latencyWeight) + ms*latencyWeight
+			}
+		}
+	}
+}
+
+// order returns peers sorted from the healthiest one, peers in backoff are returned separately.
+// Peers with the same score are shuffled to spread the load between replicas
+func (h *peerHealth) order(peers []string) (ready, skipped []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.now()
+	scores := make(map[string]float64, len(peers))
+	for _, peerId := range peers {
+		s, ok := h.peers[peerId]
+		if !ok {
+			scores[peerId] = 0
+			ready = append(ready, peerId)
+			continue
+		}
+		if now.Before(s.backoffUntil) {
+			skipped = append(skipped, peerId)
+			continue
+		}
+		scores[peerId] = s.score()
+		ready = append(ready, peerId)
+	}
+	rand.Shuffle(len(ready), func(i, j int) {
+		ready[i], ready[j] = ready[j], ready[i]
+	})
+	sort.SliceStable(ready, func(i, j int) bool {
+		return scores[ready[i]] < scores[ready[j]]
+	})
+	return
+}
+
+func (h *peerHealth) stat() (stats []PeerHealthStat) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := h.now()
+	stats = make([]PeerHealthStat, 0, len(h.peers))
+	for peerId, s := range h.peers {
+		stats = append(stats, PeerHealthStat{
+			PeerId:              peerId,
+			Score:               s.score(),
+			LatencyMs:           s.latency,
+			Successes:           s.successes,
+			Failures:            s.failures,
+			ConsecutiveFailures: s.consecutiveFailures,
+			Inflight:            s.inflight,
+			LastError:           s.lastError,
+			LastFailure:         s.lastFailure,
+			BackoffUntil:        s.backoffUntil,
+			InBackoff:           now.Before(s.backoffUntil),
+		})
+	}
+	slices.SortFunc(stats, func(a, b PeerHealthStat) int {
+		return strings.Compare(a.PeerId, b.PeerId)
+	})
+	return
+}
+
+func (h *peerHealth) ProvideStat() any {
+	return h.stat()
+}
+
+func (h *peerHealth) StatId() string {
+	return "peers"
+}
+
+func (h *peerHealth) StatType() string {
+	return CName
+}
+
+// isPeerFailure tells whether the peer is unreachable or broken: dial, transport and timeout errors.
+// Errors about one space (missing, invalid store, restoring, busy) and local conflicts don't count, the peer serves other spaces well
+func isPeerFailure(err error) bool {
+	var (
+		netErr       net.Error
+		handshakeErr handshake.HandshakeError
+	)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, anynet.ErrUnableToConnect),
+		errors.Is(err, peerservice.ErrAddrsNotFound),
+		errors.Is(err, transport.ErrConnClosed),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		drpc.ClosedError.Has(err),
+		errors.As(err, &netErr),
+		errors.As(err, &handshakeErr):
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,13 @@
+package rebalancer
+
+type configGetter interface {
+	GetRebalancer() Config
+}
+
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+	// CheckIntervalSeconds is how often the network configuration is checked for changes, 60 by default
+	CheckIntervalSeconds int `yaml:"checkIntervalSeconds"`
+	// GracePeriodMinutes is the delay before spaces the node is not responsible for are deleted, 1440 by default
+	GracePeriodMinutes int `yaml:"gracePeriodMinutes"`
+}
@@ -0,0 +1,128 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/anyproto/any-sync-node/nodesync/rebalancer (interfaces: Rebalancer)
+//
+// Generated by this command:
+//
+//	mockgen -destination mock_rebalancer/mock_rebalancer.go github.com/anyproto/any-sync-node/nodesync/rebalancer Rebalancer
+//
+
+// Package mock_rebalancer is a generated GoMock package.
+package mock_rebalancer
+
+import (
+	context "context"
+	reflect "reflect"
+
+	rebalancer "github.com/anyproto/any-sync-node/nodesync/rebalancer"
+	app "github.com/anyproto/any-sync/app"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRebalancer is a mock of Rebalancer interface.
+type MockRebalancer struct {
+	ctrl     *gomock.Controller
+	recorder *MockRebalancerMockRecorder
+	isgomock struct{}
+}
+
+// MockRebalancerMockRecorder is the mock recorder for MockRebalancer.
+type MockRebalancerMockRecorder struct {
+	mock *MockRebalancer
+}
+
+// NewMockRebalancer creates a new mock instance.
+func NewMockRebalancer(ctrl *gomock.Controller) *MockRebalancer {
+	mock := &MockRebalancer{ctrl: ctrl}
+	mock.recorder = &MockRebalancerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRebalancer) EXPECT() *MockRebalancerMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockRebalancer) Check(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockRebalancerMockRecorder) Check(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockRebalancer)(nil).Check), ctx)
+}
+
+// Close mocks base method.
+func (m *MockRebalancer) Close(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockRebalancerMockRecorder) Close(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockRebalancer)(nil).Close), ctx)
+}
+
+// Init mocks base method.
+func (m *MockRebalancer) Init(a *app.App) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Init", a)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Init indicates an expected call of Init.
+func (mr *MockRebalancerMockRecorder) Init(a any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Init", reflect.TypeOf((*MockRebalancer)(nil).Init), a)
+}
+
+// Name mocks base method.
+func (m *MockRebalancer) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockRebalancerMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockRebalancer)(nil).Name))
+}
+
+// Run mocks base method.
+func (m *MockRebalancer) Run(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Run", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockRebalancerMockRecorder) Run(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockRebalancer)(nil).Run), ctx)
+}
+
+// Status mocks base method.
+func (m *MockRebalancer) Status(ctx context.Context) (rebalancer.Status, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Status", ctx)
+	ret0, _ := ret[0].(rebalancer.Status)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Status indicates an expected call of Status.
+func (mr *MockRebalancerMockRecorder) Status(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockRebalancer)(nil).Status), ctx)
+}
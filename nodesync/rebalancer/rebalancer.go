@@ -0,0 +1,416 @@
+//go:generate mockgen -destination mock_rebalancer/mock_rebalancer.go github.com/anyproto/any-sync-node/nodesync/rebalancer Rebalancer
+package rebalancer
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	commonaccount "github.com/anyproto/any-sync/accountservice"
+	"github.com/anyproto/any-sync/app"
+	"github.com/anyproto/any-sync/app/logger"
+	"github.com/anyproto/any-sync/commonspace/spacestorage"
+	"github.com/anyproto/any-sync/net/pool"
+	"github.com/anyproto/any-sync/nodeconf"
+	"github.com/anyproto/any-sync/util/periodicsync"
+	"go.uber.org/zap"
+	"storj.io/drpc"
+
+	"github.com/anyproto/any-sync-node/nodehead"
+	"github.com/anyproto/any-sync-node/nodestorage"
+	"github.com/anyproto/any-sync-node/nodesync"
+	"github.com/anyproto/any-sync-node/nodesync/nodesyncproto"
+)
+
+const CName = "node.nodesync.rebalancer"
+
+var log = logger.NewNamed(CName)
+
+const (
+	defaultCheckInterval = 60
+	defaultGracePeriod   = time.Hour * 24
+	// headsBatch is the max number of spaces in one SpaceHeads request
+	headsBatch = 100
+)
+
+func New() Rebalancer {
+	return new(rebalancer)
+}
+
+// Rebalancer follows network configuration changes: it syncs partitions the node became responsible for
+// and deletes spaces it's not responsible for anymore after new owners have all their changes
+type Rebalancer interface {
+	// Check handles the configuration change and the handoff of spaces, it's called periodically
+	Check(ctx context.Context) (err error)
+	Status(ctx context.Context) (status Status, err error)
+	app.ComponentRunnable
+}
+
+type Status struct {
+	Enabled           bool      `json:"enabled"`
+	ConfId            string    `json:"confId"`
+	Partitions        int       `json:"partitions"`
+	PendingPartitions []int     `json:"pendingPartitions"`
+	Handoffs          []Handoff `json:"handoffs"`
+}
+
+// Handoff is a space waiting for the deletion
+type Handoff struct {
+	SpaceId     string    `json:"spaceId"`
+	MarkedAt    time.Time `json:"markedAt"`
+	DeleteAfter time.Time `json:"deleteAfter"`
+}
+
+type rebalancer struct {
+	conf         Config
+	nodeconf     nodeconf.Service
+	nodehead     nodehead.NodeHead
+	nodeSync     nodesync.NodeSync
+	storage      nodestorage.NodeStorage
+	pool         pool.Pool
+	peerId       string
+	grace        time.Duration
+	periodicSync periodicsync.PeriodicSync
+	now          func() time.Time
+	// ownerStates returns states of spaces stored on the peer compared with the local heads, missing spaces have empty heads
+	ownerStates func(ctx context.Context, peerId string, spaceIds, heads []string) (states map[string]ownerState, err error)
+
+	checkMu sync.Mutex
+
+	mu           sync.Mutex
+	confId       string
+	parts        map[int]struct{}
+	pendingParts []int
+}
+
+func (r *rebalancer) Init(a *app.App) (err error) {
+	r.conf = a.MustComponent("config").(configGetter).GetRebalancer()
+	r.nodeconf = a.MustComponent(nodeconf.CName).(nodeconf.Service)
+	r.nodehead = a.MustComponent(nodehead.CName).(nodehead.NodeHead)
+	r.nodeSync = a.MustComponent(nodesync.CName).(nodesync.NodeSync)
+	r.storage = a.MustComponent(spacestorage.CName).(nodestorage.NodeStorage)
+	r.pool = a.MustComponent(pool.CName).(pool.Pool)
+	r.peerId = a.MustComponent(commonaccount.CName).(commonaccount.Service).Account().PeerId
+	r.grace = time.Duration(r.conf.GracePeriodMinutes) * time.Minute
+	if r.grace <= 0 {
+		r.grace = defaultGracePeriod
+	}
+	interval := r.conf.CheckIntervalSeconds
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+	r.now = time.Now
+	r.ownerStates = r.fetchStates
+	r.periodicSync = periodicsync.NewPeriodicSync(interval, 0, r.Check, log)
+	return
+}
+
+func (r *rebalancer) Name() (name string) {
+	return CName
+}
+
+func (r *rebalancer) Run(ctx context.Context) (err error) {
+	if r.conf.Enabled {
+		r.periodicSync.Run()
+	}
+	return
+}
+
+func (r *rebalancer) Check(ctx context.Context) (err error) {
+	r.checkMu.Lock()
+	defer r.checkMu.Unlock()
+	if err = r.checkConf(ctx); err != nil {
+		return
+	}
+	r.syncGained()
+	return r.handoff(ctx)
+}
+
+// checkConf finds partitions the node gained and marks spaces it lost when the configuration is changed
+func (r *rebalancer) checkConf(ctx context.Context) (err error) {
+	confId := r.nodeconf.Id()
+	r.mu.Lock()
+	lastConfId, lastParts := r.confId, r.parts
+	r.mu.Unlock()
+	if confId == lastConfId {
+		return
+	}
+	parts, err := r.memberParts()
+	if err != nil {
+		return
+	}
+	if err = r.markLost(ctx); err != nil {
+		return
+	}
+	var gained []int
+	// on start the node sync brings all spaces, so only changes after the start are handled
+	if lastParts != nil {
+		for partId := range parts {
+			if _, ok := lastParts[partId]; !ok {
+				gained = append(gained, partId)
+			}
+		}
+		slices.Sort(gained)
+	}
+	log.Info("network configuration is checked", zap.String("confId", confId), zap.Int("partitions", len(parts)), zap.Int("gained", len(gained)))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.confId, r.parts = confId, parts
+	for _, partId := range gained {
+		if !slices.Contains(r.pendingParts, partId) {
+			r.pendingParts = append(r.pendingParts, partId)
+		}
+	}
+	return
+}
+
+func (r *rebalancer) memberParts() (parts map[int]struct{}, err error) {
+	ch := r.nodeconf.CHash()
+	parts = make(map[int]struct{})
+	for i := 0; i < ch.PartitionCount(); i++ {
+		memb, err := ch.GetPartitionMembers(i)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range memb {
+			if m.Id() == r.peerId {
+				parts[i] = struct{}{}
+				break
+			}
+		}
+	}
+	return
+}
+
+// markLost sets the notResponsible status to local spaces of other nodes and schedules their deletion
+func (r *rebalancer) markLost(ctx context.Context) (err error) {
+	spaceIds, err := r.storage.AllSpaceIds()
+	if err != nil {
+		return
+	}
+	var (
+		index  = r.storage.IndexStorage()
+		now    = r.now()
+		marked int
+	)
+	for _, spaceId := range spaceIds {
+		if !isSpaceId(spaceId) || r.nodeconf.IsResponsible(spaceId) {
+			continue
+		}
+		status, err := index.SpaceStatus(ctx, spaceId)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case nodestorage.SpaceStatusOk:
+			if err = index.SetSpaceStatus(nodestorage.WithOrigin(ctx, nodestorage.OriginRebalance), spaceId, nodestorage.SpaceStatusNotResponsible, ""); err != nil {
+				return err
+			}
+		case nodestorage.SpaceStatusNotResponsible:
+		default:
+			continue
+		}
+		if err = index.SaveHandoff(ctx, spaceId, now); err != nil {
+			return err
+		}
+		marked++
+	}
+	if marked > 0 {
+		log.Info("spaces are not responsible anymore", zap.Int("count", marked))
+	}
+	return
+}
+
+// syncGained runs the node sync of partitions the node became responsible for, one by one
+func (r *rebalancer) syncGained() {
+	for {
+		r.mu.Lock()
+		if len(r.pendingParts) == 0 {
+			r.mu.Unlock()
+			return
+		}
+		partId := r.pendingParts[0]
+		r.mu.Unlock()
+
+		status, err := r.nodeSync.SyncPartition(partId)
+		switch {
+		case errors.Is(err, nodesync.ErrSyncInProgress):
+			log.Debug("node sync is in progress, gained partitions are postponed")
+			return
+		case errors.Is(err, nodesync.ErrNotResponsible), errors.Is(err, nodesync.ErrUnknownPartition):
+			log.Info("gained partition is skipped", zap.Int("partId", partId), zap.Error(err))
+		case err != nil || status.PartsErrors > 0:
+			log.Warn("can't sync gained partition", zap.Int("partId", partId), zap.String("runId", status.Id), zap.Error(err))
+			return
+		default:
+			log.Info("gained partition is synced", zap.Int("partId", partId), zap.String("runId", status.Id))
+		}
+		r.mu.Lock()
+		r.pendingParts = slices.DeleteFunc(r.pendingParts, func(p int) bool { return p == partId })
+		r.mu.Unlock()
+	}
+}
+
+// ownerState is the space on the new owner
+type ownerState struct {
+	head string
+	// contains is true when the local head is the current or a previous head of the owner
+	contains bool
+}
+
+// confirms tells whether the owner has every local change of the space. The local space doesn't change
+// after the mark, clients write to new owners only, so the owner having the local head in its history has them all
+func (s ownerState) confirms() bool {
+	return s.head != "" && s.contains
+}
+
+// handoff deletes spaces after the grace period when all their new owners confirm they have the local changes
+func (r *rebalancer) handoff(ctx context.Context) (err error) {
+	index := r.storage.IndexStorage()
+	entries, err := index.Handoffs(ctx)
+	if err != nil {
+		return
+	}
+	var (
+		now       = r.now()
+		localHead = make(map[string]string)
+		byOwner   = make(map[string][]string)
+	)
+	for _, entry := range entries {
+		if r.nodeconf.IsResponsible(entry.SpaceId) {
+			if err = r.restore(ctx, entry.SpaceId); err != nil {
+				return
+			}
+			continue
+		}
+		if now.Sub(entry.MarkedAt) < r.grace {
+			continue
+		}
+		head, hErr := r.nodehead.GetHead(entry.SpaceId)
+		if hErr != nil {
+			log.Warn("can't get head of the handed off space", zap.String("spaceId", entry.SpaceId), zap.Error(hErr))
+			continue
+		}
+		localHead[entry.SpaceId] = head
+		for _, peerId := range r.nodeconf.NodeIds(entry.SpaceId) {
+			byOwner[peerId] = append(byOwner[peerId], entry.SpaceId)
+		}
+	}
+	if len(localHead) == 0 {
+		return
+	}
+
+	confirmed := make(map[string]int)
+	for peerId, spaceIds := range byOwner {
+		for batch := range slices.Chunk(spaceIds, headsBatch) {
+			heads := make([]string, len(batch))
+			for i, spaceId := range batch {
+				heads[i] = localHead[spaceId]
+			}
+			states, sErr := r.ownerStates(ctx, peerId, batch, heads)
+			if sErr != nil {
+				log.Info("can't get heads from the new owner", zap.String("peerId", peerId), zap.Error(sErr))
+				break
+			}
+			for _, spaceId := range batch {
+				if states[spaceId].confirms() {
+					confirmed[spaceId]++
+				}
+			}
+		}
+	}
+
+	for spaceId := range localHead {
+		if owners := len(r.nodeconf.NodeIds(spaceId)); owners == 0 || confirmed[spaceId] < owners {
+			log.Debug("handoff is not confirmed", zap.String("spaceId", spaceId), zap.Int("confirmed", confirmed[spaceId]), zap.Int("owners", owners))
+			continue
+		}
+		if err = r.storage.DeleteSpaceStorage(ctx, spaceId); err != nil && !errors.Is(err, spacestorage.ErrSpaceStorageMissing) {
+			return
+		}
+		if err = index.DeleteHandoff(ctx, spaceId); err != nil {
+			return
+		}
+		log.Info("space is handed off and deleted", zap.String("spaceId", spaceId))
+	}
+	return nil
+}
+
+// restore returns the ok status to the space the node is responsible for again
+func (r *rebalancer) restore(ctx context.Context, spaceId string) (err error) {
+	index := r.storage.IndexStorage()
+	status, err := index.SpaceStatus(ctx, spaceId)
+	if err != nil {
+		return
+	}
+	if status == nodestorage.SpaceStatusNotResponsible {
+		if err = index.SetSpaceStatus(nodestorage.WithOrigin(ctx, nodestorage.OriginRebalance), spaceId, nodestorage.SpaceStatusOk, ""); err != nil {
+			return
+		}
+	}
+	log.Info("space is responsible again, handoff is canceled", zap.String("spaceId", spaceId))
+	return index.DeleteHandoff(ctx, spaceId)
+}
+
+func (r *rebalancer) fetchStates(ctx context.Context, peerId string, spaceIds, heads []string) (states map[string]ownerState, err error) {
+	pr, err := r.pool.Get(ctx, peerId)
+	if err != nil {
+		return
+	}
+	err = pr.DoDrpc(ctx, func(conn drpc.Conn) error {
+		resp, err := nodesyncproto.NewDRPCNodeSyncClient(conn).SpaceHeads(ctx, &nodesyncproto.SpaceHeadsRequest{SpaceIds: spaceIds, Heads: heads})
+		if err != nil {
+			return err
+		}
+		states = make(map[string]ownerState, len(resp.Heads))
+		for i, h := range resp.Heads {
+			st := ownerState{head: h.Head}
+			// older nodes don't check heads
+			if i < len(resp.Contains) {
+				st.contains = resp.Contains[i]
+			}
+			states[h.Id] = st
+		}
+		return nil
+	})
+	return
+}
+
+func (r *rebalancer) Status(ctx context.Context) (status Status, err error) {
+	entries, err := r.storage.IndexStorage().Handoffs(ctx)
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	status = Status{
+		Enabled:           r.conf.Enabled,
+		ConfId:            r.confId,
+		Partitions:        len(r.parts),
+		PendingPartitions: slices.Clone(r.pendingParts),
+	}
+	r.mu.Unlock()
+	status.Handoffs = make([]Handoff, len(entries))
+	for i, entry := range entries {
+		status.Handoffs[i] = Handoff{
+			SpaceId:     entry.SpaceId,
+			MarkedAt:    entry.MarkedAt,
+			DeleteAfter: entry.MarkedAt.Add(r.grace),
+		}
+	}
+	return
+}
+
+func (r *rebalancer) Close(ctx context.Context) (err error) {
+	if r.conf.Enabled {
+		r.periodicSync.Close()
+	}
+	return
+}
+
+// isSpaceId skips service dirs of the storage, space ids always have the dot before the replication key
+func isSpaceId(id string) bool {
+	return strings.Contains(id, ".")
+}
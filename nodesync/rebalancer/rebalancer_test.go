@@ -0,0 +1,172 @@
+package rebalancer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/anyproto/any-sync/nodeconf/mock_nodeconf"
+	"github.com/anyproto/go-chash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/anyproto/any-sync-node/nodehead/mock_nodehead"
+	"github.com/anyproto/any-sync-node/nodestorage"
+	"github.com/anyproto/any-sync-node/nodestorage/mock_nodestorage"
+	"github.com/anyproto/any-sync-node/nodesync"
+	"github.com/anyproto/any-sync-node/nodesync/mock_nodesync"
+	"github.com/anyproto/any-sync-node/util/chashtest"
+)
+
+var ctx = context.Background()
+
+func TestRebalancer_checkConf(t *testing.T) {
+	fx := newFixture(t)
+	ch1 := chashtest.New(t, 2, "self", "a", "b")
+	ch2 := chashtest.New(t, 2, "self", "a")
+
+	// on start lost spaces are marked, partitions are synced by the node sync
+	fx.nodeConf.EXPECT().Id().Return("1")
+	fx.nodeConf.EXPECT().CHash().Return(ch1)
+	fx.storage.EXPECT().AllSpaceIds().Return([]string{"s1.x", "s2.x", "notresponsible"}, nil)
+	fx.nodeConf.EXPECT().IsResponsible("s1.x").Return(true)
+	fx.nodeConf.EXPECT().IsResponsible("s2.x").Return(false)
+	fx.index.EXPECT().SpaceStatus(ctx, "s2.x").Return(nodestorage.SpaceStatusOk, nil)
+	fx.index.EXPECT().SetSpaceStatus(gomock.Any(), "s2.x", nodestorage.SpaceStatusNotResponsible, "")
+	fx.index.EXPECT().SaveHandoff(ctx, "s2.x", fx.now)
+	fx.index.EXPECT().Handoffs(ctx).Return(nil, nil)
+	require.NoError(t, fx.Check(ctx))
+	assert.Empty(t, fx.pendingParts)
+
+	// gained partitions are synced one by one, they wait while the node sync is running
+	gained := gainedParts(t, ch1, ch2)
+	require.NotEmpty(t, gained)
+	fx.nodeConf.EXPECT().Id().Return("2")
+	fx.nodeConf.EXPECT().CHash().Return(ch2)
+	fx.storage.EXPECT().AllSpaceIds().Return(nil, nil)
+	fx.nodeSync.EXPECT().SyncPartition(gained[0]).Return(nodesync.RunStatus{}, nodesync.ErrSyncInProgress)
+	fx.index.EXPECT().Handoffs(ctx).Return(nil, nil)
+	require.NoError(t, fx.Check(ctx))
+	assert.Equal(t, gained, fx.pendingParts)
+
+	fx.nodeConf.EXPECT().Id().Return("2")
+	for _, partId := range gained {
+		fx.nodeSync.EXPECT().SyncPartition(partId).Return(nodesync.RunStatus{Id: "run"}, nil)
+	}
+	fx.index.EXPECT().Handoffs(ctx).Return(nil, nil)
+	require.NoError(t, fx.Check(ctx))
+	assert.Empty(t, fx.pendingParts)
+}
+
+func TestRebalancer_handoff(t *testing.T) {
+	fx := newFixture(t)
+	old := fx.now.Add(-fx.grace * 2)
+	fx.index.EXPECT().Handoffs(ctx).Return([]nodestorage.HandoffEntry{
+		{SpaceId: "contained.x", MarkedAt: old},
+		{SpaceId: "timesynced.x", MarkedAt: old},
+		{SpaceId: "diverged.x", MarkedAt: old},
+		{SpaceId: "fresh.x", MarkedAt: fx.now},
+		{SpaceId: "back.x", MarkedAt: old},
+	}, nil)
+	for _, spaceId := range []string{"contained.x", "timesynced.x", "diverged.x", "fresh.x"} {
+		fx.nodeConf.EXPECT().IsResponsible(spaceId).Return(false)
+	}
+	fx.nodeConf.EXPECT().NodeIds(gomock.Any()).Return([]string{"p1", "p2"}).AnyTimes()
+
+	// the space is responsible again
+	fx.nodeConf.EXPECT().IsResponsible("back.x").Return(true)
+	fx.index.EXPECT().SpaceStatus(ctx, "back.x").Return(nodestorage.SpaceStatusNotResponsible, nil)
+	fx.index.EXPECT().SetSpaceStatus(gomock.Any(), "back.x", nodestorage.SpaceStatusOk, "")
+	fx.index.EXPECT().DeleteHandoff(ctx, "back.x")
+
+	fx.nodeHead.EXPECT().GetHead("contained.x").Return("h1", nil)
+	fx.nodeHead.EXPECT().GetHead("timesynced.x").Return("h2", nil)
+	fx.nodeHead.EXPECT().GetHead("diverged.x").Return("h3", nil)
+	fx.ownerStates = func(ctx context.Context, peerId string, spaceIds, heads []string) (map[string]ownerState, error) {
+		local := map[string]string{"contained.x": "h1", "timesynced.x": "h2", "diverged.x": "h3"}
+		require.Len(t, heads, len(spaceIds))
+		for i, spaceId := range spaceIds {
+			assert.Equal(t, local[spaceId], heads[i])
+		}
+		// owners have new changes of clients, so heads differ from the local ones
+		states := map[string]ownerState{
+			"contained.x": {head: "n1", contains: true},
+			// owners synced with each other only and have no local head, the space is kept
+			"timesynced.x": {head: "n2"},
+			"diverged.x":   {head: "n3", contains: true},
+		}
+		if peerId == "p2" {
+			states["diverged.x"] = ownerState{head: "n4"}
+		}
+		return states, nil
+	}
+	fx.storage.EXPECT().DeleteSpaceStorage(ctx, "contained.x")
+	fx.index.EXPECT().DeleteHandoff(ctx, "contained.x")
+	require.NoError(t, fx.handoff(ctx))
+}
+
+func TestRebalancer_Status(t *testing.T) {
+	fx := newFixture(t)
+	fx.confId = "1"
+	fx.pendingParts = []int{3}
+	fx.index.EXPECT().Handoffs(ctx).Return([]nodestorage.HandoffEntry{{SpaceId: "s.x", MarkedAt: fx.now}}, nil)
+	status, err := fx.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "1", status.ConfId)
+	assert.Equal(t, []int{3}, status.PendingPartitions)
+	require.Len(t, status.Handoffs, 1)
+	assert.Equal(t, fx.now.Add(fx.grace), status.Handoffs[0].DeleteAfter)
+}
+
+func gainedParts(t *testing.T, before, after chash.CHash) (gained []int) {
+	isMember := func(ch chash.CHash, partId int) bool {
+		memb, err := ch.GetPartitionMembers(partId)
+		require.NoError(t, err)
+		for _, m := range memb {
+			if m.Id() == "self" {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i < after.PartitionCount(); i++ {
+		if isMember(after, i) && !isMember(before, i) {
+			gained = append(gained, i)
+		}
+	}
+	return
+}
+
+type fixture struct {
+	*rebalancer
+	now      time.Time
+	nodeConf *mock_nodeconf.MockService
+	nodeHead *mock_nodehead.MockNodeHead
+	nodeSync *mock_nodesync.MockNodeSync
+	storage  *mock_nodestorage.MockNodeStorage
+	index    *mock_nodestorage.MockIndexStorage
+}
+
+func newFixture(t *testing.T) *fixture {
+	ctrl := gomock.NewController(t)
+	fx := &fixture{
+		now:      time.Now(),
+		nodeConf: mock_nodeconf.NewMockService(ctrl),
+		nodeHead: mock_nodehead.NewMockNodeHead(ctrl),
+		nodeSync: mock_nodesync.NewMockNodeSync(ctrl),
+		storage:  mock_nodestorage.NewMockNodeStorage(ctrl),
+		index:    mock_nodestorage.NewMockIndexStorage(ctrl),
+	}
+	fx.storage.EXPECT().IndexStorage().Return(fx.index).AnyTimes()
+	fx.rebalancer = &rebalancer{
+		nodeconf: fx.nodeConf,
+		nodehead: fx.nodeHead,
+		nodeSync: fx.nodeSync,
+		storage:  fx.storage,
+		peerId:   "self",
+		grace:    time.Hour,
+		now:      func() time.Time { return fx.now },
+	}
+	return fx
+}
@@ -113,3 +113,49 @@ func registerMetric(s *SyncStat, registry *prometheus.Registry) {
 		return float64(ms)
 	}))
 }
+
+func registerPeerHealthMetric(h *peerHealth, registry *prometheus.Registry) {
+	registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "nodesync",
+		Subsystem: "peers",
+		Name:      "backoff_count",
+	}, func() float64 {
+		var cnt int
+		for _, st := range h.stat() {
+			if st.InBackoff {
+				cnt++
+			}
+		}
+		return float64(cnt)
+	}))
+	registry.MustRegister(&peerHealthCollector{h: h})
+}
+
+var (
+	peerLatencyDesc  = prometheus.NewDesc("nodesync_peer_latency_ms", "moving average of the diff latency", []string{"peer"}, nil)
+	peerFailuresDesc = prometheus.NewDesc("nodesync_peer_failures_count", "failed requests to the peer", []string{"peer"}, nil)
+	peerBackoffDesc  = prometheus.NewDesc("nodesync_peer_backoff", "1 when the peer is skipped after failures", []string{"peer"}, nil)
+)
+
+// peerHealthCollector exports the health of every known peer
+type peerHealthCollector struct {
+	h *peerHealth
+}
+
+func (c *peerHealthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- peerLatencyDesc
+	ch <- peerFailuresDesc
+	ch <- peerBackoffDesc
+}
+
+func (c *peerHealthCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, st := range c.h.stat() {
+		var backoff float64
+		if st.InBackoff {
+			backoff = 1
+		}
+		ch <- prometheus.MustNewConstMetric(peerLatencyDesc, prometheus.GaugeValue, st.LatencyMs, st.PeerId)
+		ch <- prometheus.MustNewConstMetric(peerFailuresDesc, prometheus.CounterValue, float64(st.Failures), st.PeerId)
+		ch <- prometheus.MustNewConstMetric(peerBackoffDesc, prometheus.GaugeValue, backoff, st.PeerId)
+	}
+}
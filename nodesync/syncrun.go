@@ -0,0 +1,302 @@
+package nodesync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSyncInProgress = errors.New("sync in progress")
+	ErrRunNotFound    = errors.New("sync run not found")
+	ErrRunFinished    = errors.New("sync run is finished")
+	// ErrNotResponsible is returned for partitions that are not stored on this node
+	ErrNotResponsible   = errors.New("node is not responsible for the partition")
+	ErrUnknownPartition = errors.New("unknown partition")
+)
+
+const (
+	RunRunning  = "running"
+	RunDone     = "done"
+	RunCanceled = "canceled"
+	RunFailed   = "failed"
+)
+
+const defaultRunHistory = 20
+
+// RunStatus is the progress of one node sync run
+type RunStatus struct {
+	Id           string       `json:"id"`
+	State        string       `json:"state"`
+	StartedAt    time.Time    `json:"startedAt"`
+	FinishedAt   time.Time    `json:"finishedAt"`
+	PartsTotal   int          `json:"partsTotal"`
+	PartsHandled int          `json:"partsHandled"`
+	PartsErrors  int          `json:"partsErrors"`
+	Error        string       `json:"error,omitempty"`
+	Parts        []PartStatus `json:"parts,omitempty"`
+}
+
+// PartStatus is the sync of one partition with all its peers
+type PartStatus struct {
+	PartId     int          `json:"partId"`
+	Peers      []PeerStatus `json:"peers"`
+	Done       bool         `json:"done"`
+	DurationMs int64        `json:"durationMs"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// PeerStatus is the result of the partition diff with one peer
+type PeerStatus struct {
+	PeerId         string `json:"peerId"`
+	New            int    `json:"new"`
+	Changed        int    `json:"changed"`
+	ColdSyncErrors int    `json:"coldSyncErrors"`
+	DurationMs     int64  `json:"durationMs"`
+	Error          string `json:"error,omitempty"`
+}
+
+type syncRun struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu      sync.Mutex
+	status  RunStatus
+	partIdx map[int]int
+}
+
+func newSyncRun(parent context.Context, id string) *syncRun {
+	ctx, cancel := context.WithCancel(parent)
+	return &syncRun{
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		partIdx: make(map[int]int),
+		status: RunStatus{
+			Id:        id,
+			State:     RunRunning,
+			StartedAt: time.Now(),
+		},
+	}
+}
+
+// newFinishedRun restores the run from its persisted report
+func newFinishedRun(status RunStatus) *syncRun {
+	run := &syncRun{
+		cancel: func() {},
+		done:   make(chan struct{}),
+		status: status,
+	}
+	close(run.done)
+	return run
+}
+
+func (r *syncRun) setParts(parts []part) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.PartsTotal = len(parts)
+	r.status.Parts = make([]PartStatus, len(parts))
+	for i, p := range parts {
+		r.status.Parts[i].PartId = p.partId
+		r.partIdx[p.partId] = i
+	}
+}
+
+func (r *syncRun) peerDone(partId int, peer PeerStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ps := &r.status.Parts[r.partIdx[partId]]
+	ps.Peers = append(ps.Peers, peer)
+}
+
+func (r *syncRun) partDone(partId int, dur time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ps := &r.status.Parts[r.partIdx[partId]]
+	ps.Done = true
+	ps.DurationMs = dur.Milliseconds()
+	r.status.PartsHandled++
+	if err != nil {
+		ps.Error = err.Error()
+		r.status.PartsErrors++
+	}
+}
+
+func (r *syncRun) finish(err error) {
+	r.mu.Lock()
+	r.status.FinishedAt = time.Now()
+	switch {
+	case r.ctx.Err() != nil:
+		r.status.State = RunCanceled
+	case err != nil:
+		r.status.State = RunFailed
+		r.status.Error = err.Error()
+	default:
+		r.status.State = RunDone
+	}
+	r.mu.Unlock()
+	r.cancel()
+	close(r.done)
+}
+
+func (r *syncRun) finished() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// snapshot returns a copy of the status, parts are omitted when withParts is false
+func (r *syncRun) snapshot(withParts bool) RunStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := r.status
+	status.Parts = nil
+	if withParts {
+		status.Parts = make([]PartStatus, len(r.status.Parts))
+		for i, ps := range r.status.Parts {
+			ps.Peers = slices.Clone(ps.Peers)
+			status.Parts[i] = ps
+		}
+	}
+	return status
+}
+
+// startRun registers a new run, only one run works at once
+func (n *nodeSync) startRun() (run *syncRun, err error) {
+	n.syncMu.Lock()
+	defer n.syncMu.Unlock()
+	if n.syncInProgress != nil {
+		return nil, ErrSyncInProgress
+	}
+	n.syncInProgress = make(chan struct{})
+	n.runSeq++
+	run = newSyncRun(n.syncCtx, fmt.Sprintf("%s-%d", time.Now().UTC().Format("20060102T150405"), n.runSeq))
+	n.runs = append(n.runs, run)
+	if len(n.runs) > n.runHistory {
+		n.runs = slices.Delete(n.runs, 0, len(n.runs)-n.runHistory)
+	}
+	return run, nil
+}
+
+func (n *nodeSync) StartSync() (runId string, err error) {
+	run, err := n.startRun()
+	if err != nil {
+		return
+	}
+	go func() {
+		if e := n.runSync(run, n.getRelatePartitions); e != nil {
+			log.Warn("nodesync failed", zap.String("runId", run.status.Id), zap.Error(e))
+		}
+	}()
+	return run.status.Id, nil
+}
+
+func (n *nodeSync) CancelSync(runId string) (err error) {
+	run, err := n.getRun(runId)
+	if err != nil {
+		return
+	}
+	if run.finished() {
+		return ErrRunFinished
+	}
+	log.Info("nodesync run is canceled", zap.String("runId", runId))
+	run.cancel()
+	return nil
+}
+
+func (n *nodeSync) SyncStatus(runId string) (status RunStatus, err error) {
+	run, err := n.getRun(runId)
+	if err != nil {
+		return
+	}
+	return run.snapshot(true), nil
+}
+
+func (n *nodeSync) WatchSync(ctx context.Context, runId string, interval time.Duration, onStatus func(status RunStatus) error) (err error) {
+	run, err := n.getRun(runId)
+	if err != nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		finished := run.finished()
+		if err = onStatus(run.snapshot(true)); err != nil || finished {
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-run.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (n *nodeSync) SyncHistory() (runs []RunStatus) {
+	n.syncMu.Lock()
+	defer n.syncMu.Unlock()
+	runs = make([]RunStatus, 0, len(n.runs))
+	for i := len(n.runs) - 1; i >= 0; i-- {
+		runs = append(runs, n.runs[i].snapshot(false))
+	}
+	return
+}
+
+// getRun returns the run by id, the latest one for the empty id
+func (n *nodeSync) getRun(runId string) (*syncRun, error) {
+	n.syncMu.Lock()
+	defer n.syncMu.Unlock()
+	for i := len(n.runs) - 1; i >= 0; i-- {
+		if runId == "" || n.runs[i].status.Id == runId {
+			return n.runs[i], nil
+		}
+	}
+	return nil, ErrRunNotFound
+}
+
+func (n *nodeSync) saveRun(run *syncRun) {
+	if n.storage == nil {
+		return
+	}
+	status := run.snapshot(true)
+	data, err := json.Marshal(status)
+	if err == nil {
+		err = n.storage.IndexStorage().SaveSyncRun(context.Background(), status.Id, status.StartedAt, data, n.runHistory)
+	}
+	if err != nil {
+		log.Warn("can't save sync run", zap.String("runId", status.Id), zap.Error(err))
+	}
+}
+
+// loadRuns fills the history with runs of previous launches
+func (n *nodeSync) loadRuns(ctx context.Context) (err error) {
+	if n.storage == nil {
+		return
+	}
+	data, err := n.storage.IndexStorage().SyncRuns(ctx, n.runHistory)
+	if err != nil {
+		return
+	}
+	n.syncMu.Lock()
+	defer n.syncMu.Unlock()
+	// reports are the newest first
+	for i := len(data) - 1; i >= 0; i-- {
+		var status RunStatus
+		if err = json.Unmarshal(data[i], &status); err != nil {
+			return
+		}
+		n.runs = append(n.runs, newFinishedRun(status))
+	}
+	return
+}
@@ -0,0 +1,36 @@
+// Package chashtest builds consistent hashes of equal members for tests
+package chashtest
+
+import (
+	"testing"
+
+	"github.com/anyproto/go-chash"
+	"github.com/stretchr/testify/require"
+)
+
+// PartitionCount is small, so tests can walk all partitions
+const PartitionCount = 30
+
+// New returns the hash of the member ids with the given replication factor
+func New(t testing.TB, replicationFactor int, ids ...string) chash.CHash {
+	ch, err := chash.New(chash.Config{
+		PartitionCount:    PartitionCount,
+		ReplicationFactor: replicationFactor,
+	})
+	require.NoError(t, err)
+	for _, id := range ids {
+		require.NoError(t, ch.AddMembers(Member(id)))
+	}
+	return ch
+}
+
+// Member is a member with the unit capacity
+type Member string
+
+func (m Member) Id() string {
+	return string(m)
+}
+
+func (m Member) Capacity() float64 {
+	return 1
+}
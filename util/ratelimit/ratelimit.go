@@ -0,0 +1,50 @@
+// Package ratelimit shapes byte traffic with token buckets, one token is one byte
+package ratelimit
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// MaxBurst bounds the token bucket size, so a high limit doesn't allow a huge spike after idle time
+const MaxBurst = 4 << 20
+
+// New returns the token bucket for bytesPerSec, it doesn't limit when bytesPerSec <= 0
+func New(bytesPerSec int64) *rate.Limiter {
+	l := rate.NewLimiter(rate.Inf, MaxBurst)
+	SetLimit(l, bytesPerSec)
+	return l
+}
+
+// SetLimit changes the limiter in place, so waiting callers get the new limit
+func SetLimit(l *rate.Limiter, bytesPerSec int64) {
+	if bytesPerSec <= 0 {
+		l.SetLimit(rate.Inf)
+		l.SetBurst(MaxBurst)
+		return
+	}
+	l.SetLimit(rate.Limit(bytesPerSec))
+	l.SetBurst(int(min(bytesPerSec, MaxBurst)))
+}
+
+// WaitBytes takes n tokens from every limiter by burst-sized portions, nil limiters are skipped
+func WaitBytes(ctx context.Context, n int64, limiters ...*rate.Limiter) error {
+	for _, l := range limiters {
+		if l == nil {
+			continue
+		}
+		for left := n; left > 0; {
+			portion := min(left, int64(l.Burst()))
+			if err := l.WaitN(ctx, int(portion)); err != nil {
+				if ctx.Err() == nil && portion > int64(l.Burst()) {
+					// the burst was lowered after it was read, retry with the new one
+					continue
+				}
+				return err
+			}
+			left -= portion
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+var ctx = context.Background()
+
+func TestNew(t *testing.T) {
+	assert.Equal(t, rate.Inf, New(0).Limit())
+	assert.Equal(t, MaxBurst, New(0).Burst())
+	assert.Equal(t, 1000, New(1000).Burst())
+	assert.Equal(t, MaxBurst, New(MaxBurst*2).Burst())
+}
+
+func TestWaitBytes(t *testing.T) {
+	t.Run("portions", func(t *testing.T) {
+		l := New(10000)
+		// the request above the burst is taken by portions
+		st := time.Now()
+		require.NoError(t, WaitBytes(ctx, 12500, l, nil))
+		assert.GreaterOrEqual(t, time.Since(st), time.Millisecond*200)
+	})
+	t.Run("lowered burst", func(t *testing.T) {
+		l := New(10000)
+		require.NoError(t, l.WaitN(ctx, l.Burst()))
+		done := make(chan error)
+		go func() {
+			done <- WaitBytes(ctx, 10000, l)
+		}()
+		time.Sleep(time.Millisecond * 50)
+		SetLimit(l, 100000)
+		SetLimit(l, 5000)
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(time.Second * 10):
+			t.Fatal("wait is not finished")
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		l := New(10)
+		cctx, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.Error(t, WaitBytes(cctx, 100, l))
+	})
+}